@@ -0,0 +1,18 @@
+package main
+
+import (
+	_ "embed"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// consolePage is the single-page SQL console served at /console
+//
+//go:embed console/index.html
+var consolePage []byte
+
+// handleConsole serves the web console
+func handleConsole(c *fiber.Ctx) error {
+	c.Type("html", "utf-8")
+	return c.Send(consolePage)
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// runDump writes tables out as CREATE TABLE and INSERT statements that
+// `pesapal import` can load
+func runDump(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	var opts options
+	opts.register(fs, executor.ResultLimits{})
+	output := fs.String("o", "", "write the script to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pesapal dump [flags] [table...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Dumping never writes, so it can run against a database in use
+	opts.readOnly = true
+	db, err := openDatabase(opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tables := fs.Args()
+	if len(tables) == 0 {
+		tables = db.store.ListTables()
+		sort.Strings(tables)
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *output, err)
+		}
+		defer out.Close()
+	}
+
+	dumped := make([]*storage.Table, 0, len(tables))
+	for _, name := range tables {
+		table, err := db.store.GetTable(name)
+		if err != nil {
+			return err
+		}
+		dumped = append(dumped, table)
+	}
+	dumped = dumpOrder(dumped)
+
+	w := bufio.NewWriter(out)
+	for _, table := range dumped {
+		if err := dumpTable(w, db.store, table); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// dumpOrder orders tables so partitioned tables come before their
+// partitions, referenced tables, with their rows, before the tables whose
+// foreign keys reference them, and materialized views after the tables
+// they read, keeping the given order otherwise
+func dumpOrder(tables []*storage.Table) []*storage.Table {
+	dumped := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		dumped[table.Schema.TableName] = true
+	}
+	placed := make(map[string]bool, len(tables))
+	waitsFor := func(table *storage.Table, name string) bool {
+		return name != "" && name != table.Schema.TableName && dumped[name] && !placed[name]
+	}
+
+	ordered := make([]*storage.Table, 0, len(tables))
+	for len(ordered) < len(tables) {
+		progress := false
+		for _, table := range tables {
+			name := table.Schema.TableName
+			if placed[name] || waitsFor(table, table.Schema.PartitionOf) {
+				continue
+			}
+			ready := true
+			for _, key := range table.Schema.ForeignKeys {
+				if waitsFor(table, key.RefTable) {
+					ready = false
+				}
+			}
+			if view := table.Schema.View; view != nil {
+				for _, source := range view.Tables {
+					if waitsFor(table, source) {
+						ready = false
+					}
+				}
+			}
+			if ready {
+				ordered = append(ordered, table)
+				placed[name] = true
+				progress = true
+			}
+		}
+		// Tables are created after those they reference, so there are no
+		// cycles, but never loop on a damaged catalog
+		if !progress {
+			for _, table := range tables {
+				if !placed[table.Schema.TableName] {
+					ordered = append(ordered, table)
+				}
+			}
+			break
+		}
+	}
+	return ordered
+}
+
+// dumpTable writes the statements that recreate a table
+func dumpTable(w io.Writer, store *storage.Storage, table *storage.Table) error {
+	schema := table.Schema
+
+	// A materialized view's rows are computed again from its query
+	if schema.Materialized() {
+		fmt.Fprintf(w, "CREATE MATERIALIZED VIEW %s AS %s;\n", schema.TableName, schema.View.Query)
+		dumpAccess(w, schema)
+		return nil
+	}
+
+	columns := make([]string, len(schema.Columns))
+	definitions := make([]string, len(schema.Columns))
+	for i, col := range schema.Columns {
+		columns[i] = col.Name
+		definitions[i] = columnDefinition(col)
+	}
+	for _, constraint := range schema.UniqueConstraints {
+		definitions = append(definitions, "UNIQUE ("+strings.Join(constraint.Columns, ", ")+")")
+	}
+	for _, key := range schema.ForeignKeys {
+		definition := "FOREIGN KEY (" + strings.Join(key.Columns, ", ") + ") REFERENCES " + key.RefTable + " (" + strings.Join(key.RefColumns, ", ") + ")"
+		if key.OnDelete != "" {
+			definition += " ON DELETE " + key.OnDelete
+		}
+		definitions = append(definitions, definition)
+	}
+	if bound, _, ok := store.PartitionBound(table); ok {
+		values, err := partitionBound(bound)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", schema.TableName, err)
+		}
+		if bound.Dir != "" {
+			location, err := parser.FormatLiteral(bound.Dir)
+			if err != nil {
+				return fmt.Errorf("table %s: %w", schema.TableName, err)
+			}
+			values += " LOCATION " + location
+		}
+		fmt.Fprintf(w, "CREATE TABLE %s PARTITION OF %s FOR VALUES %s;\n", schema.TableName, schema.PartitionOf, values)
+	} else if schema.Foreign() {
+		options, err := foreignOptions(schema.ForeignTable.Options)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", schema.TableName, err)
+		}
+		fmt.Fprintf(w, "CREATE FOREIGN TABLE %s (%s) SERVER %s OPTIONS (%s);\n", schema.TableName, strings.Join(definitions, ", "), schema.ForeignTable.Server, options)
+	} else if schema.Partitioned() {
+		fmt.Fprintf(w, "CREATE TABLE %s (%s) PARTITION BY %s (%s);\n", schema.TableName, strings.Join(definitions, ", "), schema.PartitionMethod, schema.PartitionKey)
+	} else if schema.Columnar() {
+		fmt.Fprintf(w, "CREATE TABLE %s (%s) USING COLUMNAR;\n", schema.TableName, strings.Join(definitions, ", "))
+	} else {
+		fmt.Fprintf(w, "CREATE TABLE %s (%s);\n", schema.TableName, strings.Join(definitions, ", "))
+	}
+
+	// Rows referencing rows of their own table may come before them, so
+	// such tables' rows go in one INSERT, checked as a whole
+	selfReferencing := slices.ContainsFunc(schema.ForeignKeys, func(key storage.ForeignKey) bool {
+		return key.RefTable == schema.TableName || key.RefTable == schema.PartitionOf
+	})
+	var tuples []string
+	for _, row := range table.SelectRows() {
+		values := []string{}
+		for i, value := range row.Values {
+			// Generated columns are recomputed on import
+			if schema.Columns[i].Generated != "" {
+				continue
+			}
+			literal, err := parser.FormatLiteral(value)
+			if err != nil {
+				return fmt.Errorf("table %s, column %s: %w", schema.TableName, columns[i], err)
+			}
+			values = append(values, literal)
+		}
+		// Values are written in schema order, matching the CREATE TABLE above
+		if selfReferencing {
+			tuples = append(tuples, "("+strings.Join(values, ", ")+")")
+			continue
+		}
+		fmt.Fprintf(w, "INSERT INTO %s VALUES (%s);\n", schema.TableName, strings.Join(values, ", "))
+	}
+	if len(tuples) > 0 {
+		fmt.Fprintf(w, "INSERT INTO %s VALUES %s;\n", schema.TableName, strings.Join(tuples, ", "))
+	}
+	dumpAccess(w, schema)
+	return nil
+}
+
+// dumpAccess writes the statements that recreate the policies, masks,
+// soft delete and grants of a table, followed by a blank line
+func dumpAccess(w io.Writer, schema *storage.Schema) {
+	for _, policy := range schema.Policies {
+		fmt.Fprintf(w, "CREATE POLICY %s ON %s USING (%s);\n", policy.Name, schema.TableName, policy.Using)
+	}
+	for _, col := range schema.Columns {
+		if col.Mask != "" {
+			fmt.Fprintf(w, "ALTER TABLE %s ALTER COLUMN %s SET MASK %s;\n", schema.TableName, col.Name, col.Mask)
+		}
+	}
+	// Soft-deleted rows are not dumped
+	if schema.SoftDelete {
+		fmt.Fprintf(w, "ALTER TABLE %s ENABLE SOFT DELETE;\n", schema.TableName)
+	}
+	for _, grant := range schema.Grants {
+		privilege := grant.Privilege
+		if grant.Columns != nil {
+			privilege += " (" + strings.Join(grant.Columns, ", ") + ")"
+		}
+		fmt.Fprintf(w, "GRANT %s ON %s TO %s;\n", privilege, schema.TableName, grant.User)
+	}
+
+	fmt.Fprintln(w)
+}
+
+// foreignOptions renders a foreign table's options as they appear inside
+// OPTIONS (...)
+func foreignOptions(options map[string]string) (string, error) {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rendered := make([]string, len(names))
+	for i, name := range names {
+		value, err := parser.FormatLiteral(options[name])
+		if err != nil {
+			return "", err
+		}
+		rendered[i] = name + " " + value
+	}
+	return strings.Join(rendered, ", "), nil
+}
+
+// partitionBound renders a partition bound as it appears after FOR VALUES
+func partitionBound(bound storage.PartitionBound) (string, error) {
+	if bound.Modulus > 0 {
+		return bound.String(), nil
+	}
+	format := func(value interface{}, unbounded string) (string, error) {
+		if value == nil {
+			return unbounded, nil
+		}
+		return parser.FormatLiteral(value)
+	}
+	from, err := format(bound.From, "MINVALUE")
+	if err != nil {
+		return "", err
+	}
+	to, err := format(bound.To, "MAXVALUE")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("FROM (%s) TO (%s)", from, to), nil
+}
+
+// columnDefinition renders a column as it appears in CREATE TABLE
+func columnDefinition(col storage.Column) string {
+	def := col.Name + " " + col.DataType.String()
+	if col.DataType == storage.TypeVarchar && col.Size > 0 {
+		def += fmt.Sprintf("(%d)", col.Size)
+	}
+	if col.Generated != "" {
+		def += " GENERATED ALWAYS AS (" + col.Generated + ")"
+		if col.Virtual {
+			def += " VIRTUAL"
+		}
+	}
+	if col.Collation != "" {
+		def += " COLLATE " + col.Collation
+	}
+	if col.Dictionary {
+		def += " ENCODING DICTIONARY"
+	}
+	if col.PrimaryKey {
+		def += " PRIMARY KEY"
+	}
+	if col.Unique {
+		def += " UNIQUE"
+	}
+	if col.NotNull {
+		def += " NOT NULL"
+	}
+	if col.Default != "" {
+		def += " DEFAULT " + col.Default
+	}
+	if col.OnUpdate != "" {
+		def += " ON UPDATE " + col.OnUpdate
+	}
+	return def
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// TestDumpImportRoundTrip checks that a dumped table, booleans and negative
+// numbers included, imports back to the same rows
+func TestDumpImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	open := func() (*storage.Storage, *executor.Executor) {
+		store, err := storage.NewStorage(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store, executor.NewExecutor(store)
+	}
+
+	store, exec := open()
+	for _, query := range []string{
+		"CREATE TABLE t (id INTEGER PRIMARY KEY, ok BOOLEAN, n INTEGER DEFAULT -1, f FLOAT)",
+		"INSERT INTO t VALUES (1, TRUE, -7, -1.5), (2, FALSE, 3, -0.25), (3, NULL, 0, 2.0)",
+	} {
+		if _, err := exec.Query(ctx, query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	table, err := store.GetTable("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var script bytes.Buffer
+	if err := dumpTable(&script, store, table); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "dump.sql")
+	if err := os.WriteFile(name, script.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, imported := open()
+	if _, err := importFile(ctx, imported, name, false); err != nil {
+		t.Fatalf("import of\n%s\nfailed: %v", script.String(), err)
+	}
+	const query = "SELECT * FROM t ORDER BY id"
+	want, err := exec.Query(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := imported.Query(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Rows, want.Rows) {
+		t.Errorf("imported rows %v, want %v", got.Rows, want.Rows)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+)
+
+// bulkBatchRows caps how many rows -bulk merges into one INSERT
+const bulkBatchRows = 1000
+
+// scriptStatement is one statement of a SQL script
+type scriptStatement struct {
+	Line  int // line the statement starts on
+	SQL   string
+	Count int // script statements it stands for
+}
+
+// runImport executes SQL scripts (e.g. from `pesapal dump`) against the
+// database, stopping at the first failing statement
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	var opts options
+	opts.register(fs, executor.ResultLimits{})
+	bulk := fs.Bool("bulk", false, "merge consecutive INSERTs into batches and write tables once at the end")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pesapal import [flags] [file...]")
+		fmt.Fprintln(fs.Output(), "Reads standard input when no file (or -) is given.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if opts.readOnly {
+		return fmt.Errorf("cannot import into a database opened read-only")
+	}
+
+	db, err := openDatabase(opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	total := 0
+	load := func(ctx context.Context) error {
+		for _, name := range files {
+			count, err := importFile(ctx, db.exec, name, *bulk)
+			total += count
+			if err != nil {
+				return fmt.Errorf("%w (%d statement(s) imported before the failure)", err, total)
+			}
+		}
+		return nil
+	}
+	if *bulk {
+		err = db.exec.BulkLoad(context.Background(), load)
+	} else {
+		err = load(context.Background())
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d statement(s)\n", total)
+	return nil
+}
+
+// importFile executes every statement in a script, returning how many
+// succeeded. With bulk set, runs of INSERTs are executed as batches.
+func importFile(ctx context.Context, exec *executor.Executor, name string, bulk bool) (int, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		name = "stdin"
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	// Each script runs in its own session, so SET only affects that script
+	ctx = executor.WithSession(ctx, executor.NewSession(name))
+	statements := splitStatements(string(data))
+	if bulk {
+		statements = batchInserts(statements)
+	}
+
+	imported := 0
+	for _, stmt := range statements {
+		if _, err := exec.Query(ctx, stmt.SQL); err != nil {
+			return imported, fmt.Errorf("%s:%d: %w", name, stmt.Line, err)
+		}
+		imported += stmt.Count
+	}
+	return imported, nil
+}
+
+// batchInserts merges runs of INSERTs into the same table and columns into
+// multi-row INSERTs of up to bulkBatchRows rows, so each run is checked and
+// logged once. A failing batch is reported at the line of its first
+// statement.
+func batchInserts(statements []scriptStatement) []scriptStatement {
+	batched := []scriptStatement{}
+
+	var run *parser.InsertStmt
+	var runStart scriptStatement
+	runCount := 0
+	flush := func() {
+		if run == nil {
+			return
+		}
+		batched = append(batched, scriptStatement{Line: runStart.Line, SQL: formatInsert(run), Count: runCount})
+		run = nil
+	}
+
+	for _, stmt := range statements {
+		insert, ok := parseInsert(stmt.SQL)
+		if !ok || insert.Select != nil || insert.OnConflict != nil || insert.Returning != nil {
+			flush()
+			batched = append(batched, stmt)
+			continue
+		}
+		if run != nil && (run.TableName != insert.TableName ||
+			strings.Join(run.Columns, ",") != strings.Join(insert.Columns, ",") ||
+			len(run.Values)+len(insert.Values) > bulkBatchRows) {
+			flush()
+		}
+		if run == nil {
+			run = &parser.InsertStmt{TableName: insert.TableName, Columns: insert.Columns}
+			runStart, runCount = stmt, 0
+		}
+		run.Values = append(run.Values, insert.Values...)
+		runCount += stmt.Count
+	}
+	flush()
+
+	return batched
+}
+
+// parseInsert parses sql, reporting whether it is an INSERT
+func parseInsert(sql string) (*parser.InsertStmt, bool) {
+	stmt, err := parser.NewParser(sql).Parse()
+	if err != nil {
+		return nil, false
+	}
+	insert, ok := stmt.(*parser.InsertStmt)
+	return insert, ok
+}
+
+// formatInsert renders an INSERT as SQL
+func formatInsert(stmt *parser.InsertStmt) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO " + stmt.TableName)
+	if len(stmt.Columns) > 0 {
+		b.WriteString(" (" + strings.Join(stmt.Columns, ", ") + ")")
+	}
+	b.WriteString(" VALUES ")
+	for i, values := range stmt.Values {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		exprs := make([]string, len(values))
+		for j, value := range values {
+			exprs[j] = parser.FormatExpression(value)
+		}
+		b.WriteString("(" + strings.Join(exprs, ", ") + ")")
+	}
+	return b.String()
+}
+
+// splitStatements splits a script into its statements, as the parser
+// does, so semicolons in string literals and comments do not end one
+func splitStatements(script string) []scriptStatement {
+	statements := []scriptStatement{}
+	for _, stmt := range parser.Split(script) {
+		line := strings.Count(script[:stmt.Offset], "\n") + 1
+		statements = append(statements, scriptStatement{Line: line, SQL: stmt.Text, Count: 1})
+	}
+	return statements
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command is a pesapal subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "Start the HTTP API server", runServe},
+	{"repl", "Start the interactive SQL shell", runRepl},
+	{"dump", "Write the database out as a SQL script", runDump},
+	{"import", "Execute a SQL script against the database", runImport},
+	{"seed", "Load JSON or YAML fixture files into the database", runSeed},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "pesapal %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "pesapal: unknown command %q\n\n", name)
+	printUsage()
+	os.Exit(2)
+}
+
+// printUsage lists the available subcommands
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Pesapal RDBMS")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Usage: pesapal <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'pesapal <command> -h' for the flags of a command.")
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/tracing"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/wal"
+)
+
+// options holds the flags shared by every subcommand. Each flag defaults
+// to the matching environment variable when it is set.
+type options struct {
+	dataDir  string
+	readOnly bool
+	tracing  string
+	maxRows  int
+	maxBytes int64
+
+	flush         string
+	flushInterval time.Duration
+
+	resultCache      int
+	resultCacheBytes int64
+	resultCacheTTL   time.Duration
+}
+
+// register adds the shared flags to fs. limits are the result limits used
+// when neither a flag nor an environment variable sets them.
+func (o *options) register(fs *flag.FlagSet, limits executor.ResultLimits) {
+	fs.StringVar(&o.dataDir, "data", envString("DATA_DIR", "./data"), "data directory (env DATA_DIR)")
+	fs.BoolVar(&o.readOnly, "read-only", os.Getenv("READ_ONLY") == "true", "open the data directory read-only without locking it (env READ_ONLY)")
+	fs.StringVar(&o.tracing, "tracing", os.Getenv("TRACING"), "trace exporter, \"log\" to log spans (env TRACING)")
+	fs.IntVar(&o.maxRows, "max-result-rows", envInt("MAX_RESULT_ROWS", limits.MaxRows), "maximum rows in a query result, 0 for no limit (env MAX_RESULT_ROWS)")
+	fs.Int64Var(&o.maxBytes, "max-result-bytes", int64(envInt("MAX_RESULT_BYTES", int(limits.MaxBytes))), "maximum bytes in a query result, 0 for no limit (env MAX_RESULT_BYTES)")
+	fs.StringVar(&o.flush, "flush", envString("FLUSH_POLICY", "statement"), "when writes reach disk: statement, interval or group (env FLUSH_POLICY)")
+	fs.IntVar(&o.resultCache, "result-cache", envInt("RESULT_CACHE_ENTRIES", 1000), "SELECT results to cache, 0 to disable (env RESULT_CACHE_ENTRIES)")
+	fs.Int64Var(&o.resultCacheBytes, "result-cache-bytes", int64(envInt("RESULT_CACHE_BYTES", 32<<20)), "maximum estimated size of cached results, 0 for no limit (env RESULT_CACHE_BYTES)")
+	fs.DurationVar(&o.resultCacheTTL, "result-cache-ttl", envDuration("RESULT_CACHE_TTL", time.Minute), "how long a cached result is served, 0 for no limit (env RESULT_CACHE_TTL)")
+	fs.DurationVar(&o.flushInterval, "flush-interval", envDuration("FLUSH_INTERVAL", executor.DefaultFlushInterval), "how often the interval and group policies write tables (env FLUSH_INTERVAL)")
+}
+
+// database is an opened data directory with its executor and WAL
+type database struct {
+	dir   string
+	store *storage.Storage
+	exec  *executor.Executor
+	wal   *wal.Log // nil when opened read-only
+}
+
+// openDatabase opens the data directory described by opts
+func openDatabase(opts options) (*database, error) {
+	if opts.maxRows < 0 || opts.maxBytes < 0 {
+		return nil, fmt.Errorf("result limits must not be negative")
+	}
+
+	store, err := storage.Open(opts.dataDir, storage.Options{ReadOnly: opts.readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	db := &database{
+		dir:   opts.dataDir,
+		store: store,
+		exec:  executor.NewExecutor(store),
+	}
+
+	// Open the write-ahead log; a read-only open leaves it to the owner
+	if !opts.readOnly {
+		db.wal, err = wal.Open(filepath.Join(opts.dataDir, "wal.log"))
+		if err != nil {
+			store.Close()
+			return nil, fmt.Errorf("failed to open WAL: %w", err)
+		}
+		db.exec.SetWAL(db.wal)
+	}
+
+	// Bound result sizes so a large SELECT cannot exhaust memory
+	db.exec.SetResultLimits(executor.ResultLimits{MaxRows: opts.maxRows, MaxBytes: opts.maxBytes})
+
+	// Serve repeated SELECTs from memory until a table they read changes
+	db.exec.SetResultCacheLimits(executor.ResultCacheLimits{
+		MaxEntries: opts.resultCache,
+		MaxBytes:   opts.resultCacheBytes,
+		TTL:        opts.resultCacheTTL,
+	})
+
+	// Enable tracing when requested
+	switch opts.tracing {
+	case "":
+	case "log":
+		tracing.SetExporter(tracing.NewLogExporter(nil))
+	default:
+		db.Close()
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.tracing)
+	}
+
+	// Apply the flush policy and replay writes a crash left out of the
+	// table files
+	if !opts.readOnly {
+		policy, err := executor.ParseFlushPolicy(opts.flush)
+		if err == nil {
+			err = db.exec.SetFlushPolicy(policy, opts.flushInterval)
+		}
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		replayed, err := db.exec.Recover(context.Background())
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		if replayed > 0 {
+			fmt.Fprintf(os.Stderr, "Recovered %d statement(s) from the WAL\n", replayed)
+		}
+	}
+
+	return db, nil
+}
+
+// Close flushes pending writes, closes the WAL and releases the data
+// directory lock
+func (db *database) Close() error {
+	if err := db.exec.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "final checkpoint failed: %v\n", err)
+	}
+	if db.wal != nil {
+		db.wal.Close()
+	}
+	return db.store.Close()
+}
+
+// envString returns the environment variable key, or def when it is unset
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envInt returns the environment variable key as an integer, or def when
+// it is unset or not a number
+func envInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+		fmt.Fprintf(os.Stderr, "ignoring invalid %s=%q\n", key, v)
+	}
+	return def
+}
+
+// envDuration returns the environment variable key as a duration, or def
+// when it is unset or invalid
+func envDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+		fmt.Fprintf(os.Stderr, "ignoring invalid %s=%q\n", key, v)
+	}
+	return def
+}
+
+// splitList returns the non-empty items of a comma-separated flag value,
+// trimmed of spaces
+func splitList(list string) []string {
+	items := []string{}
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorPurple = "\033[35m"
+	colorCyan   = "\033[36m"
+)
+
+// runRepl starts the interactive SQL shell
+func runRepl(args []string) error {
+	fs := flag.NewFlagSet("repl", flag.ExitOnError)
+	var opts options
+	opts.register(fs, executor.ResultLimits{})
+	user := fs.String("user", "", "run statements for this user, so row-level security policies apply")
+	fs.Parse(args)
+
+	fmt.Println(colorCyan + "╔═══════════════════════════════════════════════════════════╗" + colorReset)
+	fmt.Println(colorCyan + "║" + colorReset + "         " + colorPurple + "Pesapal RDBMS - Interactive REPL" + colorReset + "              " + colorCyan + "║" + colorReset)
+	fmt.Println(colorCyan + "║" + colorReset + "         " + colorYellow + "Junior Dev Challenge 2026" + colorReset + "                    " + colorCyan + "║" + colorReset)
+	fmt.Println(colorCyan + "╚═══════════════════════════════════════════════════════════╝" + colorReset)
+	fmt.Println()
+	fmt.Println(colorBlue + "Type SQL commands and press Enter to execute." + colorReset)
+	fmt.Println(colorBlue + "Type 'help' for available commands, 'exit' or 'quit' to exit." + colorReset)
+	fmt.Println()
+
+	db, err := openDatabase(opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	store, exec := db.store, db.exec
+	ctx := executor.WithSession(context.Background(), executor.NewSession("repl"))
+	if *user != "" {
+		ctx = executor.WithUser(ctx, *user)
+	}
+	if opts.readOnly {
+		fmt.Println(colorYellow + "Opened read-only: changes are rejected and nothing is written to disk." + colorReset)
+		fmt.Println()
+	}
+
+	// Start REPL
+	reader := bufio.NewReader(os.Stdin)
+	var multiLineQuery strings.Builder
+	inMultiLine := false
+
+	for {
+		// Display prompt
+		if inMultiLine {
+			fmt.Print(colorYellow + "... " + colorReset)
+		} else {
+			fmt.Print(colorGreen + "pesapal> " + colorReset)
+		}
+
+		// Read input
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Printf(colorRed+"Error reading input: %v\n"+colorReset, err)
+			break
+		}
+
+		line = strings.TrimSpace(line)
+
+		// Handle empty lines
+		if line == "" {
+			continue
+		}
+
+		// Handle special commands
+		if !inMultiLine {
+			switch strings.ToLower(line) {
+			case "exit", "quit":
+				fmt.Println(colorCyan + "Goodbye!" + colorReset)
+				return nil
+			case "help":
+				printHelp()
+				continue
+			case "tables":
+				listTables(store)
+				continue
+			case "clear":
+				clearScreen()
+				continue
+			}
+		}
+
+		// Build multi-line query, keeping line breaks so a -- comment
+		// only runs to the end of its line
+		if inMultiLine {
+			multiLineQuery.WriteString("\n")
+		}
+		multiLineQuery.WriteString(line)
+
+		// Check if query is complete (ends with semicolon)
+		if endsStatement(line) {
+			query := multiLineQuery.String()
+			multiLineQuery.Reset()
+			inMultiLine = false
+
+			// Execute query; COPY reads its rows from the lines that follow
+			if isCopy(query) {
+				copyFromInput(ctx, exec, reader, query)
+			} else {
+				executeQuery(ctx, exec, query)
+			}
+		} else {
+			inMultiLine = true
+		}
+	}
+
+	return nil
+}
+
+// endsStatement reports whether the last token of a line is a semicolon,
+// which may be followed by a comment
+func endsStatement(line string) bool {
+	lexer := parser.NewLexer(line)
+	last := parser.EOF
+	for tok := lexer.NextToken(); tok.Type != parser.EOF; tok = lexer.NextToken() {
+		last = tok.Type
+	}
+	return last == parser.SEMICOLON
+}
+
+func executeQuery(ctx context.Context, exec *executor.Executor, query string) {
+	// Remove trailing semicolon
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+
+	// Parse and execute statement
+	result, err := exec.Query(ctx, query)
+	if err != nil {
+		var parseErr *executor.ParseError
+		if errors.As(err, &parseErr) {
+			printParseError(err)
+		} else {
+			fmt.Printf(colorRed+"Execution error: %v\n"+colorReset, err)
+		}
+		return
+	}
+
+	// Display result. A write with RETURNING has both rows and a message.
+	if len(result.Columns) > 0 {
+		table := result.FormatTable()
+		if result.Message != "" && !strings.HasSuffix(table, "\n") {
+			table += "\n"
+		}
+		fmt.Print(table)
+	}
+	if result.Message != "" {
+		fmt.Println(colorGreen + result.Message + colorReset)
+	}
+	fmt.Println()
+}
+
+// printParseError prints a parse error, showing each syntax error under
+// the line it is on with a caret at the offending token
+func printParseError(err error) {
+	var syntaxErrs parser.SyntaxErrors
+	if !errors.As(err, &syntaxErrs) {
+		fmt.Printf(colorRed+"Parse error: %v\n"+colorReset, err)
+		return
+	}
+	for _, syntaxErr := range syntaxErrs {
+		fmt.Printf(colorRed+"Parse error: %v\n"+colorReset, syntaxErr)
+		for _, line := range strings.Split(syntaxErr.Snippet, "\n") {
+			fmt.Println(colorYellow + "  " + line + colorReset)
+		}
+	}
+}
+
+// isCopy reports whether query is a COPY ... FROM STDIN statement
+func isCopy(query string) bool {
+	stmt, err := parser.NewParser(strings.TrimSuffix(strings.TrimSpace(query), ";")).Parse()
+	if err != nil {
+		return false
+	}
+	copyStmt, ok := stmt.(*parser.CopyStmt)
+	return ok && copyStmt.Path == ""
+}
+
+// copyFromInput runs a COPY statement on the rows typed after it, up to a
+// line containing only \.
+func copyFromInput(ctx context.Context, exec *executor.Executor, reader *bufio.Reader, query string) {
+	fmt.Println(colorBlue + `Enter rows, ending with a line containing only \.` + colorReset)
+
+	input := &copyInput{reader: reader}
+	result, err := exec.Copy(ctx, strings.TrimSuffix(strings.TrimSpace(query), ";"), input)
+	// Skip rows left after a failure so they are not run as SQL
+	io.Copy(io.Discard, input)
+	if err != nil {
+		fmt.Printf(colorRed+"Execution error: %v\n"+colorReset, err)
+		return
+	}
+	fmt.Println(colorGreen + result.Message + colorReset)
+	fmt.Println()
+}
+
+// copyInput reads lines from the REPL input up to the \. terminator
+type copyInput struct {
+	reader  *bufio.Reader
+	pending string
+	done    bool
+}
+
+// Read implements io.Reader
+func (c *copyInput) Read(p []byte) (int, error) {
+	for c.pending == "" {
+		if c.done {
+			return 0, io.EOF
+		}
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			c.done = true
+		}
+		if strings.TrimRight(line, "\r\n") == `\.` {
+			c.done = true
+			continue
+		}
+		c.pending = line
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func printHelp() {
+	fmt.Println(colorCyan + "╔═══════════════════════════════════════════════════════════╗" + colorReset)
+	fmt.Println(colorCyan + "║" + colorReset + "                    " + colorPurple + "Available Commands" + colorReset + "                    " + colorCyan + "║" + colorReset)
+	fmt.Println(colorCyan + "╚═══════════════════════════════════════════════════════════╝" + colorReset)
+	fmt.Println()
+	fmt.Println(colorYellow + "SQL Commands:" + colorReset)
+	fmt.Println("  CREATE TABLE <name> (<columns>) [PARTITION BY RANGE|HASH (<column>)] [USING COLUMNAR|ROW];")
+	fmt.Println("  CREATE TABLE <name> PARTITION OF <table> FOR VALUES FROM (<v>|MINVALUE) TO (<v>|MAXVALUE) [LOCATION '<dir>'];")
+	fmt.Println("  CREATE TABLE <name> PARTITION OF <table> FOR VALUES WITH (MODULUS <n>, REMAINDER <r>) [LOCATION '<dir>'];")
+	fmt.Println("  ALTER TABLE <table> ATTACH PARTITION <name> FOR VALUES ... [LOCATION '<dir>']; | ALTER TABLE <table> DETACH PARTITION <name>;")
+	fmt.Println("  CREATE FOREIGN TABLE <name> (<columns>) SERVER csv OPTIONS (path '<file>', header 'true');")
+	fmt.Println("  CREATE POLICY <name> ON <table> USING (<column> = @current_user); | DROP POLICY <name> ON <table>;")
+	fmt.Println("  GRANT SELECT [(<columns>)] | INSERT | UPDATE | DELETE | UNMASK ON <table> TO <user>; | REVOKE ... FROM <user>;")
+	fmt.Println("  CREATE USER <user>; | DROP USER <user>;")
+	fmt.Println("  CREATE SEQUENCE <name> [START WITH <n>] [INCREMENT BY <n>]; | ALTER SEQUENCE <name> RESTART [WITH <n>]; | DROP SEQUENCE <name>;")
+	fmt.Println("  CREATE FUNCTION <name>(<param> <type>, ...) RETURNS <type> AS 'SELECT <expression>'; | CALL <name>(<args>); | DROP FUNCTION <name>;")
+	fmt.Println("  INSERT INTO <table> VALUES (NEXTVAL('<sequence>'), ...); | CURRVAL('<sequence>')")
+	fmt.Println("  CREATE JOB <name> SCHEDULE '<cron>' AS <statement>; | DROP JOB <name>;   (run by serve)")
+	fmt.Println("  ALTER TABLE <table> ALTER COLUMN <column> SET MASK FULL() | EMAIL() | PARTIAL(<n>, '<padding>', <n>); | ... DROP MASK;")
+	fmt.Println("  DROP TABLE <name>;")
+	fmt.Println("  INSERT INTO <table> VALUES (<values>);")
+	fmt.Println("  SELECT <column> [AS <alias>], ... FROM <table> [WHERE <condition>] [ORDER BY <column> [ASC|DESC] [NULLS FIRST|LAST], ...];")
+	fmt.Println("  <condition>: <expr> <op> <expr> | <expr> [NOT] REGEXP <pattern> | <expr> [NOT] BETWEEN <expr> AND <expr> | <expr> [NOT] IN (SELECT ...) | NOT, AND, OR and ( ) over conditions; (SELECT ...) is also a value")
+	fmt.Println("  SELECT <columns> FROM <table1> [[AS] <alias>] INNER | LEFT [OUTER] JOIN <table2> [[AS] <alias>] ON <condition> [JOIN ...];")
+	fmt.Println("  SELECT <columns> FROM <table> TABLESAMPLE (<n> ROWS) | BERNOULLI (<percent>) [REPEATABLE (<seed>)];")
+	fmt.Println("  SELECT [<column>,] COUNT(*) | COUNT|SUM|AVG|MIN|MAX|APPROX_COUNT_DISTINCT([DISTINCT] <column>), ... FROM <table> [WHERE <condition>] [GROUP BY <column>] [HAVING <condition>];")
+	fmt.Println("  UPDATE <table> SET <column>=<expression>, ... [WHERE <condition>];")
+	fmt.Println("  DELETE FROM <table> [WHERE <condition>];")
+	fmt.Println("  MERGE INTO <table> [<alias>] USING <table> [<alias>] ON <condition> WHEN [NOT] MATCHED [AND <condition>] THEN UPDATE SET ... | DELETE | INSERT [(<columns>)] VALUES (...) | DO NOTHING [WHEN ...];")
+	fmt.Println("  ALTER TABLE <table> ENABLE | DISABLE SOFT DELETE; | SELECT ... WITH DELETED; | PURGE <table> [WHERE <condition>];")
+	fmt.Println("  BACKUP; | CHECKPOINT;")
+	fmt.Println("  RESTORE TO LSN <n>; | RESTORE TO TIMESTAMP '<time>';")
+	fmt.Println("  SHOW STATS; | SHOW TABLES; | DESCRIBE <table>; | SHOW INDEXES FROM <table>;")
+	fmt.Println("  EXPLAIN [ANALYZE] [(FORMAT TEXT | JSON)] SELECT ...; | EXPLAIN ADVISE [<table>];")
+	fmt.Println("  COPY <table> [(<columns>)] FROM STDIN [WITH (FORMAT csv, DELIMITER ',', HEADER, NULL '')];  (rows follow, end with \\.)")
+	fmt.Println("  COPY <table> [(<columns>)] FROM '<file>' [WITH (...)];")
+	fmt.Println("  SET <name> = <value>; | SHOW <name>; | SHOW ALL;  (use @name in expressions)")
+	fmt.Println("  SET GLOBAL <setting> = <value> | DEFAULT; | PRAGMA <setting> = <value>; | PRAGMA [<setting>];")
+	fmt.Println()
+	fmt.Println(colorYellow + "Data Types:" + colorReset)
+	fmt.Println("  INTEGER, VARCHAR(size), TEXT, BOOLEAN, FLOAT, CITEXT (case-insensitive text), JSON (data->'key' as JSON, data->>'key' as a value), TIMESTAMP, DATE, UUID, BLOB (X'<hex>', FROM_BASE64('...'))")
+	fmt.Println("  NOW(), INTERVAL '<n> <unit> ...' (TIMESTAMP +/- INTERVAL, TIMESTAMP - TIMESTAMP), DATE 'YYYY-MM-DD'")
+	fmt.Println()
+	fmt.Println(colorYellow + "Constraints:" + colorReset)
+	fmt.Println("  PRIMARY KEY, UNIQUE, NOT NULL; UNIQUE (<column>, ...) after the columns")
+	fmt.Println("  <column> <type> GENERATED ALWAYS AS (<expr>) [STORED|VIRTUAL]")
+	fmt.Println("  <column> VARCHAR(size) COLLATE binary|nocase|unicode")
+	fmt.Println("  <column> VARCHAR(size) ENCODING DICTIONARY; ALTER TABLE <table> ALTER COLUMN <column> SET ENCODING DICTIONARY|PLAIN;")
+	fmt.Println("  <column> TIMESTAMP DEFAULT NOW() [ON UPDATE NOW()]")
+	fmt.Println("  <column> UUID DEFAULT GEN_RANDOM_UUID()")
+	fmt.Println()
+	fmt.Println(colorYellow + "REPL Commands:" + colorReset)
+	fmt.Println("  help      - Show this help message")
+	fmt.Println("  tables    - List all tables")
+	fmt.Println("  clear     - Clear the screen")
+	fmt.Println("  exit/quit - Exit the REPL")
+	fmt.Println()
+	fmt.Println(colorYellow + "Examples:" + colorReset)
+	fmt.Println("  CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(100), email VARCHAR(100) UNIQUE);")
+	fmt.Println("  INSERT INTO users VALUES (1, 'John Doe', 'john@example.com');")
+	fmt.Println("  SELECT * FROM users WHERE id = 1;")
+	fmt.Println("  UPDATE users SET name = 'Jane Doe' WHERE id = 1;")
+	fmt.Println("  DELETE FROM users WHERE id = 1;")
+	fmt.Println()
+}
+
+func listTables(store *storage.Storage) {
+	tables := store.ListTables()
+	if len(tables) == 0 {
+		fmt.Println(colorYellow + "No tables found." + colorReset)
+		return
+	}
+
+	fmt.Println(colorCyan + "╔═══════════════════════════════════════════════════════════╗" + colorReset)
+	fmt.Println(colorCyan + "║" + colorReset + "                       " + colorPurple + "Tables" + colorReset + "                           " + colorCyan + "║" + colorReset)
+	fmt.Println(colorCyan + "╚═══════════════════════════════════════════════════════════╝" + colorReset)
+	fmt.Println()
+	for i, table := range tables {
+		fmt.Printf("  %d. %s\n", i+1, table)
+	}
+	fmt.Println()
+}
+
+func clearScreen() {
+	fmt.Print("\033[H\033[2J")
+}
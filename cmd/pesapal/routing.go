@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+)
+
+// Read routing headers. Clients send X-Consistency: strong to read from the
+// leader, or X-Min-LSN with the X-LSN of an earlier response to read their
+// own writes; X-Served-By names the server that answered.
+const (
+	headerConsistency = "X-Consistency"
+	headerMinLSN      = "X-Min-LSN"
+	headerLSN         = "X-LSN"
+	headerServedBy    = "X-Served-By"
+)
+
+// routeRead forwards a SELECT to a replica and relays its answer, reporting
+// whether it did. Anything else, and reads no replica can take, are left
+// for the leader to execute.
+func routeRead(c *fiber.Ctx, ctx context.Context, query string) bool {
+	if router == nil || strings.EqualFold(c.Get(headerConsistency), "strong") {
+		return false
+	}
+	stmt, err := parser.NewParser(query).Parse()
+	if err != nil {
+		return false
+	}
+	if _, ok := stmt.(*parser.SelectStmt); !ok {
+		return false
+	}
+	minLSN, _ := strconv.ParseUint(c.Get(headerMinLSN), 10, 64)
+
+	// The replica has no copy of this session, so send the values along
+	query, err = executor.ResolveVariables(ctx, query)
+	if err != nil {
+		return false
+	}
+	body, err := json.Marshal(QueryRequest{Query: query})
+	if err != nil {
+		return false
+	}
+	header := http.Header{}
+	header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	header.Set(headerMinLSN, strconv.FormatUint(minLSN, 10))
+	for _, name := range forwardHeaders {
+		if c.Get(name) != "" {
+			header.Set(name, c.Get(name))
+		}
+	}
+
+	// Try each replica at most once before falling back to the leader
+	for range router.Replicas() {
+		replicaURL, ok := router.Pick(minLSN)
+		if !ok {
+			return false
+		}
+		resp, err := router.Forward(ctx, replicaURL, body, header)
+		if err != nil {
+			continue
+		}
+
+		// The body is streamed to the client and closed once sent
+		c.Status(resp.StatusCode)
+		c.Set(fiber.HeaderContentType, resp.Header.Get(fiber.HeaderContentType))
+		c.Set(headerServedBy, replicaURL)
+		c.Response().SetBodyStream(resp.Body, int(resp.ContentLength))
+		return true
+	}
+	return false
+}
+
+// replicaBehind rejects a read on a follower that has not yet applied the
+// LSN the client asked for, so the leader or client can go elsewhere
+func replicaBehind(c *fiber.Ctx) bool {
+	minLSN, err := strconv.ParseUint(c.Get(headerMinLSN, "0"), 10, 64)
+	if err != nil || follower == nil {
+		return false
+	}
+	applied := exec.WAL().LastLSN()
+	if applied >= minLSN {
+		return false
+	}
+	c.Set(fiber.HeaderRetryAfter, "1")
+	c.Status(fiber.StatusServiceUnavailable).JSON(QueryResponse{
+		Success: false,
+		Error:   fmt.Sprintf("replica has applied LSN %d, behind the requested %d", applied, minLSN),
+	})
+	return true
+}
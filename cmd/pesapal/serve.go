@@ -0,0 +1,1100 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/replication"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/scheduler"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/tracing"
+)
+
+var (
+	store    *storage.Storage
+	exec     *executor.Executor
+	follower *replication.Follower
+	router   *replication.Router // set on a leader, to route reads to its replicas
+	// forwardHeaders are copied onto reads routed to a replica
+	forwardHeaders = []string{"traceparent"}
+	sessions       = executor.NewSessions(30 * time.Minute)
+	// admins are the users named by -user-header who may use the admin
+	// endpoints and see every user's running statements
+	admins []string
+)
+
+// QueryRequest represents a SQL query request
+type QueryRequest struct {
+	Query string `json:"query"`
+	// Params are bound to the query's ? or $n placeholders, in order
+	Params []json.RawMessage `json:"params,omitempty"`
+}
+
+// QueryResponse represents a SQL query response
+type QueryResponse struct {
+	Success      bool          `json:"success"`
+	Message      string        `json:"message,omitempty"`
+	Columns      []string      `json:"columns,omitempty"`
+	Rows         [][]interface{} `json:"rows,omitempty"`
+	RowsAffected int           `json:"rowsAffected"`
+	Error        string        `json:"error,omitempty"`
+
+	// SyntaxErrors locates each syntax error when the query failed to parse
+	SyntaxErrors parser.SyntaxErrors `json:"syntaxErrors,omitempty"`
+
+	// Plan is the plan tree of an EXPLAIN
+	Plan *executor.Plan `json:"plan,omitempty"`
+
+	// Results holds the response to each statement of a query with more
+	// than one, up to the first that failed
+	Results []QueryResponse `json:"results,omitempty"`
+}
+
+// ExplainRequest represents a request for the plan of a SELECT
+type ExplainRequest struct {
+	Query   string `json:"query"`
+	Analyze bool   `json:"analyze"`
+}
+
+// TableInfo represents table metadata
+type TableInfo struct {
+	Name       string              `json:"name"`
+	Columns    []ColumnInfo        `json:"columns"`
+	Indexes    []storage.IndexInfo `json:"indexes"`
+	SoftDelete bool                `json:"softDelete,omitempty"`
+	Layout     string              `json:"layout,omitempty"`
+}
+
+// ColumnInfo represents column metadata
+type ColumnInfo struct {
+	Name       string `json:"name"`
+	DataType   string `json:"dataType"`
+	Size       int    `json:"size,omitempty"`
+	PrimaryKey bool   `json:"primaryKey"`
+	Unique     bool   `json:"unique"`
+	NotNull    bool   `json:"notNull"`
+	Generated  string `json:"generated,omitempty"`
+	Virtual    bool   `json:"virtual,omitempty"`
+	Collation  string `json:"collation,omitempty"`
+	Mask       string `json:"mask,omitempty"`
+	Dictionary bool   `json:"dictionary,omitempty"`
+}
+
+// runServe starts the HTTP API server
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var opts options
+	opts.register(fs, executor.ResultLimits{MaxRows: 100000, MaxBytes: 64 << 20})
+	port := fs.String("port", envString("PORT", "8080"), "port to listen on (env PORT)")
+	leaderURL := fs.String("leader", os.Getenv("LEADER_URL"), "replicate from the leader at this URL and serve read-only (env LEADER_URL)")
+	advertiseURL := fs.String("advertise-url", os.Getenv("ADVERTISE_URL"), "on a follower, the URL the leader lists this server under in -replicas, sent with each poll so reads are only routed here once it has caught up (env ADVERTISE_URL)")
+	replicaURLs := fs.String("replicas", os.Getenv("REPLICA_URLS"), "on a leader, comma-separated follower URLs to route reads to (env REPLICA_URLS)")
+	userHeader := fs.String("user-header", os.Getenv("USER_HEADER"), "run queries for the user named in this request header, set by an authenticating proxy; requests without it are rejected (env USER_HEADER)")
+	adminUsers := fs.String("admin-users", os.Getenv("ADMIN_USERS"), "with -user-header, comma-separated users allowed to use the admin, replication and change data capture endpoints (env ADMIN_USERS)")
+	replicationUser := fs.String("replication-user", os.Getenv("REPLICATION_USER"), "on a follower, the user named in the -user-header header when pulling the leader's WAL; one of the leader's -admin-users (env REPLICATION_USER)")
+	maxConcurrent := fs.Int("max-concurrent", envInt("MAX_CONCURRENT", 4*runtime.NumCPU()), "statements executing at once, 0 for no limit (env MAX_CONCURRENT)")
+	maxQueued := fs.Int("max-queued", envInt("MAX_QUEUED", 100), "statements waiting to execute; more are rejected with 503 (env MAX_QUEUED)")
+	compression := fs.String("compression", envString("COMPRESSION", "default"), "compress responses for clients that send Accept-Encoding gzip, deflate or br: default, speed, best or off (env COMPRESSION)")
+	queueTimeout := fs.Duration("queue-timeout", envDuration("QUEUE_TIMEOUT", 5*time.Second), "how long a statement waits to execute before it is rejected with 503, 0 for no limit (env QUEUE_TIMEOUT)")
+	fs.Parse(args)
+
+	if opts.readOnly && *leaderURL != "" {
+		return fmt.Errorf("-read-only cannot be combined with -leader: a follower must own its data directory")
+	}
+	if *leaderURL != "" && *replicaURLs != "" {
+		return fmt.Errorf("-replicas is set on the leader, not on a follower")
+	}
+	if *leaderURL == "" && *advertiseURL != "" {
+		return fmt.Errorf("-advertise-url requires -leader")
+	}
+	if *userHeader == "" && (*adminUsers != "" || *replicationUser != "") {
+		return fmt.Errorf("-admin-users and -replication-user require -user-header")
+	}
+	if *leaderURL == "" && *replicationUser != "" {
+		return fmt.Errorf("-replication-user requires -leader")
+	}
+	if *maxConcurrent < 0 || *maxQueued < 0 || *queueTimeout < 0 {
+		return fmt.Errorf("admission limits must not be negative")
+	}
+	compressLevel, err := compressionLevel(*compression)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase(opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	store, exec = db.store, db.exec
+
+	// Queue statements beyond the concurrency limit so bursts of heavy
+	// queries wait (or are turned away) instead of exhausting memory
+	exec.SetAdmissionLimits(executor.AdmissionLimits{
+		MaxConcurrent: *maxConcurrent,
+		MaxQueued:     *maxQueued,
+		QueueTimeout:  *queueTimeout,
+	})
+
+	// Publish committed row changes for change data capture
+	if db.wal != nil {
+		exec.SetChangeStream(cdc.NewStream(db.wal.LastAssignedLSN(), 10000))
+	}
+
+	// Run as a read-only follower when a leader is configured
+	if *leaderURL != "" {
+		follower, err = replication.NewFollower(*leaderURL, exec)
+		if err != nil {
+			return fmt.Errorf("failed to start replication: %w", err)
+		}
+		follower.SetAdvertiseURL(*advertiseURL)
+		if *replicationUser != "" {
+			follower.SetUser(*userHeader, *replicationUser)
+		}
+		exec.SetReadOnly(true)
+		go follower.Run(context.Background())
+	}
+
+	// A leader routes reads to the followers it is given; followers that
+	// merely poll it are not trusted with its clients' reads
+	if follower == nil && db.wal != nil && !opts.readOnly {
+		router = replication.NewRouter()
+		for _, replicaURL := range splitList(*replicaURLs) {
+			if _, err := router.Register(replicaURL); err != nil {
+				return err
+			}
+		}
+	}
+	if *userHeader != "" {
+		forwardHeaders = append(forwardHeaders, *userHeader)
+	}
+
+	// Run scheduled jobs; a follower replays their effects instead
+	if follower == nil && !opts.readOnly {
+		jobs := scheduler.New(exec)
+		jobs.Start()
+		defer jobs.Stop()
+	}
+
+	// Create Fiber app
+	app := fiber.New(fiber.Config{
+		ErrorHandler: customErrorHandler,
+		JSONEncoder:  json.Marshal,
+		JSONDecoder:  json.Unmarshal,
+		// Let COPY read large (or chunked) uploads as they arrive
+		StreamRequestBody: true,
+	})
+
+	// Middleware
+	app.Use(logger.New())
+	app.Use(traceMiddleware)
+	allowHeaders := "Origin, Content-Type, Accept, traceparent, X-Session-ID, X-Consistency, X-Min-LSN"
+	if *userHeader != "" {
+		allowHeaders += ", " + *userHeader
+	}
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:  "*",
+		AllowMethods:  "GET,POST,PUT,DELETE,OPTIONS",
+		AllowHeaders:  allowHeaders,
+		ExposeHeaders: "X-LSN, X-Served-By",
+	}))
+	// Large results compress well; the change stream and streamed results
+	// are left alone as compressing them would hold lines back until a
+	// buffer fills
+	app.Use(compress.New(compress.Config{
+		Level: compressLevel,
+		Next: func(c *fiber.Ctx) bool {
+			return c.Path() == "/api/cdc/stream" || wantsStream(c)
+		},
+	}))
+
+	// Routes
+	app.Get("/", handleRoot)
+	app.Get("/console", handleConsole)
+	app.Get("/api/health", handleHealth)
+	withUser := userMiddleware(*userHeader)
+	// The WAL and change stream hold every user's rows and statements, so
+	// under -user-header only admins may read them
+	admins = splitList(*adminUsers)
+	withAdmin := adminMiddleware(*userHeader, admins)
+	app.Post("/api/query", withUser, handleQuery)
+	app.Post("/api/explain", withUser, handleExplain)
+	app.Get("/api/tables", handleListTables)
+	app.Get("/api/tables/:name", handleGetTable)
+	app.Post("/api/tables/:name/copy", withUser, handleCopy)
+	app.Get("/api/stats", handleStats)
+	app.Get("/api/admin/storage", withAdmin, handleStorageUsage)
+	app.Post("/api/admin/checkpoint", withAdmin, handleCheckpoint)
+	app.Get("/api/admin/advise", withAdmin, handleAdvise)
+	app.Get("/api/admin/queries", withUser, handleListQueries)
+	app.Post("/api/admin/queries/:id/kill", withUser, handleKillQuery)
+	// The WAL belongs to the process that owns the data directory
+	if db.wal != nil {
+		app.Get(replication.WALPath, withAdmin, handleReplicationWAL)
+		app.Get("/api/replication/status", withAdmin, handleReplicationStatus)
+		if router != nil {
+			app.Get("/api/replication/replicas", withAdmin, handleListReplicas)
+			// Replicas are sent users' reads, so only an authenticated
+			// admin may add them; otherwise they come from -replicas alone
+			if *userHeader != "" {
+				app.Post("/api/replication/replicas", withAdmin, handleRegisterReplica)
+				app.Delete("/api/replication/replicas", withAdmin, handleUnregisterReplica)
+			}
+		}
+		app.Get("/api/cdc", withAdmin, handleChanges)
+		app.Get("/api/cdc/stream", withAdmin, handleChangeStream)
+	}
+
+	log.Printf("🚀 Pesapal RDBMS API Server starting on port %s", *port)
+	log.Printf("📊 Data directory: %s", db.dir)
+	log.Printf("🔗 API endpoint: http://localhost:%s/api/query", *port)
+	log.Printf("🖥️  Web console: http://localhost:%s/console", *port)
+	if follower != nil {
+		log.Printf("📡 Replicating from %s (read-only)", follower.Status().Leader)
+	}
+
+	// Shut down cleanly on SIGINT/SIGTERM so batched flush policies write
+	// their pending tables before exit
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		log.Printf("Shutting down")
+		app.Shutdown()
+	}()
+
+	if err := app.Listen(":" + *port); err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+	return nil
+}
+
+// compressionLevel parses the -compression flag
+func compressionLevel(name string) (compress.Level, error) {
+	switch strings.ToLower(name) {
+	case "default":
+		return compress.LevelDefault, nil
+	case "speed":
+		return compress.LevelBestSpeed, nil
+	case "best":
+		return compress.LevelBestCompression, nil
+	case "off":
+		return compress.LevelDisabled, nil
+	default:
+		return 0, fmt.Errorf("invalid -compression %q: expected default, speed, best or off", name)
+	}
+}
+
+// handleRoot handles the root endpoint
+func handleRoot(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"name":    "Pesapal RDBMS API",
+		"version": "1.0.0",
+		"challenge": "Junior Dev Challenge 2026",
+		"endpoints": fiber.Map{
+			"console":     "GET /console",
+			"health":      "GET /api/health",
+			"query":       "POST /api/query",
+			"listTables":  "GET /api/tables",
+			"getTable":    "GET /api/tables/:name",
+			"stats":       "GET /api/stats",
+		},
+	})
+}
+
+// handleHealth handles health check
+func handleHealth(c *fiber.Ctx) error {
+	tables := store.ListTables()
+	return c.JSON(fiber.Map{
+		"status": "healthy",
+		"tables": len(tables),
+	})
+}
+
+// handleQuery handles SQL query execution
+func handleQuery(c *fiber.Ctx) error {
+	var req QueryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(QueryResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	if req.Query == "" {
+		return c.Status(400).JSON(QueryResponse{
+			Success: false,
+			Error:   "Query is required",
+		})
+	}
+
+	query := req.Query
+	if len(req.Params) > 0 {
+		params, err := decodeParams(req.Params)
+		if err == nil {
+			query, err = parser.BindParameters(query, params)
+		}
+		if err != nil {
+			return c.Status(400).JSON(QueryResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Invalid params: %v", err),
+			})
+		}
+	}
+
+	// Parse and execute query
+	// Requests sharing an X-Session-ID header share session variables
+	session := executor.NewSession("")
+	if id := c.Get("X-Session-ID"); id != "" {
+		session = sessions.Get(id)
+	}
+	ctx := executor.WithSession(c.UserContext(), session)
+	if replicaBehind(c) {
+		return nil
+	}
+	// A query may hold several statements separated by semicolons
+	statements, err := parser.ParseAll(query)
+	if err != nil {
+		return queryError(c, &executor.ParseError{Err: err})
+	}
+	if len(statements) > 1 {
+		return handleScript(c, ctx, query)
+	}
+	// Streamed results are served here rather than relayed from a replica
+	if wantsStream(c) {
+		return handleQueryStream(c, ctx, query)
+	}
+	if routeRead(c, ctx, query) {
+		return nil
+	}
+	result, err := exec.Query(ctx, query)
+	// Clients pass this back as X-Min-LSN to read their writes on a replica
+	if exec.WAL() != nil {
+		c.Set(headerLSN, strconv.FormatUint(exec.WAL().LastLSN(), 10))
+	}
+	if err != nil {
+		return queryError(c, err)
+	}
+
+	return c.JSON(resultResponse(result))
+}
+
+// handleScript runs the statements of a query with more than one in order,
+// responding with the result of each. Their rows are not streamed, and
+// SELECTs among them are not sent to replicas.
+func handleScript(c *fiber.Ctx, ctx context.Context, script string) error {
+	results, err := exec.QueryAll(ctx, script)
+	if exec.WAL() != nil {
+		c.Set(headerLSN, strconv.FormatUint(exec.WAL().LastLSN(), 10))
+	}
+
+	responses := make([]QueryResponse, len(results))
+	for i, result := range results {
+		responses[i] = resultResponse(result)
+	}
+	if err != nil {
+		status, response := errorResponse(c, err)
+		response.Results = append(responses, response)
+		return c.Status(status).JSON(response)
+	}
+	return c.JSON(QueryResponse{Success: true, Results: responses})
+}
+
+// resultResponse builds the response to a statement that succeeded
+func resultResponse(result *executor.Result) QueryResponse {
+	return QueryResponse{
+		Success:      true,
+		Message:      result.Message,
+		Columns:      result.Columns,
+		Rows:         result.Rows,
+		RowsAffected: result.RowsAffected,
+		Plan:         result.Plan,
+	}
+}
+
+// decodeParams converts the JSON values of a request's params to the
+// values they bind: whole numbers to INTEGER, other numbers to FLOAT and
+// true and false to BOOLEAN
+func decodeParams(raw []json.RawMessage) ([]interface{}, error) {
+	params := make([]interface{}, len(raw))
+	for i, data := range raw {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+		}
+		switch v := value.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				params[i] = int(n)
+			} else if params[i], err = v.Float64(); err != nil {
+				return nil, fmt.Errorf("parameter %d: %w", i+1, err)
+			}
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("parameter %d: objects and arrays cannot be bound; pass JSON as a string", i+1)
+		default:
+			params[i] = v
+		}
+	}
+	return params, nil
+}
+
+// queryError responds to a query that failed
+func queryError(c *fiber.Ctx, err error) error {
+	status, response := errorResponse(c, err)
+	return c.Status(status).JSON(response)
+}
+
+// errorResponse returns the response to a query that failed and its
+// status: 400 for a parse error or an oversized result and 503 when the
+// server is busy
+func errorResponse(c *fiber.Ctx, err error) (int, QueryResponse) {
+	var parseErr *executor.ParseError
+	if errors.As(err, &parseErr) {
+		var syntaxErrs parser.SyntaxErrors
+		errors.As(err, &syntaxErrs)
+		return 400, QueryResponse{
+			Success:      false,
+			Error:        fmt.Sprintf("Parse error: %v", err),
+			SyntaxErrors: syntaxErrs,
+		}
+	}
+	status := 500
+	if errors.Is(err, executor.ErrResultTooLarge) {
+		status = 400
+	}
+	if errors.Is(err, executor.ErrOverloaded) {
+		status = 503
+		c.Set(fiber.HeaderRetryAfter, "1")
+	}
+	return status, QueryResponse{
+		Success: false,
+		Error:   fmt.Sprintf("Execution error: %v", err),
+	}
+}
+
+// handleExplain returns the plan of a SELECT as a tree
+func handleExplain(c *fiber.Ctx) error {
+	var req ExplainRequest
+	if err := c.BodyParser(&req); err != nil || req.Query == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"error":   "A query is required",
+		})
+	}
+
+	session := executor.NewSession("")
+	if id := c.Get("X-Session-ID"); id != "" {
+		session = sessions.Get(id)
+	}
+	plan, err := exec.Explain(executor.WithSession(c.UserContext(), session), req.Query, req.Analyze)
+	if err != nil {
+		var parseErr *executor.ParseError
+		if errors.As(err, &parseErr) {
+			var syntaxErrs parser.SyntaxErrors
+			errors.As(err, &syntaxErrs)
+			return c.Status(400).JSON(QueryResponse{
+				Success:      false,
+				Error:        fmt.Sprintf("Parse error: %v", err),
+				SyntaxErrors: syntaxErrs,
+			})
+		}
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"plan":    plan,
+	})
+}
+
+// handleListTables lists all tables
+func handleListTables(c *fiber.Ctx) error {
+	tables := store.ListTables()
+	
+	tableInfos := []TableInfo{}
+	for _, tableName := range tables {
+		table, err := store.GetTable(tableName)
+		if err != nil {
+			continue
+		}
+
+		columns := []ColumnInfo{}
+		for _, col := range table.Schema.Columns {
+			columns = append(columns, ColumnInfo{
+				Name:       col.Name,
+				DataType:   col.DataType.String(),
+				Size:       col.Size,
+				PrimaryKey: col.PrimaryKey,
+				Unique:     col.Unique,
+				NotNull:    col.NotNull,
+				Generated:  col.Generated,
+				Virtual:    col.Virtual,
+				Collation:  col.Collation,
+				Dictionary: col.Dictionary,
+			})
+		}
+
+		indexes, _ := store.Indexes(tableName)
+		tableInfos = append(tableInfos, TableInfo{
+			Name:       tableName,
+			Columns:    columns,
+			Indexes:    indexes,
+			SoftDelete: table.Schema.SoftDelete,
+			Layout:     table.Schema.Layout,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"tables":  tableInfos,
+	})
+}
+
+// handleCopy streams the request body into a table, like COPY ... FROM
+// STDIN. Query parameters: format (text or csv), delimiter, header, null and
+// columns (comma-separated).
+func handleCopy(c *fiber.Ctx) error {
+	tableName := c.Params("name")
+	if !store.TableExists(tableName) {
+		return c.Status(404).JSON(QueryResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Table '%s' not found", tableName),
+		})
+	}
+
+	opts := executor.CopyOptions{Header: c.QueryBool("header"), Null: c.Query("null")}
+	switch strings.ToLower(c.Query("format", "text")) {
+	case "text":
+	case "csv":
+		opts.Format = executor.CopyCSV
+	default:
+		return c.Status(400).JSON(QueryResponse{
+			Success: false,
+			Error:   fmt.Sprintf("unknown format %q (want text or csv)", c.Query("format")),
+		})
+	}
+	if delimiter := c.Query("delimiter"); delimiter != "" {
+		runes := []rune(delimiter)
+		if len(runes) != 1 {
+			return c.Status(400).JSON(QueryResponse{
+				Success: false,
+				Error:   "delimiter must be a single character",
+			})
+		}
+		opts.Delimiter = runes[0]
+	}
+	var columns []string
+	if list := c.Query("columns"); list != "" {
+		for _, name := range strings.Split(list, ",") {
+			columns = append(columns, strings.TrimSpace(name))
+		}
+	}
+
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+	copied, err := exec.CopyFrom(c.UserContext(), tableName, columns, body, opts)
+	if err != nil {
+		status := 400
+		if errors.Is(err, executor.ErrOverloaded) {
+			status = 503
+			c.Set(fiber.HeaderRetryAfter, "1")
+		}
+		return c.Status(status).JSON(QueryResponse{
+			Success:      false,
+			Error:        fmt.Sprintf("Copy error: %v (%d row(s) copied before the failure)", err, copied),
+			RowsAffected: copied,
+		})
+	}
+
+	return c.JSON(QueryResponse{
+		Success:      true,
+		Message:      fmt.Sprintf("%d row(s) copied", copied),
+		RowsAffected: copied,
+	})
+}
+
+// handleGetTable gets information about a specific table
+func handleGetTable(c *fiber.Ctx) error {
+	tableName := c.Params("name")
+	
+	if !store.TableExists(tableName) {
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("Table '%s' not found", tableName),
+		})
+	}
+
+	table, err := store.GetTable(tableName)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	columns := []ColumnInfo{}
+	for _, col := range table.Schema.Columns {
+		columns = append(columns, ColumnInfo{
+			Name:       col.Name,
+			DataType:   col.DataType.String(),
+			Size:       col.Size,
+			PrimaryKey: col.PrimaryKey,
+			Unique:     col.Unique,
+			NotNull:    col.NotNull,
+			Generated:  col.Generated,
+			Virtual:    col.Virtual,
+			Collation:  col.Collation,
+			Mask:       col.Mask,
+			Dictionary: col.Dictionary,
+		})
+	}
+
+	// Get row count; a partitioned table's rows are in its partitions
+	rowCount := table.RowCount()
+	for _, partition := range store.Partitions(table) {
+		rowCount += partition.RowCount()
+	}
+
+	indexes, err := store.Indexes(tableName)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"table": TableInfo{
+			Name:       tableName,
+			Columns:    columns,
+			Indexes:    indexes,
+			SoftDelete: table.Schema.SoftDelete,
+			Layout:     table.Schema.Layout,
+		},
+		"rowCount": rowCount,
+	})
+}
+
+// handleReplicationWAL serves WAL records to followers
+func handleReplicationWAL(c *fiber.Ctx) error {
+	since, err := strconv.ParseUint(c.Query("since", "0"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"error":   "since must be a non-negative integer",
+		})
+	}
+	limit := c.QueryInt("limit", 500)
+
+	// Registered followers say where they serve reads with each poll
+	if replicaURL := c.Query("replica"); replicaURL != "" && router != nil {
+		if err := router.Polled(replicaURL, since); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	return c.JSON(replication.ReadBatch(exec.WAL(), since, limit))
+}
+
+// handleStats returns the executor's runtime counters
+func handleStats(c *fiber.Ctx) error {
+	stats := exec.Stats()
+	return c.JSON(fiber.Map{
+		"success":       true,
+		"uptimeSeconds": stats.Uptime.Seconds(),
+		"stats":         stats,
+	})
+}
+
+// handleStorageUsage reports memory and disk usage per table along with the
+// process's heap, so operators can see what is consuming resources
+func handleStorageUsage(c *fiber.Ctx) error {
+	usage, err := store.Usage()
+	if err != nil {
+		return err
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"storage": usage,
+		"process": fiber.Map{
+			"heapAllocBytes": mem.HeapAlloc,
+			"heapSysBytes":   mem.HeapSys,
+			"sysBytes":       mem.Sys,
+			"goroutines":     runtime.NumGoroutine(),
+		},
+	})
+}
+
+// handleCheckpoint flushes the tables and truncates the WAL, so a copy of
+// the data directory taken afterwards needs little or no recovery
+func handleCheckpoint(c *fiber.Ctx) error {
+	lsn, removed, err := exec.CheckpointWAL(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success":           true,
+		"lsn":               lsn,
+		"walRecordsRemoved": removed,
+	})
+}
+
+// handleAdvise suggests indexes for the columns queries have filtered on,
+// for every table or the one named by ?table=
+func handleAdvise(c *fiber.Ctx) error {
+	suggestions, err := exec.AdviseIndexes(c.Query("table"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success":     true,
+		"suggestions": suggestions,
+	})
+}
+
+// handleListQueries lists the statements the server is executing that the
+// request's user may see, with how long each has been running
+func handleListQueries(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"queries": visibleQueries(c),
+	})
+}
+
+// handleKillQuery cancels a running statement by the id
+// /api/admin/queries lists it under
+func handleKillQuery(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err == nil {
+		err = fmt.Errorf("no running query with id %d", id)
+		// Users may only cancel the statements they can see
+		for _, q := range visibleQueries(c) {
+			if q.ID == id {
+				err = exec.KillQuery(id)
+				break
+			}
+		}
+	}
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("query %d canceled", id),
+	})
+}
+
+// visibleQueries returns the running statements the request's user may see
+// and cancel: every statement for admins and requests for no user, and only
+// their own for other users
+func visibleQueries(c *fiber.Ctx) []executor.RunningQuery {
+	queries := exec.RunningQueries()
+	user := executor.UserFrom(c.UserContext())
+	if user == "" || slices.Contains(admins, user) {
+		return queries
+	}
+	own := []executor.RunningQuery{}
+	for _, q := range queries {
+		if q.User == user {
+			own = append(own, q)
+		}
+	}
+	return own
+}
+
+// handleReplicationStatus reports this node's replication role and position
+func handleReplicationStatus(c *fiber.Ctx) error {
+	if follower == nil {
+		response := fiber.Map{
+			"success": true,
+			"role":    "leader",
+			"lastLsn": exec.WAL().LastLSN(),
+		}
+		if router != nil {
+			response["replicas"] = router.Replicas()
+		}
+		return c.JSON(response)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"role":    "follower",
+		"status":  follower.Status(),
+	})
+}
+
+// handleListReplicas lists the replicas reads are routed to
+func handleListReplicas(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"replicas": router.Replicas(),
+	})
+}
+
+// handleRegisterReplica registers a replica to route reads to
+func handleRegisterReplica(c *fiber.Ctx) error {
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid request body",
+		})
+	}
+	replicaURL, err := router.Register(req.URL)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"url":     replicaURL,
+	})
+}
+
+// handleUnregisterReplica stops routing reads to the replica in ?url=
+func handleUnregisterReplica(c *fiber.Ctx) error {
+	if !router.Unregister(c.Query("url")) {
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("replica %q is not registered", c.Query("url")),
+		})
+	}
+	return c.JSON(fiber.Map{"success": true})
+}
+
+// handleChanges returns committed row changes from an LSN, optionally
+// waiting up to `wait` seconds for new ones (long polling)
+func handleChanges(c *fiber.Ctx) error {
+	stream := exec.ChangeStream()
+	from, err := strconv.ParseUint(c.Query("from", "1"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"error":   "from must be a non-negative integer",
+		})
+	}
+
+	if wait := c.QueryInt("wait", 0); wait > 0 {
+		ctx, cancel := context.WithTimeout(c.UserContext(), time.Duration(wait)*time.Second)
+		defer cancel()
+		stream.Wait(ctx, from)
+	}
+
+	changes, err := stream.Read(from, c.QueryInt("limit", 1000))
+	if err != nil {
+		return c.Status(410).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	next := from
+	if len(changes) > 0 {
+		next = changes[len(changes)-1].LSN + 1
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"changes": changes,
+		"next":    next,
+	})
+}
+
+// handleChangeStream streams committed row changes as server-sent events.
+// Each statement's last change carries its LSN as the event id, so
+// reconnecting clients resume via the Last-Event-ID header.
+func handleChangeStream(c *fiber.Ctx) error {
+	stream := exec.ChangeStream()
+	from, err := strconv.ParseUint(c.Query("from", "1"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"error":   "from must be a non-negative integer",
+		})
+	}
+	if lastID := c.Get("Last-Event-ID"); lastID != "" {
+		if lsn, err := strconv.ParseUint(lastID, 10, 64); err == nil {
+			from = lsn + 1
+		}
+	}
+
+	if _, err := stream.Read(from, 1); err != nil {
+		return c.Status(410).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		next := from
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			waitErr := stream.Wait(ctx, next)
+			cancel()
+
+			if waitErr != nil {
+				// Keep the connection alive and detect disconnected clients
+				fmt.Fprint(w, ": ping\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+				continue
+			}
+
+			changes, err := stream.Read(next, 0)
+			if err != nil {
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+				w.Flush()
+				return
+			}
+
+			for i, change := range changes {
+				data, _ := json.Marshal(change)
+				if i == len(changes)-1 || changes[i+1].LSN != change.LSN {
+					fmt.Fprintf(w, "id: %d\n", change.LSN)
+				}
+				fmt.Fprintf(w, "event: change\ndata: %s\n\n", data)
+				next = change.LSN + 1
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// traceMiddleware starts a span for each request, continuing the caller's
+// trace when a traceparent header is present
+func traceMiddleware(c *fiber.Ctx) error {
+	ctx := context.Background()
+	if sc, ok := tracing.ParseTraceparent(c.Get("traceparent")); ok {
+		ctx = tracing.ContextWithRemoteParent(ctx, sc)
+	}
+
+	ctx, span := tracing.Start(ctx, c.Method()+" "+c.Path())
+	defer span.End()
+	c.SetUserContext(ctx)
+
+	if sc := span.Context(); sc.IsValid() {
+		c.Set("traceparent", sc.Traceparent())
+	}
+
+	err := c.Next()
+	span.RecordError(err)
+	span.SetAttribute("http.status_code", c.Response().StatusCode())
+	return err
+}
+
+// userMiddleware runs a request's statements for the user named in header,
+// so row-level security policies apply to them. Without a header every
+// request runs for no user.
+func userMiddleware(header string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if header == "" {
+			return c.Next()
+		}
+		user := c.Get(header)
+		if user == "" {
+			return c.Status(401).JSON(QueryResponse{
+				Success: false,
+				Error:   fmt.Sprintf("missing %s header", header),
+			})
+		}
+		c.SetUserContext(executor.WithUser(c.UserContext(), user))
+		return c.Next()
+	}
+}
+
+// adminMiddleware lets only the users in admins through when users are
+// named in header. Without a header every request is let through, as every
+// statement runs for no user.
+func adminMiddleware(header string, admins []string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if header == "" {
+			return c.Next()
+		}
+		user := c.Get(header)
+		if user == "" {
+			return c.Status(401).JSON(fiber.Map{
+				"success": false,
+				"error":   fmt.Sprintf("missing %s header", header),
+			})
+		}
+		if !slices.Contains(admins, user) {
+			return c.Status(403).JSON(fiber.Map{
+				"success": false,
+				"error":   fmt.Sprintf("permission denied: user %s is not an admin", user),
+			})
+		}
+		return c.Next()
+	}
+}
+
+// customErrorHandler handles errors
+func customErrorHandler(c *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError
+	if e, ok := err.(*fiber.Error); ok {
+		code = e.Code
+	}
+
+	return c.Status(code).JSON(fiber.Map{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
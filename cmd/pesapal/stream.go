@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+)
+
+const (
+	// mimeNDJSON is the content type of streamed query results
+	mimeNDJSON = "application/x-ndjson"
+	// streamBuffer is how many lines a streamed query may run ahead of the
+	// client before it waits for the client to catch up
+	streamBuffer = 256
+	// streamWriteTimeout is how long a write to a streaming client may
+	// take. A client that stops reading for longer ends its stream, which
+	// stops the query and releases what it holds.
+	streamWriteTimeout = 30 * time.Second
+)
+
+// streamHeader is the first line of a streamed result
+type streamHeader struct {
+	Columns []string `json:"columns"`
+}
+
+// wantsStream reports whether a query request asked for its result as
+// NDJSON
+func wantsStream(c *fiber.Ctx) bool {
+	return strings.Contains(c.Get(fiber.HeaderAccept), mimeNDJSON)
+}
+
+// queryStream encodes the rows of a streamed query as lines for the
+// response to send
+type queryStream struct {
+	ctx      context.Context
+	lines    chan []byte
+	streamed bool // whether the columns line was written
+
+	// result and err are set before lines is closed
+	result *executor.Result
+	err    error
+}
+
+// WriteColumns sends the header line
+func (s *queryStream) WriteColumns(columns []string) error {
+	s.streamed = true
+	return s.send(streamHeader{Columns: columns})
+}
+
+// WriteRow sends a row as a JSON array
+func (s *queryStream) WriteRow(row []interface{}) error {
+	return s.send(row)
+}
+
+// send encodes a line, waiting while the client is behind
+func (s *queryStream) send(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	select {
+	case s.lines <- data:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// run executes the query, writing out the rows of statements that do not
+// stream (such as SHOW TABLES) once they finish
+func (s *queryStream) run(query string) {
+	defer close(s.lines)
+	result, err := exec.QueryStream(s.ctx, query, s)
+	if err == nil && !s.streamed && len(result.Columns) > 0 {
+		err = s.WriteColumns(result.Columns)
+		for _, row := range result.Rows {
+			if err != nil {
+				break
+			}
+			err = s.WriteRow(row)
+		}
+	}
+	s.result, s.err = result, err
+}
+
+// handleQueryStream executes a query and streams its result as NDJSON: a
+// {"columns": [...]} line, one JSON array per row as the executor produces
+// it, then a summary line shaped like a QueryResponse without the rows. A
+// query that fails before its first row gets the usual error response; one
+// that fails later ends the stream with an unsuccessful summary.
+func handleQueryStream(c *fiber.Ctx, ctx context.Context, query string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	stream := &queryStream{ctx: ctx, lines: make(chan []byte, streamBuffer)}
+	go stream.run(query)
+
+	first, more := <-stream.lines
+	if exec.WAL() != nil {
+		c.Set(headerLSN, strconv.FormatUint(exec.WAL().LastLSN(), 10))
+	}
+	if !more && stream.err != nil {
+		cancel()
+		return queryError(c, stream.err)
+	}
+
+	c.Set(fiber.HeaderContentType, mimeNDJSON)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+		// The query holds its locks and admission slot while it waits for
+		// the client, so writes get a deadline, pushed back as the client
+		// keeps up. A missed deadline fails the writes below.
+		var deadline time.Time
+		extend := func() {
+			if time.Until(deadline) < streamWriteTimeout/2 {
+				deadline = time.Now().Add(streamWriteTimeout)
+				conn.SetWriteDeadline(deadline)
+			}
+		}
+		defer conn.SetWriteDeadline(time.Time{})
+
+		for line := first; more; line, more = <-stream.lines {
+			extend()
+			if _, err := w.Write(line); err != nil {
+				return // the client went away or stopped reading
+			}
+			w.WriteByte('\n')
+			// Flush whenever the query is not ahead, so rows reach the
+			// client as they are produced without a chunk for each
+			if len(stream.lines) == 0 {
+				if err := w.Flush(); err != nil {
+					return // cancel stops the query
+				}
+			}
+		}
+
+		summary := QueryResponse{Success: true}
+		if stream.err != nil {
+			summary.Error = fmt.Sprintf("Execution error: %v", stream.err)
+			summary.Success = false
+		} else {
+			summary.Message = stream.result.Message
+			summary.RowsAffected = stream.result.RowsAffected
+			summary.Plan = stream.result.Plan
+		}
+		data, _ := json.Marshal(summary)
+		extend()
+		w.Write(data)
+		w.WriteByte('\n')
+		w.Flush()
+	})
+	return nil
+}
@@ -0,0 +1,206 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Op identifies the kind of row change
+type Op string
+
+const (
+	OpInsert Op = "INSERT"
+	OpUpdate Op = "UPDATE"
+	OpDelete Op = "DELETE"
+)
+
+// Change represents a single committed row change. Changes produced by one
+// statement share its LSN and are numbered by Seq.
+type Change struct {
+	LSN   uint64                 `json:"lsn"`
+	Seq   int                    `json:"seq"`
+	Time  time.Time              `json:"time"`
+	Table string                 `json:"table"`
+	Op    Op                     `json:"op"`
+	Old   map[string]interface{} `json:"old,omitempty"`
+	New   map[string]interface{} `json:"new,omitempty"`
+}
+
+// ErrOffsetExpired is returned when a consumer asks for changes that have
+// already been dropped from the retained history
+var ErrOffsetExpired = errors.New("offset is older than the retained change history")
+
+// Stream is an ordered, bounded, in-memory history of committed row changes.
+// Consumers resume by asking for changes from the LSN after the last one
+// they fully processed.
+type Stream struct {
+	mu       sync.Mutex
+	changes  []Change
+	capacity int
+	earliest uint64 // smallest LSN that can still be read
+	lastLSN  uint64
+	notify   chan struct{}
+}
+
+// NewStream creates a stream that continues after startLSN and retains
+// roughly capacity changes
+func NewStream(startLSN uint64, capacity int) *Stream {
+	return &Stream{
+		capacity: capacity,
+		earliest: startLSN + 1,
+		lastLSN:  startLSN,
+		notify:   make(chan struct{}),
+	}
+}
+
+// Publish appends the changes of one committed statement. An lsn of zero
+// assigns the next one in sequence. Statements must be published in LSN
+// order: readers resume after the last LSN they saw.
+func (s *Stream) Publish(lsn uint64, changes []Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lsn == 0 {
+		lsn = s.lastLSN + 1
+	}
+	if lsn > s.lastLSN {
+		s.lastLSN = lsn
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	for i := range changes {
+		changes[i].LSN = lsn
+		changes[i].Seq = i
+		changes[i].Time = now
+	}
+	s.changes = append(s.changes, changes...)
+	s.trim()
+
+	// Wake up waiting readers
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// Reset drops the retained history after a restore at lsn rewound the
+// tables. Reading from lsn or earlier then fails with ErrOffsetExpired,
+// so consumers start over from the tables instead of applying later
+// changes to rows the restore took back.
+func (s *Stream) Reset(lsn uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.changes = nil
+	if lsn > s.lastLSN {
+		s.lastLSN = lsn
+	}
+	s.earliest = s.lastLSN + 1
+
+	// Wake up waiting readers so they find their offset expired
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// trim drops whole statements from the front until within capacity
+func (s *Stream) trim() {
+	if s.capacity <= 0 {
+		return
+	}
+	for len(s.changes) > s.capacity {
+		first := s.changes[0].LSN
+		i := 0
+		for i < len(s.changes) && s.changes[i].LSN == first {
+			i++
+		}
+		s.changes = s.changes[i:]
+		s.earliest = first + 1
+	}
+}
+
+// Read returns changes with an LSN of at least from. Statements are never
+// split, so the result may exceed limit to finish the last one. A limit of
+// zero or less returns everything available.
+func (s *Stream) Read(from uint64, limit int) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if from < s.earliest {
+		return nil, ErrOffsetExpired
+	}
+
+	result := []Change{}
+	for i, change := range s.changes {
+		if change.LSN < from {
+			continue
+		}
+		if limit > 0 && len(result) >= limit && change.LSN != s.changes[i-1].LSN {
+			break
+		}
+		result = append(result, change)
+	}
+	return result, nil
+}
+
+// Wait blocks until changes with an LSN of at least from are available,
+// from has expired, or ctx is done
+func (s *Stream) Wait(ctx context.Context, from uint64) error {
+	for {
+		s.mu.Lock()
+		ready := from < s.earliest || len(s.changes) > 0 && s.changes[len(s.changes)-1].LSN >= from
+		notify := s.notify
+		s.mu.Unlock()
+
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+// Subscribe delivers changes from the given LSN onwards until ctx is done.
+// The channel is closed when the subscription ends.
+func (s *Stream) Subscribe(ctx context.Context, from uint64) (<-chan Change, error) {
+	if _, err := s.Read(from, 1); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan Change)
+	go func() {
+		defer close(ch)
+		next := from
+		for {
+			if err := s.Wait(ctx, next); err != nil {
+				return
+			}
+			changes, err := s.Read(next, 0)
+			if err != nil {
+				return
+			}
+			for _, change := range changes {
+				select {
+				case ch <- change:
+				case <-ctx.Done():
+					return
+				}
+				next = change.LSN + 1
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// LastLSN returns the LSN of the most recently published statement
+func (s *Stream) LastLSN() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastLSN
+}
@@ -0,0 +1,128 @@
+// Package cron parses the five-field schedules of cron(8)
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is the range of values one schedule field accepts
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Schedule is a parsed cron schedule: minute, hour, day of month, month
+// and day of week
+type Schedule struct {
+	sets [5]uint64 // bit n is set when the field matches value n
+	// Cron matches a day when either day field matches, unless one of
+	// them is *
+	anyDayOfMonth, anyDayOfWeek bool
+}
+
+// Parse parses a schedule such as "0 3 * * *" or "*/15 9-17 * * 1-5".
+// Each field is *, a number, a range a-b or a comma-separated list of
+// those, optionally followed by /step. Sunday is 0 (or 7).
+func Parse(spec string) (*Schedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("schedule %q must have 5 fields (minute hour day-of-month month day-of-week), got %d", spec, len(parts))
+	}
+
+	s := &Schedule{}
+	for i, part := range parts {
+		f := fields[i]
+		if i == 4 {
+			f.max = 7 // 7 is Sunday too
+		}
+		set, err := parseField(part, f)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+		s.sets[i] = set
+	}
+	if s.sets[4]&(1<<7) != 0 {
+		s.sets[4] |= 1
+	}
+	s.anyDayOfMonth = strings.HasPrefix(parts[2], "*")
+	s.anyDayOfWeek = strings.HasPrefix(parts[4], "*")
+	return s, nil
+}
+
+// parseField parses one comma-separated field into a bit set
+func parseField(spec string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepSpec)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepSpec, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rangeSpec != "*" {
+			from, to, isRange := strings.Cut(rangeSpec, "-")
+			var err error
+			if lo, err = parseValue(from, f); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(to, f); err != nil {
+					return 0, err
+				}
+				if hi < lo {
+					return 0, fmt.Errorf("invalid range %q in %s field", rangeSpec, f.name)
+				}
+			} else if hasStep {
+				hi = f.max // a/n means a, a+n, ... up to the maximum
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// parseValue parses a number within a field's range
+func parseValue(spec string, f field) (int, error) {
+	n, err := strconv.Atoi(spec)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("invalid %s %q (want %d-%d)", f.name, spec, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Matches reports whether the schedule fires in the minute containing t
+func (s *Schedule) Matches(t time.Time) bool {
+	if !s.has(0, t.Minute()) || !s.has(1, t.Hour()) || !s.has(3, int(t.Month())) {
+		return false
+	}
+	dayOfMonth := s.has(2, t.Day())
+	dayOfWeek := s.has(4, int(t.Weekday()))
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// has reports whether field i matches value v
+func (s *Schedule) has(i, v int) bool {
+	return s.sets[i]&(1<<v) != 0
+}
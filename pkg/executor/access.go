@@ -0,0 +1,303 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// executeGrant executes GRANT and REVOKE statements
+func (e *Executor) executeGrant(ctx context.Context, stmt *parser.GrantStmt) (*Result, error) {
+	grant := storage.Grant{User: stmt.User, Privilege: stmt.Privilege, Columns: stmt.Columns}
+	var message string
+	if stmt.Revoke {
+		if err := e.storage.RevokePrivilege(stmt.TableName, grant); err != nil {
+			return nil, err
+		}
+		message = fmt.Sprintf("%s on %s revoked from %s", grantDescription(stmt), stmt.TableName, stmt.User)
+	} else {
+		if err := e.storage.GrantPrivilege(stmt.TableName, grant); err != nil {
+			return nil, err
+		}
+		message = fmt.Sprintf("%s on %s granted to %s", grantDescription(stmt), stmt.TableName, stmt.User)
+	}
+
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: message}, nil
+}
+
+// grantDescription renders the privilege of a GRANT or REVOKE
+func grantDescription(stmt *parser.GrantStmt) string {
+	if stmt.Columns == nil {
+		return stmt.Privilege
+	}
+	return stmt.Privilege + " (" + strings.Join(stmt.Columns, ", ") + ")"
+}
+
+// maskFunctions are the functions a column can be masked with, and how
+// many arguments each takes
+var maskFunctions = map[string]int{
+	"FULL":    0, // replace the whole value
+	"EMAIL":   0, // keep the first character of an email address
+	"PARTIAL": 3, // PARTIAL(prefix, padding, suffix): keep the first prefix and last suffix characters
+}
+
+// maskColumn validates a masking function and sets it on a column
+func (e *Executor) maskColumn(tableName, column string, mask parser.Expression) error {
+	table, err := e.storage.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+	idx := table.Schema.GetColumnIndex(column)
+	if idx == -1 {
+		return fmt.Errorf("column %s does not exist in table %s", column, tableName)
+	}
+	if err := checkMask(mask, table.Schema.Columns[idx]); err != nil {
+		return fmt.Errorf("mask of column %s: %w", column, err)
+	}
+	return e.storage.SetColumnMask(tableName, column, parser.FormatExpression(mask))
+}
+
+// checkMask rejects masks that are not a masking function suited to the
+// column's type
+func checkMask(mask parser.Expression, col storage.Column) error {
+	call, ok := mask.(*parser.FunctionCall)
+	if !ok {
+		return fmt.Errorf("expected FULL(), EMAIL() or PARTIAL(prefix, padding, suffix)")
+	}
+	args, ok := maskFunctions[call.Name]
+	if !ok {
+		return fmt.Errorf("unknown masking function %s (supported: FULL, EMAIL, PARTIAL)", call.Name)
+	}
+	if len(call.Args) != args {
+		return fmt.Errorf("%s() takes %d argument(s), got %d", call.Name, args, len(call.Args))
+	}
+	isString := col.DataType == storage.TypeVarchar || col.DataType == storage.TypeCIText || col.DataType == storage.TypeText
+	if call.Name != "FULL" && !isString {
+		return fmt.Errorf("%s() can only mask VARCHAR, TEXT and CITEXT columns", call.Name)
+	}
+	if call.Name == "PARTIAL" {
+		prefix, ok1 := literalValue(call.Args[0]).(int)
+		_, ok2 := literalValue(call.Args[1]).(string)
+		suffix, ok3 := literalValue(call.Args[2]).(int)
+		if !ok1 || !ok2 || !ok3 || prefix < 0 || suffix < 0 {
+			return fmt.Errorf("PARTIAL() takes a non-negative prefix length, a padding string and a non-negative suffix length")
+		}
+	}
+	return nil
+}
+
+// literalValue returns the value of a literal expression, or nil
+func literalValue(expr parser.Expression) interface{} {
+	if lit, ok := expr.(*parser.Literal); ok {
+		return lit.Value
+	}
+	return nil
+}
+
+// applyMask masks a non-NULL value with a function checkMask accepted
+func applyMask(mask *parser.FunctionCall, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch mask.Name {
+	case "EMAIL":
+		s := []rune(value.(string))
+		if len(s) == 0 {
+			return "XXX@XXXX.com"
+		}
+		return string(s[0]) + "XXX@XXXX.com"
+	case "PARTIAL":
+		s := []rune(value.(string))
+		prefix := literalValue(mask.Args[0]).(int)
+		padding := literalValue(mask.Args[1]).(string)
+		suffix := literalValue(mask.Args[2]).(int)
+		if len(s) <= prefix+suffix {
+			return padding
+		}
+		return string(s[:prefix]) + padding + string(s[len(s)-suffix:])
+	default:
+		switch value.(type) {
+		case string:
+			return "XXXX"
+		case int:
+			return 0
+		case float64:
+			return 0.0
+		case bool:
+			return false
+		}
+		return nil
+	}
+}
+
+// columnAccess is what the current user may read of each column of a
+// table (or of the joined tables) a SELECT reads
+type columnAccess struct {
+	names  []string               // table.column of each column
+	denied []bool                 // columns the user has no SELECT privilege on
+	masks  []*parser.FunctionCall // masking function of each column, nil if read unmasked
+}
+
+// newColumnAccess returns access to every column of a table, unmasked
+func newColumnAccess(schema *storage.Schema) *columnAccess {
+	a := &columnAccess{
+		names:  make([]string, len(schema.Columns)),
+		denied: make([]bool, len(schema.Columns)),
+		masks:  make([]*parser.FunctionCall, len(schema.Columns)),
+	}
+	for i, col := range schema.Columns {
+		a.names[i] = schema.TableName + "." + col.Name
+	}
+	return a
+}
+
+// accessTo returns the current user's access to the columns of a table, or
+// nil when they may read all of them unmasked. Tables without SELECT grants
+// may be read by anyone; masks apply to users without UNMASK. Statements
+// that run for no user are not restricted.
+func (e *Executor) accessTo(ctx context.Context, schema *storage.Schema) (*columnAccess, error) {
+	user := UserFrom(ctx)
+	if user == "" {
+		return nil, nil
+	}
+	var access *columnAccess
+
+	if grants := schema.GrantsOf(storage.PrivilegeSelect); len(grants) > 0 {
+		i := slices.IndexFunc(grants, func(g storage.Grant) bool { return g.User == user })
+		if i == -1 {
+			return nil, fmt.Errorf("permission denied for table %s", schema.TableName)
+		}
+		if grants[i].Columns != nil {
+			access = newColumnAccess(schema)
+			for j, col := range schema.Columns {
+				access.denied[j] = !slices.Contains(grants[i].Columns, col.Name)
+			}
+		}
+	}
+
+	unmasked := slices.ContainsFunc(schema.GrantsOf(storage.PrivilegeUnmask), func(g storage.Grant) bool {
+		return g.User == user
+	})
+	for i, col := range schema.Columns {
+		if col.Mask == "" || unmasked {
+			continue
+		}
+		mask, err := e.storedExpression(col.Mask)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mask of column %s: %w", col.Name, err)
+		}
+		if access == nil {
+			access = newColumnAccess(schema)
+		}
+		access.masks[i], _ = mask.(*parser.FunctionCall)
+	}
+	return access, nil
+}
+
+// joinAccess combines the access to the tables of a join, one per table
+// in join order, indexed like the joined rows
+func joinAccess(joined *joinSchema, accesses []*columnAccess) *columnAccess {
+	restricted := false
+	for _, a := range accesses {
+		restricted = restricted || a != nil
+	}
+	if !restricted {
+		return nil
+	}
+	combined := &columnAccess{}
+	for i, a := range accesses {
+		if a == nil {
+			a = newColumnAccess(joined.sources[i].schema)
+		}
+		combined.names = append(combined.names, a.names...)
+		combined.denied = append(combined.denied, a.denied...)
+		combined.masks = append(combined.masks, a.masks...)
+	}
+	return combined
+}
+
+// readable reports whether the user may read a column
+func (a *columnAccess) readable(idx int) bool {
+	return a == nil || !a.denied[idx]
+}
+
+// check rejects reading a column the user has no privilege on
+func (a *columnAccess) check(idx int) error {
+	if !a.readable(idx) {
+		return fmt.Errorf("permission denied for column %s", a.names[idx])
+	}
+	return nil
+}
+
+// mask returns a column's value as the user may see it
+func (a *columnAccess) mask(idx int, value interface{}) interface{} {
+	if a == nil || a.masks[idx] == nil {
+		return value
+	}
+	return applyMask(a.masks[idx], value)
+}
+
+// checkReferences rejects conditions on columns the user may not read,
+// which would let them probe the values. resolve maps a column name to its
+// index, or -1.
+func (a *columnAccess) checkReferences(expr parser.Expression, resolve func(name string) int) error {
+	if a == nil || expr == nil {
+		return nil
+	}
+	var err error
+	parser.WalkExpression(expr, func(expr parser.Expression) {
+		if ident, ok := expr.(*parser.Identifier); ok && err == nil {
+			if idx := resolve(ident.Value); idx != -1 {
+				err = a.check(idx)
+			}
+		}
+	})
+	return err
+}
+
+// checkReadable rejects a WHERE clause of a write that reads columns the
+// user may not read
+func (e *Executor) checkReadable(ctx context.Context, schema *storage.Schema, where parser.Expression) error {
+	if where == nil {
+		return nil
+	}
+	access, err := e.accessTo(ctx, schema)
+	if err != nil {
+		return err
+	}
+	return access.checkReferences(where, schema.GetColumnIndex)
+}
+
+// storedExpression returns the parsed form of an expression a schema stores
+// as text, such as a policy condition or a column mask
+func (e *Executor) storedExpression(definition string) (parser.Expression, error) {
+	if expr, ok := e.expressions.Load(definition); ok {
+		return expr.(parser.Expression), nil
+	}
+	expr, err := parser.ParseExpression(definition)
+	if err != nil {
+		return nil, err
+	}
+	e.expressions.Store(definition, expr)
+	return expr, nil
+}
+
+// changesAccess reports whether a statement changes who may read what,
+// which must not race with statements checking it
+func changesAccess(stmt parser.Statement) bool {
+	switch s := stmt.(type) {
+	case *parser.CreatePolicyStmt, *parser.DropPolicyStmt, *parser.GrantStmt, *parser.CreateUserStmt, *parser.DropUserStmt:
+		return true
+	case *parser.AlterTableStmt:
+		return s.Action == "SET MASK" || s.Action == "DROP MASK"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,114 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrOverloaded is returned when a statement is not admitted because too
+// many statements are executing and waiting
+var ErrOverloaded = errors.New("server is busy, try again later")
+
+// AdmissionLimits bounds how many statements run at once. A zero
+// MaxConcurrent means no limit.
+type AdmissionLimits struct {
+	MaxConcurrent int           // statements executing at once
+	MaxQueued     int           // statements waiting for a slot; more are rejected
+	QueueTimeout  time.Duration // longest wait for a slot, 0 for as long as the caller waits
+}
+
+// admission admits statements within the limits, queueing the rest
+type admission struct {
+	mu     sync.Mutex
+	limits AdmissionLimits
+	slots  chan struct{} // one token per executing statement; nil when unlimited
+	queued int
+
+	admitted, waited, rejected int64
+}
+
+// SetAdmissionLimits sets the limits on concurrently executing statements.
+// Statements already executing or waiting keep the slots they were given.
+func (e *Executor) SetAdmissionLimits(limits AdmissionLimits) {
+	a := &e.admission
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.limits = limits
+	a.slots = nil
+	if limits.MaxConcurrent > 0 {
+		a.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+}
+
+// admit waits for a slot to execute a statement and returns the function
+// that releases it
+func (e *Executor) admit(ctx context.Context) (func(), error) {
+	a := &e.admission
+	a.mu.Lock()
+	slots, limits := a.slots, a.limits
+	if slots == nil {
+		a.admitted++
+		a.mu.Unlock()
+		return func() {}, nil
+	}
+	release := func() { <-slots }
+
+	// Take a free slot without queueing
+	select {
+	case slots <- struct{}{}:
+		a.admitted++
+		a.mu.Unlock()
+		return release, nil
+	default:
+	}
+	if a.queued >= limits.MaxQueued {
+		a.rejected++
+		a.mu.Unlock()
+		return nil, fmt.Errorf("%d statements executing and %d waiting: %w", limits.MaxConcurrent, a.queued, ErrOverloaded)
+	}
+	a.queued++
+	a.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if limits.QueueTimeout > 0 {
+		timer := time.NewTimer(limits.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	select {
+	case slots <- struct{}{}:
+	case <-timeout:
+		err = fmt.Errorf("no slot to execute within %s: %w", limits.QueueTimeout, ErrOverloaded)
+	case <-ctx.Done():
+		err = context.Cause(ctx)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.queued--
+	if err != nil {
+		a.rejected++
+		return nil, err
+	}
+	a.admitted++
+	a.waited++
+	return release, nil
+}
+
+// counters returns the admission counters
+func (a *admission) counters() AdmissionStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return AdmissionStats{
+		Executing: len(a.slots),
+		Queued:    a.queued,
+		Admitted:  a.admitted,
+		Waited:    a.waited,
+		Rejected:  a.rejected,
+	}
+}
@@ -0,0 +1,213 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+const (
+	// adviseMinRows is the smallest table worth indexing; scanning fewer
+	// rows costs about as much as an index lookup
+	adviseMinRows = 100
+	// adviseMaxSelectivity is the largest fraction of rows a predicate may
+	// match and still be served better by an index than by a scan
+	adviseMaxSelectivity = 0.2
+)
+
+// IndexSuggestion is an index the advisor recommends, with the evidence
+// for it
+type IndexSuggestion struct {
+	Table     string `json:"table"`
+	Column    string `json:"column"`
+	Statement string `json:"statement"` // the CREATE INDEX to run
+	// Queries is how many statements filtered on the column, of which
+	// Equality compared it with = and Range with <, >, <= or >=
+	Queries  int64 `json:"queries"`
+	Equality int64 `json:"equality"`
+	Range    int64 `json:"range"`
+	// Selectivity is the average fraction of scanned rows that matched
+	Selectivity float64 `json:"selectivity"`
+	RowsScanned int64   `json:"rowsScanned"`
+	// RowsSaved estimates the rows the recorded statements would not have
+	// read with the index: those scanned but not matched
+	RowsSaved int64 `json:"rowsSaved"`
+}
+
+// predicateStats holds what the advisor saw of filters on one column
+type predicateStats struct {
+	queries     int64
+	equality    int64
+	ranges      int64
+	rowsScanned int64
+	rowsMatched int64
+}
+
+// indexAdvisor records the columns statements filter on
+type indexAdvisor struct {
+	mu      sync.Mutex
+	columns map[string]map[string]*predicateStats // table -> column
+}
+
+// newIndexAdvisor creates an advisor with nothing recorded
+func newIndexAdvisor() *indexAdvisor {
+	return &indexAdvisor{columns: make(map[string]map[string]*predicateStats)}
+}
+
+// record notes a statement that filtered a table on where, scanning
+// scanned rows of which matched passed the filter. Only a column compared
+// with a value is recorded, as that is what an index can serve.
+func (a *indexAdvisor) record(table string, where parser.Expression, scanned, matched int) {
+	expr, ok := where.(*parser.BinaryExpr)
+	if !ok || scanned == 0 {
+		return
+	}
+	column, isRange := "", false
+	switch expr.Operator {
+	case "=":
+	case "<", ">", "<=", ">=":
+		isRange = true
+	default:
+		return
+	}
+	left, leftOK := expr.Left.(*parser.Identifier)
+	right, rightOK := expr.Right.(*parser.Identifier)
+	switch {
+	case leftOK && !rightOK:
+		column = left.Value
+	case rightOK && !leftOK:
+		column = right.Value
+	default:
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	columns, ok := a.columns[table]
+	if !ok {
+		columns = make(map[string]*predicateStats)
+		a.columns[table] = columns
+	}
+	stats, ok := columns[column]
+	if !ok {
+		stats = &predicateStats{}
+		columns[column] = stats
+	}
+	stats.queries++
+	if isRange {
+		stats.ranges++
+	} else {
+		stats.equality++
+	}
+	stats.rowsScanned += int64(scanned)
+	stats.rowsMatched += int64(matched)
+}
+
+// forget drops what was recorded for a table
+func (a *indexAdvisor) forget(table string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.columns, table)
+}
+
+// AdviseIndexes suggests indexes for the columns statements have filtered
+// on since startup, most beneficial first. A column is suggested when it
+// has no index, its table has at least 100 rows and its filters matched at
+// most a fifth of the rows scanned. With a table name only that table is
+// considered.
+func (e *Executor) AdviseIndexes(table string) ([]IndexSuggestion, error) {
+	if table != "" {
+		if _, err := e.storage.GetTable(table); err != nil {
+			return nil, err
+		}
+	}
+
+	e.advisor.mu.Lock()
+	type candidate struct {
+		table, column string
+		stats         predicateStats
+	}
+	candidates := []candidate{}
+	for name, columns := range e.advisor.columns {
+		if table != "" && name != table {
+			continue
+		}
+		for column, stats := range columns {
+			candidates = append(candidates, candidate{name, column, *stats})
+		}
+	}
+	e.advisor.mu.Unlock()
+
+	suggestions := []IndexSuggestion{}
+	for _, c := range candidates {
+		t, err := e.storage.GetTable(c.table)
+		if err != nil || t.Schema.GetColumnIndex(c.column) == -1 || t.RowCount() < adviseMinRows {
+			continue
+		}
+		indexes, err := e.storage.Indexes(c.table)
+		if err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(indexes, func(index storage.IndexInfo) bool {
+			return len(index.Columns) > 0 && index.Columns[0] == c.column
+		}) {
+			continue
+		}
+
+		selectivity := float64(c.stats.rowsMatched) / float64(c.stats.rowsScanned)
+		if selectivity > adviseMaxSelectivity {
+			continue
+		}
+		suggestions = append(suggestions, IndexSuggestion{
+			Table:       c.table,
+			Column:      c.column,
+			Statement:   fmt.Sprintf("CREATE INDEX %s_%s_idx ON %s (%s);", c.table, c.column, c.table, c.column),
+			Queries:     c.stats.queries,
+			Equality:    c.stats.equality,
+			Range:       c.stats.ranges,
+			Selectivity: selectivity,
+			RowsScanned: c.stats.rowsScanned,
+			RowsSaved:   c.stats.rowsScanned - c.stats.rowsMatched,
+		})
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].RowsSaved != suggestions[j].RowsSaved {
+			return suggestions[i].RowsSaved > suggestions[j].RowsSaved
+		}
+		return suggestions[i].Statement < suggestions[j].Statement
+	})
+	return suggestions, nil
+}
+
+// executeAdvise executes EXPLAIN ADVISE statement
+func (e *Executor) executeAdvise(ctx context.Context, stmt *parser.AdviseStmt) (*Result, error) {
+	suggestions, err := e.AdviseIndexes(stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(suggestions) == 0 {
+		return &Result{Message: "No indexes to suggest"}, nil
+	}
+
+	result := &Result{
+		Columns: []string{"statement", "queries", "selectivity", "rows_scanned", "rows_saved"},
+	}
+	for _, s := range suggestions {
+		result.Rows = append(result.Rows, []interface{}{
+			s.Statement,
+			int(s.Queries),
+			math.Round(s.Selectivity*10000) / 10000,
+			int(s.RowsScanned),
+			int(s.RowsSaved),
+		})
+	}
+	result.RowsAffected = len(result.Rows)
+	return result, nil
+}
@@ -0,0 +1,565 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// aggregator folds the values of a column into one result
+type aggregator interface {
+	// add adds a row's value, which may be NULL
+	add(value interface{})
+	result() interface{}
+}
+
+// aggregateFunction is a built-in aggregate function
+type aggregateFunction struct {
+	star    bool // accepts * as well as a column
+	numeric bool // takes only INTEGER and FLOAT columns
+	// new returns an aggregator for the values of col, or for whole rows
+	// when the argument is *
+	new func(col storage.Column) aggregator
+}
+
+// aggregateFunctions holds the built-in aggregate functions by upper-cased
+// name
+var aggregateFunctions = map[string]aggregateFunction{
+	"COUNT": {
+		star: true,
+		new:  func(storage.Column) aggregator { return &count{} },
+	},
+	"SUM": {
+		numeric: true,
+		new:     func(col storage.Column) aggregator { return &sum{float: col.DataType == storage.TypeFloat} },
+	},
+	"AVG": {
+		numeric: true,
+		new:     func(storage.Column) aggregator { return &avg{} },
+	},
+	"MIN": {
+		new: func(col storage.Column) aggregator { return &extreme{col: col, keep: -1} },
+	},
+	"MAX": {
+		new: func(col storage.Column) aggregator { return &extreme{col: col, keep: 1} },
+	},
+	"APPROX_COUNT_DISTINCT": {
+		new: func(col storage.Column) aggregator { return &approxCountDistinct{col: col} },
+	},
+}
+
+// count counts rows, or the non-NULL values of a column
+type count struct {
+	n int
+}
+
+func (a *count) add(value interface{}) {
+	if value != nil {
+		a.n++
+	}
+}
+
+func (a *count) result() interface{} {
+	return a.n
+}
+
+// sum adds up the non-NULL values of a column: an INTEGER total for an
+// INTEGER column, a FLOAT one for a FLOAT column, and NULL if there were no
+// values
+type sum struct {
+	float bool
+	seen  bool
+	ints  int
+	total float64
+}
+
+func (a *sum) add(value interface{}) {
+	if value == nil {
+		return
+	}
+	a.seen = true
+	if v, ok := value.(int); ok && !a.float {
+		a.ints += v
+	} else if f, ok := toFloat(value); ok {
+		a.total += f
+	}
+}
+
+func (a *sum) result() interface{} {
+	switch {
+	case !a.seen:
+		return nil
+	case a.float:
+		return a.total
+	default:
+		return a.ints
+	}
+}
+
+// avg averages the non-NULL values of a column as a FLOAT, or is NULL if
+// there were none
+type avg struct {
+	n     int
+	total float64
+}
+
+func (a *avg) add(value interface{}) {
+	if f, ok := toFloat(value); ok {
+		a.n++
+		a.total += f
+	}
+}
+
+func (a *avg) result() interface{} {
+	if a.n == 0 {
+		return nil
+	}
+	return a.total / float64(a.n)
+}
+
+// extreme keeps the smallest (keep -1) or largest (keep 1) non-NULL value
+// of a column, compared under the column's collation
+type extreme struct {
+	col   storage.Column
+	keep  int
+	value interface{}
+}
+
+func (a *extreme) add(value interface{}) {
+	if value == nil {
+		return
+	}
+	if a.value == nil {
+		a.value = value
+		return
+	}
+	if c, err := storage.CompareValues(value, a.value, a.col.CompareCollation()); err == nil && c == a.keep {
+		a.value = value
+	}
+}
+
+func (a *extreme) result() interface{} {
+	return a.value
+}
+
+// distinct passes each distinct non-NULL value to an aggregator once, for
+// calls such as COUNT(DISTINCT email). Values equal under the column's
+// collation are the same value.
+type distinct struct {
+	col  storage.Column
+	seen map[interface{}]bool
+	agg  aggregator
+}
+
+func (a *distinct) add(value interface{}) {
+	if value == nil {
+		return
+	}
+	key := storage.CollationKey(value, a.col)
+	if a.seen[key] {
+		return
+	}
+	a.seen[key] = true
+	a.agg.add(value)
+}
+
+func (a *distinct) result() interface{} {
+	return a.agg.result()
+}
+
+// approxCountDistinct estimates the number of distinct non-NULL values
+// with a HyperLogLog sketch, in fixed memory however many rows there are
+type approxCountDistinct struct {
+	col    storage.Column
+	sketch hyperLogLog
+}
+
+func (a *approxCountDistinct) add(value interface{}) {
+	if value != nil {
+		a.sketch.add(hashDistinct(value, a.col))
+	}
+}
+
+func (a *approxCountDistinct) result() interface{} {
+	return a.sketch.estimate()
+}
+
+// aggregateGroup is the rows of a GROUP BY group folded so far
+type aggregateGroup struct {
+	values      []interface{} // the GROUP BY columns' values, as the user sees them
+	aggregators []aggregator  // one per result slot; nil for GROUP BY columns
+	results     []interface{}
+}
+
+// aggregated reports whether a SELECT aggregates its rows
+func aggregated(stmt *parser.SelectStmt) bool {
+	return stmt.Aggregates != nil || len(stmt.GroupBy) > 0 || stmt.Having != nil
+}
+
+// groupKey returns a key equal for exactly the rows whose values in the
+// GROUP BY columns are equal under the columns' collations. NULLs are
+// equal to each other, forming a group of their own.
+func groupKey(schema *storage.Schema, values []interface{}, groupIdxs []int) string {
+	var b strings.Builder
+	for i, idx := range groupIdxs {
+		key := storage.CollationKey(values[i], schema.Columns[idx])
+		if f, ok := key.(float64); ok && f == 0 {
+			key = 0.0 // -0 equals 0
+		}
+		// The type keeps 1 and '1' apart; the length keeps ('a,', 'b') and
+		// ('a', ',b') apart
+		s := fmt.Sprint(key)
+		fmt.Fprintf(&b, "%T:%d:%s", values[i], len(s), s)
+	}
+	return b.String()
+}
+
+// executeAggregate executes a SELECT with aggregate calls, GROUP BY or
+// HAVING. It returns a row for each group of matching rows that passes
+// HAVING, in the order the groups were first seen unless there is an
+// ORDER BY, or without GROUP BY a single row aggregating every matching
+// row.
+func (e *Executor) executeAggregate(ctx context.Context, stmt *parser.SelectStmt, table *storage.Table, access *columnAccess) (*Result, error) {
+	schema := table.Schema
+	groupIdxs := make([]int, len(stmt.GroupBy))
+	for i, name := range stmt.GroupBy {
+		if groupIdxs[i] = schema.GetColumnIndex(name); groupIdxs[i] == -1 {
+			return nil, fmt.Errorf("column %s does not exist", name)
+		}
+		if err := access.check(groupIdxs[i]); err != nil {
+			return nil, err
+		}
+	}
+	// groupPosition returns the position of a column among the GROUP BY
+	// columns, or -1
+	groupPosition := func(name string) int {
+		idx := schema.GetColumnIndex(name)
+		if idx == -1 {
+			return -1
+		}
+		return slices.Index(groupIdxs, idx)
+	}
+	grouped := func(name string) bool {
+		return groupPosition(name) != -1
+	}
+	for _, key := range stmt.OrderBy {
+		if !grouped(key.Column) {
+			return nil, fmt.Errorf("column %s must appear in the GROUP BY clause or be used in an aggregate function", key.Column)
+		}
+	}
+
+	// Each group computes a result slot per select-list entry, then one
+	// per aggregate call only HAVING uses
+	names := append([]string{}, stmt.Columns...)
+	calls := make([]*parser.AggregateCall, len(names))
+	if stmt.Aggregates != nil {
+		copy(calls, stmt.Aggregates)
+	}
+	having, err := bindHaving(stmt.Having, grouped, func(call *parser.AggregateCall) string {
+		name := call.String()
+		for i, c := range calls {
+			if c != nil && c.String() == name {
+				return names[i]
+			}
+		}
+		names = append(names, name)
+		calls = append(calls, call)
+		return name
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	functions := make([]*aggregateFunction, len(calls))
+	// columns holds the column each aggregate reads, or -1 for *, and for
+	// the other slots the position of their GROUP BY column
+	columns := make([]int, len(calls))
+	// HAVING is evaluated against a row of the slots' results followed by
+	// the GROUP BY columns' values
+	slotColumns := make([]storage.Column, len(calls), len(calls)+len(groupIdxs))
+	for i, call := range calls {
+		slotColumns[i] = storage.Column{Name: names[i]}
+		if call == nil {
+			position := groupPosition(names[i])
+			if position == -1 {
+				return nil, fmt.Errorf("column %s must appear in the GROUP BY clause or be used in an aggregate function", names[i])
+			}
+			columns[i] = position
+			slotColumns[i] = schema.Columns[groupIdxs[position]]
+			continue
+		}
+		fn, ok := aggregateFunctions[call.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s() is not an aggregate function, and the select list takes only columns and aggregates", call.Name)
+		}
+		functions[i] = &fn
+
+		columns[i] = -1
+		if call.Column == "*" {
+			if !fn.star {
+				return nil, fmt.Errorf("%s() takes a column, not *", call.Name)
+			}
+			continue
+		}
+		idx := schema.GetColumnIndex(call.Column)
+		if idx == -1 {
+			return nil, fmt.Errorf("column %s does not exist", call.Column)
+		}
+		if err := access.check(idx); err != nil {
+			return nil, err
+		}
+		read := schema.Columns[idx]
+		if call.Cast != "" {
+			read = castColumn(read, call.Cast)
+		}
+		if dt := read.DataType; fn.numeric && dt != storage.TypeInteger && dt != storage.TypeFloat {
+			return nil, fmt.Errorf("%s() takes a numeric column, not %s column %s", call.Name, dt, call.Column)
+		}
+		columns[i] = idx
+		// MIN and MAX results compare under the column's collation
+		slotColumns[i].Collation = read.CompareCollation()
+	}
+	var slotSchema *storage.Schema
+	if having != nil {
+		for _, idx := range groupIdxs {
+			slotColumns = append(slotColumns, schema.Columns[idx])
+		}
+		slotSchema = &storage.Schema{TableName: stmt.TableName, Columns: slotColumns}
+	}
+
+	newGroup := func(values []interface{}) *aggregateGroup {
+		g := &aggregateGroup{values: values, aggregators: make([]aggregator, len(functions))}
+		for i, fn := range functions {
+			if fn == nil {
+				continue
+			}
+			var col storage.Column
+			if columns[i] != -1 {
+				col = schema.Columns[columns[i]]
+				if calls[i].Cast != "" {
+					col = castColumn(col, calls[i].Cast)
+				}
+			}
+			g.aggregators[i] = fn.new(col)
+			if calls[i].Distinct {
+				g.aggregators[i] = &distinct{col: col, seen: map[interface{}]bool{}, agg: g.aggregators[i]}
+			}
+		}
+		return g
+	}
+
+	start := time.Now()
+	rows, scanned, err := e.selectRows(ctx, table, stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without GROUP BY every row is in one group, which exists even when
+	// no rows match
+	var groups []*aggregateGroup
+	byKey := map[string]*aggregateGroup{}
+	if len(groupIdxs) == 0 {
+		groups = append(groups, newGroup(nil))
+	}
+	guard := e.newResultGuard()
+	if rowWriterFrom(ctx) != nil {
+		guard = &resultGuard{}
+	}
+	for i, row := range rows {
+		if err := checkCancelled(ctx, i); err != nil {
+			return nil, err
+		}
+		var g *aggregateGroup
+		if len(groupIdxs) == 0 {
+			g = groups[0]
+		} else {
+			// Group what the user would be shown, with values equal under
+			// the columns' collations together
+			values := make([]interface{}, len(groupIdxs))
+			for k, idx := range groupIdxs {
+				values[k] = access.mask(idx, row.Values[idx])
+			}
+			key := groupKey(schema, values, groupIdxs)
+			if g = byKey[key]; g == nil {
+				g = newGroup(values)
+				byKey[key] = g
+				groups = append(groups, g)
+				if err := guard.checkRows(len(groups)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		for j, agg := range g.aggregators {
+			if agg == nil {
+				continue
+			}
+			var value interface{} = row
+			if idx := columns[j]; idx != -1 {
+				// Aggregate what the user would be shown
+				value = access.mask(idx, row.Values[idx])
+				if calls[j].Cast != "" {
+					if value, err = castValue(value, calls[j].Cast); err != nil {
+						return nil, err
+					}
+				}
+			}
+			agg.add(value)
+		}
+	}
+
+	kept := groups[:0]
+	for _, g := range groups {
+		g.results = make([]interface{}, len(g.aggregators), len(g.aggregators)+len(g.values))
+		for i, agg := range g.aggregators {
+			if agg == nil {
+				g.results[i] = g.values[columns[i]]
+			} else {
+				g.results[i] = agg.result()
+			}
+		}
+		if having != nil {
+			match, err := e.evaluateCondition(ctx, having, &storage.Row{Values: append(g.results, g.values...)}, slotSchema)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+		}
+		kept = append(kept, g)
+	}
+	groups = kept
+
+	if stmt.OrderBy != nil {
+		positions := make([]int, len(stmt.OrderBy))
+		collations := make([]string, len(stmt.OrderBy))
+		for i, key := range stmt.OrderBy {
+			positions[i] = groupPosition(key.Column)
+			collations[i] = schema.Columns[groupIdxs[positions[i]]].CompareCollation()
+		}
+		key := func(g *aggregateGroup, i int) interface{} { return g.values[positions[i]] }
+		if groups, err = sortRows(ctx, groups, key, stmt.OrderBy, collations); err != nil {
+			return nil, err
+		}
+	}
+
+	labels := make([]string, len(stmt.Columns))
+	for i := range labels {
+		labels[i] = columnLabel(stmt, i)
+	}
+	builder, err := e.newResultBuilder(ctx, labels)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		if err := builder.add(g.results[:len(stmt.Columns)]); err != nil {
+			return nil, err
+		}
+	}
+	result := builder.finish()
+	recordActuals(ctx, "aggregate", result.RowsAffected, start)
+	// Groups are sorted as they are aggregated
+	if stmt.OrderBy != nil {
+		recordActuals(ctx, "sort", result.RowsAffected, start)
+	}
+
+	e.stats.recordTable(stmt.TableName, func(t *TableStats) {
+		t.Statements++
+		t.RowsScanned += int64(scanned)
+		t.RowsReturned += int64(result.RowsAffected)
+	})
+	e.advisor.record(stmt.TableName, stmt.Where, scanned, len(rows))
+
+	return result, nil
+}
+
+// bindHaving rewrites a HAVING condition to be evaluated against a group's
+// result slots: each aggregate call becomes a reference to the slot slot
+// returns for it, and other columns must be GROUP BY columns
+func bindHaving(expr parser.Expression, grouped func(string) bool, slot func(*parser.AggregateCall) string) (parser.Expression, error) {
+	switch ex := expr.(type) {
+	case *parser.Identifier:
+		if !grouped(ex.Value) {
+			return nil, fmt.Errorf("column %s must appear in the GROUP BY clause or be used in an aggregate function", ex.Value)
+		}
+	case *parser.BinaryExpr:
+		left, err := bindHaving(ex.Left, grouped, slot)
+		if err != nil {
+			return nil, err
+		}
+		right, err := bindHaving(ex.Right, grouped, slot)
+		if err != nil {
+			return nil, err
+		}
+		return &parser.BinaryExpr{Left: left, Operator: ex.Operator, Right: right}, nil
+	case *parser.UnaryExpr:
+		operand, err := bindHaving(ex.Operand, grouped, slot)
+		if err != nil {
+			return nil, err
+		}
+		return &parser.UnaryExpr{Operator: ex.Operator, Operand: operand}, nil
+	case *parser.InExpr:
+		left, err := bindHaving(ex.Left, grouped, slot)
+		if err != nil {
+			return nil, err
+		}
+		return &parser.InExpr{Left: left, Subquery: ex.Subquery, Not: ex.Not}, nil
+	case *parser.FunctionCall:
+		if _, ok := aggregateFunctions[ex.Name]; ok {
+			call := &parser.AggregateCall{Name: ex.Name, Column: "*", Distinct: ex.Distinct}
+			if !ex.Star {
+				ident, ok := singleIdentifier(ex.Args)
+				if !ok {
+					ident, call.Cast, ok = castIdentifier(ex.Args)
+				}
+				if !ok {
+					return nil, fmt.Errorf("%s() takes a column", ex.Name)
+				}
+				call.Column = ident.Value
+			}
+			return &parser.Identifier{Value: slot(call)}, nil
+		}
+		call := &parser.FunctionCall{Name: ex.Name, Args: make([]parser.Expression, len(ex.Args)), Star: ex.Star, Distinct: ex.Distinct}
+		for i, arg := range ex.Args {
+			bound, err := bindHaving(arg, grouped, slot)
+			if err != nil {
+				return nil, err
+			}
+			call.Args[i] = bound
+		}
+		return call, nil
+	}
+	return expr, nil
+}
+
+// singleIdentifier returns the only argument of a call if it is a column
+func singleIdentifier(args []parser.Expression) (*parser.Identifier, bool) {
+	if len(args) != 1 {
+		return nil, false
+	}
+	ident, ok := args[0].(*parser.Identifier)
+	return ident, ok
+}
+
+// castIdentifier returns the column and type of the only argument of a
+// call if it is CAST(<column> AS <type>)
+func castIdentifier(args []parser.Expression) (*parser.Identifier, string, bool) {
+	if len(args) != 1 {
+		return nil, "", false
+	}
+	cast, ok := args[0].(*parser.FunctionCall)
+	if !ok || cast.Name != "CAST" {
+		return nil, "", false
+	}
+	ident, ok := cast.Args[0].(*parser.Identifier)
+	if !ok {
+		return nil, "", false
+	}
+	typeName, _ := cast.Args[1].(*parser.Literal).Value.(string)
+	return ident, typeName, true
+}
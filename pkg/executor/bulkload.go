@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+type bulkLoadKey struct{}
+
+// bulkLoading reports whether ctx runs inside BulkLoad
+func bulkLoading(ctx context.Context) bool {
+	loading, _ := ctx.Value(bulkLoadKey{}).(bool)
+	return loading
+}
+
+// BulkLoad runs load with table writes deferred: statements executed with
+// the context it is given skip the per-statement flush, and every changed
+// table is written once when load returns, whether or not it failed.
+//
+// Statements are still logged to the WAL, and under the per-statement flush
+// policy a checkpoint is taken first so a crash mid-load is recovered by
+// replaying the WAL. Bulk loads are meant for initial loads and imports
+// with no other writers running.
+func (e *Executor) BulkLoad(ctx context.Context, load func(ctx context.Context) error) error {
+	e.mu.Lock()
+	tracked, err := e.startBulkLoad(ctx)
+	e.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	loadErr := load(context.WithValue(ctx, bulkLoadKey{}, true))
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.finishBulkLoad(ctx, tracked); err != nil {
+		return errors.Join(loadErr, err)
+	}
+	return loadErr
+}
+
+// startBulkLoad takes the checkpoint a bulk load starts from, reporting
+// whether it did; callers must hold e.mu exclusively
+func (e *Executor) startBulkLoad(ctx context.Context) (bool, error) {
+	// Batched policies already checkpoint in the background
+	tracked := e.flushPolicy == FlushEveryStatement && e.wal != nil && !e.storage.ReadOnly()
+	if tracked {
+		if err := e.checkpoint(ctx, true); err != nil {
+			return false, fmt.Errorf("failed to start bulk load: %w", err)
+		}
+	}
+	return tracked, nil
+}
+
+// finishBulkLoad writes the tables a bulk load changed and removes the
+// checkpoint startBulkLoad took; callers must hold e.mu exclusively
+func (e *Executor) finishBulkLoad(ctx context.Context, tracked bool) error {
+	if e.flushPolicy != FlushEveryStatement || e.storage.ReadOnly() {
+		return nil
+	}
+	if err := e.checkpoint(ctx, tracked); err != nil {
+		return fmt.Errorf("failed to persist data: %w", err)
+	}
+
+	// Tables are written after every statement again, so the checkpoint no
+	// longer describes them
+	if tracked {
+		path := filepath.Join(e.storage.DataDir(), checkpointFileName)
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove checkpoint: %w", err)
+		}
+	}
+	return nil
+}
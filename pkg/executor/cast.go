@@ -0,0 +1,148 @@
+package executor
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// castValue converts a value to the type CAST names: INTEGER, FLOAT,
+// BOOLEAN, VARCHAR or VARCHAR(n). NULL stays NULL. Strings are trimmed of
+// surrounding spaces before they are read as numbers or booleans.
+func castValue(value interface{}, typeName string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if size, ok := varcharSize(typeName); ok {
+		return castToVarchar(value, size), nil
+	}
+
+	switch typeName {
+	case "INTEGER":
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case float64:
+			// Floats round to the nearest integer, halves away from zero
+			rounded := math.Round(v)
+			if math.IsNaN(rounded) || rounded < math.MinInt64 || rounded >= math.MaxInt64 {
+				return nil, fmt.Errorf("cannot cast %v to INTEGER: out of range", v)
+			}
+			return int(rounded), nil
+		case string:
+			i, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("cannot cast %q to INTEGER", v)
+			}
+			return i, nil
+		case bool:
+			if v {
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case "FLOAT":
+		switch v := value.(type) {
+		case int:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, fmt.Errorf("cannot cast %q to FLOAT", v)
+			}
+			return f, nil
+		}
+	case "BOOLEAN":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int:
+			return v != 0, nil
+		case float64:
+			return v != 0, nil
+		case string:
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true", "t", "yes", "y", "on", "1":
+				return true, nil
+			case "false", "f", "no", "n", "off", "0":
+				return false, nil
+			}
+			return nil, fmt.Errorf("cannot cast %q to BOOLEAN", v)
+		}
+	default:
+		return nil, fmt.Errorf("cannot cast to %s", typeName)
+	}
+	return nil, fmt.Errorf("cannot cast %s value %v to %s", sqlTypeName(value), value, typeName)
+}
+
+// castToVarchar renders a value as text, keeping at most size characters
+// when size is positive. Floats are written in full, without an exponent.
+func castToVarchar(value interface{}, size int) string {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		text = fmt.Sprint(v)
+	}
+	if runes := []rune(text); size > 0 && len(runes) > size {
+		return string(runes[:size])
+	}
+	return text
+}
+
+// castColumn returns col as it reads once its values are CAST to typeName
+func castColumn(col storage.Column, typeName string) storage.Column {
+	if size, ok := varcharSize(typeName); ok {
+		return storage.Column{Name: col.Name, DataType: storage.TypeVarchar, Size: size}
+	}
+	switch typeName {
+	case "INTEGER":
+		return storage.Column{Name: col.Name, DataType: storage.TypeInteger}
+	case "FLOAT":
+		return storage.Column{Name: col.Name, DataType: storage.TypeFloat}
+	default:
+		return storage.Column{Name: col.Name, DataType: storage.TypeBoolean}
+	}
+}
+
+// varcharSize reports whether typeName is VARCHAR or VARCHAR(n), and n, or
+// 0 for no limit
+func varcharSize(typeName string) (int, bool) {
+	if typeName == "VARCHAR" {
+		return 0, true
+	}
+	inner, ok := strings.CutPrefix(typeName, "VARCHAR(")
+	if !ok {
+		return 0, false
+	}
+	size, err := strconv.Atoi(strings.TrimSuffix(inner, ")"))
+	return size, err == nil
+}
+
+// sqlTypeName names the SQL type of a value, for error messages
+func sqlTypeName(value interface{}) string {
+	switch value.(type) {
+	case int:
+		return "INTEGER"
+	case float64:
+		return "FLOAT"
+	case string:
+		return "VARCHAR"
+	case bool:
+		return "BOOLEAN"
+	case Interval:
+		return "INTERVAL"
+	case storage.Blob:
+		return "BLOB"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
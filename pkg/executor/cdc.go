@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// SetChangeStream attaches a change data capture stream. Row changes made by
+// write statements are published to it once the statement commits.
+func (e *Executor) SetChangeStream(stream *cdc.Stream) {
+	e.changes = stream
+}
+
+// ChangeStream returns the attached change stream, or nil
+func (e *Executor) ChangeStream() *cdc.Stream {
+	return e.changes
+}
+
+// changeSet collects the row changes made by a single statement
+type changeSet struct {
+	changes []cdc.Change
+}
+
+type changeSetKey struct{}
+
+// withChangeSet returns a context that collects row changes into cs
+func withChangeSet(ctx context.Context, cs *changeSet) context.Context {
+	return context.WithValue(ctx, changeSetKey{}, cs)
+}
+
+// changeSetFrom returns the change set in ctx, or nil when changes are not
+// being captured
+func changeSetFrom(ctx context.Context) *changeSet {
+	cs, _ := ctx.Value(changeSetKey{}).(*changeSet)
+	return cs
+}
+
+// add records a row change. Values are copied so later mutations of the
+// row do not leak into the captured change.
+func (cs *changeSet) add(schema *storage.Schema, op cdc.Op, oldValues, newValues []interface{}) {
+	cs.changes = append(cs.changes, cdc.Change{
+		Table: schema.TableName,
+		Op:    op,
+		Old:   rowMap(schema, oldValues),
+		New:   rowMap(schema, newValues),
+	})
+}
+
+// rowMap converts row values to a column name -> value map
+func rowMap(schema *storage.Schema, values []interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	m := make(map[string]interface{}, len(values))
+	for i, col := range schema.Columns {
+		if i < len(values) {
+			m[col.Name] = values[i]
+		}
+	}
+	return m
+}
+
+// trackMatches wraps a row condition so every matching row is passed to
+// onMatch before the table mutates it. A nil condition matches all rows.
+func trackMatches(condition func(*storage.Row) bool, onMatch func(*storage.Row)) func(*storage.Row) bool {
+	return func(row *storage.Row) bool {
+		if condition != nil && !condition(row) {
+			return false
+		}
+		onMatch(row)
+		return true
+	}
+}
+
+// publishChanges hands a committed statement's changes to the stream
+func (e *Executor) publishChanges(lsn uint64, cs *changeSet) {
+	if e.changes == nil || cs == nil {
+		return
+	}
+	e.changes.Publish(lsn, cs.changes)
+}
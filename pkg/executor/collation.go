@@ -0,0 +1,74 @@
+package executor
+
+import (
+	"fmt"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// compareCollated compares two values like compareValues, but orders and
+// matches strings under collation. Regular expressions match bytes as
+// written, whatever the collation, while LIKE ignores case under nocase.
+func (e *Executor) compareCollated(left, right interface{}, operator, collation string) (bool, error) {
+	if isMatchOperator(operator) {
+		return e.matchPattern(left, right, operator)
+	}
+	if isLikeOperator(operator) {
+		return e.matchLike(left, right, operator, collation)
+	}
+	ls, lok := left.(string)
+	rs, rok := right.(string)
+	if !lok || !rok || collation == "" {
+		return e.compareValues(left, right, operator)
+	}
+
+	return compareOrder(storage.CompareStrings(ls, rs, collation), operator)
+}
+
+// compareOrder applies a comparison operator to the result of comparing
+// two values, which is negative, zero or positive
+func compareOrder(c int, operator string) (bool, error) {
+	switch operator {
+	case "=":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case ">":
+		return c > 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">=":
+		return c >= 0, nil
+	default:
+		return false, fmt.Errorf("unsupported operator: %s", operator)
+	}
+}
+
+// conditionCollation returns the collation a comparison uses: that of the
+// first operand that is a column with a collation
+func conditionCollation(schema *storage.Schema, operands ...parser.Expression) string {
+	for _, operand := range operands {
+		if ident, ok := operand.(*parser.Identifier); ok {
+			if idx := schema.GetColumnIndex(ident.Value); idx != -1 && schema.Columns[idx].CompareCollation() != "" {
+				return schema.Columns[idx].CompareCollation()
+			}
+		}
+	}
+	return ""
+}
+
+// joinConditionCollation is conditionCollation for a joined row, where
+// columns may be qualified with their table name
+func joinConditionCollation(row *CombinedRow, operands ...parser.Expression) string {
+	for _, operand := range operands {
+		if ident, ok := operand.(*parser.Identifier); ok {
+			if idx := row.schema.index(ident.Value); idx != -1 && row.schema.column(idx).CompareCollation() != "" {
+				return row.schema.column(idx).CompareCollation()
+			}
+		}
+	}
+	return ""
+}
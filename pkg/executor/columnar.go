@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// columnarScan reports whether a single-table SELECT reads a table through
+// its column vectors. Samples, soft-deleted rows and virtual columns need
+// the rows themselves, so those scans read them as for any table.
+func columnarScan(schema *storage.Schema, sample *parser.TableSample, withDeleted bool) bool {
+	return schema.Columnar() && sample == nil && !withDeleted && !hasVirtualColumns(schema)
+}
+
+// scanColumnar returns the rows of a columnar table matching where, and
+// how many rows were scanned. A comparison of a column with a constant,
+// the usual filter of an analytics query, is evaluated once for the
+// constant and then against the column's vector alone; other conditions
+// are evaluated row by row.
+func (e *Executor) scanColumnar(ctx context.Context, table *storage.Table, where parser.Expression) ([]*storage.Row, int, error) {
+	schema := table.Schema
+	column, constant, columnLeft := columnComparison(schema, where)
+	if column == -1 {
+		rows, _ := table.ScanColumns(nil)
+		filtered, err := e.filterRows(ctx, rows, where, schema)
+		return filtered, len(rows), err
+	}
+
+	cond := where.(*parser.BinaryExpr)
+	value, err := e.getColumnValue(ctx, constant, nil, schema)
+	if err != nil {
+		return nil, 0, err
+	}
+	collation := conditionCollation(schema, cond.Left, cond.Right)
+
+	rows, vectors := table.ScanColumns([]int{column})
+	matched := []*storage.Row{}
+	for i, v := range vectors[0] {
+		if err := checkCancelled(ctx, i); err != nil {
+			return nil, 0, err
+		}
+		left, right := v, value
+		if !columnLeft {
+			left, right = value, v
+		}
+		match, err := e.compareCollated(left, right, cond.Operator, collation)
+		if err != nil {
+			return nil, 0, err
+		}
+		if match {
+			matched = append(matched, rows[i])
+		}
+	}
+	return matched, len(rows), nil
+}
+
+// columnComparison picks apart a WHERE clause comparing a column with an
+// expression that names no column and calls no volatile function,
+// returning the column's index, the expression and whether the column is
+// on the left. The index is -1 for any other condition.
+func columnComparison(schema *storage.Schema, where parser.Expression) (int, parser.Expression, bool) {
+	cond, ok := where.(*parser.BinaryExpr)
+	if !ok || isValueOperator(cond.Operator) {
+		return -1, nil, false
+	}
+	for _, side := range []struct {
+		column, constant parser.Expression
+		left             bool
+	}{{cond.Left, cond.Right, true}, {cond.Right, cond.Left, false}} {
+		ident, ok := side.column.(*parser.Identifier)
+		if !ok || !constantExpression(side.constant) {
+			continue
+		}
+		if idx := schema.GetColumnIndex(ident.Value); idx != -1 {
+			return idx, side.constant, side.left
+		}
+	}
+	return -1, nil, false
+}
+
+// constantExpression reports whether an expression has the same value for
+// every row
+func constantExpression(expr parser.Expression) bool {
+	constant := true
+	parser.WalkExpression(expr, func(expr parser.Expression) {
+		switch ex := expr.(type) {
+		case *parser.Identifier:
+			constant = false
+		case *parser.FunctionCall:
+			if functions[ex.Name].volatile {
+				constant = false
+			}
+		}
+	})
+	return constant
+}
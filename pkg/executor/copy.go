@@ -0,0 +1,498 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/tracing"
+)
+
+// copyBatchRows is how many rows COPY inserts and logs at a time
+const copyBatchRows = 1000
+
+// CopyFormat is the encoding of COPY input
+type CopyFormat int
+
+const (
+	// CopyText is one row per line with tab-separated fields, \N for NULL
+	// and backslash escapes (\t, \n, \r, \\) inside fields
+	CopyText CopyFormat = iota
+	// CopyCSV is RFC 4180 CSV; empty fields are NULL
+	CopyCSV
+)
+
+// CopyOptions describes COPY input
+type CopyOptions struct {
+	Format    CopyFormat
+	Delimiter rune   // 0 for the format's default (tab or comma)
+	Header    bool   // skip the first line
+	Null      string // marker for NULL; empty for the format's default
+}
+
+// Copy runs a COPY ... FROM STDIN statement, reading its rows from r
+func (e *Executor) Copy(ctx context.Context, query string, r io.Reader) (*Result, error) {
+	stmt, err := parser.NewParser(query).Parse()
+	if err != nil {
+		e.stats.recordParseError()
+		return nil, &ParseError{Err: err}
+	}
+	copyStmt, ok := stmt.(*parser.CopyStmt)
+	if !ok {
+		return nil, fmt.Errorf("expected a COPY statement")
+	}
+	if copyStmt.Path != "" {
+		return nil, fmt.Errorf("COPY FROM a file reads no input: run it with Query")
+	}
+
+	copied, err := e.CopyFrom(ctx, copyStmt.TableName, copyStmt.Columns, r, copyOptions(copyStmt))
+	return copyResult(copied, err)
+}
+
+// CopyFrom streams delimited rows from r into a table without building
+// INSERT statements, returning how many rows were copied. columns lists the
+// columns the fields fill, in order; empty means every non-generated column.
+//
+// Rows are inserted and logged in batches of copyBatchRows, so a failure
+// keeps the batches before the one that failed. The table is written once,
+// when the copy ends.
+func (e *Executor) CopyFrom(ctx context.Context, tableName string, columns []string, r io.Reader, opts CopyOptions) (int, error) {
+	ctx, span := tracing.Start(ctx, "copy")
+	defer span.End()
+	span.SetAttribute("db.table", tableName)
+
+	text := "COPY " + tableName
+	if len(columns) > 0 {
+		text += " (" + strings.Join(columns, ", ") + ")"
+	}
+	ctx, running, done := e.trackQuery(ctx, text+" FROM STDIN")
+	defer done()
+
+	copied, err := e.runCopy(ctx, running, tableName, columns, r, opts)
+	span.RecordError(err)
+	span.SetAttribute("db.rows_affected", copied)
+	return copied, err
+}
+
+// copyFile runs COPY ... FROM '<path>' for Query, which tracks it. Only
+// unrestricted callers may read files on the server.
+func (e *Executor) copyFile(ctx context.Context, running *runningQuery, stmt *parser.CopyStmt) (*Result, error) {
+	if user := UserFrom(ctx); user != "" {
+		return nil, fmt.Errorf("permission denied: user %s cannot COPY from a file", user)
+	}
+	file, err := os.Open(stmt.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	copied, err := e.runCopy(ctx, running, stmt.TableName, stmt.Columns, file, copyOptions(stmt))
+	return copyResult(copied, err)
+}
+
+// copyOptions returns the options a COPY statement sets
+func copyOptions(stmt *parser.CopyStmt) CopyOptions {
+	opts := CopyOptions{Header: stmt.Header, Null: stmt.Null}
+	if stmt.Format == "csv" {
+		opts.Format = CopyCSV
+	}
+	if stmt.Delimiter != "" {
+		opts.Delimiter = []rune(stmt.Delimiter)[0]
+	}
+	return opts
+}
+
+// copyResult reports the outcome of a COPY statement
+func copyResult(copied int, err error) (*Result, error) {
+	if err != nil {
+		return nil, fmt.Errorf("%w (%d row(s) copied before the failure)", err, copied)
+	}
+	return &Result{
+		Message:      fmt.Sprintf("%d row(s) copied", copied),
+		RowsAffected: copied,
+	}, nil
+}
+
+// runCopy runs a tracked COPY once it is admitted
+func (e *Executor) runCopy(ctx context.Context, running *runningQuery, tableName string, columns []string, r io.Reader, opts CopyOptions) (int, error) {
+	release, err := e.admit(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	e.setRunning(running)
+
+	start := time.Now()
+	copied, err := e.copyFrom(ctx, tableName, columns, r, opts)
+	e.stats.recordStatement("COPY", time.Since(start), err)
+	return copied, err
+}
+
+// copyFrom implements CopyFrom
+func (e *Executor) copyFrom(ctx context.Context, tableName string, columns []string, r io.Reader, opts CopyOptions) (copied int, err error) {
+	if e.readOnly {
+		return 0, fmt.Errorf("cannot execute COPY: database is read-only")
+	}
+
+	table, err := e.storage.GetTable(tableName)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkWritable(ctx, table); err != nil {
+		return 0, err
+	}
+	if err := e.checkUser(ctx); err != nil {
+		return 0, err
+	}
+	if err := checkPrivilege(ctx, table.Schema, storage.PrivilegeInsert); err != nil {
+		return 0, err
+	}
+	schema := table.Schema
+
+	// Determine column order, as INSERT does
+	if len(columns) == 0 {
+		for _, col := range schema.Columns {
+			if col.Generated == "" {
+				columns = append(columns, col.Name)
+			}
+		}
+	}
+	columnIndices := make([]int, len(columns))
+	for i, colName := range columns {
+		idx := schema.GetColumnIndex(colName)
+		if idx == -1 {
+			return 0, fmt.Errorf("column %s does not exist in table %s", colName, tableName)
+		}
+		if schema.Columns[idx].Generated != "" {
+			return 0, fmt.Errorf("cannot copy into generated column %s", colName)
+		}
+		columnIndices[i] = idx
+	}
+
+	// Columns left out get their defaults, DEFAULT NOW() the time COPY
+	// started and DEFAULT UUID() a new UUID for each row
+	defaults := make(map[int]interface{})
+	var uuidColumns []int
+	for i, col := range schema.Columns {
+		if col.Default != "" && !slices.Contains(columns, col.Name) {
+			if call := e.resolvedDefault(col); call != nil && uuidFunctions[call.Name] {
+				uuidColumns = append(uuidColumns, i)
+				continue
+			}
+			if defaults[i], err = e.columnDefault(ctx, col); err != nil {
+				return 0, fmt.Errorf("column %s: %w", col.Name, err)
+			}
+		}
+	}
+
+	// The copy holds the lock exclusively and, like BulkLoad, writes the
+	// table once at the end rather than after every batch; no other writer
+	// may flush the table halfway, as recovery would replay its rows again
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !bulkLoading(ctx) {
+		tracked, startErr := e.startBulkLoad(ctx)
+		if startErr != nil {
+			return 0, startErr
+		}
+		ctx = context.WithValue(ctx, bulkLoadKey{}, true)
+		defer func() {
+			if finishErr := e.finishBulkLoad(ctx, tracked); finishErr != nil {
+				err = errors.Join(err, finishErr)
+			}
+		}()
+	}
+
+	input := newCopyReader(r, opts)
+	header := opts.Header
+	batch := make([]*storage.Row, 0, copyBatchRows)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := e.copyBatch(ctx, tableName, batch); err != nil {
+			return err
+		}
+		copied += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		fields, line, err := input.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return copied, err
+		}
+		if header {
+			header = false
+			continue
+		}
+		if len(fields) != len(columns) {
+			return copied, fmt.Errorf("line %d: expected %d field(s), got %d", line, len(columns), len(fields))
+		}
+
+		row := storage.NewRow(make([]interface{}, len(schema.Columns)))
+		for i, field := range fields {
+			if field == nil {
+				continue
+			}
+			col := schema.Columns[columnIndices[i]]
+			value, err := parseCopyValue(*field, col)
+			if err != nil {
+				return copied, fmt.Errorf("line %d: %w", line, err)
+			}
+			row.Values[columnIndices[i]] = value
+		}
+		for idx, value := range defaults {
+			row.Values[idx] = value
+		}
+		for _, idx := range uuidColumns {
+			row.Values[idx] = storage.NewUUID()
+		}
+		if err := e.computeGenerated(ctx, schema, row.Values, false); err != nil {
+			return copied, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		batch = append(batch, row)
+		if len(batch) == copyBatchRows {
+			if err := flush(); err != nil {
+				return copied, fmt.Errorf("batch ending at line %d: %w", line, err)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return copied, err
+	}
+	return copied, nil
+}
+
+// copyBatch inserts rows into a table and logs them to the WAL as one
+// INSERT, so replicas and RESTORE replay COPY like any other write. Callers
+// must hold e.mu exclusively.
+func (e *Executor) copyBatch(ctx context.Context, tableName string, rows []*storage.Row) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+
+	// Look the table up again: it may have been dropped or restored before
+	// the copy took the lock
+	table, err := e.storage.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+	policies, err := e.policies(ctx, table.Schema)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := e.checkPolicies(ctx, table.Schema, policies, row.Values); err != nil {
+			return err
+		}
+	}
+	query, err := copyInsert(table.Schema, rows)
+	if err != nil {
+		return err
+	}
+
+	if err := e.insertRows(table, rows); err != nil {
+		return err
+	}
+	e.invalidateResults(tableName)
+	e.stats.recordTable(tableName, func(t *TableStats) {
+		t.Statements++
+		t.RowsInserted += int64(len(rows))
+	})
+
+	var lsn uint64
+	if e.wal != nil {
+		rec, err := e.wal.Append(query, UserFrom(ctx))
+		if err != nil {
+			return fmt.Errorf("rows copied but not logged: %w", err)
+		}
+		lsn = rec.LSN
+	}
+	if e.changes != nil {
+		cs := &changeSet{}
+		for _, row := range rows {
+			cs.add(table.Schema, cdc.OpInsert, nil, row.Values)
+		}
+		e.publishChanges(lsn, cs)
+	}
+	return nil
+}
+
+// copyInsert renders rows as the INSERT that is logged for them, listing
+// values for every non-generated column in schema order
+func copyInsert(schema *storage.Schema, rows []*storage.Row) (string, error) {
+	var b strings.Builder
+	b.WriteString("INSERT INTO " + schema.TableName + " VALUES ")
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString("(")
+		first := true
+		for j, value := range row.Values {
+			if schema.Columns[j].Generated != "" {
+				continue
+			}
+			literal, err := parser.FormatLiteral(value)
+			if err != nil {
+				return "", fmt.Errorf("column %s: %w", schema.Columns[j].Name, err)
+			}
+			if !first {
+				b.WriteString(", ")
+			}
+			b.WriteString(literal)
+			first = false
+		}
+		b.WriteString(")")
+	}
+	return b.String(), nil
+}
+
+// parseCopyValue converts a COPY field to a value of the column's type
+func parseCopyValue(field string, col storage.Column) (interface{}, error) {
+	switch col.DataType {
+	case storage.TypeInteger:
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("column %s expects INTEGER, got %q", col.Name, field)
+		}
+		return n, nil
+	case storage.TypeFloat:
+		f, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("column %s expects FLOAT, got %q", col.Name, field)
+		}
+		return f, nil
+	case storage.TypeBoolean:
+		b, err := strconv.ParseBool(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("column %s expects BOOLEAN, got %q", col.Name, field)
+		}
+		return b, nil
+	case storage.TypeTimestamp, storage.TypeDate, storage.TypeBlob, storage.TypeUUID:
+		return storage.NormalizeValue(strings.TrimSpace(field), col)
+	default:
+		return field, nil
+	}
+}
+
+// copyReader splits COPY input into fields. A nil field is NULL.
+type copyReader struct {
+	opts  CopyOptions
+	text  *bufio.Reader
+	csv   *csv.Reader
+	lines int
+}
+
+// newCopyReader reads rows from r in the format opts describes
+func newCopyReader(r io.Reader, opts CopyOptions) *copyReader {
+	c := &copyReader{opts: opts}
+	if opts.Format == CopyCSV {
+		c.csv = csv.NewReader(r)
+		c.csv.FieldsPerRecord = -1
+		c.csv.ReuseRecord = true
+		if opts.Delimiter != 0 {
+			c.csv.Comma = opts.Delimiter
+		}
+	} else {
+		c.text = bufio.NewReader(r)
+		if c.opts.Delimiter == 0 {
+			c.opts.Delimiter = '\t'
+		}
+		if c.opts.Null == "" {
+			c.opts.Null = `\N`
+		}
+	}
+	return c
+}
+
+// next returns the fields of the next row and the line it started on
+func (c *copyReader) next() ([]*string, int, error) {
+	if c.csv != nil {
+		record, err := c.csv.Read()
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, parseErr.StartLine, fmt.Errorf("line %d: %w", parseErr.StartLine, parseErr.Err)
+			}
+			return nil, 0, err
+		}
+		line, _ := c.csv.FieldPos(0)
+		fields := make([]*string, len(record))
+		for i, value := range record {
+			if value != c.opts.Null {
+				value := value
+				fields[i] = &value
+			}
+		}
+		return fields, line, nil
+	}
+
+	for {
+		line, err := c.text.ReadString('\n')
+		if line == "" && err != nil {
+			return nil, 0, err
+		}
+		if err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		c.lines++
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line == "" {
+			continue
+		}
+
+		parts := strings.Split(line, string(c.opts.Delimiter))
+		fields := make([]*string, len(parts))
+		for i, part := range parts {
+			if part != c.opts.Null {
+				value := unescapeCopyText(part)
+				fields[i] = &value
+			}
+		}
+		return fields, c.lines, nil
+	}
+}
+
+// unescapeCopyText decodes the backslash escapes of the text format
+func unescapeCopyText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
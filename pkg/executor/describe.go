@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// executeShowTables executes SHOW TABLES, listing the tables in name order
+// with what kind of table each is
+func (e *Executor) executeShowTables() *Result {
+	names := e.storage.ListTables()
+	slices.Sort(names)
+
+	result := &Result{Columns: []string{"name", "type"}, Rows: [][]interface{}{}}
+	for _, name := range names {
+		table, err := e.storage.GetTable(name)
+		if err != nil {
+			continue // dropped since it was listed
+		}
+		result.Rows = append(result.Rows, []interface{}{name, tableKind(table.Schema)})
+	}
+	result.RowsAffected = len(result.Rows)
+	return result
+}
+
+// tableKind names the kind of table a schema describes, as SHOW TABLES
+// shows it
+func tableKind(schema *storage.Schema) string {
+	switch {
+	case systemTables[schema.TableName] != nil:
+		return "system table"
+	case schema.Materialized():
+		return "materialized view"
+	case schema.Foreign():
+		return "foreign table"
+	case schema.Partitioned():
+		return "partitioned table"
+	case schema.PartitionOf != "":
+		return "partition"
+	default:
+		return "table"
+	}
+}
+
+// executeDescribe executes DESCRIBE, listing a table's columns in order.
+// Expressions are shown as written; empty attributes are NULL.
+func (e *Executor) executeDescribe(stmt *parser.DescribeStmt) (*Result, error) {
+	table, err := e.storage.GetTable(stmt.TableName)
+	if err != nil {
+		return nil, fmt.Errorf("table %s does not exist", stmt.TableName)
+	}
+
+	result := &Result{
+		Columns: []string{"name", "type", "nullable", "primary", "unique", "default", "generated", "collation"},
+		Rows:    [][]interface{}{},
+	}
+	for _, col := range table.Schema.Columns {
+		typ := col.DataType.String()
+		if col.DataType == storage.TypeVarchar && col.Size > 0 {
+			typ += fmt.Sprintf("(%d)", col.Size)
+		}
+		result.Rows = append(result.Rows, []interface{}{
+			col.Name, typ, !col.NotNull && !col.PrimaryKey, col.PrimaryKey, col.Unique,
+			nullIfEmpty(col.Default), nullIfEmpty(col.Generated), nullIfEmpty(col.Collation),
+		})
+	}
+	result.RowsAffected = len(result.Rows)
+	return result, nil
+}
+
+// nullIfEmpty returns s, or nil for an empty string
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
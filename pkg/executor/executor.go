@@ -1,45 +1,541 @@
 package executor
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
 	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
 	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/tracing"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/wal"
 )
 
 // Executor executes SQL statements
 type Executor struct {
-	storage *storage.Storage
+	storage   *storage.Storage
+	wal       *wal.Log
+	changes   *cdc.Stream
+	readOnly  bool
+	stats     *statsCollector
+	advisor   *indexAdvisor
+	plans     *planCache
+	results   *resultCache
+	limits    ResultLimits
+	admission admission
+	generated sync.Map // generated column definition -> parsed expression
+
+	expressions sync.Map     // policy condition or column mask -> parsed expression
+	patterns    patternCache // compiled REGEXP patterns
+
+	flushPolicy     FlushPolicy
+	flushInterval   time.Duration
+	checkpointLSN   uint64 // LSN in the checkpoint file
+	stopCheckpoints func() // stops background checkpoints; nil when not running
+	recovering      bool   // set while Recover replays the WAL
+
+	defaultCollation string            // collation of VARCHAR columns created without COLLATE
+	settingDefaults  map[string]string // values settings had before SET GLOBAL first changed them
+	settingsMu       sync.Mutex        // guards statementTimeout, which is read before e.mu is taken
+	statementTimeout time.Duration     // for sessions that set no statement_timeout
+
+	sequenceMu sync.Mutex // serializes advancing sequences so they are logged in order
+	commitMu   sync.Mutex // serializes running and logging write statements
+
+	queries queryRegistry // statements being executed, which KillQuery can cancel
+
+	mu sync.RWMutex // held exclusively by BACKUP and RESTORE
 }
 
 // NewExecutor creates a new executor
 func NewExecutor(storage *storage.Storage) *Executor {
-	return &Executor{storage: storage}
+	return &Executor{
+		storage:  storage,
+		readOnly: storage.ReadOnly(),
+		stats:    newStatsCollector(),
+		advisor:  newIndexAdvisor(),
+		plans:    newPlanCache(DefaultPlanCacheSize),
+		results:  newResultCache(),
+
+		settingDefaults: make(map[string]string),
+	}
+}
+
+// ParseError wraps a syntax error so callers can tell it apart from
+// execution failures
+type ParseError struct {
+	Err error
+}
+
+func (p *ParseError) Error() string {
+	return p.Err.Error()
+}
+
+func (p *ParseError) Unwrap() error {
+	return p.Err
+}
+
+// Query parses and executes a SQL query
+func (e *Executor) Query(ctx context.Context, query string) (*Result, error) {
+	ctx, span := tracing.Start(ctx, "query")
+	defer span.End()
+	span.SetAttribute("db.statement", query)
+
+	ctx, running, done := e.trackQuery(ctx, query)
+	defer done()
+
+	_, parseSpan := tracing.Start(ctx, "parse")
+	stmt, err := e.parse(query)
+	parseSpan.RecordError(err)
+	parseSpan.End()
+	if err != nil {
+		e.stats.recordParseError()
+		span.RecordError(err)
+		return nil, &ParseError{Err: err}
+	}
+
+	// COPY from a file takes its own lock and logs its rows as INSERTs
+	if copyStmt, ok := stmt.(*parser.CopyStmt); ok && copyStmt.Path != "" {
+		result, err := e.copyFile(ctx, running, copyStmt)
+		span.RecordError(err)
+		return result, err
+	}
+
+	// SQL functions are inlined, and NOW(), UUID() and the columns
+	// defaulting to them resolved once, so the logged statement carries
+	// what they stood for
+	text := query
+	if resolved, changed, err := e.resolveFunctions(stmt, time.Now()); err != nil {
+		span.RecordError(err)
+		return nil, err
+	} else if changed {
+		stmt, text = resolved, formatStatement(resolved)
+	}
+
+	// Logged statements must not depend on session state when replayed
+	logged := query
+	if isLogged(stmt) {
+		if err = checkDeterministic(stmt); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		// A policy keeps @current_user, which is resolved for each reader
+		if _, ok := stmt.(*parser.CreatePolicyStmt); !ok {
+			if logged, err = loggedQuery(ctx, text); err != nil {
+				span.RecordError(err)
+				return nil, err
+			}
+		}
+	}
+
+	release, err := e.admit(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	defer release()
+	e.setRunning(running)
+
+	var cs *changeSet
+	if e.changes != nil && isLogged(stmt) {
+		cs = &changeSet{}
+		ctx = withChangeSet(ctx, cs)
+	}
+
+	ctx, cancel := e.withStatementTimeout(ctx)
+	defer cancel()
+
+	unlock := e.lock(stmt)
+	defer unlock()
+
+	// Logged statements run, reach the WAL and are published one at a
+	// time, so replicas, RESTORE and change consumers see them in the order
+	// they changed the tables. Reads still run alongside.
+	committing := isLogged(stmt)
+	if committing {
+		e.commitMu.Lock()
+		defer func() {
+			if committing {
+				e.commitMu.Unlock()
+			}
+		}()
+	}
+
+	// NEXTVAL and CURRVAL are resolved once, so the logged INSERT carries
+	// the values they returned
+	if insert, ok := stmt.(*parser.InsertStmt); ok && usesSequences(insert) {
+		if insert, err = e.resolveSequences(ctx, insert); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		stmt = insert
+		if logged, err = loggedQuery(ctx, parser.FormatInsert(insert)); err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+
+	// Only the query's own SELECT streams its rows, and it bypasses the
+	// result cache as its rows are not kept
+	sel, isSelect := stmt.(*parser.SelectStmt)
+	if !isSelect {
+		ctx = withoutRowWriter(ctx)
+	}
+
+	var result *Result
+	if isSelect && rowWriterFrom(ctx) == nil {
+		result, err = e.cachedSelect(ctx, query, sel, func() (*Result, error) {
+			return e.executeChecked(ctx, stmt)
+		})
+	} else {
+		result, err = e.executeChecked(ctx, stmt)
+	}
+	if err == nil && isLogged(stmt) {
+		var lsn uint64
+		if e.wal != nil {
+			rec, walErr := e.wal.Add(logged, UserFrom(ctx))
+			if walErr != nil {
+				err = fmt.Errorf("statement applied but not logged: %w", walErr)
+			}
+			lsn = rec.LSN
+		}
+		if err == nil {
+			e.publishChanges(lsn, cs)
+		}
+
+		// Statements logged meanwhile may share the fsync
+		committing = false
+		e.commitMu.Unlock()
+		if err == nil && e.wal != nil {
+			if walErr := e.wal.Commit(lsn); walErr != nil {
+				err = fmt.Errorf("statement applied but not logged: %w", walErr)
+			}
+		}
+	}
+	span.RecordError(err)
+	return result, err
+}
+
+// QueryParams executes a SQL query with ? or $n parameter placeholders,
+// binding params to them in order. Values are bound as literals, so they
+// can be anything a literal can: NULL, a string, a number or a boolean.
+func (e *Executor) QueryParams(ctx context.Context, query string, params []interface{}) (*Result, error) {
+	bound, err := parser.BindParameters(query, params)
+	if err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	return e.Query(ctx, bound)
+}
+
+// QueryAll executes a script of statements separated by semicolons, one
+// after the other, returning the result of each. Nothing runs if any
+// statement fails to parse; otherwise execution stops at the first failing
+// statement, returning the results of the ones before it.
+func (e *Executor) QueryAll(ctx context.Context, script string) ([]*Result, error) {
+	statements, err := parser.ParseAll(script)
+	if err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	if len(statements) == 0 {
+		return nil, &ParseError{Err: fmt.Errorf("empty statement")}
+	}
+
+	results := make([]*Result, 0, len(statements))
+	for i, stmt := range statements {
+		result, err := e.Query(ctx, stmt.Text)
+		if err != nil {
+			return results, fmt.Errorf("statement %d: %w", i+1, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
 }
 
 // Execute executes a SQL statement
 func (e *Executor) Execute(stmt parser.Statement) (*Result, error) {
+	return e.ExecuteContext(context.Background(), stmt)
+}
+
+// ExecuteContext executes a SQL statement, recording trace spans on ctx
+func (e *Executor) ExecuteContext(ctx context.Context, stmt parser.Statement) (*Result, error) {
+	stmt, _, err := e.resolveFunctions(stmt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := e.withStatementTimeout(ctx)
+	defer cancel()
+
+	unlock := e.lock(stmt)
+	defer unlock()
+
+	return e.executeChecked(ctx, stmt)
+}
+
+// lock takes the executor lock for a statement. BACKUP and RESTORE run
+// exclusively so they see (and produce) a state that matches a single LSN,
+// as do statements that change a table's partitions, who may read it or a
+// sequence.
+func (e *Executor) lock(stmt parser.Statement) func() {
+	if isMaintenance(stmt) || changesPartitions(stmt) || changesAccess(stmt) || changesSequence(stmt) || changesFunction(stmt) || changesSoftDelete(stmt) || changesSettings(stmt) || changesEncoding(stmt) || changesView(stmt) || mergesRows(stmt) || e.checksReferences(stmt) {
+		e.mu.Lock()
+		return e.mu.Unlock
+	}
+	e.mu.RLock()
+	return e.mu.RUnlock
+}
+
+// executeChecked runs a statement after rejecting writes in read-only mode
+func (e *Executor) executeChecked(ctx context.Context, stmt parser.Statement) (*Result, error) {
+	if e.readOnly && !isReadOnly(stmt) {
+		return nil, fmt.Errorf("cannot execute %s: database is read-only", statementType(stmt))
+	}
+	// Users must not be able to lift their own restrictions, nor schedule
+	// statements that would run without them
+	if user := UserFrom(ctx); user != "" && (changesAccess(stmt) || !isReadOnly(stmt) && isSystemTable(targetTable(stmt))) {
+		return nil, fmt.Errorf("permission denied: user %s cannot run %s", user, statementType(stmt))
+	}
+	if err := e.checkUser(ctx); err != nil {
+		return nil, err
+	}
+	if err := e.checkPrivileges(ctx, stmt); err != nil {
+		return nil, err
+	}
+	return e.execute(ctx, stmt)
+}
+
+// execute runs a statement without the read-only check
+func (e *Executor) execute(ctx context.Context, stmt parser.Statement) (*Result, error) {
+	ctx, span := tracing.Start(ctx, "execute")
+	defer span.End()
+	span.SetAttribute("db.statement_type", statementType(stmt))
+
+	start := time.Now()
+	result, err := e.dispatch(ctx, stmt)
+	e.stats.recordStatement(statementType(stmt), time.Since(start), err)
+	if err == nil && invalidatesPlans(stmt) {
+		e.plans.invalidate()
+	}
+	// A failed write may still have changed some rows
+	// Functions are inlined into the queries whose results are cached
+	if _, ok := stmt.(*parser.RestoreStmt); ok || changesPartitions(stmt) || changesFunction(stmt) {
+		e.results.invalidateAll()
+	} else if name := targetTable(stmt); name != "" {
+		e.invalidateResults(name)
+	}
+	span.RecordError(err)
+	if result != nil {
+		span.SetAttribute("db.rows_affected", result.RowsAffected)
+	}
+	return result, err
+}
+
+// dispatch routes a statement to its handler
+func (e *Executor) dispatch(ctx context.Context, stmt parser.Statement) (*Result, error) {
 	switch s := stmt.(type) {
 	case *parser.CreateTableStmt:
-		return e.executeCreateTable(s)
+		return e.executeCreateTable(ctx, s)
 	case *parser.DropTableStmt:
-		return e.executeDropTable(s)
+		return e.executeDropTable(ctx, s)
+	case *parser.CreateMaterializedViewStmt:
+		return e.executeCreateMaterializedView(ctx, s)
+	case *parser.RefreshMaterializedViewStmt:
+		return e.executeRefreshMaterializedView(ctx, s)
 	case *parser.InsertStmt:
-		return e.executeInsert(s)
+		return e.executeInsert(ctx, s)
 	case *parser.SelectStmt:
-		return e.executeSelect(s)
+		return e.executeSelect(ctx, s)
+	case *parser.ExplainStmt:
+		return e.executeExplain(ctx, s)
+	case *parser.AdviseStmt:
+		return e.executeAdvise(ctx, s)
 	case *parser.UpdateStmt:
-		return e.executeUpdate(s)
+		return e.executeUpdate(ctx, s)
 	case *parser.DeleteStmt:
-		return e.executeDelete(s)
+		return e.executeDelete(ctx, s)
+	case *parser.MergeStmt:
+		return e.executeMerge(ctx, s)
+	case *parser.PurgeStmt:
+		return e.executePurge(ctx, s)
+	case *parser.BackupStmt:
+		return e.executeBackup(ctx, s)
+	case *parser.CheckpointStmt:
+		return e.executeCheckpoint(ctx)
+	case *parser.RestoreStmt:
+		return e.executeRestore(ctx, s)
+	case *parser.ShowStatsStmt:
+		return statsResult(e.Stats()), nil
+	case *parser.ShowIndexesStmt:
+		return e.executeShowIndexes(s)
+	case *parser.ShowTablesStmt:
+		return e.executeShowTables(), nil
+	case *parser.DescribeStmt:
+		return e.executeDescribe(s)
+	case *parser.SetGlobalStmt:
+		return e.executeSetGlobal(ctx, s)
+	case *parser.ShowSettingsStmt:
+		return e.executeShowSettings(ctx, s)
+	case *parser.SetStmt:
+		return e.executeSet(ctx, s)
+	case *parser.ShowVariableStmt:
+		return e.executeShowVariable(ctx, s)
+	case *parser.AlterTableStmt:
+		return e.executeAlterTable(ctx, s)
+	case *parser.CreatePolicyStmt:
+		return e.executeCreatePolicy(ctx, s)
+	case *parser.DropPolicyStmt:
+		return e.executeDropPolicy(ctx, s)
+	case *parser.GrantStmt:
+		return e.executeGrant(ctx, s)
+	case *parser.CreateUserStmt:
+		return e.executeCreateUser(ctx, s)
+	case *parser.DropUserStmt:
+		return e.executeDropUser(ctx, s)
+	case *parser.CreateSequenceStmt:
+		return e.executeCreateSequence(ctx, s)
+	case *parser.AlterSequenceStmt:
+		return e.executeAlterSequence(ctx, s)
+	case *parser.DropSequenceStmt:
+		return e.executeDropSequence(ctx, s)
+	case *parser.CreateFunctionStmt:
+		return e.executeCreateFunction(ctx, s)
+	case *parser.DropFunctionStmt:
+		return e.executeDropFunction(ctx, s)
+	case *parser.CallStmt:
+		return e.executeCall(ctx, s)
+	case *parser.CreateJobStmt:
+		return e.executeCreateJob(ctx, s)
+	case *parser.DropJobStmt:
+		return e.executeDropJob(ctx, s)
+	case *parser.CopyStmt:
+		if s.Path != "" {
+			return nil, fmt.Errorf("COPY FROM a file takes its own lock: run it with Query")
+		}
+		return nil, fmt.Errorf("COPY FROM STDIN needs its rows: use the REPL, POST /api/tables/%s/copy or Executor.Copy", s.TableName)
 	default:
 		return nil, fmt.Errorf("unsupported statement type")
 	}
 }
 
+// statementType returns a short name for a statement, used in traces
+func statementType(stmt parser.Statement) string {
+	switch s := stmt.(type) {
+	case *parser.CreateTableStmt:
+		return "CREATE TABLE"
+	case *parser.DropTableStmt:
+		if s.MaterializedView {
+			return "DROP MATERIALIZED VIEW"
+		}
+		return "DROP TABLE"
+	case *parser.CreateMaterializedViewStmt:
+		return "CREATE MATERIALIZED VIEW"
+	case *parser.RefreshMaterializedViewStmt:
+		return "REFRESH MATERIALIZED VIEW"
+	case *parser.InsertStmt:
+		return "INSERT"
+	case *parser.SelectStmt:
+		return "SELECT"
+	case *parser.ExplainStmt:
+		return "EXPLAIN"
+	case *parser.AdviseStmt:
+		return "EXPLAIN ADVISE"
+	case *parser.UpdateStmt:
+		return "UPDATE"
+	case *parser.DeleteStmt:
+		return "DELETE"
+	case *parser.MergeStmt:
+		return "MERGE"
+	case *parser.PurgeStmt:
+		return "PURGE"
+	case *parser.BackupStmt:
+		return "BACKUP"
+	case *parser.CheckpointStmt:
+		return "CHECKPOINT"
+	case *parser.RestoreStmt:
+		return "RESTORE"
+	case *parser.ShowStatsStmt:
+		return "SHOW STATS"
+	case *parser.ShowIndexesStmt:
+		return "SHOW INDEXES"
+	case *parser.ShowTablesStmt:
+		return "SHOW TABLES"
+	case *parser.DescribeStmt:
+		return "DESCRIBE"
+	case *parser.SetStmt:
+		return "SET"
+	case *parser.ShowVariableStmt:
+		return "SHOW"
+	case *parser.SetGlobalStmt:
+		return "SET GLOBAL"
+	case *parser.ShowSettingsStmt:
+		return "PRAGMA"
+	case *parser.AlterTableStmt:
+		return "ALTER TABLE"
+	case *parser.CreatePolicyStmt:
+		return "CREATE POLICY"
+	case *parser.DropPolicyStmt:
+		return "DROP POLICY"
+	case *parser.GrantStmt:
+		if s.Revoke {
+			return "REVOKE"
+		}
+		return "GRANT"
+	case *parser.CreateUserStmt:
+		return "CREATE USER"
+	case *parser.DropUserStmt:
+		return "DROP USER"
+	case *parser.CreateSequenceStmt:
+		return "CREATE SEQUENCE"
+	case *parser.AlterSequenceStmt:
+		return "ALTER SEQUENCE"
+	case *parser.DropSequenceStmt:
+		return "DROP SEQUENCE"
+	case *parser.CreateFunctionStmt:
+		return "CREATE FUNCTION"
+	case *parser.DropFunctionStmt:
+		return "DROP FUNCTION"
+	case *parser.CallStmt:
+		return "CALL"
+	case *parser.CreateJobStmt:
+		return "CREATE JOB"
+	case *parser.DropJobStmt:
+		return "DROP JOB"
+	case *parser.CopyStmt:
+		return "COPY"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// persist flushes all tables to disk
+func (e *Executor) persist(ctx context.Context) error {
+	// Batched flush policies leave table writes to the next checkpoint, and
+	// bulk loads to the end of the load
+	if e.flushPolicy != FlushEveryStatement || bulkLoading(ctx) {
+		return nil
+	}
+
+	_, span := tracing.Start(ctx, "persist")
+	defer span.End()
+
+	err := e.storage.SaveAllTables()
+	if err == nil {
+		e.stats.recordFlush()
+	}
+	span.RecordError(err)
+	return err
+}
+
 // executeCreateTable executes CREATE TABLE statement
-func (e *Executor) executeCreateTable(stmt *parser.CreateTableStmt) (*Result, error) {
+func (e *Executor) executeCreateTable(ctx context.Context, stmt *parser.CreateTableStmt) (*Result, error) {
+	if stmt.PartitionOf != "" {
+		return e.executeCreatePartition(ctx, stmt)
+	}
+
 	schema := storage.NewSchema(stmt.TableName)
 
 	for _, colDef := range stmt.Columns {
@@ -61,19 +557,103 @@ func (e *Executor) executeCreateTable(stmt *parser.CreateTableStmt) (*Result, er
 			col.DataType = storage.TypeBoolean
 		case "FLOAT":
 			col.DataType = storage.TypeFloat
+		case "CITEXT":
+			col.DataType = storage.TypeCIText
+		case "JSON":
+			col.DataType = storage.TypeJSON
+		case "TIMESTAMP":
+			col.DataType = storage.TypeTimestamp
+		case "DATE":
+			col.DataType = storage.TypeDate
+		case "TEXT":
+			col.DataType = storage.TypeText
+		case "BLOB":
+			col.DataType = storage.TypeBlob
+		case "UUID":
+			col.DataType = storage.TypeUUID
 		default:
 			return nil, fmt.Errorf("unsupported data type: %s", colDef.DataType)
 		}
 
+		isVarchar := col.DataType == storage.TypeVarchar || col.DataType == storage.TypeText
+		if colDef.Collation == "" && isVarchar {
+			col.Collation = e.defaultCollation
+		}
+		if colDef.Collation != "" {
+			if !isVarchar {
+				return nil, fmt.Errorf("COLLATE is only supported on VARCHAR and TEXT columns")
+			}
+			collation, err := storage.ParseCollation(colDef.Collation)
+			if err != nil {
+				return nil, err
+			}
+			if collation != storage.CollationBinary {
+				col.Collation = collation
+			}
+		}
+
+		if colDef.Dictionary {
+			if !isVarchar && col.DataType != storage.TypeCIText {
+				return nil, fmt.Errorf("ENCODING DICTIONARY is only supported on VARCHAR, TEXT and CITEXT columns")
+			}
+			col.Dictionary = true
+		}
+
+		if colDef.Generated != nil {
+			if err := generatedColumn(schema, colDef, &col); err != nil {
+				return nil, err
+			}
+		}
+		if err := e.columnDefaults(ctx, colDef, &col); err != nil {
+			return nil, err
+		}
+
 		schema.AddColumn(col)
 	}
 
+	for _, columns := range stmt.Unique {
+		if err := schema.AddUniqueConstraint(columns); err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.Server != "" {
+		foreign, err := foreignTable(stmt)
+		if err != nil {
+			return nil, err
+		}
+		schema.ForeignTable = foreign
+	}
+
+	if stmt.PartitionBy != "" {
+		if err := partitionKey(schema, stmt.PartitionMethod, stmt.PartitionBy); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.addForeignKeys(schema, stmt.ForeignKeys); err != nil {
+		return nil, err
+	}
+
+	if stmt.Layout != "" {
+		layout, err := storage.ParseLayout(stmt.Layout)
+		if err != nil {
+			return nil, err
+		}
+		if layout == storage.LayoutColumnar {
+			if schema.Partitioned() {
+				return nil, fmt.Errorf("USING COLUMNAR is not supported on partitioned tables")
+			}
+			schema.Layout = layout
+		}
+	}
+
 	if err := e.storage.CreateTable(schema); err != nil {
 		return nil, err
 	}
 
 	// Save to disk
-	if err := e.storage.SaveAllTables(); err != nil {
+	if err := e.persist(ctx); err != nil {
 		return nil, fmt.Errorf("failed to persist table: %w", err)
 	}
 
@@ -84,11 +664,45 @@ func (e *Executor) executeCreateTable(stmt *parser.CreateTableStmt) (*Result, er
 }
 
 // executeDropTable executes DROP TABLE statement
-func (e *Executor) executeDropTable(stmt *parser.DropTableStmt) (*Result, error) {
+func (e *Executor) executeDropTable(ctx context.Context, stmt *parser.DropTableStmt) (*Result, error) {
+	table, err := e.storage.GetTable(stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.MaterializedView && !table.Schema.Materialized() {
+		return nil, fmt.Errorf("%s is not a materialized view", stmt.TableName)
+	}
+	if !stmt.MaterializedView && table.Schema.Materialized() {
+		return nil, fmt.Errorf("%s is a materialized view; use DROP MATERIALIZED VIEW", stmt.TableName)
+	}
+	if view := e.dependentView(stmt.TableName); view != "" {
+		return nil, fmt.Errorf("cannot drop table %s: materialized view %s depends on it", stmt.TableName, view)
+	}
+	// Partitions are dropped with their partitioned table
+	partitions := table.Schema.Partitions
+
+	refs, err := e.referencesTo(table)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		if ref.key.RefTable == stmt.TableName && ref.child.Schema.TableName != stmt.TableName {
+			return nil, fmt.Errorf("cannot drop table %s: foreign key %s of table %s references it", stmt.TableName, ref.key.Name, ref.child.Schema.TableName)
+		}
+	}
+
 	if err := e.storage.DropTable(stmt.TableName); err != nil {
 		return nil, err
 	}
+	e.stats.forgetTable(stmt.TableName)
+	e.advisor.forget(stmt.TableName)
+	for _, bound := range partitions {
+		e.stats.forgetTable(bound.Table)
+	}
 
+	if stmt.MaterializedView {
+		return &Result{Message: fmt.Sprintf("Materialized view '%s' dropped successfully", stmt.TableName)}, nil
+	}
 	return &Result{
 		Message:      fmt.Sprintf("Table '%s' dropped successfully", stmt.TableName),
 		RowsAffected: 0,
@@ -96,19 +710,19 @@ func (e *Executor) executeDropTable(stmt *parser.DropTableStmt) (*Result, error)
 }
 
 // executeInsert executes INSERT statement
-func (e *Executor) executeInsert(stmt *parser.InsertStmt) (*Result, error) {
+func (e *Executor) executeInsert(ctx context.Context, stmt *parser.InsertStmt) (*Result, error) {
 	table, err := e.storage.GetTable(stmt.TableName)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkWritable(ctx, table); err != nil {
+		return nil, err
+	}
 
 	// Determine column order
 	columns := stmt.Columns
 	if len(columns) == 0 {
-		// Use all columns in schema order
-		for _, col := range table.Schema.Columns {
-			columns = append(columns, col.Name)
-		}
+		columns = valueColumns(table.Schema)
 	}
 
 	// Validate columns exist
@@ -118,75 +732,208 @@ func (e *Executor) executeInsert(stmt *parser.InsertStmt) (*Result, error) {
 		if idx == -1 {
 			return nil, fmt.Errorf("column %s does not exist in table %s", colName, stmt.TableName)
 		}
+		if table.Schema.Columns[idx].Generated != "" {
+			return nil, fmt.Errorf("cannot insert into generated column %s", colName)
+		}
 		columnIndices[i] = idx
 	}
+	returned, err := e.newReturning(ctx, table.Schema, stmt.Returning)
+	if err != nil {
+		return nil, err
+	}
 
-	rowsInserted := 0
-	for _, valueSet := range stmt.Values {
-		if len(valueSet) != len(columns) {
-			return nil, fmt.Errorf("column count mismatch: expected %d, got %d", len(columns), len(valueSet))
+	policies, err := e.policies(ctx, table.Schema)
+	if err != nil {
+		return nil, err
+	}
+	valueSets, err := e.insertValues(ctx, stmt, table.Schema, columnIndices)
+	if err != nil {
+		return nil, err
+	}
+
+	// Columns left out get their defaults
+	defaults := make(map[int]interface{})
+	for i, col := range table.Schema.Columns {
+		if col.Generated != "" || slices.Contains(columnIndices, i) {
+			continue
+		}
+		if defaults[i], err = e.columnDefault(ctx, col); err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.Name, err)
 		}
+	}
 
-		// Create a row with NULL values
+	rows := make([]*storage.Row, 0, len(valueSets))
+	for _, valueSet := range valueSets {
 		row := storage.NewRow(make([]interface{}, len(table.Schema.Columns)))
-		for i := range row.Values {
-			row.Values[i] = nil
+		for i, value := range defaults {
+			row.Values[i] = value
 		}
 
 		// Fill in provided values
-		for i, expr := range valueSet {
-			value, err := e.evaluateExpression(expr, nil)
-			if err != nil {
+		for i, value := range valueSet {
+			var err error
+			if value, err = storage.NormalizeValue(value, table.Schema.Columns[columnIndices[i]]); err != nil {
 				return nil, err
 			}
 			row.Values[columnIndices[i]] = value
 		}
+		if err := e.computeGenerated(ctx, table.Schema, row.Values, false); err != nil {
+			return nil, err
+		}
+		if err := e.checkPolicies(ctx, table.Schema, policies, row.Values); err != nil {
+			return nil, err
+		}
 
-		if err := table.InsertRow(row); err != nil {
+		rows = append(rows, row)
+	}
+
+	// Insert the batch at once so constraints are checked in one pass and a
+	// failing row leaves the table unchanged
+	inserted, updated := rows, []*storage.Row(nil)
+	if stmt.OnConflict != nil {
+		if inserted, updated, err = e.upsertRows(ctx, table, stmt, rows, policies); err != nil {
 			return nil, err
 		}
-		rowsInserted++
+	} else {
+		if err := e.insertRows(table, rows); err != nil {
+			return nil, err
+		}
+		if cs := changeSetFrom(ctx); cs != nil {
+			for _, row := range rows {
+				cs.add(table.Schema, cdc.OpInsert, nil, row.Values)
+			}
+		}
 	}
+	rowsInserted, rowsUpdated := len(inserted), len(updated)
+	e.stats.recordTable(stmt.TableName, func(t *TableStats) {
+		t.Statements++
+		t.RowsInserted += int64(rowsInserted)
+		t.RowsUpdated += int64(rowsUpdated)
+	})
 
 	// Save to disk
-	if err := e.storage.SaveAllTables(); err != nil {
+	if err := e.persist(ctx); err != nil {
 		return nil, fmt.Errorf("failed to persist data: %w", err)
 	}
 
-	return &Result{
-		Message:      fmt.Sprintf("%d row(s) inserted", rowsInserted),
-		RowsAffected: rowsInserted,
-	}, nil
+	if returned != nil {
+		for _, row := range slices.Concat(inserted, updated) {
+			if err := returned.add(ctx, e, row.Values); err != nil {
+				return nil, err
+			}
+		}
+	}
+	message := fmt.Sprintf("%d row(s) inserted", rowsInserted)
+	if stmt.OnConflict != nil && stmt.OnConflict.Set != nil {
+		message += fmt.Sprintf(", %d updated", rowsUpdated)
+	}
+	return returned.result(message, rowsInserted+rowsUpdated), nil
+}
+
+// insertValues returns the values of the rows an INSERT adds, for the
+// columns of schema at columnIndices in order: its VALUES rows evaluated,
+// with DEFAULT the column's default, or the rows of its SELECT, which runs
+// with the user's grants and policies like a subquery
+func (e *Executor) insertValues(ctx context.Context, stmt *parser.InsertStmt, schema *storage.Schema, columnIndices []int) ([][]interface{}, error) {
+	columns := len(columnIndices)
+	if stmt.Select != nil {
+		inner := withoutRowWriter(context.WithValue(ctx, planActualsKey{}, nil))
+		result, err := e.executeSelect(inner, stmt.Select)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Columns) != columns {
+			return nil, fmt.Errorf("column count mismatch: INSERT has %d column(s), SELECT returns %d", columns, len(result.Columns))
+		}
+		return result.Rows, nil
+	}
+
+	var exprs []parser.Expression
+	for _, valueSet := range stmt.Values {
+		exprs = append(exprs, valueSet...)
+	}
+	ctx, err := e.withSubqueries(ctx, exprs...)
+	if err != nil {
+		return nil, err
+	}
+
+	valueSets := make([][]interface{}, len(stmt.Values))
+	for i, valueSet := range stmt.Values {
+		if len(valueSet) != columns {
+			return nil, fmt.Errorf("column count mismatch: expected %d, got %d", columns, len(valueSet))
+		}
+		valueSets[i] = make([]interface{}, len(valueSet))
+		for j, expr := range valueSet {
+			if _, ok := expr.(*parser.DefaultValue); ok {
+				valueSets[i][j], err = e.columnDefault(ctx, schema.Columns[columnIndices[j]])
+			} else {
+				valueSets[i][j], err = e.evaluateExpression(ctx, expr, nil)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return valueSets, nil
 }
 
 // executeSelect executes SELECT statement
-func (e *Executor) executeSelect(stmt *parser.SelectStmt) (*Result, error) {
+func (e *Executor) executeSelect(ctx context.Context, stmt *parser.SelectStmt) (*Result, error) {
+	_, planSpan := tracing.Start(ctx, "plan")
+	defer planSpan.End()
+
 	table, err := e.storage.GetTable(stmt.TableName)
 	if err != nil {
+		planSpan.RecordError(err)
+		return nil, err
+	}
+	if ctx, err = e.withSubqueries(ctx, selectConditions(stmt)...); err != nil {
+		planSpan.RecordError(err)
 		return nil, err
 	}
 
-	// Get all rows from the main table
-	rows := table.SelectRows()
+	if len(stmt.Joins) == 0 {
+		if stmt, err = unqualifySelect(stmt); err != nil {
+			planSpan.RecordError(err)
+			return nil, err
+		}
+	}
 
 	// Handle JOINs
 	if len(stmt.Joins) > 0 {
-		return e.executeSelectWithJoin(stmt, table, rows)
+		if aggregated(stmt) {
+			err := fmt.Errorf("aggregate functions, GROUP BY and HAVING are not supported with JOIN")
+			planSpan.RecordError(err)
+			return nil, err
+		}
+		planSpan.End()
+		start := time.Now()
+		leftRows, err := e.scan(ctx, table, stmt)
+		if err == nil {
+			leftRows, err = e.withVirtualRows(ctx, table.Schema, leftRows)
+		}
+		if err == nil {
+			leftRows, err = e.visibleRows(ctx, table.Schema, leftRows)
+		}
+		if err != nil {
+			return nil, err
+		}
+		recordActuals(ctx, "scan 0", len(leftRows), start)
+		return e.executeSelectWithJoin(ctx, stmt, table, leftRows, start)
 	}
 
-	// Filter by WHERE clause (no joins)
-	if stmt.Where != nil {
-		filteredRows := []*storage.Row{}
-		for _, row := range rows {
-			match, err := e.evaluateCondition(stmt.Where, row, table.Schema)
-			if err != nil {
-				return nil, err
-			}
-			if match {
-				filteredRows = append(filteredRows, row)
-			}
-		}
-		rows = filteredRows
+	access, err := e.accessTo(ctx, table.Schema)
+	if err == nil {
+		err = access.checkReferences(stmt.Where, table.Schema.GetColumnIndex)
+	}
+	if err != nil {
+		planSpan.RecordError(err)
+		return nil, err
+	}
+
+	if aggregated(stmt) {
+		planSpan.End()
+		return e.executeAggregate(ctx, stmt, table, access)
 	}
 
 	// Determine columns to return
@@ -194,96 +941,406 @@ func (e *Executor) executeSelect(stmt *parser.SelectStmt) (*Result, error) {
 	var columnNames []string
 
 	if len(stmt.Columns) == 1 && stmt.Columns[0] == "*" {
-		// Select all columns
+		// Select all columns the user may read
 		for i, col := range table.Schema.Columns {
-			columnIndices = append(columnIndices, i)
-			columnNames = append(columnNames, col.Name)
+			if access.readable(i) {
+				columnIndices = append(columnIndices, i)
+				columnNames = append(columnNames, col.Name)
+			}
 		}
 	} else {
 		// Select specific columns
-		for _, colName := range stmt.Columns {
+		for i, colName := range stmt.Columns {
 			idx := table.Schema.GetColumnIndex(colName)
 			if idx == -1 {
-				return nil, fmt.Errorf("column %s does not exist", colName)
+				err := fmt.Errorf("column %s does not exist", colName)
+				planSpan.RecordError(err)
+				return nil, err
+			}
+			if err := access.check(idx); err != nil {
+				planSpan.RecordError(err)
+				return nil, err
 			}
 			columnIndices = append(columnIndices, idx)
-			columnNames = append(columnNames, colName)
+			columnNames = append(columnNames, columnLabel(stmt, i))
+		}
+	}
+
+	orderIdxs := make([]int, len(stmt.OrderBy))
+	collations := make([]string, len(stmt.OrderBy))
+	for i, key := range stmt.OrderBy {
+		orderIdxs[i] = table.Schema.GetColumnIndex(key.Column)
+		if orderIdxs[i] == -1 {
+			err := fmt.Errorf("column %s does not exist", key.Column)
+			planSpan.RecordError(err)
+			return nil, err
+		}
+		if err := access.check(orderIdxs[i]); err != nil {
+			planSpan.RecordError(err)
+			return nil, err
+		}
+		collations[i] = table.Schema.Columns[orderIdxs[i]].CompareCollation()
+	}
+
+	planSpan.End()
+
+	start := time.Now()
+	rows, scanned, err := e.selectRows(ctx, table, stmt)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.OrderBy != nil {
+		// Sort by what the user would be shown, so the order does not give
+		// away masked values
+		key := func(row *storage.Row, i int) interface{} {
+			return access.mask(orderIdxs[i], row.Values[orderIdxs[i]])
 		}
+		if rows, err = sortRows(ctx, rows, key, stmt.OrderBy, collations); err != nil {
+			return nil, err
+		}
+		recordActuals(ctx, "sort", len(rows), start)
 	}
 
 	// Build result rows
-	resultRows := [][]interface{}{}
-	for _, row := range rows {
+	builder, err := e.newResultBuilder(ctx, columnNames)
+	if err != nil {
+		return nil, err
+	}
+	for i, row := range rows {
+		if err := checkCancelled(ctx, i); err != nil {
+			return nil, err
+		}
 		resultRow := []interface{}{}
 		for _, idx := range columnIndices {
-			resultRow = append(resultRow, row.Values[idx])
+			resultRow = append(resultRow, access.mask(idx, row.Values[idx]))
+		}
+		if err := builder.add(resultRow); err != nil {
+			return nil, err
+		}
+	}
+	result := builder.finish()
+
+	e.stats.recordTable(stmt.TableName, func(t *TableStats) {
+		t.Statements++
+		t.RowsScanned += int64(scanned)
+		t.RowsReturned += int64(result.RowsAffected)
+	})
+	e.advisor.record(stmt.TableName, stmt.Where, scanned, result.RowsAffected)
+
+	return result, nil
+}
+
+// columnLabel returns the result column name of a select-list entry: its
+// AS alias, or the entry as written
+func columnLabel(stmt *parser.SelectStmt, i int) string {
+	if stmt.Aliases != nil && stmt.Aliases[i] != "" {
+		return stmt.Aliases[i]
+	}
+	return stmt.Columns[i]
+}
+
+// unqualifySelect returns a single-table SELECT with the columns qualified
+// with its table's name or alias, such as u.id, written unqualified, so
+// they can be looked up in the table's schema. The statement itself may be
+// cached and is not modified.
+func unqualifySelect(stmt *parser.SelectStmt) (*parser.SelectStmt, error) {
+	table := qualifier(stmt.TableName, stmt.TableAlias)
+	unqualify := func(name string) (string, error) {
+		prefix, column, ok := strings.Cut(name, ".")
+		if !ok {
+			return name, nil
+		}
+		if prefix != table {
+			return "", fmt.Errorf("unknown table: %s", prefix)
+		}
+		return column, nil
+	}
+	var err error
+	unqualifyExpr := func(expr parser.Expression) parser.Expression {
+		return parser.MapExpression(expr, func(expr parser.Expression) parser.Expression {
+			ident, ok := expr.(*parser.Identifier)
+			if !ok || err != nil {
+				return expr
+			}
+			var name string
+			if name, err = unqualify(ident.Value); err != nil || name == ident.Value {
+				return expr
+			}
+			return &parser.Identifier{Value: name}
+		})
+	}
+
+	s := *stmt
+	s.Columns = append([]string{}, stmt.Columns...)
+	for i, name := range s.Columns {
+		if stmt.Aggregates == nil || stmt.Aggregates[i] == nil {
+			if s.Columns[i], err = unqualify(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if stmt.Aggregates != nil {
+		s.Aggregates = make([]*parser.AggregateCall, len(stmt.Aggregates))
+		for i, call := range stmt.Aggregates {
+			if call == nil {
+				continue
+			}
+			unqualified := *call
+			if unqualified.Column, err = unqualify(call.Column); err != nil {
+				return nil, err
+			}
+			s.Aggregates[i] = &unqualified
+		}
+	}
+	if stmt.GroupBy != nil {
+		s.GroupBy = make([]string, len(stmt.GroupBy))
+		for i, name := range stmt.GroupBy {
+			if s.GroupBy[i], err = unqualify(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if stmt.OrderBy != nil {
+		s.OrderBy = slices.Clone(stmt.OrderBy)
+		for i := range s.OrderBy {
+			if s.OrderBy[i].Column, err = unqualify(s.OrderBy[i].Column); err != nil {
+				return nil, err
+			}
+		}
+	}
+	s.Where = unqualifyExpr(stmt.Where)
+	s.Having = unqualifyExpr(stmt.Having)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// selectRows returns the rows of a SELECT's table (with no joins) that
+// match its WHERE clause and the user may see, and how many rows were
+// scanned
+func (e *Executor) selectRows(ctx context.Context, table *storage.Table, stmt *parser.SelectStmt) ([]*storage.Row, int, error) {
+	start := time.Now()
+	var rows []*storage.Row
+	var scanned int
+	var err error
+	if table.Schema.Partitioned() && stmt.Sample == nil {
+		rows, scanned, err = e.scanPartitions(ctx, table, stmt.Where)
+	} else if columnarScan(table.Schema, stmt.Sample, stmt.WithDeleted) {
+		rows, scanned, err = e.scanColumnar(ctx, table, stmt.Where)
+	} else if found, ok := e.indexScan(table, stmt); ok {
+		rows, err = e.withVirtualRows(ctx, table.Schema, found)
+		scanned = len(rows)
+		if err == nil {
+			rows, err = e.filterRows(ctx, rows, stmt.Where, table.Schema)
+		}
+	} else {
+		rows, err = e.scan(ctx, table, stmt)
+		if err == nil {
+			rows, err = e.withVirtualRows(ctx, table.Schema, rows)
+		}
+		scanned = len(rows)
+		if err == nil {
+			rows, err = e.filterRows(ctx, rows, stmt.Where, table.Schema)
+		}
+	}
+	if err == nil {
+		rows, err = e.visibleRows(ctx, table.Schema, rows)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	recordActuals(ctx, stmt.TableName, len(rows), start)
+	return rows, scanned, nil
+}
+
+// filterRows returns the rows matching a WHERE clause
+func (e *Executor) filterRows(ctx context.Context, rows []*storage.Row, where parser.Expression, schema *storage.Schema) ([]*storage.Row, error) {
+	if where == nil {
+		return rows, nil
+	}
+	filteredRows := []*storage.Row{}
+	for i, row := range rows {
+		if err := checkCancelled(ctx, i); err != nil {
+			return nil, err
+		}
+		match, err := e.evaluateCondition(ctx, where, row, schema)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			filteredRows = append(filteredRows, row)
+		}
+	}
+	return filteredRows, nil
+}
+
+// scan returns the rows of the FROM table, sampled when the query has a
+// TABLESAMPLE clause. Partitions the WHERE clause rules out are skipped.
+func (e *Executor) scan(ctx context.Context, table *storage.Table, stmt *parser.SelectStmt) ([]*storage.Row, error) {
+	where := stmt.Where
+	if len(stmt.Joins) > 0 {
+		where = nil // the WHERE clause may name joined tables' columns
+	}
+	rows, err := e.tableRows(ctx, table, where, stmt.WithDeleted)
+	if err != nil {
+		return nil, err
+	}
+	if stmt.Sample != nil {
+		rows = sampleRows(rows, stmt.Sample)
+	}
+	return rows, nil
+}
+
+// executeSelectWithJoin executes SELECT with JOIN. The tables are joined
+// in order, each join extending the rows built so far with the rows of its
+// table that match its condition. start is when the first table's scan
+// began.
+func (e *Executor) executeSelectWithJoin(ctx context.Context, stmt *parser.SelectStmt, leftTable *storage.Table, leftRows []*storage.Row, start time.Time) (*Result, error) {
+	joined := &joinSchema{}
+	if err := joined.add(qualifier(stmt.TableName, stmt.TableAlias), leftTable.Schema); err != nil {
+		return nil, err
+	}
+	leftAccess, err := e.accessTo(ctx, leftTable.Schema)
+	if err != nil {
+		return nil, err
+	}
+	accesses := []*columnAccess{leftAccess}
+	tables := make([]*storage.Table, len(stmt.Joins))
+	for i, join := range stmt.Joins {
+		if tables[i], err = e.storage.GetTable(join.TableName); err != nil {
+			return nil, err
+		}
+		tableAccess, err := e.accessTo(ctx, tables[i].Schema)
+		if err != nil {
+			return nil, err
+		}
+		if err := joined.add(qualifier(join.TableName, join.Alias), tables[i].Schema); err != nil {
+			return nil, err
+		}
+		accesses = append(accesses, tableAccess)
+	}
+
+	access := joinAccess(joined, accesses)
+	for i, join := range stmt.Joins {
+		// A join condition sees the tables joined so far
+		if err := access.checkReferences(join.On, joined.prefix(i+2).index); err != nil {
+			return nil, err
+		}
+	}
+	if err := access.checkReferences(stmt.Where, joined.index); err != nil {
+		return nil, err
+	}
+
+	orderIdxs := make([]int, len(stmt.OrderBy))
+	collations := make([]string, len(stmt.OrderBy))
+	for i, key := range stmt.OrderBy {
+		if orderIdxs[i] = joined.index(key.Column); orderIdxs[i] == -1 {
+			return nil, fmt.Errorf("column %s not found", key.Column)
+		}
+		if err := access.check(orderIdxs[i]); err != nil {
+			return nil, err
 		}
-		resultRows = append(resultRows, resultRow)
+		collations[i] = joined.column(orderIdxs[i]).CompareCollation()
 	}
 
-	return &Result{
-		Columns:      columnNames,
-		Rows:         resultRows,			RowsAffected: len(resultRows),
-	}, nil
-}
-
-// executeSelectWithJoin executes SELECT with JOIN
-func (e *Executor) executeSelectWithJoin(stmt *parser.SelectStmt, leftTable *storage.Table, leftRows []*storage.Row) (*Result, error) {
-	// For now, we only support INNER JOIN with one join table
-	if len(stmt.Joins) > 1 {
-		return nil, fmt.Errorf("multiple joins not yet supported")
+	// Perform nested loop joins
+	guard := e.newResultGuard()
+	if rowWriterFrom(ctx) != nil {
+		guard = &resultGuard{}
 	}
-
-	join := stmt.Joins[0]
-	rightTable, err := e.storage.GetTable(join.TableName)
-	if err != nil {
-		return nil, err
+	joinedRows := make([][]interface{}, len(leftRows))
+	for i, row := range leftRows {
+		joinedRows[i] = row.Values
 	}
+	// Count the pairs compared rather than the outer rows, so a join with a
+	// large inner table still notices cancellation promptly
+	compared := 0
+	for i, join := range stmt.Joins {
+		table := tables[i]
+		scanStart := time.Now()
+		rightRows, err := e.tableRows(ctx, table, nil, stmt.WithDeleted)
+		if err == nil {
+			rightRows, err = e.withVirtualRows(ctx, table.Schema, rightRows)
+		}
+		if err == nil {
+			rightRows, err = e.visibleRows(ctx, table.Schema, rightRows)
+		}
+		if err != nil {
+			return nil, err
+		}
+		recordActuals(ctx, fmt.Sprintf("scan %d", i+1), len(rightRows), scanStart)
+		e.stats.recordTable(join.TableName, func(t *TableStats) {
+			t.Statements++
+			t.RowsScanned += int64(len(joinedRows) * len(rightRows))
+		})
+
+		schema := joined.prefix(i + 2)
+		width := schema.sources[i+1].offset
+		where := stmt.Where
+		if i < len(stmt.Joins)-1 {
+			where = nil // the WHERE clause applies once every table is joined
+		}
+		next := [][]interface{}{}
+		// add keeps a row that satisfies the join condition if it passes
+		// the WHERE clause
+		add := func(combinedRow *CombinedRow) error {
+			if where != nil {
+				match, err := e.evaluateJoinCondition(ctx, where, combinedRow)
+				if err != nil || !match {
+					return err
+				}
+			}
+			next = append(next, combinedRow.values)
+			return guard.checkRows(len(next))
+		}
 
-	rightRows := rightTable.SelectRows()
+		for _, row := range joinedRows {
+			matched := false
+			for _, rightRow := range rightRows {
+				if err := checkCancelled(ctx, compared); err != nil {
+					return nil, err
+				}
+				compared++
 
-	// Perform nested loop join
-	joinedRows := [][]interface{}{}
+				// Create a combined row; the capacity limit makes append copy
+				combinedRow := &CombinedRow{values: append(row[:width:width], rightRow.Values...), schema: schema}
 
-	for _, leftRow := range leftRows {
-		for _, rightRow := range rightRows {
-			// Create a combined row
-			combinedRow := &CombinedRow{
-				leftRow:    leftRow,
-				rightRow:   rightRow,
-				leftSchema: leftTable.Schema,
-				rightSchema: rightTable.Schema,
-				leftTableName: stmt.TableName,
-				rightTableName: join.TableName,
-			}
+				// Evaluate join condition
+				if join.On != nil {
+					match, err := e.evaluateJoinCondition(ctx, join.On, combinedRow)
+					if err != nil {
+						return nil, err
+					}
+					if !match {
+						continue
+					}
+				}
+				matched = true
 
-			// Evaluate join condition
-			if join.On != nil {
-				match, err := e.evaluateJoinCondition(join.On, combinedRow)
-				if err != nil {
+				if err := add(combinedRow); err != nil {
 					return nil, err
 				}
-				if !match {
-					continue
-				}
 			}
 
-			// Apply WHERE clause if present
-			if stmt.Where != nil {
-				match, err := e.evaluateJoinCondition(stmt.Where, combinedRow)
-				if err != nil {
+			// A LEFT JOIN pairs a row matching no row of its table with NULLs
+			if !matched && join.JoinType == "LEFT" {
+				nulls := make([]interface{}, len(table.Schema.Columns))
+				if err := add(&CombinedRow{values: append(row[:width:width], nulls...), schema: schema}); err != nil {
 					return nil, err
 				}
-				if !match {
-					continue
-				}
 			}
+		}
+		joinedRows = next
+		recordActuals(ctx, fmt.Sprintf("join %d", i+1), len(joinedRows), start)
+	}
 
-			// Combine rows
-			combined := append([]interface{}{}, leftRow.Values...)
-			combined = append(combined, rightRow.Values...)
-			joinedRows = append(joinedRows, combined)
+	if stmt.OrderBy != nil {
+		key := func(row []interface{}, i int) interface{} { return access.mask(orderIdxs[i], row[orderIdxs[i]]) }
+		if joinedRows, err = sortRows(ctx, joinedRows, key, stmt.OrderBy, collations); err != nil {
+			return nil, err
 		}
+		recordActuals(ctx, "sort", len(joinedRows), start)
 	}
 
 	// Determine columns to return
@@ -291,270 +1348,546 @@ func (e *Executor) executeSelectWithJoin(stmt *parser.SelectStmt, leftTable *sto
 	var columnNames []string
 
 	if len(stmt.Columns) == 1 && stmt.Columns[0] == "*" {
-		// Select all columns from both tables
-		for i, col := range leftTable.Schema.Columns {
-			columnIndices = append(columnIndices, i)
-			columnNames = append(columnNames, stmt.TableName+"."+col.Name)
-		}
-		for i, col := range rightTable.Schema.Columns {
-			columnIndices = append(columnIndices, len(leftTable.Schema.Columns)+i)
-			columnNames = append(columnNames, join.TableName+"."+col.Name)
+		// Select all columns the user may read from every table
+		for _, source := range joined.sources {
+			for i, col := range source.schema.Columns {
+				if idx := source.offset + i; access.readable(idx) {
+					columnIndices = append(columnIndices, idx)
+					columnNames = append(columnNames, source.name+"."+col.Name)
+				}
+			}
 		}
 	} else {
 		// Select specific columns (support table.column notation)
-		for _, colSpec := range stmt.Columns {
-			parts := strings.Split(colSpec, ".")
-			if len(parts) == 2 {
-				// table.column format
-				tableName := parts[0]
-				colName := parts[1]
-				if tableName == stmt.TableName {
-					idx := leftTable.Schema.GetColumnIndex(colName)
-					if idx == -1 {
-						return nil, fmt.Errorf("column %s not found in table %s", colName, tableName)
-					}
-					columnIndices = append(columnIndices, idx)
-					columnNames = append(columnNames, colSpec)
-				} else if tableName == join.TableName {
-					idx := rightTable.Schema.GetColumnIndex(colName)
-					if idx == -1 {
-						return nil, fmt.Errorf("column %s not found in table %s", colName, tableName)
-					}
-					columnIndices = append(columnIndices, len(leftTable.Schema.Columns)+idx)
-					columnNames = append(columnNames, colSpec)
-				} else {
-					return nil, fmt.Errorf("unknown table: %s", tableName)
-				}
-			} else {
-				// Try to find in left table first, then right
-				idx := leftTable.Schema.GetColumnIndex(colSpec)
-				if idx != -1 {
-					columnIndices = append(columnIndices, idx)
-					columnNames = append(columnNames, colSpec)
-				} else {
-					idx = rightTable.Schema.GetColumnIndex(colSpec)
-					if idx != -1 {
-						columnIndices = append(columnIndices, len(leftTable.Schema.Columns)+idx)
-						columnNames = append(columnNames, colSpec)
-					} else {
-						return nil, fmt.Errorf("column %s not found", colSpec)
-					}
-				}
+		for i, colSpec := range stmt.Columns {
+			idx, err := joined.resolve(colSpec)
+			if err != nil {
+				return nil, err
+			}
+			if err := access.check(idx); err != nil {
+				return nil, err
 			}
+			columnIndices = append(columnIndices, idx)
+			columnNames = append(columnNames, columnLabel(stmt, i))
 		}
 	}
 
 	// Build result rows
-	resultRows := [][]interface{}{}
+	builder, err := e.newResultBuilder(ctx, columnNames)
+	if err != nil {
+		return nil, err
+	}
 	for _, row := range joinedRows {
 		resultRow := []interface{}{}
 		for _, idx := range columnIndices {
-			resultRow = append(resultRow, row[idx])
+			resultRow = append(resultRow, access.mask(idx, row[idx]))
+		}
+		if err := builder.add(resultRow); err != nil {
+			return nil, err
 		}
-		resultRows = append(resultRows, resultRow)
 	}
+	result := builder.finish()
 
-	return &Result{
-		Columns:      columnNames,
-		Rows:         resultRows,
-		RowsAffected: len(resultRows),
-	}, nil
+	// Rows returned are attributed to the FROM table
+	e.stats.recordTable(stmt.TableName, func(t *TableStats) {
+		t.Statements++
+		t.RowsScanned += int64(len(leftRows))
+		t.RowsReturned += int64(result.RowsAffected)
+	})
+
+	return result, nil
 }
 
-// CombinedRow represents a row from a JOIN operation
+// CombinedRow represents a row from a JOIN operation: the values of the
+// joined tables' rows, one after another
 type CombinedRow struct {
-	leftRow        *storage.Row
-	rightRow       *storage.Row
-	leftSchema     *storage.Schema
-	rightSchema    *storage.Schema
-	leftTableName  string
-	rightTableName string
+	values []interface{}
+	schema *joinSchema
 }
 
-// evaluateJoinCondition evaluates a condition for a joined row
-func (e *Executor) evaluateJoinCondition(expr parser.Expression, row *CombinedRow) (bool, error) {
-	switch ex := expr.(type) {
-	case *parser.BinaryExpr:
-		left, err := e.getJoinColumnValue(ex.Left, row)
-		if err != nil {
-			return false, err
+// joinSource is a table of a join, whose columns start at offset in the
+// joined rows
+type joinSource struct {
+	name   string // the name its columns are qualified with
+	schema *storage.Schema
+	offset int
+}
+
+// qualifier returns the name a table's columns are qualified with in a
+// query: its alias, or its name if it has none
+func qualifier(table, alias string) string {
+	if alias != "" {
+		return alias
+	}
+	return table
+}
+
+// joinSchema describes the rows of a join, in which each table's columns
+// follow those of the tables before it
+type joinSchema struct {
+	sources []joinSource
+	width   int
+}
+
+// add appends a table's columns. Each table must be qualified with a name
+// of its own, so a table joined with itself needs an alias for at least
+// one side.
+func (s *joinSchema) add(name string, schema *storage.Schema) error {
+	for _, source := range s.sources {
+		if source.name == name {
+			return fmt.Errorf("table name %s specified more than once; give one of them an alias", name)
 		}
+	}
+	s.sources = append(s.sources, joinSource{name: name, schema: schema, offset: s.width})
+	s.width += len(schema.Columns)
+	return nil
+}
 
-		right, err := e.getJoinColumnValue(ex.Right, row)
-		if err != nil {
-			return false, err
+// prefix returns the schema of the rows of the first n tables
+func (s *joinSchema) prefix(n int) *joinSchema {
+	prefix := &joinSchema{sources: s.sources[:n:n]}
+	if n < len(s.sources) {
+		prefix.width = s.sources[n].offset
+	} else {
+		prefix.width = s.width
+	}
+	return prefix
+}
+
+// resolve returns the position of a column in the joined rows. The column
+// may be qualified with its table's name; an unqualified name is looked up
+// in the tables in join order.
+func (s *joinSchema) resolve(name string) (int, error) {
+	if table, column, ok := strings.Cut(name, "."); ok {
+		for _, source := range s.sources {
+			if source.name != table {
+				continue
+			}
+			if idx := source.schema.GetColumnIndex(column); idx != -1 {
+				return source.offset + idx, nil
+			}
+			return -1, fmt.Errorf("column %s not found in table %s", column, table)
 		}
+		return -1, fmt.Errorf("unknown table: %s", table)
+	}
+	for _, source := range s.sources {
+		if idx := source.schema.GetColumnIndex(name); idx != -1 {
+			return source.offset + idx, nil
+		}
+	}
+	return -1, fmt.Errorf("column %s not found", name)
+}
 
-		return e.compareValues(left, right, ex.Operator)
-	default:
-		return false, fmt.Errorf("unsupported join condition type")
+// index is resolve returning -1 for a column that is not found
+func (s *joinSchema) index(name string) int {
+	idx, _ := s.resolve(name)
+	return idx
+}
+
+// column returns the definition of the column at a position
+func (s *joinSchema) column(idx int) storage.Column {
+	for i := len(s.sources) - 1; i >= 0; i-- {
+		if source := s.sources[i]; idx >= source.offset {
+			return source.schema.Columns[idx-source.offset]
+		}
 	}
+	return storage.Column{}
+}
+
+// evaluateJoinCondition evaluates a condition for a joined row
+func (e *Executor) evaluateJoinCondition(ctx context.Context, expr parser.Expression, row *CombinedRow) (bool, error) {
+	t, err := e.evaluateTruth(ctx, expr, func(operand parser.Expression) (interface{}, error) {
+		return e.getJoinColumnValue(ctx, operand, row)
+	}, func(operands ...parser.Expression) string {
+		return joinConditionCollation(row, operands...)
+	})
+	return t == isTrue, err
 }
 
 // getJoinColumnValue gets a value from a joined row
-func (e *Executor) getJoinColumnValue(expr parser.Expression, row *CombinedRow) (interface{}, error) {
+func (e *Executor) getJoinColumnValue(ctx context.Context, expr parser.Expression, row *CombinedRow) (interface{}, error) {
 	switch ex := expr.(type) {
 	case *parser.Identifier:
-		// Check if it's table.column format
-		parts := strings.Split(ex.Value, ".")
-		if len(parts) == 2 {
-			tableName := parts[0]
-			colName := parts[1]
-			if tableName == row.leftTableName {
-				idx := row.leftSchema.GetColumnIndex(colName)
-				if idx == -1 {
-					return nil, fmt.Errorf("column %s not found in table %s", colName, tableName)
-				}
-				return row.leftRow.Values[idx], nil
-			} else if tableName == row.rightTableName {
-				idx := row.rightSchema.GetColumnIndex(colName)
-				if idx == -1 {
-					return nil, fmt.Errorf("column %s not found in table %s", colName, tableName)
-				}
-				return row.rightRow.Values[idx], nil
-			} else {
-				return nil, fmt.Errorf("unknown table: %s", tableName)
-			}
-		} else {
-			// Try to find in left table first
-			idx := row.leftSchema.GetColumnIndex(ex.Value)
-			if idx != -1 {
-				return row.leftRow.Values[idx], nil
-			}
-			// Try right table
-			idx = row.rightSchema.GetColumnIndex(ex.Value)
-			if idx != -1 {
-				return row.rightRow.Values[idx], nil
-			}
-			return nil, fmt.Errorf("column %s not found", ex.Value)
+		idx, err := row.schema.resolve(ex.Value)
+		if err != nil {
+			return nil, err
 		}
+		return row.values[idx], nil
 	case *parser.Literal:
 		return ex.Value, nil
 	case *parser.NullLiteral:
 		return nil, nil
+	case *parser.VariableRef:
+		return e.variable(ctx, ex.Name)
+	case *parser.SubqueryExpr:
+		return subqueryValue(ctx, ex.Select)
+	case *parser.FunctionCall:
+		args := make([]interface{}, len(ex.Args))
+		for i, arg := range ex.Args {
+			value, err := e.getJoinColumnValue(ctx, arg, row)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = value
+		}
+		return callFunction(ex.Name, args)
+	case *parser.BinaryExpr:
+		if !isValueOperator(ex.Operator) {
+			return nil, fmt.Errorf("unsupported expression in join condition")
+		}
+		left, err := e.getJoinColumnValue(ctx, ex.Left, row)
+		if err != nil {
+			return nil, err
+		}
+		right, err := e.getJoinColumnValue(ctx, ex.Right, row)
+		if err != nil {
+			return nil, err
+		}
+		return applyOperator(left, right, ex.Operator)
 	default:
 		return nil, fmt.Errorf("unsupported expression in join condition")
 	}
 }
 
 // executeUpdate executes UPDATE statement
-func (e *Executor) executeUpdate(stmt *parser.UpdateStmt) (*Result, error) {
+func (e *Executor) executeUpdate(ctx context.Context, stmt *parser.UpdateStmt) (*Result, error) {
 	table, err := e.storage.GetTable(stmt.TableName)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkWritable(ctx, table); err != nil {
+		return nil, err
+	}
+
+	if err := e.checkReadable(ctx, table.Schema, stmt.Where); err != nil {
+		return nil, err
+	}
+	returned, err := e.newReturning(ctx, table.Schema, stmt.Returning)
+	if err != nil {
+		return nil, err
+	}
+	exprs := []parser.Expression{stmt.Where}
+	for _, expr := range stmt.Set {
+		exprs = append(exprs, expr)
+	}
+	if ctx, err = e.withSubqueries(ctx, exprs...); err != nil {
+		return nil, err
+	}
 
-	// Build condition function
+	// Build condition function; rows hidden by policies are left alone
+	policies, err := e.policies(ctx, table.Schema)
+	if err != nil {
+		return nil, err
+	}
 	var condition func(*storage.Row) bool
-	if stmt.Where != nil {
+	if stmt.Where != nil || policies != nil {
 		condition = func(row *storage.Row) bool {
-			match, err := e.evaluateCondition(stmt.Where, row, table.Schema)
+			row, err := e.withVirtual(ctx, table.Schema, row)
 			if err != nil {
 				return false
 			}
-			return match
+			if stmt.Where != nil {
+				match, err := e.evaluateCondition(ctx, stmt.Where, row, table.Schema)
+				if err != nil || !match {
+					return false
+				}
+			}
+			match, err := e.visible(ctx, policies, row, table.Schema)
+			return err == nil && match
 		}
 	}
 
-	// Evaluate update values
-	updates := make(map[string]interface{})
+	// Evaluate update values. Those naming columns are evaluated for each
+	// row, against its values before the update.
+	updates := make(map[int]interface{})
+	perRow := make(map[int]parser.Expression)
 	for colName, expr := range stmt.Set {
-		value, err := e.evaluateExpression(expr, nil)
+		idx := table.Schema.GetColumnIndex(colName)
+		if idx == -1 {
+			return nil, fmt.Errorf("column %s not found", colName)
+		}
+		if table.Schema.Columns[idx].Generated != "" {
+			return nil, fmt.Errorf("cannot update generated column %s", colName)
+		}
+		if !constantExpression(expr) {
+			if err := e.checkReadable(ctx, table.Schema, expr); err != nil {
+				return nil, err
+			}
+			perRow[idx] = expr
+			continue
+		}
+		value, err := e.evaluateExpression(ctx, expr, nil)
 		if err != nil {
 			return nil, err
 		}
-		updates[colName] = value
+		if value, err = storage.NormalizeValue(value, table.Schema.Columns[idx]); err != nil {
+			return nil, err
+		}
+		updates[idx] = value
+	}
+
+	// Capture before-images of matching rows for change data capture, and
+	// the rows for RETURNING
+	cs := changeSetFrom(ctx)
+	var matched []*storage.Row
+	var before [][]interface{}
+	if cs != nil || returned != nil {
+		condition = trackMatches(condition, func(row *storage.Row) {
+			matched = append(matched, row)
+			before = append(before, append([]interface{}{}, row.Values...))
+		})
 	}
 
-	count, err := table.UpdateRows(condition, updates)
+	// Stored generated columns are recomputed, so they may change too
+	refs, err := e.newReferenceCheck(table, func(idx int) bool {
+		_, set := stmt.Set[table.Schema.Columns[idx].Name]
+		return set || table.Schema.Columns[idx].Generated != ""
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	scanned, count := 0, 0
+	for _, target := range e.partitionsFor(table, stmt.Where) {
+		bound, keyCol, bounded := e.storage.PartitionBound(target)
+		scanned += target.RowCount()
+		// Stored generated columns are recomputed from the updated values
+		updated, err := target.UpdateRowsWith(condition, func(values []interface{}) error {
+			var old []interface{}
+			if refs != nil {
+				old = slices.Clone(values)
+			}
+			if len(perRow) > 0 {
+				row, err := e.withVirtual(ctx, table.Schema, storage.NewRow(slices.Clone(values)))
+				if err != nil {
+					return err
+				}
+				for idx, expr := range perRow {
+					value, err := e.getColumnValue(ctx, expr, row, table.Schema)
+					if err != nil {
+						return err
+					}
+					if values[idx], err = storage.NormalizeValue(value, table.Schema.Columns[idx]); err != nil {
+						return err
+					}
+				}
+			}
+			for idx, value := range updates {
+				values[idx] = value
+			}
+			if err := e.computeGenerated(ctx, table.Schema, values, false); err != nil {
+				return err
+			}
+			if err := e.checkPolicies(ctx, table.Schema, policies, values); err != nil {
+				return err
+			}
+			if err := refs.check(old, values); err != nil {
+				return err
+			}
+			if bounded {
+				return checkPartitionBound(target, bound, keyCol, values)
+			}
+			return nil
+		})
+		count += updated
+		if err != nil {
+			return nil, err
+		}
+	}
+	if table.Schema.Partitioned() && count > 0 {
+		table.MarkDirty()
+	}
+	e.stats.recordTable(stmt.TableName, func(t *TableStats) {
+		t.Statements++
+		t.RowsScanned += int64(scanned)
+		t.RowsUpdated += int64(count)
+	})
+	e.advisor.record(stmt.TableName, stmt.Where, scanned, count)
+	if cs != nil {
+		for i, row := range matched {
+			cs.add(table.Schema, cdc.OpUpdate, before[i], row.Values)
+		}
+	}
+
 	// Save to disk
-	if err := e.storage.SaveAllTables(); err != nil {
+	if err := e.persist(ctx); err != nil {
 		return nil, fmt.Errorf("failed to persist data: %w", err)
 	}
 
-	return &Result{
-		Message:      fmt.Sprintf("%d row(s) updated", count),
-		RowsAffected: count,
-	}, nil
+	if returned != nil {
+		for _, row := range matched {
+			if err := returned.add(ctx, e, row.Values); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return returned.result(fmt.Sprintf("%d row(s) updated", count), count), nil
 }
 
 // executeDelete executes DELETE statement
-func (e *Executor) executeDelete(stmt *parser.DeleteStmt) (*Result, error) {
+func (e *Executor) executeDelete(ctx context.Context, stmt *parser.DeleteStmt) (*Result, error) {
 	table, err := e.storage.GetTable(stmt.TableName)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkWritable(ctx, table); err != nil {
+		return nil, err
+	}
 
-	// Build condition function
-	var condition func(*storage.Row) bool
-	if stmt.Where != nil {
-		condition = func(row *storage.Row) bool {
-			match, err := e.evaluateCondition(stmt.Where, row, table.Schema)
-			if err != nil {
-				return false
-			}
-			return match
-		}
+	condition, err := e.deleteCondition(ctx, table.Schema, stmt.Where)
+	if err != nil {
+		return nil, err
+	}
+	returned, err := e.newReturning(ctx, table.Schema, stmt.Returning)
+	if err != nil {
+		return nil, err
+	}
+	targets := e.partitionsFor(table, stmt.Where)
+	cascade, err := e.planDelete(table, targets, condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if cs := changeSetFrom(ctx); cs != nil {
+		condition = trackMatches(condition, func(row *storage.Row) {
+			cs.add(table.Schema, cdc.OpDelete, row.Values, nil)
+		})
+	}
+	var deleted [][]interface{}
+	if returned != nil {
+		condition = trackMatches(condition, func(row *storage.Row) {
+			deleted = append(deleted, row.Values)
+		})
 	}
 
-	count := table.DeleteRows(condition)
+	// Tables with soft delete enabled keep the rows, marked deleted
+	scanned, count := 0, 0
+	for _, target := range targets {
+		scanned += target.RowCount()
+		if target.Schema.SoftDelete {
+			count += target.SoftDeleteRows(condition)
+		} else {
+			count += target.DeleteRows(condition)
+		}
+	}
+	if table.Schema.Partitioned() && count > 0 {
+		table.MarkDirty()
+	}
+	if err := e.applyCascades(ctx, cascade); err != nil {
+		return nil, err
+	}
+	e.stats.recordTable(stmt.TableName, func(t *TableStats) {
+		t.Statements++
+		t.RowsScanned += int64(scanned)
+		t.RowsDeleted += int64(count)
+	})
+	e.advisor.record(stmt.TableName, stmt.Where, scanned, count)
 
 	// Save to disk
-	if err := e.storage.SaveAllTables(); err != nil {
+	if err := e.persist(ctx); err != nil {
 		return nil, fmt.Errorf("failed to persist data: %w", err)
 	}
 
-	return &Result{
-		Message:      fmt.Sprintf("%d row(s) deleted", count),
-		RowsAffected: count,
+	for _, values := range deleted {
+		if err := returned.add(ctx, e, values); err != nil {
+			return nil, err
+		}
+	}
+	return returned.result(fmt.Sprintf("%d row(s) deleted", count), count), nil
+}
+
+// deleteCondition returns the condition selecting the rows a DELETE or
+// PURGE with where removes, nil for all of them. Rows hidden by policies
+// are left alone.
+func (e *Executor) deleteCondition(ctx context.Context, schema *storage.Schema, where parser.Expression) (func(*storage.Row) bool, error) {
+	if err := e.checkReadable(ctx, schema, where); err != nil {
+		return nil, err
+	}
+	ctx, err := e.withSubqueries(ctx, where)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := e.policies(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	if where == nil && policies == nil {
+		return nil, nil
+	}
+	return func(row *storage.Row) bool {
+		row, err := e.withVirtual(ctx, schema, row)
+		if err != nil {
+			return false
+		}
+		if where != nil {
+			match, err := e.evaluateCondition(ctx, where, row, schema)
+			if err != nil || !match {
+				return false
+			}
+		}
+		match, err := e.visible(ctx, policies, row, schema)
+		return err == nil && match
 	}, nil
 }
 
 // evaluateExpression evaluates an expression to a value
-func (e *Executor) evaluateExpression(expr parser.Expression, row *storage.Row) (interface{}, error) {
-	switch e := expr.(type) {
+func (e *Executor) evaluateExpression(ctx context.Context, expr parser.Expression, row *storage.Row) (interface{}, error) {
+	switch ex := expr.(type) {
 	case *parser.Literal:
-		return e.Value, nil
+		return ex.Value, nil
 	case *parser.NullLiteral:
 		return nil, nil
+	case *parser.DefaultValue:
+		return nil, fmt.Errorf("DEFAULT is only allowed as a value in INSERT ... VALUES")
+	case *parser.VariableRef:
+		return e.variable(ctx, ex.Name)
+	case *parser.SubqueryExpr:
+		return subqueryValue(ctx, ex.Select)
 	case *parser.Identifier:
 		if row == nil {
 			return nil, fmt.Errorf("cannot evaluate identifier without row context")
 		}
 		return nil, fmt.Errorf("identifier evaluation in INSERT not supported")
+	case *parser.FunctionCall:
+		args := make([]interface{}, len(ex.Args))
+		for i, arg := range ex.Args {
+			value, err := e.evaluateExpression(ctx, arg, row)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = value
+		}
+		return callFunction(ex.Name, args)
 	case *parser.BinaryExpr:
-		return nil, fmt.Errorf("binary expressions in INSERT not supported")
-	default:
-		return nil, fmt.Errorf("unsupported expression type")
-	}
-}
-
-// evaluateCondition evaluates a WHERE condition
-func (e *Executor) evaluateCondition(expr parser.Expression, row *storage.Row, schema *storage.Schema) (bool, error) {
-	switch ex := expr.(type) {
-	case *parser.BinaryExpr:
-		left, err := e.getColumnValue(ex.Left, row, schema)
+		if !isValueOperator(ex.Operator) {
+			return nil, fmt.Errorf("binary expressions in INSERT not supported")
+		}
+		left, err := e.evaluateExpression(ctx, ex.Left, row)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
-
-		right, err := e.getColumnValue(ex.Right, row, schema)
+		right, err := e.evaluateExpression(ctx, ex.Right, row)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
-
-		return e.compareValues(left, right, ex.Operator)
+		return applyOperator(left, right, ex.Operator)
 	default:
-		return false, fmt.Errorf("unsupported condition type")
+		return nil, fmt.Errorf("unsupported expression type")
 	}
 }
 
+// evaluateCondition evaluates a WHERE condition
+func (e *Executor) evaluateCondition(ctx context.Context, expr parser.Expression, row *storage.Row, schema *storage.Schema) (bool, error) {
+	t, err := e.evaluateTruth(ctx, expr, func(operand parser.Expression) (interface{}, error) {
+		return e.getColumnValue(ctx, operand, row, schema)
+	}, func(operands ...parser.Expression) string {
+		return conditionCollation(schema, operands...)
+	})
+	return t == isTrue, err
+}
+
 // getColumnValue gets a value from a row or literal
-func (e *Executor) getColumnValue(expr parser.Expression, row *storage.Row, schema *storage.Schema) (interface{}, error) {
+func (e *Executor) getColumnValue(ctx context.Context, expr parser.Expression, row *storage.Row, schema *storage.Schema) (interface{}, error) {
 	switch ex := expr.(type) {
 	case *parser.Identifier:
 		idx := schema.GetColumnIndex(ex.Value)
@@ -566,6 +1899,42 @@ func (e *Executor) getColumnValue(expr parser.Expression, row *storage.Row, sche
 		return ex.Value, nil
 	case *parser.NullLiteral:
 		return nil, nil
+	case *parser.VariableRef:
+		return e.variable(ctx, ex.Name)
+	case *parser.SubqueryExpr:
+		return subqueryValue(ctx, ex.Select)
+	case *parser.FunctionCall:
+		if _, ok := aggregateFunctions[ex.Name]; ok {
+			return nil, fmt.Errorf("aggregate function %s is only allowed in the select list and HAVING", ex.Name)
+		}
+		if ex.Star {
+			return nil, fmt.Errorf("%s(*) is not allowed: only aggregate functions take *", ex.Name)
+		}
+		if ex.Distinct {
+			return nil, fmt.Errorf("DISTINCT is not allowed in %s(): only aggregate functions take it", ex.Name)
+		}
+		args := make([]interface{}, len(ex.Args))
+		for i, arg := range ex.Args {
+			value, err := e.getColumnValue(ctx, arg, row, schema)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = value
+		}
+		return callFunction(ex.Name, args)
+	case *parser.BinaryExpr:
+		if !isValueOperator(ex.Operator) {
+			return nil, fmt.Errorf("unsupported expression in condition")
+		}
+		left, err := e.getColumnValue(ctx, ex.Left, row, schema)
+		if err != nil {
+			return nil, err
+		}
+		right, err := e.getColumnValue(ctx, ex.Right, row, schema)
+		if err != nil {
+			return nil, err
+		}
+		return applyOperator(left, right, ex.Operator)
 	default:
 		return nil, fmt.Errorf("unsupported expression in condition")
 	}
@@ -580,6 +1949,28 @@ func (e *Executor) compareValues(left, right interface{}, operator string) (bool
 		}
 		return false, nil
 	}
+	if l, ok := left.(Interval); ok {
+		if r, ok := right.(Interval); ok {
+			return compareOrder(compareIntervals(l, r), operator)
+		}
+	}
+	// A DATE compares with a TIMESTAMP as midnight of its day
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			left, right = storage.DateAsTimestamp(l, r)
+		}
+	}
+	// Numbers extracted from JSON may be integers in one row and floats
+	// in the next, so an INTEGER compares with a FLOAT as a float
+	if _, ok := left.(float64); ok {
+		if r, ok := right.(int); ok {
+			right = float64(r)
+		}
+	} else if _, ok := right.(float64); ok {
+		if l, ok := left.(int); ok {
+			left = float64(l)
+		}
+	}
 
 	switch operator {
 	case "=":
@@ -0,0 +1,51 @@
+package executor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// TestQueryParamsBindsBooleansAndNegativeNumbers checks that true, false
+// and negative numbers bind, including right after a minus sign
+func TestQueryParamsBindsBooleansAndNegativeNumbers(t *testing.T) {
+	store, err := storage.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	e := NewExecutor(store)
+	ctx := context.Background()
+
+	steps := []struct {
+		query  string
+		params []interface{}
+	}{
+		{"CREATE TABLE t (id INTEGER PRIMARY KEY, ok BOOLEAN, n INTEGER, f FLOAT)", nil},
+		{"INSERT INTO t VALUES (?, ?, ?, ?)", []interface{}{1, true, -1, -1.5}},
+		{"INSERT INTO t VALUES ($1, $2, $3, $4)", []interface{}{2, false, 5, 2.5}},
+		{"UPDATE t SET n = n-$1 WHERE ok = $2", []interface{}{-3, false}},
+	}
+	for _, step := range steps {
+		if _, err := e.QueryParams(ctx, step.query, step.params); err != nil {
+			t.Fatalf("%s: %v", step.query, err)
+		}
+	}
+
+	result, err := e.QueryParams(ctx, "SELECT id, n, f FROM t WHERE n > ? AND ok = ? ORDER BY id", []interface{}{-2, true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]interface{}{{1, -1, -1.5}}; !reflect.DeepEqual(result.Rows, want) {
+		t.Errorf("rows %v, want %v", result.Rows, want)
+	}
+	result, err = e.QueryParams(ctx, "SELECT n FROM t WHERE id = ?", []interface{}{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]interface{}{{8}}; !reflect.DeepEqual(result.Rows, want) {
+		t.Errorf("n-$1 with -3 gave %v, want %v", result.Rows, want)
+	}
+}
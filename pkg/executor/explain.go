@@ -0,0 +1,487 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// foreignTableRows is the row estimate for foreign tables, whose files are
+// only read when queried
+const foreignTableRows = 1000
+
+// Plan is the plan EXPLAIN shows for a SELECT
+type Plan struct {
+	Root     *PlanNode `json:"root"`
+	Analyzed bool      `json:"analyzed"`
+	// ExecutionTimeMs is how long the SELECT took, when analyzed
+	ExecutionTimeMs float64 `json:"executionTimeMs,omitempty"`
+}
+
+// PlanNode is one step of a query plan. Every step produces rows for the
+// step above it; the root produces the rows of the result.
+type PlanNode struct {
+	Operation     string      `json:"operation"` // e.g. "Seq Scan", "Index Scan", "Append", "Nested Loop"
+	Table         string      `json:"table,omitempty"`
+	Index         string      `json:"index,omitempty"` // the index an Index Scan searches
+	Alias         string      `json:"alias,omitempty"` // the table's alias in a join
+	Filter        string      `json:"filter,omitempty"`
+	JoinFilter    string      `json:"joinFilter,omitempty"`
+	Sample        string      `json:"sample,omitempty"`
+	GroupKey      string      `json:"groupKey,omitempty"`
+	SortKey       string      `json:"sortKey,omitempty"`
+	EstimatedRows int         `json:"estimatedRows"`
+	ActualRows    *int        `json:"actualRows,omitempty"`   // set by ANALYZE for the steps it measured
+	ActualTimeMs  *float64    `json:"actualTimeMs,omitempty"` // time the step took, including the steps below it
+	Children      []*PlanNode `json:"children,omitempty"`
+
+	key string // name the step's actuals are recorded under
+}
+
+// Explain returns the plan of a SELECT, running it to measure actual row
+// counts and timings when analyze is set. The query may also be an EXPLAIN statement,
+// whose ANALYZE option then applies too.
+func (e *Executor) Explain(ctx context.Context, query string, analyze bool) (*Plan, error) {
+	stmt, err := e.parse(query)
+	if err != nil {
+		return nil, &ParseError{Err: err}
+	}
+
+	explain := &parser.ExplainStmt{Analyze: analyze}
+	switch s := stmt.(type) {
+	case *parser.SelectStmt:
+		explain.Select = s
+	case *parser.ExplainStmt:
+		explain.Select = s.Select
+		explain.Analyze = analyze || s.Analyze
+	default:
+		return nil, fmt.Errorf("only SELECT statements can be explained")
+	}
+	explain.Format = "json"
+
+	ctx, running, done := e.trackQuery(ctx, query)
+	defer done()
+	e.setRunning(running)
+
+	result, err := e.ExecuteContext(ctx, explain)
+	if err != nil {
+		return nil, err
+	}
+	return result.Plan, nil
+}
+
+// executeExplain executes EXPLAIN statement
+func (e *Executor) executeExplain(ctx context.Context, stmt *parser.ExplainStmt) (*Result, error) {
+	plan, err := e.planSelect(ctx, stmt.Select)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.Analyze {
+		actuals := &planActuals{rows: map[string]int{}, times: map[string]time.Duration{}}
+		start := time.Now()
+		if _, err := e.executeSelect(context.WithValue(ctx, planActualsKey{}, actuals), stmt.Select); err != nil {
+			return nil, err
+		}
+		plan.Analyzed = true
+		plan.ExecutionTimeMs = float64(time.Since(start).Microseconds()) / 1000
+		plan.Root.setActuals(actuals)
+	}
+
+	result := &Result{Columns: []string{"QUERY PLAN"}, Plan: plan}
+	if stmt.Format == "json" {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		result.Rows = [][]interface{}{{string(data)}}
+	} else {
+		for _, line := range plan.lines() {
+			result.Rows = append(result.Rows, []interface{}{line})
+		}
+	}
+	result.RowsAffected = len(result.Rows)
+	return result, nil
+}
+
+// planSelect builds the plan for a SELECT the way executeSelect runs it
+func (e *Executor) planSelect(ctx context.Context, stmt *parser.SelectStmt) (*Plan, error) {
+	table, err := e.storage.GetTable(stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.accessTo(ctx, table.Schema); err != nil {
+		return nil, err
+	}
+
+	if len(stmt.Joins) == 0 {
+		if stmt, err = unqualifySelect(stmt); err != nil {
+			return nil, err
+		}
+		if !(len(stmt.Columns) == 1 && stmt.Columns[0] == "*") {
+			for i, name := range stmt.Columns {
+				if stmt.Aggregates != nil && stmt.Aggregates[i] != nil {
+					name = stmt.Aggregates[i].Column
+				}
+				if name != "*" && table.Schema.GetColumnIndex(name) == -1 {
+					return nil, fmt.Errorf("column %s does not exist", name)
+				}
+			}
+		}
+		root := e.planScan(table, stmt.Where, stmt.Sample, stmt.WithDeleted)
+		if columnarScan(table.Schema, stmt.Sample, stmt.WithDeleted) {
+			root.Operation = "Columnar Scan"
+		} else if _, ok := e.indexScan(table, stmt); ok {
+			column := indexedColumn(table.Schema, stmt.Where)
+			root.Operation, root.Index = "Index Scan", e.indexName(table.Schema.TableName, column)
+		}
+		root.key = stmt.TableName
+		if aggregated(stmt) {
+			if root, err = planAggregate(root, stmt, table.Schema); err != nil {
+				return nil, err
+			}
+		}
+		if stmt.OrderBy != nil {
+			for _, key := range stmt.OrderBy {
+				if table.Schema.GetColumnIndex(key.Column) == -1 {
+					return nil, fmt.Errorf("column %s does not exist", key.Column)
+				}
+			}
+			root = sortNode(root, stmt.OrderBy)
+		}
+		return &Plan{Root: root}, nil
+	}
+
+	// Tables are joined in order, each join taking the rows of the ones
+	// before it as its outer side
+	root := e.planScan(table, nil, stmt.Sample, stmt.WithDeleted)
+	root.key = "scan 0"
+	root.Alias = stmt.TableAlias
+	joined := &joinSchema{}
+	if err := joined.add(qualifier(stmt.TableName, stmt.TableAlias), table.Schema); err != nil {
+		return nil, err
+	}
+	for i, join := range stmt.Joins {
+		rightTable, err := e.storage.GetTable(join.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := e.accessTo(ctx, rightTable.Schema); err != nil {
+			return nil, err
+		}
+		if err := joined.add(qualifier(join.TableName, join.Alias), rightTable.Schema); err != nil {
+			return nil, err
+		}
+
+		outer := root
+		inner := e.planScan(rightTable, nil, nil, stmt.WithDeleted)
+		inner.Alias = join.Alias
+		inner.key = fmt.Sprintf("scan %d", i+1)
+
+		// Joins usually match each row on one side with about one on the other
+		rows := outer.EstimatedRows * inner.EstimatedRows
+		if join.On != nil {
+			rows = max(outer.EstimatedRows, inner.EstimatedRows)
+		}
+		operation := "Nested Loop"
+		if join.JoinType == "LEFT" {
+			// Every outer row is kept at least once
+			operation = "Nested Loop Left Join"
+			rows = max(rows, outer.EstimatedRows)
+		}
+		root = &PlanNode{
+			Operation:     operation,
+			JoinFilter:    formatCondition(join.On),
+			EstimatedRows: rows,
+			Children:      []*PlanNode{outer, inner},
+			key:           fmt.Sprintf("join %d", i+1),
+		}
+	}
+	if stmt.Where != nil {
+		root.Filter = formatCondition(stmt.Where)
+		root.EstimatedRows = clampRows(float64(root.EstimatedRows) * selectivity(stmt.Where, nil, root.EstimatedRows))
+	}
+	if stmt.OrderBy != nil {
+		for _, key := range stmt.OrderBy {
+			if joined.index(key.Column) == -1 {
+				return nil, fmt.Errorf("column %s not found", key.Column)
+			}
+		}
+		root = sortNode(root, stmt.OrderBy)
+	}
+	return &Plan{Root: root}, nil
+}
+
+// planAggregate puts the aggregation of a SELECT with aggregate calls,
+// GROUP BY or HAVING on top of its scan. Without statistics a GROUP BY is
+// assumed to find 200 groups, as in PostgreSQL, or one per row of a unique
+// column.
+func planAggregate(child *PlanNode, stmt *parser.SelectStmt, schema *storage.Schema) (*PlanNode, error) {
+	node := &PlanNode{
+		Operation:     "Aggregate",
+		Filter:        formatCondition(stmt.Having),
+		EstimatedRows: 1,
+		Children:      []*PlanNode{child},
+		key:           "aggregate",
+	}
+	if len(stmt.GroupBy) == 0 {
+		return node, nil
+	}
+	node.Operation = "HashAggregate"
+	node.GroupKey = strings.Join(stmt.GroupBy, ", ")
+	node.EstimatedRows = min(child.EstimatedRows, 200)
+	for _, name := range stmt.GroupBy {
+		idx := schema.GetColumnIndex(name)
+		if idx == -1 {
+			return nil, fmt.Errorf("column %s does not exist", name)
+		}
+		if col := schema.Columns[idx]; col.PrimaryKey || col.Unique {
+			node.EstimatedRows = child.EstimatedRows
+		}
+	}
+	if stmt.Having != nil {
+		node.EstimatedRows = clampRows(float64(node.EstimatedRows) * selectivity(stmt.Having, nil, node.EstimatedRows))
+	}
+	return node, nil
+}
+
+// sortNode puts an ORDER BY sort on top of a plan
+func sortNode(child *PlanNode, order []parser.OrderBy) *PlanNode {
+	keys := make([]string, len(order))
+	for i, key := range order {
+		keys[i] = parser.FormatOrderBy(key)
+	}
+	return &PlanNode{
+		Operation:     "Sort",
+		SortKey:       strings.Join(keys, ", "),
+		EstimatedRows: child.EstimatedRows,
+		Children:      []*PlanNode{child},
+		key:           "sort",
+	}
+}
+
+// planScan returns the step reading a table's rows, filtered by where
+func (e *Executor) planScan(table *storage.Table, where parser.Expression, sample *parser.TableSample, withDeleted bool) *PlanNode {
+	schema := table.Schema
+	node := &PlanNode{Operation: "Seq Scan", Table: schema.TableName, Filter: formatCondition(where)}
+
+	var rows int
+	switch {
+	case schema.Foreign():
+		node.Operation = "Foreign Scan"
+		rows = foreignTableRows
+	case schema.Partitioned():
+		node.Operation = "Append"
+		node.Table = ""
+		for _, partition := range e.partitionsFor(table, where) {
+			child := &PlanNode{
+				Operation:     "Seq Scan",
+				Table:         partition.Schema.TableName,
+				Filter:        node.Filter,
+				EstimatedRows: estimateRows(partition.RowCount(), where, partition.Schema),
+				key:           partition.Schema.TableName,
+			}
+			node.Children = append(node.Children, child)
+			rows += partition.RowCount()
+		}
+		node.Filter = ""
+	case withDeleted:
+		rows = len(table.SelectRowsWithDeleted())
+	default:
+		rows = table.RowCount()
+	}
+
+	if sample != nil {
+		if node.Operation == "Seq Scan" {
+			node.Operation = "Sample Scan"
+		}
+		node.Sample = formatSample(sample)
+		if sample.Method == "ROWS" {
+			rows = min(rows, sample.Rows)
+		} else {
+			rows = clampRows(float64(rows) * sample.Percent / 100)
+		}
+	}
+	node.EstimatedRows = estimateRows(rows, where, schema)
+	return node
+}
+
+// estimateRows estimates how many of a table's rows match where
+func estimateRows(rows int, where parser.Expression, schema *storage.Schema) int {
+	if where == nil || rows == 0 {
+		return rows
+	}
+	return clampRows(float64(rows) * selectivity(where, schema, rows))
+}
+
+// selectivity estimates the fraction of rows a condition keeps. Equality
+// on a PRIMARY KEY or UNIQUE column keeps one row; otherwise the defaults
+// PostgreSQL uses without statistics apply. NOT keeps the rest, and AND
+// and OR combine their sides' estimates as if they were independent.
+func selectivity(where parser.Expression, schema *storage.Schema, rows int) float64 {
+	if not, ok := where.(*parser.UnaryExpr); ok && not.Operator == "NOT" {
+		return 1 - selectivity(not.Operand, schema, rows)
+	}
+	expr, ok := where.(*parser.BinaryExpr)
+	if !ok {
+		return 0.5
+	}
+	switch expr.Operator {
+	case "AND":
+		return selectivity(expr.Left, schema, rows) * selectivity(expr.Right, schema, rows)
+	case "OR":
+		left, right := selectivity(expr.Left, schema, rows), selectivity(expr.Right, schema, rows)
+		return left + right - left*right
+	case "=":
+		if schema != nil && rows > 0 && (isUniqueColumn(expr.Left, schema) || isUniqueColumn(expr.Right, schema)) {
+			return 1 / float64(rows)
+		}
+		return 0.005
+	case "!=":
+		return 0.995
+	case "<", ">", "<=", ">=":
+		return 1.0 / 3
+	default:
+		return 0.5
+	}
+}
+
+// isUniqueColumn reports whether expr names a PRIMARY KEY or UNIQUE column
+func isUniqueColumn(expr parser.Expression, schema *storage.Schema) bool {
+	ident, ok := expr.(*parser.Identifier)
+	if !ok {
+		return false
+	}
+	idx := schema.GetColumnIndex(ident.Value)
+	return idx != -1 && (schema.Columns[idx].PrimaryKey || schema.Columns[idx].Unique)
+}
+
+// clampRows rounds an estimate, keeping at least one row as PostgreSQL does
+func clampRows(rows float64) int {
+	return max(1, int(math.Round(rows)))
+}
+
+// formatCondition renders a condition, or "" for none
+func formatCondition(expr parser.Expression) string {
+	if expr == nil {
+		return ""
+	}
+	return parser.FormatExpression(expr)
+}
+
+// formatSample renders a TABLESAMPLE clause
+func formatSample(sample *parser.TableSample) string {
+	var s string
+	if sample.Method == "ROWS" {
+		s = fmt.Sprintf("%d ROWS", sample.Rows)
+	} else {
+		s = fmt.Sprintf("%s (%g)", sample.Method, sample.Percent)
+	}
+	if sample.Repeatable {
+		s += fmt.Sprintf(" REPEATABLE (%d)", sample.Seed)
+	}
+	return s
+}
+
+// setActuals fills in the measured row counts and timings of a plan
+func (n *PlanNode) setActuals(actuals *planActuals) {
+	if count, ok := actuals.rows[n.key]; ok {
+		ms := float64(actuals.times[n.key].Microseconds()) / 1000
+		n.ActualRows, n.ActualTimeMs = &count, &ms
+	}
+	for _, child := range n.Children {
+		child.setActuals(actuals)
+	}
+}
+
+// lines renders the plan as text, one step per line followed by its
+// details, with children indented under their parent
+func (p *Plan) lines() []string {
+	lines := []string{}
+	var walk func(n *PlanNode, depth int)
+	walk = func(n *PlanNode, depth int) {
+		prefix, indent := "", strings.Repeat(" ", 6*depth+2)
+		if depth > 0 {
+			prefix = strings.Repeat(" ", 6*depth-4) + "->  "
+		}
+
+		line := prefix + n.Operation
+		if n.Index != "" {
+			line += " using " + n.Index
+		}
+		if n.Table != "" {
+			line += " on " + n.Table
+		}
+		if n.Alias != "" {
+			line += " " + n.Alias
+		}
+		line += fmt.Sprintf("  (rows=%d)", n.EstimatedRows)
+		if p.Analyzed {
+			if n.ActualRows != nil {
+				line += fmt.Sprintf(" (actual time=%.3f ms rows=%d)", *n.ActualTimeMs, *n.ActualRows)
+			} else {
+				line += " (never measured)"
+			}
+		}
+		lines = append(lines, line)
+
+		if n.GroupKey != "" {
+			lines = append(lines, indent+"Group Key: "+n.GroupKey)
+		}
+		if n.SortKey != "" {
+			lines = append(lines, indent+"Sort Key: "+n.SortKey)
+		}
+		if n.Sample != "" {
+			lines = append(lines, indent+"Sampling: "+n.Sample)
+		}
+		if n.JoinFilter != "" {
+			lines = append(lines, indent+"Join Filter: "+n.JoinFilter)
+		}
+		if n.Filter != "" {
+			lines = append(lines, indent+"Filter: "+n.Filter)
+		}
+		for _, child := range n.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(p.Root, 0)
+
+	if p.Analyzed {
+		lines = append(lines, fmt.Sprintf("Execution Time: %.3f ms", p.ExecutionTimeMs))
+	}
+	return lines
+}
+
+// planActualsKey is the context key of the row counts and timings EXPLAIN
+// ANALYZE collects
+type planActualsKey struct{}
+
+// planActuals collects the rows each plan step produced and how long it
+// took
+type planActuals struct {
+	mu    sync.Mutex
+	rows  map[string]int
+	times map[string]time.Duration
+}
+
+// recordActuals records the rows a plan step produced, and the time since
+// start, when the statement is run by EXPLAIN ANALYZE. start is when the
+// first step below it began, so a step's time includes its inputs'.
+func recordActuals(ctx context.Context, key string, rows int, start time.Time) {
+	actuals, ok := ctx.Value(planActualsKey{}).(*planActuals)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(start)
+	actuals.mu.Lock()
+	actuals.rows[key] += rows
+	actuals.times[key] += elapsed
+	actuals.mu.Unlock()
+}
@@ -0,0 +1,413 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/tracing"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/wal"
+)
+
+// FlushPolicy controls when write statements reach the disk
+type FlushPolicy int
+
+const (
+	// FlushEveryStatement fsyncs the WAL and rewrites the tables after
+	// every write statement
+	FlushEveryStatement FlushPolicy = iota
+	// FlushInterval fsyncs the WAL and writes changed tables once per
+	// interval. A crash of the machine loses at most one interval of writes.
+	FlushInterval
+	// FlushGroupCommit fsyncs the WAL before each write returns, letting
+	// concurrent writes share one fsync, and writes changed tables once per
+	// interval. Nothing acknowledged is lost.
+	FlushGroupCommit
+)
+
+// DefaultFlushInterval is how often batched flush policies checkpoint
+const DefaultFlushInterval = time.Second
+
+// checkpointFileName is the file in the data directory holding the WAL LSN
+// of the last checkpoint
+const checkpointFileName = "checkpoint"
+
+// ParseFlushPolicy parses a flush policy name: statement, interval or group
+func ParseFlushPolicy(name string) (FlushPolicy, error) {
+	switch strings.ToLower(name) {
+	case "", "statement":
+		return FlushEveryStatement, nil
+	case "interval":
+		return FlushInterval, nil
+	case "group":
+		return FlushGroupCommit, nil
+	default:
+		return 0, fmt.Errorf("unknown flush policy %q (want statement, interval or group)", name)
+	}
+}
+
+// String returns the name ParseFlushPolicy accepts
+func (p FlushPolicy) String() string {
+	switch p {
+	case FlushInterval:
+		return "interval"
+	case FlushGroupCommit:
+		return "group"
+	default:
+		return "statement"
+	}
+}
+
+// SetFlushPolicy sets when writes are flushed. It must be called after
+// SetWAL and before Recover, which starts the background checkpoints that
+// the batched policies rely on.
+func (e *Executor) SetFlushPolicy(policy FlushPolicy, interval time.Duration) error {
+	if policy == FlushGroupCommit && e.wal == nil {
+		return fmt.Errorf("group commit requires a WAL")
+	}
+	if policy != FlushEveryStatement && interval <= 0 {
+		return fmt.Errorf("flush interval must be positive")
+	}
+
+	e.flushPolicy = policy
+	e.flushInterval = interval
+	if e.wal != nil {
+		switch policy {
+		case FlushInterval:
+			e.wal.SetSyncMode(wal.SyncDeferred)
+		case FlushGroupCommit:
+			e.wal.SetSyncMode(wal.SyncGroup)
+		default:
+			e.wal.SetSyncMode(wal.SyncEveryRecord)
+		}
+	}
+	return nil
+}
+
+// Recover brings the tables up to date after an unclean shutdown by
+// replaying WAL records logged after the last checkpoint, and returns how
+// many were replayed. With a batched flush policy it then starts
+// checkpointing in the background.
+func (e *Executor) Recover(ctx context.Context) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Settings changed with SET GLOBAL apply from the start, including to
+	// the flush policy recovery ends with
+	e.recovering = true
+	defer func() { e.recovering = false }()
+	if err := e.loadSettings(); err != nil {
+		return 0, err
+	}
+
+	if e.wal == nil || e.storage.ReadOnly() {
+		return 0, nil
+	}
+
+	path := filepath.Join(e.storage.DataDir(), checkpointFileName)
+	lsn, found, err := readCheckpoint(path)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	if found {
+		for _, rec := range e.wal.ReadFrom(lsn, 0) {
+			applied, err := e.redo(ctx, rec)
+			if err != nil {
+				return replayed, fmt.Errorf("recovery failed at LSN %d: %w", rec.LSN, err)
+			}
+			if applied {
+				replayed++
+			}
+		}
+	}
+
+	if e.flushPolicy != FlushEveryStatement {
+		if err := e.checkpoint(ctx, true); err != nil {
+			return replayed, err
+		}
+		if e.stopCheckpoints == nil {
+			e.startCheckpoints()
+		}
+		return replayed, nil
+	}
+
+	// Tables are written after every statement from now on, so the
+	// checkpoint no longer describes them
+	if found {
+		if err := e.storage.SaveAllTables(); err != nil {
+			return replayed, err
+		}
+		if err := os.Remove(path); err != nil {
+			return replayed, fmt.Errorf("failed to remove checkpoint: %w", err)
+		}
+	}
+	return replayed, nil
+}
+
+// redo applies a WAL record during recovery, unless the table it changes
+// was already written by a checkpoint at or after the record
+func (e *Executor) redo(ctx context.Context, rec wal.Record) (bool, error) {
+	stmt, err := e.parse(rec.Query)
+	if err != nil {
+		return false, err
+	}
+
+	if name := targetTable(stmt); name != "" {
+		table, err := e.storage.GetTable(name)
+		switch {
+		case err != nil:
+			if _, ok := stmt.(*parser.DropTableStmt); ok {
+				return false, nil // dropped before the crash
+			}
+		case table.CheckpointLSN() >= rec.LSN:
+			return false, nil
+		default:
+			// CREATE TABLE writes the empty table file immediately, before
+			// any checkpoint covers it; recreate it from the log
+			if _, ok := stmt.(*parser.CreateTableStmt); ok {
+				if err := e.storage.DropTable(name); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+
+	if _, err := e.execute(WithUser(ctx, rec.User), stmt); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// targetTable returns the table a logged statement changes, or "" if it is
+// not tied to one table
+func targetTable(stmt parser.Statement) string {
+	switch s := stmt.(type) {
+	case *parser.CreateTableStmt:
+		return s.TableName
+	case *parser.DropTableStmt:
+		return s.TableName
+	case *parser.CreateMaterializedViewStmt:
+		return s.Name
+	case *parser.RefreshMaterializedViewStmt:
+		return s.Name
+	case *parser.InsertStmt:
+		return s.TableName
+	case *parser.UpdateStmt:
+		return s.TableName
+	case *parser.DeleteStmt:
+		return s.TableName
+	case *parser.MergeStmt:
+		return s.TableName
+	case *parser.PurgeStmt:
+		return s.TableName
+	case *parser.AlterTableStmt:
+		return s.TableName
+	case *parser.CreatePolicyStmt:
+		return s.TableName
+	case *parser.DropPolicyStmt:
+		return s.TableName
+	case *parser.GrantStmt:
+		return s.TableName
+	case *parser.CreateJobStmt, *parser.DropJobStmt:
+		return JobsTable
+	case *parser.CreateSequenceStmt, *parser.AlterSequenceStmt, *parser.DropSequenceStmt:
+		return SequencesTable
+	case *parser.CreateFunctionStmt, *parser.DropFunctionStmt:
+		return FunctionsTable
+	case *parser.SetGlobalStmt:
+		return SettingsTable
+	case *parser.CreateUserStmt, *parser.DropUserStmt:
+		return UsersTable
+	default:
+		return ""
+	}
+}
+
+// Checkpoint fsyncs the WAL, writes every changed table and records the
+// LSN they reflect, so recovery only has to replay later records
+func (e *Executor) Checkpoint(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.checkpoint(ctx, false)
+}
+
+// CheckpointWAL checkpoints like Checkpoint, even when nothing changed, and
+// then removes the WAL records the tables now reflect, so recovery replays
+// nothing older. It returns the checkpoint LSN and the number of records
+// removed.
+func (e *Executor) CheckpointWAL(ctx context.Context) (uint64, int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.checkpointWAL(ctx)
+}
+
+// checkpointWAL implements CheckpointWAL; callers must hold e.mu exclusively
+func (e *Executor) checkpointWAL(ctx context.Context) (uint64, int, error) {
+	if e.wal == nil {
+		return 0, 0, e.checkpoint(ctx, true)
+	}
+
+	// Tables written after every statement already reflect the whole WAL,
+	// and a checkpoint file would make recovery replay later statements
+	lsn := e.wal.LastLSN()
+	if e.flushPolicy == FlushEveryStatement {
+		if err := e.wal.Sync(); err != nil {
+			return 0, 0, err
+		}
+	} else {
+		if err := e.checkpoint(ctx, true); err != nil {
+			return 0, 0, err
+		}
+		lsn = e.checkpointLSN
+	}
+
+	removed, err := e.wal.TruncateBefore(lsn)
+	if err != nil {
+		return 0, 0, fmt.Errorf("checkpoint written but WAL not truncated: %w", err)
+	}
+	return lsn, removed, nil
+}
+
+// executeCheckpoint executes CHECKPOINT statement
+func (e *Executor) executeCheckpoint(ctx context.Context) (*Result, error) {
+	lsn, removed, err := e.checkpointWAL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if e.wal == nil {
+		return &Result{Message: "Checkpoint complete"}, nil
+	}
+	return &Result{
+		Message: fmt.Sprintf("Checkpoint at LSN %d, %d WAL record(s) removed", lsn, removed),
+	}, nil
+}
+
+// checkpoint implements Checkpoint; callers must hold e.mu exclusively so
+// the tables match the WAL position. The checkpoint file is only rewritten
+// when the LSN moved, unless force is set.
+func (e *Executor) checkpoint(ctx context.Context, force bool) error {
+	_, span := tracing.Start(ctx, "checkpoint")
+	defer span.End()
+
+	var lsn uint64
+	if e.wal != nil {
+		lsn = e.wal.LastLSN()
+		if err := e.wal.Sync(); err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	written, err := e.storage.Checkpoint(lsn)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if written > 0 {
+		e.stats.recordFlush()
+	}
+
+	if e.wal != nil && (force || lsn != e.checkpointLSN) {
+		path := filepath.Join(e.storage.DataDir(), checkpointFileName)
+		if err := writeCheckpoint(path, lsn); err != nil {
+			span.RecordError(err)
+			return err
+		}
+		e.checkpointLSN = lsn
+	}
+	return nil
+}
+
+// startCheckpoints checkpoints every flush interval until Close
+func (e *Executor) startCheckpoints() {
+	stop, done := make(chan struct{}), make(chan struct{})
+	e.stopCheckpoints = func() {
+		close(stop)
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(e.flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				interval, err := e.scheduledCheckpoint()
+				if err != nil {
+					log.Printf("checkpoint failed: %v", err)
+				}
+				ticker.Reset(interval)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// scheduledCheckpoint runs a background checkpoint and returns the
+// interval until the next. SET GLOBAL may have changed the interval, or the
+// policy to one that needs no checkpoints.
+func (e *Executor) scheduledCheckpoint() (time.Duration, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.flushPolicy == FlushEveryStatement {
+		return e.flushInterval, nil
+	}
+	return e.flushInterval, e.checkpoint(context.Background(), false)
+}
+
+// Close stops background checkpoints and flushes outstanding writes. The
+// WAL and storage are left open for their owner to close.
+func (e *Executor) Close() error {
+	if e.stopCheckpoints == nil {
+		return nil
+	}
+	e.stopCheckpoints()
+	e.stopCheckpoints = nil
+	// SET GLOBAL may have switched to a policy that wrote every statement
+	if e.flushPolicy == FlushEveryStatement {
+		return nil
+	}
+	return e.Checkpoint(context.Background())
+}
+
+// readCheckpoint reads the LSN stored in the checkpoint file, reporting
+// whether the file exists
+func readCheckpoint(path string) (uint64, bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	lsn, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("corrupt checkpoint file %s: %w", path, err)
+	}
+	return lsn, true, nil
+}
+
+// writeCheckpoint atomically replaces the checkpoint file
+func writeCheckpoint(path string, lsn uint64) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(strconv.FormatUint(lsn, 10)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
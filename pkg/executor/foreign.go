@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// foreignTable validates CREATE FOREIGN TABLE and returns the table's source
+func foreignTable(stmt *parser.CreateTableStmt) (*storage.ForeignTable, error) {
+	if stmt.Server != "csv" {
+		return nil, fmt.Errorf("unknown foreign server %s (supported: csv)", stmt.Server)
+	}
+	for _, colDef := range stmt.Columns {
+		if colDef.PrimaryKey || colDef.Unique {
+			return nil, fmt.Errorf("foreign table column %s cannot be PRIMARY KEY or UNIQUE", colDef.Name)
+		}
+		if colDef.Generated != nil {
+			return nil, fmt.Errorf("foreign table column %s cannot be generated", colDef.Name)
+		}
+	}
+	if len(stmt.Unique) > 0 {
+		return nil, fmt.Errorf("foreign tables cannot have UNIQUE constraints")
+	}
+	if _, _, err := csvOptions(stmt.Options); err != nil {
+		return nil, err
+	}
+	return &storage.ForeignTable{Server: stmt.Server, Options: stmt.Options}, nil
+}
+
+// csvOptions reads the options of a csv foreign table: path (required),
+// header, delimiter and null, which mean what they do for COPY ... CSV
+func csvOptions(options map[string]string) (string, CopyOptions, error) {
+	opts := CopyOptions{Format: CopyCSV}
+	path := ""
+	for name, value := range options {
+		switch name {
+		case "path":
+			path = value
+		case "header":
+			header, err := strconv.ParseBool(value)
+			if err != nil {
+				return "", opts, fmt.Errorf("invalid header option %q: expected true or false", value)
+			}
+			opts.Header = header
+		case "delimiter":
+			if len([]rune(value)) != 1 {
+				return "", opts, fmt.Errorf("delimiter option must be a single character")
+			}
+			opts.Delimiter = []rune(value)[0]
+		case "null":
+			opts.Null = value
+		default:
+			return "", opts, fmt.Errorf("unknown csv option %s (supported: path, header, delimiter, null)", name)
+		}
+	}
+	if path == "" {
+		return "", opts, fmt.Errorf("csv foreign table needs a path option")
+	}
+	return path, opts, nil
+}
+
+// foreignRows reads the rows of a foreign table from its source
+func (e *Executor) foreignRows(ctx context.Context, table *storage.Table) ([]*storage.Row, error) {
+	schema := table.Schema
+	path, opts, err := csvOptions(schema.ForeignTable.Options)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("foreign table %s: %w", schema.TableName, err)
+	}
+	defer file.Close()
+
+	input := newCopyReader(file, opts)
+	header := opts.Header
+	rows := []*storage.Row{}
+	for i := 0; ; i++ {
+		if err := checkCancelled(ctx, i); err != nil {
+			return nil, err
+		}
+		fields, line, err := input.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("foreign table %s: %w", schema.TableName, err)
+		}
+		if header {
+			header = false
+			continue
+		}
+		if len(fields) != len(schema.Columns) {
+			return nil, fmt.Errorf("foreign table %s, line %d: expected %d field(s), got %d", schema.TableName, line, len(schema.Columns), len(fields))
+		}
+
+		row := storage.NewRow(make([]interface{}, len(schema.Columns)))
+		for j, field := range fields {
+			col := schema.Columns[j]
+			if field != nil {
+				value, err := parseCopyValue(*field, col)
+				if err != nil {
+					return nil, fmt.Errorf("foreign table %s, line %d: %w", schema.TableName, line, err)
+				}
+				row.Values[j] = value
+			}
+			if err := storage.ValidateValue(row.Values[j], col); err != nil {
+				return nil, fmt.Errorf("foreign table %s, line %d: %w", schema.TableName, line, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// checkWritable rejects writes to tables whose rows the database does not
+// own or computes, and writes by users to the system tables
+func checkWritable(ctx context.Context, table *storage.Table) error {
+	if table.Schema.Foreign() {
+		return fmt.Errorf("foreign table %s is read-only", table.Schema.TableName)
+	}
+	if table.Schema.Materialized() {
+		return fmt.Errorf("materialized view %s is read-only; use REFRESH MATERIALIZED VIEW", table.Schema.TableName)
+	}
+	if UserFrom(ctx) != "" && isSystemTable(table.Schema.TableName) {
+		return fmt.Errorf("permission denied for system table %s", table.Schema.TableName)
+	}
+	return nil
+}
+
+// readsForeignTable reports whether a SELECT reads a foreign table, whose
+// rows can change without the database seeing a write
+func (e *Executor) readsForeignTable(stmt *parser.SelectStmt) bool {
+	for _, name := range selectTables(stmt) {
+		if table, err := e.storage.GetTable(name); err == nil && table.Schema.Foreign() {
+			return true
+		}
+	}
+	return false
+}
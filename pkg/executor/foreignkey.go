@@ -0,0 +1,486 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// addForeignKeys checks the FOREIGN KEY and REFERENCES clauses of a table
+// being created and adds them to its schema. A foreign key must reference
+// columns of the same types that are the primary key or a UNIQUE key of
+// the referenced table, which may be the table itself.
+func (e *Executor) addForeignKeys(schema *storage.Schema, defs []*parser.ForeignKeyDef) error {
+	for _, def := range defs {
+		parent := schema
+		if def.RefTable != schema.TableName {
+			table, err := e.storage.GetTable(def.RefTable)
+			if err != nil {
+				return err
+			}
+			parent = table.Schema
+		}
+		if schema.Foreign() || parent.Foreign() {
+			return fmt.Errorf("foreign keys are not supported on foreign tables")
+		}
+
+		refColumns := def.RefColumns
+		if len(refColumns) == 0 {
+			if len(parent.PrimaryKeys) == 0 {
+				return fmt.Errorf("table %s has no primary key for a foreign key to reference", parent.TableName)
+			}
+			refColumns = parent.PrimaryKeys
+		}
+		if len(refColumns) != len(def.Columns) {
+			return fmt.Errorf("foreign key (%s) has %d column(s) but references %d", strings.Join(def.Columns, ", "), len(def.Columns), len(refColumns))
+		}
+		for i, name := range def.Columns {
+			idx := schema.GetColumnIndex(name)
+			if idx == -1 {
+				return fmt.Errorf("column %s named in foreign key does not exist", name)
+			}
+			if slices.Index(def.Columns, name) != i {
+				return fmt.Errorf("column %s appears twice in foreign key", name)
+			}
+			refIdx := parent.GetColumnIndex(refColumns[i])
+			if refIdx == -1 {
+				return fmt.Errorf("column %s referenced by foreign key does not exist in table %s", refColumns[i], parent.TableName)
+			}
+			col, ref := schema.Columns[idx], parent.Columns[refIdx]
+			if col.Virtual || ref.Virtual {
+				return fmt.Errorf("foreign keys cannot use virtual columns")
+			}
+			if col.DataType != ref.DataType {
+				return fmt.Errorf("foreign key column %s is %s but %s.%s is %s", col.Name, col.DataType, parent.TableName, ref.Name, ref.DataType)
+			}
+		}
+		if def.OnDelete == storage.OnDeleteSetNull {
+			for _, name := range def.Columns {
+				col := schema.Columns[schema.GetColumnIndex(name)]
+				if col.NotNull || col.PrimaryKey || col.Generated != "" || name == schema.PartitionKey {
+					return fmt.Errorf("ON DELETE SET NULL cannot set column %s to NULL", name)
+				}
+			}
+		}
+		if !isKey(parent, refColumns) {
+			return fmt.Errorf("foreign key must reference the PRIMARY KEY or a UNIQUE key of %s, not (%s)", parent.TableName, strings.Join(refColumns, ", "))
+		}
+
+		name := schema.TableName + "_" + strings.Join(def.Columns, "_") + "_fkey"
+		for _, key := range schema.ForeignKeys {
+			if key.Name == name {
+				return fmt.Errorf("foreign key on (%s) already exists", strings.Join(def.Columns, ", "))
+			}
+		}
+		schema.ForeignKeys = append(schema.ForeignKeys, storage.ForeignKey{
+			Name:       name,
+			Columns:    slices.Clone(def.Columns),
+			RefTable:   parent.TableName,
+			RefColumns: slices.Clone(refColumns),
+			OnDelete:   def.OnDelete,
+		})
+	}
+	return nil
+}
+
+// isKey reports whether no two rows of a table can have the same values in
+// columns: they include a PRIMARY KEY or UNIQUE column, each a key of its
+// own, or all the columns of a UNIQUE constraint
+func isKey(schema *storage.Schema, columns []string) bool {
+	for _, name := range columns {
+		if slices.Contains(schema.PrimaryKeys, name) || slices.Contains(schema.UniqueKeys, name) {
+			return true
+		}
+	}
+	for _, constraint := range schema.UniqueConstraints {
+		if !slices.ContainsFunc(constraint.Columns, func(name string) bool { return !slices.Contains(columns, name) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// reference is a foreign key of child resolved to the column indexes of
+// its columns in child and of the columns it references in parent
+type reference struct {
+	key        storage.ForeignKey
+	child      *storage.Table
+	parent     *storage.Table
+	columns    []int
+	refColumns []int
+}
+
+// resolveReference resolves a foreign key of child
+func (e *Executor) resolveReference(child *storage.Table, key storage.ForeignKey) (*reference, error) {
+	parent, err := e.storage.GetTable(key.RefTable)
+	if err != nil {
+		return nil, fmt.Errorf("foreign key %s: %w", key.Name, err)
+	}
+	ref := &reference{key: key, child: child, parent: parent}
+	for i, name := range key.Columns {
+		idx, refIdx := child.Schema.GetColumnIndex(name), parent.Schema.GetColumnIndex(key.RefColumns[i])
+		if idx == -1 || refIdx == -1 {
+			return nil, fmt.Errorf("foreign key %s names a column that no longer exists", key.Name)
+		}
+		ref.columns = append(ref.columns, idx)
+		ref.refColumns = append(ref.refColumns, refIdx)
+	}
+	return ref, nil
+}
+
+// value returns a key equal for exactly the rows whose values at indices,
+// either the referencing or the referenced columns, are equal under the
+// referenced columns' collations, or false if any of them is NULL
+func (r *reference) value(values []interface{}, indices []int) (string, bool) {
+	var b strings.Builder
+	for i, idx := range indices {
+		if values[idx] == nil {
+			return "", false
+		}
+		s := fmt.Sprint(storage.CollationKey(values[idx], r.parent.Schema.Columns[r.refColumns[i]]))
+		fmt.Fprintf(&b, "%T:%d:%s", values[idx], len(s), s)
+	}
+	return b.String(), true
+}
+
+// describe renders the values at indices for an error, as (a, b)=(1, 2)
+func (r *reference) describe(columns []string, values []interface{}, indices []int) string {
+	shown := make([]string, len(indices))
+	for i, idx := range indices {
+		shown[i] = fmt.Sprint(values[idx])
+	}
+	return "(" + strings.Join(columns, ", ") + ")=(" + strings.Join(shown, ", ") + ")"
+}
+
+// self reports whether the foreign key references its own table, or for a
+// partition the partitioned table it belongs to
+func (r *reference) self() bool {
+	return r.key.RefTable == r.child.Schema.TableName || r.key.RefTable == r.child.Schema.PartitionOf
+}
+
+// referencedKeys returns the values of the rows of a table at indices
+func (e *Executor) referencedKeys(ref *reference, table *storage.Table, indices []int) map[string]bool {
+	keys := make(map[string]bool)
+	for _, target := range e.partitionsFor(table, nil) {
+		for _, row := range target.SelectRows() {
+			if k, ok := ref.value(row.Values, indices); ok {
+				keys[k] = true
+			}
+		}
+	}
+	return keys
+}
+
+// referencesTo returns the foreign keys referencing a table, or the
+// partitioned table a partition belongs to. Those of partitions are left
+// out: their rows are checked through the partitioned table.
+func (e *Executor) referencesTo(table *storage.Table) ([]*reference, error) {
+	names := []string{table.Schema.TableName}
+	if table.Schema.PartitionOf != "" {
+		names = append(names, table.Schema.PartitionOf)
+	}
+	var refs []*reference
+	for _, name := range e.storage.ListTables() {
+		child, err := e.storage.GetTable(name)
+		if err != nil || child.Schema.PartitionOf != "" {
+			continue
+		}
+		for _, key := range child.Schema.ForeignKeys {
+			if !slices.Contains(names, key.RefTable) {
+				continue
+			}
+			ref, err := e.resolveReference(child, key)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, ref)
+		}
+	}
+	return refs, nil
+}
+
+// referenceCheck checks the rows a statement writes to a table: their
+// values in the columns of its foreign keys must be present in the
+// referenced tables, and updates must not change values other tables'
+// rows still reference
+type referenceCheck struct {
+	outbound []*reference
+	present  []map[string]bool // referenced values of each outbound key
+	inbound  []*reference
+	used     []map[string]bool // referencing values of each inbound key
+}
+
+// newReferenceCheck returns the check of the rows written to table, nil
+// when there is nothing to check. For an INSERT changed is nil; for an
+// UPDATE it reports the columns the update may change, and only the
+// foreign keys over them are checked.
+func (e *Executor) newReferenceCheck(table *storage.Table, changed func(idx int) bool) (*referenceCheck, error) {
+	check := &referenceCheck{}
+	for _, key := range table.Schema.ForeignKeys {
+		ref, err := e.resolveReference(table, key)
+		if err != nil {
+			return nil, err
+		}
+		if changed != nil && !slices.ContainsFunc(ref.columns, changed) {
+			continue
+		}
+		check.outbound = append(check.outbound, ref)
+		check.present = append(check.present, e.referencedKeys(ref, ref.parent, ref.refColumns))
+	}
+	if changed != nil {
+		refs, err := e.referencesTo(table)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			if !slices.ContainsFunc(ref.refColumns, changed) {
+				continue
+			}
+			check.inbound = append(check.inbound, ref)
+			check.used = append(check.used, e.referencedKeys(ref, ref.child, ref.columns))
+		}
+	}
+	if len(check.outbound) == 0 && len(check.inbound) == 0 {
+		return nil, nil
+	}
+	return check, nil
+}
+
+// include makes the values of a row being inserted available to the rows
+// inserted with it that reference their own table
+func (c *referenceCheck) include(values []interface{}) {
+	if c == nil {
+		return
+	}
+	for i, ref := range c.outbound {
+		if !ref.self() {
+			continue
+		}
+		if k, ok := ref.value(values, ref.refColumns); ok {
+			c.present[i][k] = true
+		}
+	}
+}
+
+// check checks a row written with values, which for an update had the
+// values before
+func (c *referenceCheck) check(before, values []interface{}) error {
+	if c == nil {
+		return nil
+	}
+	for i, ref := range c.outbound {
+		if k, ok := ref.value(values, ref.columns); ok && !c.present[i][k] {
+			return fmt.Errorf("insert or update on table %s violates foreign key %s: %s is not present in table %s",
+				ref.child.Schema.TableName, ref.key.Name, ref.describe(ref.key.Columns, values, ref.columns), ref.key.RefTable)
+		}
+	}
+	if before == nil {
+		return nil
+	}
+	for i, ref := range c.inbound {
+		old, ok := ref.value(before, ref.refColumns)
+		if !ok || !c.used[i][old] {
+			continue
+		}
+		if k, _ := ref.value(values, ref.refColumns); k != old {
+			return fmt.Errorf("update on table %s violates foreign key %s: %s is still referenced from table %s",
+				ref.key.RefTable, ref.key.Name, ref.describe(ref.key.RefColumns, before, ref.refColumns), ref.child.Schema.TableName)
+		}
+	}
+	return nil
+}
+
+// deletion is the rows a DELETE removes, with the rows of other tables the
+// ON DELETE actions of foreign keys referencing them remove or set to NULL
+type deletion struct {
+	removed map[*storage.Row]bool
+	nulled  map[*storage.Row][]int // columns set to NULL, for rows not removed
+	targets []cascadeTarget        // tables holding cascaded rows, in the order found
+}
+
+// cascadeTarget is a table an ON DELETE action changes rows of: the table
+// itself, or a partition of it
+type cascadeTarget struct {
+	table  *storage.Table
+	target *storage.Table
+}
+
+// planDelete returns what deleting the rows of targets, the tables holding
+// table's rows, that condition matches does. Rows referencing a removed row
+// are removed too through ON DELETE CASCADE, which may remove further rows,
+// or have their referencing columns set to NULL through ON DELETE SET NULL.
+// Otherwise the delete is rejected while they still reference it, unless
+// they are removed by the same statement.
+func (e *Executor) planDelete(table *storage.Table, targets []*storage.Table, condition func(*storage.Row) bool) (*deletion, error) {
+	plan := &deletion{removed: make(map[*storage.Row]bool), nulled: make(map[*storage.Row][]int)}
+	var rows []*storage.Row
+	for _, target := range targets {
+		for _, row := range target.SelectRows() {
+			if condition == nil || condition(row) {
+				plan.removed[row] = true
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	// A rejecting key only fails if the referencing row is not removed
+	// through another key
+	type restriction struct {
+		ref    *reference
+		row    *storage.Row
+		parent []interface{}
+	}
+	var restricted []restriction
+
+	type pending struct {
+		table *storage.Table
+		rows  []*storage.Row
+	}
+	work := []pending{{table, rows}}
+	for len(work) > 0 {
+		next := work[0]
+		work = work[1:]
+		if len(next.rows) == 0 {
+			continue
+		}
+		refs, err := e.referencesTo(next.table)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			keys := make(map[string][]interface{})
+			for _, row := range next.rows {
+				if k, ok := ref.value(row.Values, ref.refColumns); ok {
+					keys[k] = row.Values
+				}
+			}
+			var cascaded []*storage.Row
+			for _, target := range e.partitionsFor(ref.child, nil) {
+				for _, row := range target.SelectRows() {
+					k, ok := ref.value(row.Values, ref.columns)
+					if !ok || keys[k] == nil || plan.removed[row] {
+						continue
+					}
+					switch ref.key.OnDelete {
+					case storage.OnDeleteCascade:
+						plan.removed[row] = true
+						cascaded = append(cascaded, row)
+					case storage.OnDeleteSetNull:
+						plan.nulled[row] = append(plan.nulled[row], ref.columns...)
+					default:
+						restricted = append(restricted, restriction{ref, row, keys[k]})
+						continue
+					}
+					plan.addTarget(ref.child, target)
+				}
+			}
+			work = append(work, pending{ref.child, cascaded})
+		}
+	}
+
+	for _, r := range restricted {
+		if !plan.removed[r.row] {
+			return nil, fmt.Errorf("delete on table %s violates foreign key %s: %s is still referenced from table %s",
+				r.ref.key.RefTable, r.ref.key.Name, r.ref.describe(r.ref.key.RefColumns, r.parent, r.ref.refColumns), r.ref.child.Schema.TableName)
+		}
+	}
+	return plan, nil
+}
+
+// addTarget records that an ON DELETE action changes rows of target
+func (d *deletion) addTarget(table, target *storage.Table) {
+	for _, t := range d.targets {
+		if t.target == target {
+			return
+		}
+	}
+	d.targets = append(d.targets, cascadeTarget{table: table, target: target})
+}
+
+// applyCascades applies the ON DELETE actions of a planned delete, once the
+// statement's own rows are deleted
+func (e *Executor) applyCascades(ctx context.Context, plan *deletion) error {
+	cs := changeSetFrom(ctx)
+	changed := make(map[*storage.Table]bool)
+	for _, t := range plan.targets {
+		schema := t.table.Schema
+
+		// The statement's own rows are already gone
+		removed := func(row *storage.Row) bool { return plan.removed[row] }
+		if cs != nil {
+			removed = trackMatches(removed, func(row *storage.Row) {
+				cs.add(schema, cdc.OpDelete, row.Values, nil)
+			})
+		}
+		var count int
+		if t.target.Schema.SoftDelete {
+			count = t.target.SoftDeleteRows(removed)
+		} else {
+			count = t.target.DeleteRows(removed)
+		}
+
+		var current *storage.Row
+		var matched []*storage.Row
+		var before [][]interface{}
+		updated, err := t.target.UpdateRowsWith(func(row *storage.Row) bool {
+			if plan.removed[row] || plan.nulled[row] == nil {
+				return false
+			}
+			current = row
+			matched = append(matched, row)
+			before = append(before, slices.Clone(row.Values))
+			return true
+		}, func(values []interface{}) error {
+			for _, idx := range plan.nulled[current] {
+				values[idx] = nil
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("ON DELETE SET NULL on table %s: %w", schema.TableName, err)
+		}
+		if cs != nil {
+			for i, row := range matched {
+				cs.add(schema, cdc.OpUpdate, before[i], row.Values)
+			}
+		}
+
+		if count > 0 || updated > 0 {
+			changed[t.table] = true
+			if schema.Partitioned() {
+				t.table.MarkDirty()
+			}
+			e.stats.recordTable(schema.TableName, func(s *TableStats) {
+				s.RowsDeleted += int64(count)
+				s.RowsUpdated += int64(updated)
+			})
+		}
+	}
+	for table := range changed {
+		e.invalidateResults(table.Schema.TableName)
+	}
+	return nil
+}
+
+// checksReferences reports whether stmt writes to a table with foreign
+// keys or referenced by one. Their checks read other tables, so such
+// writes must not interleave with others.
+func (e *Executor) checksReferences(stmt parser.Statement) bool {
+	if isReadOnly(stmt) {
+		return false
+	}
+	table, err := e.storage.GetTable(targetTable(stmt))
+	if err != nil {
+		return false
+	}
+	if len(table.Schema.ForeignKeys) > 0 {
+		return true
+	}
+	refs, err := e.referencesTo(table)
+	return err == nil && len(refs) > 0
+}
@@ -0,0 +1,270 @@
+package executor
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// function is a built-in SQL function
+type function struct {
+	args     int  // number of arguments
+	variadic bool // args is the least number of arguments, not the exact one
+	volatile bool // result can differ between calls with the same arguments
+	call     func(args []interface{}) (interface{}, error)
+}
+
+// functions holds the built-in functions by upper-cased name
+var functions = map[string]function{
+	"RANDOM": {
+		volatile: true,
+		call: func(args []interface{}) (interface{}, error) {
+			return rand.Float64(), nil
+		},
+	},
+	// NOW() in INSERT, UPDATE and DELETE is replaced by the statement's
+	// time before it runs; elsewhere it is the time of the call
+	"NOW": {
+		volatile: true,
+		call: func(args []interface{}) (interface{}, error) {
+			return storage.FormatTimestamp(time.Now()), nil
+		},
+	},
+	// UUID() and GEN_RANDOM_UUID() return a random UUID, which INSERT and
+	// UPDATE resolve before they run like NOW()
+	"UUID": {
+		volatile: true,
+		call: func(args []interface{}) (interface{}, error) {
+			return storage.NewUUID(), nil
+		},
+	},
+	"GEN_RANDOM_UUID": {
+		volatile: true,
+		call: func(args []interface{}) (interface{}, error) {
+			return storage.NewUUID(), nil
+		},
+	},
+	"INTERVAL": {
+		args: 1,
+		call: func(args []interface{}) (interface{}, error) {
+			text, ok := args[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("INTERVAL takes a string, got %T", args[0])
+			}
+			return parseInterval(text)
+		},
+	},
+	"DATE": {
+		args: 1,
+		call: func(args []interface{}) (interface{}, error) {
+			text, ok := args[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("DATE takes a string, got %T", args[0])
+			}
+			t, err := storage.ParseTimestamp(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DATE %q", text)
+			}
+			return storage.FormatDate(t), nil
+		},
+	},
+	// CAST(<expr> AS <type>) is read as a call of CAST() with the type name
+	"CAST": {
+		args: 2,
+		call: func(args []interface{}) (interface{}, error) {
+			typeName, ok := args[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("CAST takes a type name, got %T", args[1])
+			}
+			return castValue(args[0], typeName)
+		},
+	},
+	// COALESCE(a, b, ...) is its first argument that is not NULL
+	"COALESCE": {
+		args:     1,
+		variadic: true,
+		call: func(args []interface{}) (interface{}, error) {
+			for _, arg := range args {
+				if arg != nil {
+					return arg, nil
+				}
+			}
+			return nil, nil
+		},
+	},
+	// NULLIF(a, b) is NULL when a equals b, and a otherwise
+	"NULLIF": {
+		args: 2,
+		call: func(args []interface{}) (interface{}, error) {
+			if args[0] == nil || args[1] == nil {
+				return args[0], nil
+			}
+			c, err := storage.CompareValues(args[0], args[1], "")
+			if err != nil {
+				return nil, fmt.Errorf("NULLIF: %w", err)
+			}
+			if c == 0 {
+				return nil, nil
+			}
+			return args[0], nil
+		},
+	},
+	// UNHEX('4869') and FROM_BASE64('SGk=') are the BLOB the text spells,
+	// and HEX() and TO_BASE64() spell a BLOB as text
+	"UNHEX":       {args: 1, call: decodeBlob("UNHEX", hex.DecodeString)},
+	"FROM_BASE64": {args: 1, call: decodeBlob("FROM_BASE64", base64.StdEncoding.DecodeString)},
+	"HEX":         {args: 1, call: encodeBlob("HEX", hex.EncodeToString)},
+	"TO_BASE64":   {args: 1, call: encodeBlob("TO_BASE64", base64.StdEncoding.EncodeToString)},
+
+	"NEXTVAL": {args: 1, call: sequenceOutsideInsert("NEXTVAL")},
+	"CURRVAL": {args: 1, call: sequenceOutsideInsert("CURRVAL")},
+}
+
+// decodeBlob returns a function reading a BLOB from text with decode.
+// NULL stays NULL.
+func decodeBlob(name string, decode func(string) ([]byte, error)) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		text, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s takes a string, got %s", name, sqlTypeName(args[0]))
+		}
+		data, err := decode(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid input %q", name, text)
+		}
+		return storage.Blob(data), nil
+	}
+}
+
+// encodeBlob returns a function writing a BLOB as text with encode. NULL
+// stays NULL.
+func encodeBlob(name string, encode func([]byte) string) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		blob, ok := args[0].(storage.Blob)
+		if !ok {
+			return nil, fmt.Errorf("%s takes a BLOB, got %s", name, sqlTypeName(args[0]))
+		}
+		return encode(blob.Bytes()), nil
+	}
+}
+
+// sequenceOutsideInsert fails a sequence function call that was not
+// resolved before its statement ran
+func sequenceOutsideInsert(name string) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("%s() can only be used in INSERT ... VALUES", name)
+	}
+}
+
+// callFunction calls a built-in function with evaluated arguments
+func callFunction(name string, args []interface{}) (interface{}, error) {
+	fn, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function: %s", name)
+	}
+	if fn.variadic && len(args) < fn.args {
+		return nil, fmt.Errorf("%s() takes at least %d argument(s), got %d", name, fn.args, len(args))
+	}
+	if !fn.variadic && len(args) != fn.args {
+		return nil, fmt.Errorf("%s() takes %d argument(s), got %d", name, fn.args, len(args))
+	}
+	return fn.call(args)
+}
+
+// checkDeterministic rejects volatile functions in statements written to
+// the WAL, which is replayed statement by statement and must produce the
+// same rows on replicas and during recovery
+func checkDeterministic(stmt parser.Statement) error {
+	var exprs []parser.Expression
+	switch s := stmt.(type) {
+	case *parser.InsertStmt:
+		for _, values := range s.Values {
+			exprs = append(exprs, values...)
+		}
+		if s.Select != nil {
+			if s.Select.Sample != nil && !s.Select.Sample.Repeatable {
+				return fmt.Errorf("TABLESAMPLE without REPEATABLE cannot be used in INSERT statements")
+			}
+			exprs = append(exprs, selectConditions(s.Select)...)
+		}
+		if s.OnConflict != nil {
+			for _, expr := range s.OnConflict.Set {
+				exprs = append(exprs, expr)
+			}
+		}
+	case *parser.UpdateStmt:
+		for _, expr := range s.Set {
+			exprs = append(exprs, expr)
+		}
+		exprs = append(exprs, s.Where)
+	case *parser.DeleteStmt:
+		exprs = append(exprs, s.Where)
+	case *parser.MergeStmt:
+		exprs = mergeExpressions(s)
+	case *parser.CreateMaterializedViewStmt:
+		// REFRESH reruns the query, so it must give the same rows on replay
+		if s.Select.Sample != nil && !s.Select.Sample.Repeatable {
+			return fmt.Errorf("TABLESAMPLE without REPEATABLE cannot be used in materialized views")
+		}
+		exprs = append(exprs, selectConditions(s.Select)...)
+	}
+
+	// Subqueries are checked with the statement, as are theirs in turn
+	for i := 0; i < len(exprs); i++ {
+		for _, sub := range expressionSubqueries(exprs[i]) {
+			exprs = append(exprs, selectConditions(sub)...)
+		}
+	}
+
+	var err error
+	for _, expr := range exprs {
+		parser.WalkExpression(expr, func(expr parser.Expression) {
+			if call, ok := expr.(*parser.FunctionCall); ok && err == nil && functions[call.Name].volatile {
+				err = fmt.Errorf("%s() cannot be used in %s statements", call.Name, statementType(stmt))
+			}
+		})
+	}
+	return err
+}
+
+// sampleRows applies a TABLESAMPLE clause to the rows of a table scan.
+// Percentage samples keep each row independently; ROWS samples keep a
+// uniformly chosen subset of exactly n rows using reservoir sampling, so
+// memory is bounded by the sample size.
+func sampleRows(rows []*storage.Row, sample *parser.TableSample) []*storage.Row {
+	rng := rand.New(rand.NewSource(rand.Int63()))
+	if sample.Repeatable {
+		rng = rand.New(rand.NewSource(sample.Seed))
+	}
+
+	if sample.Method != "ROWS" {
+		kept := []*storage.Row{}
+		for _, row := range rows {
+			if rng.Float64()*100 < sample.Percent {
+				kept = append(kept, row)
+			}
+		}
+		return kept
+	}
+
+	reservoir := make([]*storage.Row, 0, min(sample.Rows, len(rows)))
+	for i, row := range rows {
+		if i < sample.Rows {
+			reservoir = append(reservoir, row)
+		} else if j := rng.Intn(i + 1); j < sample.Rows {
+			reservoir[j] = row
+		}
+	}
+	return reservoir
+}
@@ -0,0 +1,239 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// generatedColumn converts a GENERATED ALWAYS AS clause into the column's
+// stored definition. The expression may only use literals, arithmetic and
+// ordinary columns defined earlier in the table.
+func generatedColumn(schema *storage.Schema, colDef *parser.ColumnDef, col *storage.Column) error {
+	if err := checkGeneratedExpression(schema, colDef.Generated); err != nil {
+		return fmt.Errorf("generated column %s: %w", colDef.Name, err)
+	}
+	if colDef.PrimaryKey {
+		return fmt.Errorf("generated column %s cannot be a PRIMARY KEY", colDef.Name)
+	}
+	if colDef.Virtual && (colDef.Unique || colDef.NotNull) {
+		return fmt.Errorf("virtual column %s cannot have UNIQUE or NOT NULL constraints", colDef.Name)
+	}
+
+	col.Generated = parser.FormatExpression(colDef.Generated)
+	col.Virtual = colDef.Virtual
+	return nil
+}
+
+// checkGeneratedExpression rejects expressions a generated column cannot
+// be computed from
+func checkGeneratedExpression(schema *storage.Schema, expr parser.Expression) error {
+	switch ex := expr.(type) {
+	case *parser.Literal, *parser.NullLiteral:
+		return nil
+	case *parser.Identifier:
+		idx := schema.GetColumnIndex(ex.Value)
+		if idx == -1 {
+			return fmt.Errorf("column %s does not exist", ex.Value)
+		}
+		if schema.Columns[idx].Generated != "" {
+			return fmt.Errorf("cannot reference generated column %s", ex.Value)
+		}
+		return nil
+	case *parser.BinaryExpr:
+		if !isValueOperator(ex.Operator) {
+			return fmt.Errorf("operator %s is not allowed", ex.Operator)
+		}
+		if err := checkGeneratedExpression(schema, ex.Left); err != nil {
+			return err
+		}
+		return checkGeneratedExpression(schema, ex.Right)
+	case *parser.FunctionCall:
+		switch ex.Name {
+		case "CAST":
+			return checkGeneratedExpression(schema, ex.Args[0])
+		case "COALESCE", "NULLIF":
+			for _, arg := range ex.Args {
+				if err := checkGeneratedExpression(schema, arg); err != nil {
+					return err
+				}
+			}
+			return nil
+		default:
+			return fmt.Errorf("function %s is not allowed", ex.Name)
+		}
+	case *parser.VariableRef:
+		return fmt.Errorf("session variables are not allowed")
+	default:
+		return fmt.Errorf("unsupported expression")
+	}
+}
+
+// generatedExpression returns the parsed definition of a generated column,
+// or of a column's DEFAULT
+func (e *Executor) generatedExpression(definition string) (parser.Expression, error) {
+	if expr, ok := e.generated.Load(definition); ok {
+		return expr.(parser.Expression), nil
+	}
+	expr, err := parser.ParseExpression(definition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated column definition %q: %w", definition, err)
+	}
+	e.generated.Store(definition, expr)
+	return expr, nil
+}
+
+// computeGenerated fills in the generated columns of values that are
+// stored (virtual == false) or computed at read time (virtual == true)
+func (e *Executor) computeGenerated(ctx context.Context, schema *storage.Schema, values []interface{}, virtual bool) error {
+	row := storage.NewRow(values)
+	for i, col := range schema.Columns {
+		if col.Generated == "" || col.Virtual != virtual {
+			continue
+		}
+		expr, err := e.generatedExpression(col.Generated)
+		if err != nil {
+			return err
+		}
+		value, err := e.getColumnValue(ctx, expr, row, schema)
+		if err != nil {
+			return fmt.Errorf("generated column %s: %w", col.Name, err)
+		}
+		// Integer arithmetic feeding a FLOAT column is widened
+		if value, err = storage.NormalizeValue(value, col); err != nil {
+			return fmt.Errorf("generated column %s: %w", col.Name, err)
+		}
+		values[i] = value
+	}
+	return nil
+}
+
+// withVirtual returns row with its virtual columns computed. Rows of tables
+// without virtual columns are returned as is.
+func (e *Executor) withVirtual(ctx context.Context, schema *storage.Schema, row *storage.Row) (*storage.Row, error) {
+	if !hasVirtualColumns(schema) {
+		return row, nil
+	}
+	values := append([]interface{}{}, row.Values...)
+	if err := e.computeGenerated(ctx, schema, values, true); err != nil {
+		return nil, err
+	}
+	return storage.NewRow(values), nil
+}
+
+// withVirtualRows applies withVirtual to every row
+func (e *Executor) withVirtualRows(ctx context.Context, schema *storage.Schema, rows []*storage.Row) ([]*storage.Row, error) {
+	if !hasVirtualColumns(schema) {
+		return rows, nil
+	}
+	computed := make([]*storage.Row, len(rows))
+	for i, row := range rows {
+		if err := checkCancelled(ctx, i); err != nil {
+			return nil, err
+		}
+		var err error
+		if computed[i], err = e.withVirtual(ctx, schema, row); err != nil {
+			return nil, err
+		}
+	}
+	return computed, nil
+}
+
+// hasVirtualColumns reports whether any column of schema is computed at
+// read time
+func hasVirtualColumns(schema *storage.Schema) bool {
+	for _, col := range schema.Columns {
+		if col.Virtual {
+			return true
+		}
+	}
+	return false
+}
+
+// isValueOperator reports whether operator computes a value from its
+// operands, as arithmetic and JSON extraction do, rather than comparing them
+func isValueOperator(operator string) bool {
+	return isArithmetic(operator) || isJSONOperator(operator)
+}
+
+// applyOperator applies a value operator
+func applyOperator(left, right interface{}, operator string) (interface{}, error) {
+	if isJSONOperator(operator) {
+		return jsonExtract(left, right, operator)
+	}
+	return arithmetic(left, right, operator)
+}
+
+// isArithmetic reports whether operator is an arithmetic operator
+func isArithmetic(operator string) bool {
+	switch operator {
+	case "+", "-", "*", "/":
+		return true
+	}
+	return false
+}
+
+// arithmetic applies an arithmetic operator. Integer operands give an
+// integer result, any float operand gives a float, and NULL propagates.
+// Timestamps and intervals add and subtract as intervalArithmetic describes.
+func arithmetic(left, right interface{}, operator string) (interface{}, error) {
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	if result, ok, err := intervalArithmetic(left, right, operator); ok {
+		return result, err
+	}
+
+	l, lok := left.(int)
+	r, rok := right.(int)
+	if lok && rok {
+		switch operator {
+		case "+":
+			return l + r, nil
+		case "-":
+			return l - r, nil
+		case "*":
+			return l * r, nil
+		case "/":
+			if r == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return l / r, nil
+		}
+	}
+
+	lf, lok := toFloat(left)
+	rf, rok := toFloat(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot apply %s to %T and %T", operator, left, right)
+	}
+	switch operator {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return lf / rf, nil
+	}
+	return nil, fmt.Errorf("unsupported operator: %s", operator)
+}
+
+// toFloat converts a numeric value to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+	return 0, false
+}
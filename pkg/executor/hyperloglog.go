@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/bits"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// HyperLogLog precision: 2^14 one-byte registers give estimates within
+// about 0.8% (one standard error) in 16 KiB, however many values are added
+const (
+	hllPrecision = 14
+	hllRegisters = 1 << hllPrecision
+)
+
+// hyperLogLog estimates the number of distinct values added to it. Each
+// value's hash picks a register by its top bits, and the register keeps the
+// longest run of leading zeros seen in the remaining bits; many distinct
+// values make long runs likely, so the registers together estimate how
+// many there were.
+type hyperLogLog struct {
+	registers [hllRegisters]uint8
+}
+
+// add records a hashed value
+func (h *hyperLogLog) add(hash uint64) {
+	register := hash >> (64 - hllPrecision)
+	// The sentinel bit caps the run at the bits left after the index
+	rest := hash<<hllPrecision | 1<<(hllPrecision-1)
+	rank := uint8(bits.LeadingZeros64(rest)) + 1
+	if rank > h.registers[register] {
+		h.registers[register] = rank
+	}
+}
+
+// estimate returns the estimated number of distinct values added
+func (h *hyperLogLog) estimate() int {
+	m := float64(hllRegisters)
+	sum := 0.0
+	zeros := 0
+	for _, rank := range h.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// Small counts leave registers empty, and counting those is more
+	// accurate than the harmonic mean
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(math.Round(estimate))
+}
+
+// hashDistinct hashes a column value so that values equal under the
+// column's type and collation hash alike
+func hashDistinct(value interface{}, col storage.Column) uint64 {
+	h := fnv.New64a()
+	var buf [9]byte
+	switch v := storage.CollationKey(value, col).(type) {
+	case string:
+		h.Write([]byte{'s'})
+		h.Write([]byte(v))
+	case int:
+		buf[0] = 'i'
+		binary.BigEndian.PutUint64(buf[1:], uint64(v))
+		h.Write(buf[:])
+	case float64:
+		if v == 0 {
+			v = 0 // -0 equals 0
+		}
+		buf[0] = 'f'
+		binary.BigEndian.PutUint64(buf[1:], math.Float64bits(v))
+		h.Write(buf[:])
+	default:
+		fmt.Fprintf(h, "%T:%v", v, v)
+	}
+	return mixHash(h.Sum64())
+}
+
+// mixHash spreads the bits of an FNV hash, whose high bits barely change
+// between similar short inputs, across the whole word (the splitmix64
+// finalizer)
+func mixHash(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,135 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// executeShowIndexes executes SHOW INDEXES FROM statement
+func (e *Executor) executeShowIndexes(stmt *parser.ShowIndexesStmt) (*Result, error) {
+	indexes, err := e.storage.Indexes(stmt.TableName)
+	if err != nil {
+		return nil, fmt.Errorf("table %s does not exist", stmt.TableName)
+	}
+
+	result := &Result{
+		Columns: []string{"name", "columns", "unique", "primary", "type"},
+		Rows:    [][]interface{}{},
+	}
+	for _, index := range indexes {
+		result.Rows = append(result.Rows, []interface{}{
+			index.Name, strings.Join(index.Columns, ", "), index.Unique, index.Primary, index.Type,
+		})
+	}
+	result.RowsAffected = len(result.Rows)
+	return result, nil
+}
+
+// indexScan returns the rows of a SELECT's table that the index of a
+// PRIMARY KEY or UNIQUE column finds for an equality or range its WHERE
+// clause requires, and whether such an index could be used. The rows still
+// have to be filtered by the WHERE clause.
+func (e *Executor) indexScan(table *storage.Table, stmt *parser.SelectStmt) ([]*storage.Row, bool) {
+	if !indexable(table.Schema, stmt) {
+		return nil, false
+	}
+	if column, value := indexedEquality(table.Schema, stmt.Where); column != "" {
+		return e.storage.LookupRows(table, column, value)
+	}
+	if column, lower, upper := indexedRange(table.Schema, stmt.Where); column != "" {
+		return e.storage.LookupRange(table, column, lower, upper)
+	}
+	return nil, false
+}
+
+// indexable reports whether a SELECT reads its table in a way an index can
+// answer: without joins, sampling or soft-deleted rows, from a table
+// holding its own rows
+func indexable(schema *storage.Schema, stmt *parser.SelectStmt) bool {
+	return len(stmt.Joins) == 0 && stmt.Sample == nil && !stmt.WithDeleted &&
+		!schema.Partitioned() && !schema.Foreign()
+}
+
+// indexedColumn returns the column whose index indexScan searches for
+// where, or "" if it searches none
+func indexedColumn(schema *storage.Schema, where parser.Expression) string {
+	if column, _ := indexedEquality(schema, where); column != "" {
+		return column
+	}
+	column, _, _ := indexedRange(schema, where)
+	return column
+}
+
+// indexedEquality returns a PRIMARY KEY or UNIQUE column and the literal
+// where requires it to equal, looking through AND, or "" if there is none
+func indexedEquality(schema *storage.Schema, where parser.Expression) (string, interface{}) {
+	for i, col := range schema.Columns {
+		if !col.PrimaryKey && !col.Unique || col.Virtual {
+			continue
+		}
+		for _, r := range keyRanges(schema, i, where) {
+			if r.operator == "=" && r.value != nil {
+				return col.Name, r.value
+			}
+		}
+	}
+	return "", nil
+}
+
+// indexedRange returns a PRIMARY KEY or UNIQUE column and the tightest
+// bounds where's <, <=, > and >= comparisons of it with literals require,
+// looking through AND, or "" if there are none. A nil bound is open.
+func indexedRange(schema *storage.Schema, where parser.Expression) (string, *storage.IndexBound, *storage.IndexBound) {
+	for i, col := range schema.Columns {
+		if !col.PrimaryKey && !col.Unique || col.Virtual {
+			continue
+		}
+		var lower, upper *storage.IndexBound
+		for _, r := range keyRanges(schema, i, where) {
+			if r.value == nil {
+				continue
+			}
+			switch r.operator {
+			case ">", ">=":
+				bound := &storage.IndexBound{Value: r.value, Inclusive: r.operator == ">="}
+				if lower == nil || tighter(bound, lower, col, 1) {
+					lower = bound
+				}
+			case "<", "<=":
+				bound := &storage.IndexBound{Value: r.value, Inclusive: r.operator == "<="}
+				if upper == nil || tighter(bound, upper, col, -1) {
+					upper = bound
+				}
+			}
+		}
+		if lower != nil || upper != nil {
+			return col.Name, lower, upper
+		}
+	}
+	return "", nil, nil
+}
+
+// tighter reports whether bound narrows a range more than current does: it
+// lies further in direction (1 for lower bounds, -1 for upper), or at the
+// same value excluding it. Bounds that cannot be compared are not tighter.
+func tighter(bound, current *storage.IndexBound, col storage.Column, direction int) bool {
+	c, err := storage.CompareValues(bound.Value, current.Value, col.CompareCollation())
+	if err != nil {
+		return false
+	}
+	return c*direction > 0 || c == 0 && !bound.Inclusive
+}
+
+// indexName returns the name SHOW INDEXES gives the index of a column
+func (e *Executor) indexName(tableName, column string) string {
+	indexes, _ := e.storage.Indexes(tableName)
+	for _, index := range indexes {
+		if len(index.Columns) == 1 && index.Columns[0] == column {
+			return index.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,227 @@
+package executor
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// Interval is a span of time, the value of INTERVAL '...'. As in
+// PostgreSQL, months and days are kept apart from the rest because their
+// length depends on the timestamp they are added to.
+type Interval struct {
+	Months int
+	Days   int
+	Time   time.Duration
+}
+
+// intervalUnits maps the units an interval may be written in to how many
+// months, days or nanoseconds one of them is
+var intervalUnits = map[string]struct {
+	months, days int
+	time         time.Duration
+}{
+	"microsecond": {time: time.Microsecond},
+	"millisecond": {time: time.Millisecond},
+	"second":      {time: time.Second},
+	"sec":         {time: time.Second},
+	"minute":      {time: time.Minute},
+	"min":         {time: time.Minute},
+	"hour":        {time: time.Hour},
+	"day":         {days: 1},
+	"week":        {days: 7},
+	"month":       {months: 1},
+	"mon":         {months: 1},
+	"year":        {months: 12},
+}
+
+// parseInterval parses an interval written as '<n> <unit> ...', such as
+// '7 days' or '1 year 2 months', optionally followed or replaced by a time
+// of day such as '01:30' or '1 day 12:00:00'. Units may be plural and
+// numbers negative; only units of a day or less take fractions.
+func parseInterval(text string) (Interval, error) {
+	var iv Interval
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 0 {
+		return iv, fmt.Errorf("invalid interval %q", text)
+	}
+
+	for i := 0; i < len(fields); i++ {
+		if strings.Contains(fields[i], ":") {
+			d, err := parseClock(fields[i])
+			if err != nil {
+				return iv, fmt.Errorf("invalid interval %q: %w", text, err)
+			}
+			iv.Time += d
+			continue
+		}
+
+		n, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil || i+1 == len(fields) {
+			return iv, fmt.Errorf("invalid interval %q: expected <number> <unit>", text)
+		}
+		i++
+		unit, ok := intervalUnits[strings.TrimSuffix(fields[i], "s")]
+		if !ok {
+			unit, ok = intervalUnits[fields[i]]
+		}
+		if !ok {
+			return iv, fmt.Errorf("invalid interval %q: unknown unit %s", text, fields[i])
+		}
+
+		switch {
+		case unit.months != 0:
+			if n != math.Trunc(n) {
+				return iv, fmt.Errorf("invalid interval %q: months and years must be whole", text)
+			}
+			iv.Months += int(n) * unit.months
+		case unit.days != 0:
+			days := n * float64(unit.days)
+			iv.Days += int(days)
+			iv.Time += time.Duration((days - math.Trunc(days)) * float64(24*time.Hour))
+		default:
+			iv.Time += time.Duration(n * float64(unit.time))
+		}
+	}
+	return iv, nil
+}
+
+// parseClock parses a time of day written as [-]HH:MM[:SS[.fraction]]
+func parseClock(text string) (time.Duration, error) {
+	negative := strings.HasPrefix(text, "-")
+	parts := strings.Split(strings.TrimPrefix(text, "-"), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time %s", text)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second}[:len(parts)] {
+		n, err := strconv.ParseFloat(parts[i], 64)
+		if err != nil || n < 0 || i < 2 && strings.Contains(parts[i], ".") {
+			return 0, fmt.Errorf("invalid time %s", text)
+		}
+		d += time.Duration(n * float64(unit))
+	}
+	if negative {
+		d = -d
+	}
+	return d, nil
+}
+
+// String renders an interval the way PostgreSQL does, e.g. '1 year 2 mons
+// 3 days 04:05:06'
+func (iv Interval) String() string {
+	parts := []string{}
+	plural := func(n int, unit string) {
+		if n == 1 || n == -1 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, unit))
+		} else if n != 0 {
+			parts = append(parts, fmt.Sprintf("%d %ss", n, unit))
+		}
+	}
+	plural(iv.Months/12, "year")
+	plural(iv.Months%12, "mon")
+	plural(iv.Days, "day")
+
+	if iv.Time != 0 || len(parts) == 0 {
+		d, sign := iv.Time, ""
+		if d < 0 {
+			d, sign = -d, "-"
+		}
+		clock := fmt.Sprintf("%s%02d:%02d:%02d", sign, int(d/time.Hour), int(d%time.Hour/time.Minute), int(d%time.Minute/time.Second))
+		if micros := d % time.Second / time.Microsecond; micros != 0 {
+			clock += strings.TrimRight(fmt.Sprintf(".%06d", micros), "0")
+		}
+		parts = append(parts, clock)
+	}
+	return strings.Join(parts, " ")
+}
+
+// approximate returns the length of an interval counting a month as 30
+// days, which is how PostgreSQL orders intervals
+func (iv Interval) approximate() time.Duration {
+	return time.Duration(iv.Months*30+iv.Days)*24*time.Hour + iv.Time
+}
+
+// negate returns the interval pointing the other way
+func (iv Interval) negate() Interval {
+	return Interval{Months: -iv.Months, Days: -iv.Days, Time: -iv.Time}
+}
+
+// intervalArithmetic applies + or - where an operand is an interval, or
+// subtracts two timestamps, reporting false when neither applies. A
+// timestamp plus or minus an interval is a timestamp, two intervals add up
+// to an interval and the difference of two timestamps is an interval of
+// days and time.
+func intervalArithmetic(left, right interface{}, operator string) (interface{}, bool, error) {
+	li, lok := left.(Interval)
+	ri, rok := right.(Interval)
+	if !lok && !rok {
+		ls, lok := left.(string)
+		rs, rok := right.(string)
+		if !lok || !rok || operator != "-" {
+			return nil, false, nil
+		}
+		lt, lerr := storage.ParseTimestamp(ls)
+		rt, rerr := storage.ParseTimestamp(rs)
+		if lerr != nil || rerr != nil {
+			return nil, false, nil
+		}
+		diff := lt.Sub(rt)
+		return Interval{Days: int(diff / (24 * time.Hour)), Time: diff % (24 * time.Hour)}, true, nil
+	}
+
+	if operator != "+" && operator != "-" {
+		return nil, true, fmt.Errorf("cannot apply %s to an INTERVAL", operator)
+	}
+	switch {
+	case lok && rok:
+		if operator == "-" {
+			ri = ri.negate()
+		}
+		return Interval{Months: li.Months + ri.Months, Days: li.Days + ri.Days, Time: li.Time + ri.Time}, true, nil
+	case rok:
+		if operator == "-" {
+			ri = ri.negate()
+		}
+		result, err := addInterval(left, ri)
+		return result, true, err
+	default:
+		if operator == "-" {
+			return nil, true, fmt.Errorf("cannot subtract a timestamp from an INTERVAL")
+		}
+		result, err := addInterval(right, li)
+		return result, true, err
+	}
+}
+
+// addInterval adds an interval to a timestamp value: months first, then
+// days, then the rest, so '2024-01-31' + '1 month' is '2024-02-29'
+func addInterval(value interface{}, iv Interval) (interface{}, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("cannot add an INTERVAL to %T", value)
+	}
+	t, err := storage.ParseTimestamp(s)
+	if err != nil {
+		return nil, err
+	}
+	if iv.Months != 0 {
+		// Clamp to the last day of the month, as PostgreSQL does, rather
+		// than overflowing into the next one like time.AddDate
+		year, month, day := t.Date()
+		first := time.Date(year, month+time.Month(iv.Months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+		last := first.AddDate(0, 1, -1).Day()
+		t = first.AddDate(0, 0, min(day, last)-1)
+	}
+	return storage.FormatTimestamp(t.AddDate(0, 0, iv.Days).Add(iv.Time)), nil
+}
+
+// compareIntervals orders two intervals by their approximate length
+func compareIntervals(left, right Interval) int {
+	return cmp.Compare(left.approximate(), right.approximate())
+}
@@ -0,0 +1,138 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cron"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// Job is a statement the server runs on a cron schedule
+type Job struct {
+	Name     string
+	Schedule string
+	Query    string
+}
+
+// executeCreateJob executes CREATE JOB statement
+func (e *Executor) executeCreateJob(ctx context.Context, stmt *parser.CreateJobStmt) (*Result, error) {
+	if _, err := cron.Parse(stmt.Schedule); err != nil {
+		return nil, err
+	}
+	switch stmt.Statement.(type) {
+	case *parser.CreateJobStmt, *parser.DropJobStmt, *parser.CopyStmt:
+		return nil, fmt.Errorf("a job cannot run %s", statementType(stmt.Statement))
+	}
+	if isLogged(stmt.Statement) {
+		if err := checkDeterministic(stmt.Statement); err != nil {
+			return nil, err
+		}
+	}
+
+	table, err := e.systemTable(JobsTable)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.systemTable(JobRunsTable); err != nil {
+		return nil, err
+	}
+	for _, row := range table.SelectRows() {
+		if row.Values[0] == stmt.Name {
+			return nil, fmt.Errorf("job %s already exists", stmt.Name)
+		}
+	}
+
+	row := storage.NewRow([]interface{}{stmt.Name, stmt.Schedule, stmt.Query})
+	if err := table.InsertRow(row); err != nil {
+		return nil, err
+	}
+	if cs := changeSetFrom(ctx); cs != nil {
+		cs.add(table.Schema, cdc.OpInsert, nil, row.Values)
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: fmt.Sprintf("Job %s created", stmt.Name)}, nil
+}
+
+// executeDropJob executes DROP JOB statement
+func (e *Executor) executeDropJob(ctx context.Context, stmt *parser.DropJobStmt) (*Result, error) {
+	table, err := e.storage.GetTable(JobsTable)
+	if err != nil {
+		return nil, fmt.Errorf("job %s does not exist", stmt.Name)
+	}
+	cs := changeSetFrom(ctx)
+	count := table.DeleteRows(func(row *storage.Row) bool {
+		if row.Values[0] != stmt.Name {
+			return false
+		}
+		if cs != nil {
+			cs.add(table.Schema, cdc.OpDelete, row.Values, nil)
+		}
+		return true
+	})
+	if count == 0 {
+		return nil, fmt.Errorf("job %s does not exist", stmt.Name)
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: fmt.Sprintf("Job %s dropped", stmt.Name)}, nil
+}
+
+// Jobs returns the jobs created with CREATE JOB
+func (e *Executor) Jobs() ([]Job, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	table, err := e.storage.GetTable(JobsTable)
+	if err != nil {
+		return nil, nil // no job was ever created
+	}
+	jobs := []Job{}
+	for _, row := range table.SelectRows() {
+		name, _ := row.Values[0].(string)
+		schedule, _ := row.Values[1].(string)
+		query, _ := row.Values[2].(string)
+		jobs = append(jobs, Job{Name: name, Schedule: schedule, Query: query})
+	}
+	return jobs, nil
+}
+
+// RunJob runs a job's statement and records the run in system_job_runs. It
+// returns the statement's error, if any.
+func (e *Executor) RunJob(ctx context.Context, job Job) error {
+	start := time.Now()
+	result, err := e.Query(ctx, job.Query)
+	elapsed := time.Since(start)
+
+	status, message := "ok", ""
+	switch {
+	case err != nil:
+		status, message = "error", err.Error()
+	case result.Message != "":
+		message = result.Message
+	default:
+		message = fmt.Sprintf("%d row(s) returned", result.RowsAffected)
+	}
+
+	values := []interface{}{job.Name, start.UTC().Format(time.RFC3339), int(elapsed.Milliseconds()), status, message}
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literal, litErr := parser.FormatLiteral(value)
+		if litErr != nil {
+			return litErr
+		}
+		literals[i] = literal
+	}
+	record := fmt.Sprintf("INSERT INTO %s VALUES (%s)", JobRunsTable, strings.Join(literals, ", "))
+	if _, recErr := e.Query(ctx, record); recErr != nil {
+		return fmt.Errorf("failed to record run of job %s: %w", job.Name, recErr)
+	}
+	return err
+}
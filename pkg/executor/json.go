@@ -0,0 +1,111 @@
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+)
+
+// isJSONOperator reports whether operator extracts part of a JSON value:
+// -> returns it as JSON, ->> as a plain value
+func isJSONOperator(operator string) bool {
+	return operator == "->" || operator == "->>"
+}
+
+// jsonExtract applies -> or ->> to a JSON document. The path is an object
+// key or an array index; a missing member, like a NULL operand, gives NULL.
+func jsonExtract(document, path interface{}, operator string) (interface{}, error) {
+	if document == nil || path == nil {
+		return nil, nil
+	}
+	text, ok := document.(string)
+	if !ok {
+		return nil, fmt.Errorf("operator %s expects JSON, got %T", operator, document)
+	}
+
+	var member json.RawMessage
+	switch key := path.(type) {
+	case string:
+		var object map[string]json.RawMessage
+		if json.Unmarshal([]byte(text), &object) != nil {
+			return nil, nil // not an object
+		}
+		member, ok = object[key]
+	case int:
+		var array []json.RawMessage
+		if json.Unmarshal([]byte(text), &array) != nil {
+			return nil, nil // not an array
+		}
+		ok = key >= 0 && key < len(array)
+		if ok {
+			member = array[key]
+		}
+	default:
+		return nil, fmt.Errorf("operator %s expects a key or an index, got %T", operator, path)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	if operator == "->" {
+		return string(member), nil
+	}
+	return jsonScalar(member)
+}
+
+// jsonScalar converts a JSON value to the value ->> returns: strings,
+// numbers and booleans become the matching SQL values, null becomes NULL
+// and objects and arrays stay JSON text, as do numbers too large for a
+// FLOAT
+func jsonScalar(member json.RawMessage) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(member))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil && n >= math.MinInt && n <= math.MaxInt {
+			return int(n), nil
+		}
+		if f, err := v.Float64(); err == nil {
+			return f, nil
+		}
+		return v.String(), nil
+	default:
+		return string(member), nil
+	}
+}
+
+// isJSONPath reports whether expr extracts part of a JSON document
+func isJSONPath(expr parser.Expression) bool {
+	ex, ok := expr.(*parser.BinaryExpr)
+	return ok && isJSONOperator(ex.Operator)
+}
+
+// comparableKinds reports whether two non-NULL values are of kinds that
+// compare with each other: numbers, strings or booleans
+func comparableKinds(left, right interface{}) bool {
+	switch left.(type) {
+	case int, float64:
+		switch right.(type) {
+		case int, float64:
+			return true
+		}
+		return false
+	case string:
+		_, ok := right.(string)
+		return ok
+	case bool:
+		_, ok := right.(bool)
+		return ok
+	}
+	return true
+}
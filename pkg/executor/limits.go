@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrResultTooLarge is returned when a query result exceeds the configured
+// result limits
+var ErrResultTooLarge = errors.New("result truncated, use LIMIT or cursors")
+
+// ResultLimits bounds the size of a materialized query result. A zero
+// value for either field means no limit.
+type ResultLimits struct {
+	MaxRows  int
+	MaxBytes int64
+}
+
+// SetResultLimits sets the limits enforced on query results
+func (e *Executor) SetResultLimits(limits ResultLimits) {
+	e.limits = limits
+}
+
+// ResultLimits returns the limits enforced on query results
+func (e *Executor) ResultLimits() ResultLimits {
+	return e.limits
+}
+
+// resultGuard tracks the size of a result as it is built
+type resultGuard struct {
+	limits ResultLimits
+	rows   int
+	bytes  int64
+}
+
+// newResultGuard starts tracking a new result
+func (e *Executor) newResultGuard() *resultGuard {
+	return &resultGuard{limits: e.limits}
+}
+
+// add accounts for one more result row
+func (g *resultGuard) add(row []interface{}) error {
+	g.rows++
+	if g.limits.MaxRows > 0 && g.rows > g.limits.MaxRows {
+		return fmt.Errorf("result exceeds %d rows: %w", g.limits.MaxRows, ErrResultTooLarge)
+	}
+
+	if g.limits.MaxBytes > 0 {
+		for _, value := range row {
+			g.bytes += valueSize(value)
+		}
+		if g.bytes > g.limits.MaxBytes {
+			return fmt.Errorf("result exceeds %d bytes: %w", g.limits.MaxBytes, ErrResultTooLarge)
+		}
+	}
+	return nil
+}
+
+// checkRows fails once an intermediate result (e.g. a join) has more rows
+// than the final result may have
+func (g *resultGuard) checkRows(n int) error {
+	if g.limits.MaxRows > 0 && n > g.limits.MaxRows {
+		return fmt.Errorf("result exceeds %d rows: %w", g.limits.MaxRows, ErrResultTooLarge)
+	}
+	return nil
+}
+
+// valueSize estimates the encoded size of a value in a JSON response
+func valueSize(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 4
+	case string:
+		return int64(len(v)) + 2
+	case bool:
+		return 5
+	default:
+		return 8
+	}
+}
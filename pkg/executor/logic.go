@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+)
+
+// truth is the value of a condition under SQL's three-valued logic
+type truth int
+
+const (
+	isFalse truth = iota
+	isTrue
+	unknown
+)
+
+// not negates a truth value; NOT of unknown stays unknown
+func (t truth) not() truth {
+	switch t {
+	case isTrue:
+		return isFalse
+	case isFalse:
+		return isTrue
+	default:
+		return unknown
+	}
+}
+
+// evaluateTruth evaluates a condition made of comparisons and IN tests
+// combined with NOT, AND and OR, where value returns an operand's value and
+// collation the collation a comparison of operands uses. The right side of
+// AND and OR is skipped when the left side decides the result: false for
+// AND, true for OR.
+func (e *Executor) evaluateTruth(ctx context.Context, expr parser.Expression, value func(parser.Expression) (interface{}, error), collation func(...parser.Expression) string) (truth, error) {
+	switch ex := expr.(type) {
+	case *parser.UnaryExpr:
+		if ex.Operator != "NOT" {
+			return unknown, fmt.Errorf("unsupported operator %s in condition", ex.Operator)
+		}
+		t, err := e.evaluateTruth(ctx, ex.Operand, value, collation)
+		if err != nil {
+			return unknown, err
+		}
+		return t.not(), nil
+	case *parser.InExpr:
+		left, err := value(ex.Left)
+		if err != nil {
+			return unknown, err
+		}
+		result, err := subqueryFrom(ctx, ex.Subquery)
+		if err != nil {
+			return unknown, err
+		}
+		t, err := result.contains(e, left, collation(ex.Left))
+		if ex.Not {
+			t = t.not()
+		}
+		return t, err
+	case *parser.BinaryExpr:
+		if ex.Operator != "AND" && ex.Operator != "OR" {
+			left, err := value(ex.Left)
+			if err != nil {
+				return unknown, err
+			}
+			right, err := value(ex.Right)
+			if err != nil {
+				return unknown, err
+			}
+			// A JSON member may hold a number in one row and a string in
+			// the next, so comparing it with a value of another kind only
+			// fails to match the row
+			if (isJSONPath(ex.Left) || isJSONPath(ex.Right)) && left != nil && right != nil &&
+				!comparableKinds(left, right) {
+				return unknown, nil
+			}
+			return e.comparisonTruth(left, right, ex.Operator, collation(ex.Left, ex.Right))
+		}
+		// decisive is the value of the left side that decides the result
+		decisive := isFalse
+		if ex.Operator == "OR" {
+			decisive = isTrue
+		}
+		left, err := e.evaluateTruth(ctx, ex.Left, value, collation)
+		if err != nil || left == decisive {
+			return left, err
+		}
+		right, err := e.evaluateTruth(ctx, ex.Right, value, collation)
+		if err != nil {
+			return unknown, err
+		}
+		// The left side is unknown or leaves the result to the right side
+		if right != decisive && left == unknown {
+			return unknown, nil
+		}
+		return right, nil
+	default:
+		return unknown, fmt.Errorf("unsupported condition type")
+	}
+}
+
+// comparisonTruth compares two values. A comparison with NULL is unknown,
+// except that = treats NULL as equal to NULL, so NOT (x = NULL) holds for
+// every non-NULL x while NOT (x > NULL) holds for none.
+func (e *Executor) comparisonTruth(left, right interface{}, operator, collation string) (truth, error) {
+	if (left == nil || right == nil) && operator != "=" {
+		return unknown, nil
+	}
+	match, err := e.compareCollated(left, right, operator, collation)
+	if err != nil || !match {
+		return isFalse, err
+	}
+	return isTrue, nil
+}
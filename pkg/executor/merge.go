@@ -0,0 +1,582 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// mergeClause is a WHEN clause of a MERGE checked against the target table
+type mergeClause struct {
+	*parser.MergeClause
+	set      map[int]parser.Expression // UPDATE: value of each column set
+	columns  []int                     // INSERT: the columns of Values
+	defaults map[int]interface{}       // INSERT: values of the columns left out
+}
+
+// executeMerge executes MERGE. Each source row is paired with the target
+// rows its ON condition holds for; the first WHEN MATCHED clause whose
+// condition holds for a pair decides what happens to that target row, and
+// the first WHEN NOT MATCHED clause whose condition holds what is inserted
+// for a source row pairing with none. A target row may be matched by one
+// source row at most. Every change is worked out before any is made, and
+// the rows are then inserted, updated and deleted in that order. Target
+// rows hidden by the user's policies match no source row.
+func (e *Executor) executeMerge(ctx context.Context, stmt *parser.MergeStmt) (*Result, error) {
+	table, err := e.storage.GetTable(stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWritable(ctx, table); err != nil {
+		return nil, err
+	}
+	source, err := e.storage.GetTable(stmt.Source)
+	if err != nil {
+		return nil, err
+	}
+	schema := table.Schema
+
+	// The target's columns come first, so an unqualified name that both
+	// tables have is the target's
+	width := len(schema.Columns)
+	rowSchema := &joinSchema{}
+	if err := rowSchema.add(qualifier(stmt.TableName, stmt.Alias), schema); err != nil {
+		return nil, err
+	}
+	if err := rowSchema.add(qualifier(stmt.Source, stmt.SourceAlias), source.Schema); err != nil {
+		return nil, err
+	}
+
+	clauses, err := e.mergeClauses(ctx, stmt, rowSchema)
+	if err != nil {
+		return nil, err
+	}
+	targetAccess, err := e.accessTo(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	sourceAccess, err := e.accessTo(ctx, source.Schema)
+	if err != nil {
+		return nil, err
+	}
+	access := joinAccess(rowSchema, []*columnAccess{targetAccess, sourceAccess})
+	exprs := mergeExpressions(stmt)
+	for _, expr := range exprs {
+		if err := access.checkReferences(expr, rowSchema.index); err != nil {
+			return nil, err
+		}
+	}
+	if ctx, err = e.withSubqueries(ctx, exprs...); err != nil {
+		return nil, err
+	}
+
+	// The source is read as by SELECT * FROM it, masks included
+	sourceRows, _, err := e.selectRows(ctx, source, &parser.SelectStmt{TableName: stmt.Source, Columns: []string{"*"}})
+	if err != nil {
+		return nil, err
+	}
+	policies, err := e.policies(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	var targetRows []*storage.Row
+	var targetValues [][]interface{} // with virtual columns computed
+	owner := make(map[*storage.Row]*storage.Table)
+	for _, target := range e.partitionsFor(table, nil) {
+		for _, row := range target.SelectRows() {
+			visibleRow, err := e.withVirtual(ctx, schema, row)
+			if err != nil {
+				return nil, err
+			}
+			if visible, err := e.visible(ctx, policies, visibleRow, schema); err != nil {
+				return nil, err
+			} else if !visible {
+				continue
+			}
+			owner[row] = target
+			targetRows = append(targetRows, row)
+			targetValues = append(targetValues, visibleRow.Values)
+		}
+	}
+
+	// Work out the changes
+	matchedBy := make(map[*storage.Row]bool)
+	updates := make(map[*storage.Row][]interface{})
+	deletes := make(map[*storage.Row]bool)
+	var inserts []*storage.Row
+	compared := 0
+	for _, sourceRow := range sourceRows {
+		values := make([]interface{}, len(sourceRow.Values))
+		for i, value := range sourceRow.Values {
+			values[i] = sourceAccess.mask(i, value)
+		}
+
+		matched := false
+		for i, row := range targetRows {
+			if err := checkCancelled(ctx, compared); err != nil {
+				return nil, err
+			}
+			compared++
+
+			combined := &CombinedRow{values: append(targetValues[i][:width:width], values...), schema: rowSchema}
+			if match, err := e.evaluateJoinCondition(ctx, stmt.On, combined); err != nil {
+				return nil, err
+			} else if !match {
+				continue
+			}
+			matched = true
+			if matchedBy[row] {
+				return nil, fmt.Errorf("MERGE matched a row of %s with more than one row of %s", stmt.TableName, stmt.Source)
+			}
+			matchedBy[row] = true
+
+			clause, err := e.chooseMergeClause(ctx, clauses, true, combined)
+			if err != nil {
+				return nil, err
+			}
+			if clause == nil {
+				continue
+			}
+			switch clause.Action {
+			case "UPDATE":
+				updated := slices.Clone(row.Values)
+				for idx, expr := range clause.set {
+					value, err := e.getJoinColumnValue(ctx, expr, combined)
+					if err != nil {
+						return nil, err
+					}
+					if updated[idx], err = storage.NormalizeValue(value, schema.Columns[idx]); err != nil {
+						return nil, err
+					}
+				}
+				updates[row] = updated
+			case "DELETE":
+				deletes[row] = true
+			}
+		}
+		if matched {
+			continue
+		}
+
+		combined := &CombinedRow{values: append(make([]interface{}, width), values...), schema: rowSchema}
+		clause, err := e.chooseMergeClause(ctx, clauses, false, combined)
+		if err != nil {
+			return nil, err
+		}
+		if clause == nil || clause.Action != "INSERT" {
+			continue
+		}
+		row, err := e.mergeInsertRow(ctx, schema, clause, combined, policies)
+		if err != nil {
+			return nil, err
+		}
+		inserts = append(inserts, row)
+	}
+
+	// Deleting is planned first, as it is rejected while other rows still
+	// reference the deleted ones
+	var cascade *deletion
+	if len(deletes) > 0 {
+		if cascade, err = e.planDelete(table, e.partitionsFor(table, nil), func(row *storage.Row) bool { return deletes[row] }); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.insertRows(table, inserts); err != nil {
+		return nil, err
+	}
+	updated, err := e.applyMergeUpdates(ctx, table, clauses, updates, owner, policies)
+	if err != nil {
+		e.removeInserted(table, inserts)
+		return nil, err
+	}
+	cs := changeSetFrom(ctx)
+	if cs != nil {
+		for _, row := range inserts {
+			cs.add(schema, cdc.OpInsert, nil, row.Values)
+		}
+	}
+	deleted := 0
+	if len(deletes) > 0 {
+		condition := func(row *storage.Row) bool { return deletes[row] }
+		if cs != nil {
+			condition = trackMatches(condition, func(row *storage.Row) {
+				cs.add(schema, cdc.OpDelete, row.Values, nil)
+			})
+		}
+		for _, target := range e.partitionsFor(table, nil) {
+			if target.Schema.SoftDelete {
+				deleted += target.SoftDeleteRows(condition)
+			} else {
+				deleted += target.DeleteRows(condition)
+			}
+		}
+		if schema.Partitioned() && deleted > 0 {
+			table.MarkDirty()
+		}
+		if err := e.applyCascades(ctx, cascade); err != nil {
+			return nil, err
+		}
+	}
+
+	rowsInserted, rowsUpdated := len(inserts), len(updated)
+	e.stats.recordTable(stmt.TableName, func(t *TableStats) {
+		t.Statements++
+		t.RowsScanned += int64(len(targetRows))
+		t.RowsInserted += int64(rowsInserted)
+		t.RowsUpdated += int64(rowsUpdated)
+		t.RowsDeleted += int64(deleted)
+	})
+
+	// Save to disk
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+
+	return &Result{
+		Message:      fmt.Sprintf("%d row(s) inserted, %d updated, %d deleted", rowsInserted, rowsUpdated, deleted),
+		RowsAffected: rowsInserted + rowsUpdated + deleted,
+	}, nil
+}
+
+// mergeClauses checks the WHEN clauses of a MERGE against its target table
+// and evaluates the defaults of the columns its INSERTs leave out. A WHEN
+// NOT MATCHED clause has no target row, so it may not name the target's
+// columns.
+func (e *Executor) mergeClauses(ctx context.Context, stmt *parser.MergeStmt, rowSchema *joinSchema) ([]*mergeClause, error) {
+	schema := rowSchema.sources[0].schema
+	width := len(schema.Columns)
+	clauses := make([]*mergeClause, len(stmt.Clauses))
+	for i, c := range stmt.Clauses {
+		clause := &mergeClause{MergeClause: c}
+		clauses[i] = clause
+
+		if !c.Matched {
+			var err error
+			for _, expr := range append([]parser.Expression{c.Condition}, c.Values...) {
+				parser.WalkExpression(expr, func(expr parser.Expression) {
+					if ident, ok := expr.(*parser.Identifier); ok && err == nil {
+						if idx := rowSchema.index(ident.Value); idx != -1 && idx < width {
+							err = fmt.Errorf("WHEN NOT MATCHED cannot refer to column %s of the target table %s", ident.Value, stmt.TableName)
+						}
+					}
+				})
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		switch c.Action {
+		case "UPDATE":
+			clause.set = make(map[int]parser.Expression, len(c.Set))
+			for colName, expr := range c.Set {
+				idx := schema.GetColumnIndex(colName)
+				if idx == -1 {
+					return nil, fmt.Errorf("column %s not found", colName)
+				}
+				if schema.Columns[idx].Generated != "" {
+					return nil, fmt.Errorf("cannot update generated column %s", colName)
+				}
+				clause.set[idx] = expr
+			}
+		case "INSERT":
+			columns := c.Columns
+			if len(columns) == 0 {
+				columns = valueColumns(schema)
+			}
+			if len(c.Values) != len(columns) {
+				return nil, fmt.Errorf("column count mismatch: expected %d, got %d", len(columns), len(c.Values))
+			}
+			clause.columns = make([]int, len(columns))
+			for j, colName := range columns {
+				idx := schema.GetColumnIndex(colName)
+				if idx == -1 {
+					return nil, fmt.Errorf("column %s does not exist in table %s", colName, stmt.TableName)
+				}
+				if schema.Columns[idx].Generated != "" {
+					return nil, fmt.Errorf("cannot insert into generated column %s", colName)
+				}
+				clause.columns[j] = idx
+			}
+			clause.defaults = make(map[int]interface{})
+			for idx, col := range schema.Columns {
+				if col.Generated != "" || slices.Contains(clause.columns, idx) {
+					continue
+				}
+				value, err := e.columnDefault(ctx, col)
+				if err != nil {
+					return nil, fmt.Errorf("column %s: %w", col.Name, err)
+				}
+				clause.defaults[idx] = value
+			}
+		}
+	}
+	return clauses, nil
+}
+
+// chooseMergeClause returns the first WHEN MATCHED (or, without matched,
+// WHEN NOT MATCHED) clause whose condition holds for row, or nil if none
+// does
+func (e *Executor) chooseMergeClause(ctx context.Context, clauses []*mergeClause, matched bool, row *CombinedRow) (*mergeClause, error) {
+	for _, clause := range clauses {
+		if clause.Matched != matched {
+			continue
+		}
+		if clause.Condition != nil {
+			match, err := e.evaluateJoinCondition(ctx, clause.Condition, row)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+		}
+		return clause, nil
+	}
+	return nil, nil
+}
+
+// mergeInsertRow returns the row a WHEN NOT MATCHED THEN INSERT clause
+// inserts for a source row, built like a row of INSERT ... VALUES
+func (e *Executor) mergeInsertRow(ctx context.Context, schema *storage.Schema, clause *mergeClause, combined *CombinedRow, policies []parser.Expression) (*storage.Row, error) {
+	row := storage.NewRow(make([]interface{}, len(schema.Columns)))
+	for idx, value := range clause.defaults {
+		row.Values[idx] = value
+	}
+	for j, expr := range clause.Values {
+		col := schema.Columns[clause.columns[j]]
+		var value interface{}
+		var err error
+		if _, ok := expr.(*parser.DefaultValue); ok {
+			value, err = e.columnDefault(ctx, col)
+		} else {
+			value, err = e.getJoinColumnValue(ctx, expr, combined)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if row.Values[clause.columns[j]], err = storage.NormalizeValue(value, col); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.computeGenerated(ctx, schema, row.Values, false); err != nil {
+		return nil, err
+	}
+	if err := e.checkPolicies(ctx, schema, policies, row.Values); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// applyMergeUpdates gives the rows in updates their new values, partition
+// by partition, and returns the updated rows
+func (e *Executor) applyMergeUpdates(ctx context.Context, table *storage.Table, clauses []*mergeClause, updates map[*storage.Row][]interface{}, owner map[*storage.Row]*storage.Table, policies []parser.Expression) ([]*storage.Row, error) {
+	if len(updates) == 0 {
+		return nil, nil
+	}
+	schema := table.Schema
+
+	var targets []*storage.Table
+	for row := range updates {
+		if !slices.Contains(targets, owner[row]) {
+			targets = append(targets, owner[row])
+		}
+	}
+
+	// Stored generated columns are recomputed, so they may change too
+	refs, err := e.newReferenceCheck(table, func(idx int) bool {
+		for _, clause := range clauses {
+			if _, ok := clause.set[idx]; ok {
+				return true
+			}
+		}
+		return schema.Columns[idx].Generated != ""
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cs := changeSetFrom(ctx)
+	var matched []*storage.Row
+	var before [][]interface{}
+	for _, target := range targets {
+		bound, keyCol, bounded := e.storage.PartitionBound(target)
+		// UpdateRowsWith hands update the values of the row condition
+		// last matched
+		var current *storage.Row
+		condition := func(row *storage.Row) bool {
+			if _, ok := updates[row]; !ok {
+				return false
+			}
+			current = row
+			matched = append(matched, row)
+			if cs != nil {
+				before = append(before, slices.Clone(row.Values))
+			}
+			return true
+		}
+		_, err := target.UpdateRowsWith(condition, func(values []interface{}) error {
+			old := slices.Clone(values)
+			copy(values, updates[current])
+			if err := e.computeGenerated(ctx, schema, values, false); err != nil {
+				return err
+			}
+			if err := e.checkPolicies(ctx, schema, policies, values); err != nil {
+				return err
+			}
+			if err := refs.check(old, values); err != nil {
+				return err
+			}
+			if bounded {
+				return checkPartitionBound(target, bound, keyCol, values)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if schema.Partitioned() && len(matched) > 0 {
+		table.MarkDirty()
+	}
+	if cs != nil {
+		for i, row := range matched {
+			cs.add(schema, cdc.OpUpdate, before[i], row.Values)
+		}
+	}
+	return matched, nil
+}
+
+// mergeExpressions lists the expressions of a MERGE: its ON condition and
+// those of its WHEN clauses
+func mergeExpressions(stmt *parser.MergeStmt) []parser.Expression {
+	exprs := []parser.Expression{stmt.On}
+	for _, clause := range stmt.Clauses {
+		exprs = append(exprs, clause.Condition)
+		for _, expr := range clause.Set {
+			exprs = append(exprs, expr)
+		}
+		exprs = append(exprs, clause.Values...)
+	}
+	return exprs
+}
+
+// mapMerge returns a copy of a MERGE with replace applied to each of its
+// expressions
+func mapMerge(stmt *parser.MergeStmt, replace func(parser.Expression) (parser.Expression, error)) (*parser.MergeStmt, error) {
+	merge := *stmt
+	var err error
+	if merge.On, err = replace(stmt.On); err != nil {
+		return nil, err
+	}
+	merge.Clauses = make([]*parser.MergeClause, len(stmt.Clauses))
+	for i, c := range stmt.Clauses {
+		clause := *c
+		if clause.Condition, err = replace(c.Condition); err != nil {
+			return nil, err
+		}
+		if c.Set != nil {
+			if clause.Set, err = replaceAssignments(c.Set, replace); err != nil {
+				return nil, err
+			}
+		}
+		if c.Values != nil {
+			clause.Values = make([]parser.Expression, len(c.Values))
+			for j, expr := range c.Values {
+				if clause.Values[j], err = replace(expr); err != nil {
+					return nil, err
+				}
+			}
+		}
+		merge.Clauses[i] = &clause
+	}
+	return &merge, nil
+}
+
+// resolveMergeDefaults returns a copy of a MERGE whose INSERTs give the
+// columns defaulting to NOW() the value now stands for, and whose UPDATEs
+// set the ON UPDATE NOW() columns they leave out to it, reporting whether
+// it added any. A MERGE may insert many rows, which a value resolved once
+// for DEFAULT UUID() or DEFAULT NEXTVAL() would not tell apart, so those
+// columns must be given values.
+func (e *Executor) resolveMergeDefaults(stmt *parser.MergeStmt, now parser.Expression) (*parser.MergeStmt, bool, error) {
+	defaultColumns := e.resolvedColumns(stmt.TableName, false)
+	defaults := map[string]*parser.FunctionCall{}
+	for _, col := range defaultColumns {
+		defaults[col.Name] = e.resolvedDefault(col)
+	}
+	onUpdate := e.resolvedColumns(stmt.TableName, true)
+	if len(defaultColumns) == 0 && len(onUpdate) == 0 {
+		return stmt, false, nil
+	}
+	resolve := func(name string) (parser.Expression, error) {
+		if call := defaults[name]; call.Name != "NOW" {
+			return nil, fmt.Errorf("MERGE must give a value for column %s, which has DEFAULT %s", name, parser.FormatExpression(call))
+		}
+		return now, nil
+	}
+
+	changed := false
+	merge := *stmt
+	merge.Clauses = make([]*parser.MergeClause, len(stmt.Clauses))
+	for i, c := range stmt.Clauses {
+		clause := *c
+		merge.Clauses[i] = &clause
+		switch c.Action {
+		case "UPDATE":
+			clause.Set = maps.Clone(c.Set)
+			for _, col := range onUpdate {
+				if _, ok := clause.Set[col.Name]; !ok {
+					clause.Set[col.Name] = now
+					changed = true
+				}
+			}
+		case "INSERT":
+			columns := c.Columns
+			if len(columns) == 0 {
+				columns = e.insertColumns(stmt.TableName)
+			}
+			clause.Values = slices.Clone(c.Values)
+			for j, expr := range c.Values {
+				if _, ok := expr.(*parser.DefaultValue); ok && j < len(columns) && defaults[columns[j]] != nil {
+					value, err := resolve(columns[j])
+					if err != nil {
+						return nil, false, err
+					}
+					clause.Values[j] = value
+					changed = true
+				}
+			}
+			// Without a column list every column is given a value
+			if len(c.Columns) == 0 {
+				continue
+			}
+			for _, col := range defaultColumns {
+				name := col.Name
+				if slices.Contains(c.Columns, name) {
+					continue
+				}
+				value, err := resolve(name)
+				if err != nil {
+					return nil, false, err
+				}
+				clause.Columns = append(slices.Clip(clause.Columns), name)
+				clause.Values = append(clause.Values, value)
+				changed = true
+			}
+		}
+	}
+	return &merge, changed, nil
+}
+
+// mergesRows reports whether a statement is a MERGE, which decides what to
+// write from the rows it read first, so no other write may come in between
+func mergesRows(stmt parser.Statement) bool {
+	_, ok := stmt.(*parser.MergeStmt)
+	return ok
+}
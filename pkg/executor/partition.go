@@ -0,0 +1,472 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// partitionKey validates PARTITION BY RANGE|HASH (<column>) and sets the
+// schema's partition key
+func partitionKey(schema *storage.Schema, method, column string) error {
+	idx := schema.GetColumnIndex(column)
+	if idx == -1 {
+		return fmt.Errorf("partition key column %s does not exist", column)
+	}
+	col := schema.Columns[idx]
+	switch col.DataType {
+	case storage.TypeInteger, storage.TypeFloat, storage.TypeVarchar, storage.TypeCIText, storage.TypeText:
+	default:
+		return fmt.Errorf("cannot partition by %s column %s", col.DataType, column)
+	}
+	if col.Virtual {
+		return fmt.Errorf("cannot partition by virtual column %s", column)
+	}
+
+	// Partitions check uniqueness on their own rows, which only covers the
+	// whole table when equal values always land in the same partition
+	for _, other := range schema.Columns {
+		if (other.PrimaryKey || other.Unique) && other.Name != col.Name {
+			return fmt.Errorf("PRIMARY KEY and UNIQUE columns of a partitioned table must be its partition key, not %s", other.Name)
+		}
+	}
+	for _, constraint := range schema.UniqueConstraints {
+		if !slices.Contains(constraint.Columns, col.Name) {
+			return fmt.Errorf("UNIQUE (%s) of a partitioned table must include its partition key %s", strings.Join(constraint.Columns, ", "), col.Name)
+		}
+	}
+
+	schema.PartitionKey = col.Name
+	schema.PartitionMethod = method
+	return nil
+}
+
+// executeCreatePartition executes CREATE TABLE ... PARTITION OF, creating a
+// table with the parent's columns and attaching it
+func (e *Executor) executeCreatePartition(ctx context.Context, stmt *parser.CreateTableStmt) (*Result, error) {
+	parent, err := e.storage.GetTable(stmt.PartitionOf)
+	if err != nil {
+		return nil, err
+	}
+	bound, err := e.partitionBound(ctx, stmt.TableName, stmt.Bound, stmt.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := storage.NewSchema(stmt.TableName)
+	for _, col := range parent.Schema.Columns {
+		schema.AddColumn(col)
+	}
+	for _, constraint := range parent.Schema.UniqueConstraints {
+		if err := schema.AddUniqueConstraint(constraint.Columns); err != nil {
+			return nil, err
+		}
+	}
+	schema.ForeignKeys = slices.Clone(parent.Schema.ForeignKeys)
+	if err := e.storage.CreateTable(schema); err != nil {
+		return nil, err
+	}
+	if err := e.storage.AttachPartition(stmt.PartitionOf, bound); err != nil {
+		if dropErr := e.storage.DropTable(stmt.TableName); dropErr != nil {
+			return nil, fmt.Errorf("%w (and failed to drop %s: %v)", err, stmt.TableName, dropErr)
+		}
+		return nil, err
+	}
+
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist table: %w", err)
+	}
+
+	return &Result{
+		Message: fmt.Sprintf("Table '%s' created as a partition of '%s'", stmt.TableName, stmt.PartitionOf),
+	}, nil
+}
+
+// executeAlterTable executes ALTER TABLE ... ATTACH PARTITION, DETACH
+// PARTITION and ALTER COLUMN ... SET MASK / DROP MASK / SET ENCODING
+func (e *Executor) executeAlterTable(ctx context.Context, stmt *parser.AlterTableStmt) (*Result, error) {
+	var message string
+	switch stmt.Action {
+	case "ATTACH":
+		bound, err := e.partitionBound(ctx, stmt.Partition, stmt.Bound, stmt.Location)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.storage.AttachPartition(stmt.TableName, bound); err != nil {
+			return nil, err
+		}
+		message = fmt.Sprintf("Table '%s' attached to '%s'", stmt.Partition, stmt.TableName)
+	case "DETACH":
+		if err := e.storage.DetachPartition(stmt.TableName, stmt.Partition); err != nil {
+			return nil, err
+		}
+		message = fmt.Sprintf("Table '%s' detached from '%s'", stmt.Partition, stmt.TableName)
+	case "SET MASK":
+		if err := e.maskColumn(stmt.TableName, stmt.Column, stmt.Mask); err != nil {
+			return nil, err
+		}
+		message = fmt.Sprintf("Column '%s' of '%s' masked", stmt.Column, stmt.TableName)
+	case "DROP MASK":
+		if err := e.storage.SetColumnMask(stmt.TableName, stmt.Column, ""); err != nil {
+			return nil, err
+		}
+		message = fmt.Sprintf("Mask dropped from column '%s' of '%s'", stmt.Column, stmt.TableName)
+	case "SET ENCODING":
+		if err := e.encodeColumn(stmt.TableName, stmt.Column, stmt.Encoding == "DICTIONARY"); err != nil {
+			return nil, err
+		}
+		message = fmt.Sprintf("Column '%s' of '%s' encoded as %s", stmt.Column, stmt.TableName, strings.ToLower(stmt.Encoding))
+	case "ENABLE SOFT DELETE", "DISABLE SOFT DELETE":
+		enabled := stmt.Action == "ENABLE SOFT DELETE"
+		if err := e.storage.SetSoftDelete(stmt.TableName, enabled); err != nil {
+			return nil, err
+		}
+		message = fmt.Sprintf("Soft delete disabled on '%s'", stmt.TableName)
+		if enabled {
+			message = fmt.Sprintf("Soft delete enabled on '%s'", stmt.TableName)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported ALTER TABLE action: %s", stmt.Action)
+	}
+
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: message}, nil
+}
+
+// encodeColumn turns dictionary encoding of a column on or off. The rows of
+// a partitioned table are in its partitions, which are re-encoded too.
+func (e *Executor) encodeColumn(tableName, column string, dictionary bool) error {
+	table, err := e.storage.GetTable(tableName)
+	if err != nil {
+		return err
+	}
+	if err := e.storage.SetColumnDictionary(tableName, column, dictionary); err != nil {
+		return err
+	}
+	for _, partition := range e.storage.Partitions(table) {
+		if err := e.storage.SetColumnDictionary(partition.Schema.TableName, column, dictionary); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changesEncoding reports whether a statement changes how a column is
+// stored, which must not race with statements reading the schema
+func changesEncoding(stmt parser.Statement) bool {
+	alter, ok := stmt.(*parser.AlterTableStmt)
+	return ok && alter.Action == "SET ENCODING"
+}
+
+// partitionBound evaluates the FOR VALUES bound of a partition stored in
+// location
+func (e *Executor) partitionBound(ctx context.Context, partition string, bound *parser.PartitionBound, location string) (storage.PartitionBound, error) {
+	result := storage.PartitionBound{
+		Table:     partition,
+		Modulus:   bound.Modulus,
+		Remainder: bound.Remainder,
+		Dir:       location,
+	}
+	for _, b := range []struct {
+		expr  parser.Expression
+		value *interface{}
+	}{{bound.From, &result.From}, {bound.To, &result.To}} {
+		if b.expr == nil {
+			continue
+		}
+		value, err := e.evaluateExpression(ctx, b.expr, nil)
+		if err != nil {
+			return result, fmt.Errorf("partition bound: %w", err)
+		}
+		if value == nil {
+			return result, fmt.Errorf("partition bound cannot be NULL; use MINVALUE or MAXVALUE")
+		}
+		*b.value = value
+	}
+	return result, nil
+}
+
+// insertRows inserts rows into a table. Rows for a partitioned table are
+// routed to their partitions, and rows inserted straight into a partition
+// must fall within its bound, and rows must have the rows their foreign
+// keys reference. Nothing is inserted unless every row is.
+func (e *Executor) insertRows(table *storage.Table, rows []*storage.Row) error {
+	schema := table.Schema
+	refs, err := e.newReferenceCheck(table, nil)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		refs.include(row.Values)
+	}
+	for _, row := range rows {
+		if err := refs.check(nil, row.Values); err != nil {
+			return err
+		}
+	}
+
+	if !schema.Partitioned() {
+		if bound, col, ok := e.storage.PartitionBound(table); ok {
+			keyIndex := schema.GetColumnIndex(col.Name)
+			for _, row := range rows {
+				if !bound.Contains(row.Values[keyIndex], col) {
+					return fmt.Errorf("%s = %v is outside partition %s (%s)", col.Name, row.Values[keyIndex], schema.TableName, bound)
+				}
+			}
+		}
+		return table.InsertRows(rows)
+	}
+
+	// Route every row before inserting any
+	keyIndex := schema.GetColumnIndex(schema.PartitionKey)
+	var order []string
+	routed := make(map[string][]*storage.Row)
+	for _, row := range rows {
+		name, err := schema.PartitionFor(row.Values[keyIndex])
+		if err != nil {
+			return err
+		}
+		if _, ok := routed[name]; !ok {
+			order = append(order, name)
+		}
+		routed[name] = append(routed[name], row)
+	}
+
+	// Each partition checks its own batch; if a later one fails, take the
+	// rows back out of the earlier ones
+	var inserted []*storage.Table
+	for _, name := range order {
+		partition, err := e.storage.GetTable(name)
+		if err == nil {
+			err = partition.InsertRows(routed[name])
+		}
+		if err != nil {
+			for _, done := range inserted {
+				added := make(map[*storage.Row]bool)
+				for _, row := range routed[done.Schema.TableName] {
+					added[row] = true
+				}
+				done.DeleteRows(func(row *storage.Row) bool { return added[row] })
+			}
+			return err
+		}
+		inserted = append(inserted, partition)
+	}
+	table.MarkDirty()
+	return nil
+}
+
+// partitionsFor returns the tables holding a table's rows: the partitions
+// of a partitioned table that can hold rows matching where, or the table
+// itself
+func (e *Executor) partitionsFor(table *storage.Table, where parser.Expression) []*storage.Table {
+	schema := table.Schema
+	if !schema.Partitioned() {
+		return []*storage.Table{table}
+	}
+
+	keyIndex := schema.GetColumnIndex(schema.PartitionKey)
+	col := schema.Columns[keyIndex]
+	ranges := keyRanges(schema, keyIndex, where)
+
+	partitions := []*storage.Table{}
+	for _, partition := range e.storage.Partitions(table) {
+		bound, _, ok := e.storage.PartitionBound(partition)
+		if ok && !boundMatches(bound, col, ranges) {
+			continue
+		}
+		partitions = append(partitions, partition)
+	}
+	return partitions
+}
+
+// tableRows returns the rows of a table, taken from the partitions that can
+// hold rows matching where if it is partitioned, or read from the source of
+// a foreign table. Soft-deleted rows are included when withDeleted is set.
+func (e *Executor) tableRows(ctx context.Context, table *storage.Table, where parser.Expression, withDeleted bool) ([]*storage.Row, error) {
+	if table.Schema.Foreign() {
+		return e.foreignRows(ctx, table)
+	}
+	if !table.Schema.Partitioned() {
+		if withDeleted {
+			return table.SelectRowsWithDeleted(), nil
+		}
+		return table.SelectRows(), nil
+	}
+	rows := []*storage.Row{}
+	for _, partition := range e.partitionsFor(table, where) {
+		rows = append(rows, partition.SelectRows()...)
+	}
+	return rows, nil
+}
+
+// scanPartitions returns the rows of a partitioned table matching where,
+// and how many rows were scanned. The partitions that can hold matching
+// rows are scanned and filtered in parallel, then concatenated in order.
+func (e *Executor) scanPartitions(ctx context.Context, table *storage.Table, where parser.Expression) ([]*storage.Row, int, error) {
+	partitions := e.partitionsFor(table, where)
+	results := make([]struct {
+		rows    []*storage.Row
+		scanned int
+		err     error
+	}, len(partitions))
+
+	var wg sync.WaitGroup
+	for i, partition := range partitions {
+		wg.Add(1)
+		go func(i int, partition *storage.Table) {
+			defer wg.Done()
+			start := time.Now()
+			r := &results[i]
+			rows, err := e.withVirtualRows(ctx, table.Schema, partition.SelectRows())
+			if err == nil {
+				r.scanned = len(rows)
+				rows, err = e.filterRows(ctx, rows, where, table.Schema)
+			}
+			recordActuals(ctx, partition.Schema.TableName, len(rows), start)
+			r.rows, r.err = rows, err
+		}(i, partition)
+	}
+	wg.Wait()
+
+	rows := []*storage.Row{}
+	scanned := 0
+	for _, r := range results {
+		if r.err != nil {
+			return nil, 0, r.err
+		}
+		rows = append(rows, r.rows...)
+		scanned += r.scanned
+	}
+	return rows, scanned, nil
+}
+
+// keyRange is a comparison of the partition key against a constant
+type keyRange struct {
+	operator string
+	value    interface{}
+}
+
+// keyRanges collects the comparisons of the partition key against literals
+// that a WHERE clause requires, looking through AND
+func keyRanges(schema *storage.Schema, keyIndex int, where parser.Expression) []keyRange {
+	expr, ok := where.(*parser.BinaryExpr)
+	if !ok {
+		return nil
+	}
+	if expr.Operator == "AND" {
+		return append(keyRanges(schema, keyIndex, expr.Left), keyRanges(schema, keyIndex, expr.Right)...)
+	}
+
+	flipped := map[string]string{"=": "=", "<": ">", "<=": ">=", ">": "<", ">=": "<="}
+	if _, ok := flipped[expr.Operator]; !ok {
+		return nil
+	}
+	isKey := func(e parser.Expression) bool {
+		id, ok := e.(*parser.Identifier)
+		return ok && schema.GetColumnIndex(id.Value) == keyIndex
+	}
+	if lit, ok := expr.Right.(*parser.Literal); ok && isKey(expr.Left) {
+		return []keyRange{{expr.Operator, lit.Value}}
+	}
+	if lit, ok := expr.Left.(*parser.Literal); ok && isKey(expr.Right) {
+		return []keyRange{{flipped[expr.Operator], lit.Value}}
+	}
+	return nil
+}
+
+// boundMatches reports whether a partition bound may hold values satisfying
+// every key range. Comparisons that cannot be made keep the partition.
+func boundMatches(bound storage.PartitionBound, col storage.Column, ranges []keyRange) bool {
+	// compare reports a and b's order, or false when either is unbounded or
+	// they cannot be compared
+	compare := func(a, b interface{}) (int, bool) {
+		if a == nil || b == nil {
+			return 0, false
+		}
+		c, err := storage.CompareValues(a, b, col.CompareCollation())
+		return c, err == nil
+	}
+
+	for _, r := range ranges {
+		if bound.Modulus > 0 {
+			// Only equality picks out a hash bucket
+			hash, err := storage.HashKey(r.value, col)
+			if r.operator == "=" && err == nil && hash%uint64(bound.Modulus) != uint64(bound.Remainder) {
+				return false
+			}
+			continue
+		}
+		switch r.operator {
+		case "=":
+			if c, ok := compare(r.value, bound.From); ok && c < 0 {
+				return false
+			}
+			if c, ok := compare(r.value, bound.To); ok && c >= 0 {
+				return false
+			}
+		case "<":
+			if c, ok := compare(bound.From, r.value); ok && c >= 0 {
+				return false
+			}
+		case "<=":
+			if c, ok := compare(bound.From, r.value); ok && c > 0 {
+				return false
+			}
+		case ">", ">=":
+			if c, ok := compare(r.value, bound.To); ok && c >= 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkPartitionBound rejects an updated row whose partition key moved
+// outside the bound of the partition holding it
+func checkPartitionBound(partition *storage.Table, bound storage.PartitionBound, col storage.Column, values []interface{}) error {
+	value := values[partition.Schema.GetColumnIndex(col.Name)]
+	if !bound.Contains(value, col) {
+		return fmt.Errorf("cannot move a row out of partition %s: %s = %v is outside %s", partition.Schema.TableName, col.Name, value, bound)
+	}
+	return nil
+}
+
+// changesPartitions reports whether a statement changes which partitions a
+// partitioned table has, which concurrent statements read
+func changesPartitions(stmt parser.Statement) bool {
+	switch s := stmt.(type) {
+	case *parser.AlterTableStmt:
+		return s.Action == "ATTACH" || s.Action == "DETACH"
+	case *parser.CreateTableStmt:
+		return s.PartitionOf != ""
+	case *parser.DropTableStmt:
+		return true // the table may be a partition or partitioned
+	default:
+		return false
+	}
+}
+
+// invalidateResults drops cached results that read a table, including
+// results read through its partitioned parent or from its partitions
+func (e *Executor) invalidateResults(tableName string) {
+	e.results.invalidate(tableName)
+	table, err := e.storage.GetTable(tableName)
+	if err != nil {
+		return
+	}
+	if parent := table.Schema.PartitionOf; parent != "" {
+		e.results.invalidate(parent)
+	}
+	for _, bound := range table.Schema.Partitions {
+		e.results.invalidate(bound.Table)
+	}
+}
@@ -0,0 +1,185 @@
+package executor
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+)
+
+// DefaultPlanCacheSize is the number of parsed statements kept by default
+const DefaultPlanCacheSize = 256
+
+// planCache is an LRU cache of parsed statements keyed on normalized SQL
+// text. Cached statements are shared between callers and must not be
+// modified during execution.
+type planCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+	hits     int64
+	misses   int64
+}
+
+// planCacheEntry is a cached statement
+type planCacheEntry struct {
+	key  string
+	stmt parser.Statement
+}
+
+// newPlanCache creates a cache holding up to capacity statements
+func newPlanCache(capacity int) *planCache {
+	return &planCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// SetPlanCacheSize changes the number of parsed statements the executor
+// keeps. A size of zero disables the cache.
+func (e *Executor) SetPlanCacheSize(size int) {
+	e.plans.resize(size)
+}
+
+// parse returns the statement for a query, from the cache when possible
+func (e *Executor) parse(query string) (parser.Statement, error) {
+	key := normalizeQuery(query)
+	if stmt, ok := e.plans.get(key); ok {
+		return stmt, nil
+	}
+
+	stmt, err := parser.NewParser(query).Parse()
+	if err != nil {
+		return nil, err
+	}
+	e.plans.put(key, stmt)
+	return stmt, nil
+}
+
+// get looks up a statement and marks it as recently used
+func (c *planCache) get(key string) (parser.Statement, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capacity <= 0 {
+		return nil, false
+	}
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*planCacheEntry).stmt, true
+}
+
+// put adds a statement, evicting the least recently used one when full
+func (c *planCache) put(key string, stmt parser.Statement) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.capacity <= 0 {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*planCacheEntry).stmt = stmt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&planCacheEntry{key: key, stmt: stmt})
+	c.evict()
+}
+
+// resize changes the capacity, evicting entries as needed
+func (c *planCache) resize(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.capacity = capacity
+	c.evict()
+}
+
+// evict drops least recently used entries beyond capacity; callers must
+// hold the lock
+func (c *planCache) evict() {
+	for c.order.Len() > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*planCacheEntry).key)
+	}
+}
+
+// invalidate empties the cache, e.g. after a schema change
+func (c *planCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// counters returns the hit and miss counts and the current size
+func (c *planCache) counters() (hits, misses int64, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hits, c.misses, c.order.Len()
+}
+
+// normalizeQuery drops comments, collapses whitespace outside string
+// literals and drops a trailing semicolon, so trivially different spellings
+// share a cache entry. Comments go first: joining lines would otherwise
+// carry a -- comment on to the text of the next.
+func normalizeQuery(query string) string {
+	query = parser.StripComments(query)
+
+	var b strings.Builder
+	b.Grow(len(query))
+
+	var quote byte
+	space := false
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		if quote != 0 {
+			b.WriteByte(ch)
+			if ch == '\\' && i+1 < len(query) {
+				// An escaped character never ends the literal
+				i++
+				b.WriteByte(query[i])
+			} else if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case ' ', '\t', '\n', '\r':
+			space = true
+			continue
+		case '\'', '"':
+			quote = ch
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteByte(ch)
+	}
+
+	return strings.TrimSpace(strings.TrimSuffix(b.String(), ";"))
+}
+
+// invalidatesPlans reports whether a statement changes the schema, making
+// cached statements stale
+func invalidatesPlans(stmt parser.Statement) bool {
+	switch stmt.(type) {
+	case *parser.CreateTableStmt, *parser.DropTableStmt, *parser.RestoreStmt,
+		*parser.AlterTableStmt, *parser.CreateMaterializedViewStmt:
+		return true
+	default:
+		return false
+	}
+}
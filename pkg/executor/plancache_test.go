@@ -0,0 +1,51 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// TestCommentEndsAtLineBreak checks that a -- comment does not swallow the
+// next line in the cache key, whichever spelling is cached first
+func TestCommentEndsAtLineBreak(t *testing.T) {
+	const (
+		filtered   = "DELETE FROM t -- c\nWHERE id = 1"
+		unfiltered = "DELETE FROM t -- c WHERE id = 1"
+	)
+	tests := []struct {
+		order [2]string
+		want  [2]int // rows each deletes
+	}{
+		{[2]string{filtered, unfiltered}, [2]int{1, 2}},
+		{[2]string{unfiltered, filtered}, [2]int{3, 0}},
+	}
+	for _, tt := range tests {
+		store, err := storage.NewStorage(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := NewExecutor(store)
+		ctx := context.Background()
+		for _, query := range []string{
+			"CREATE TABLE t (id INTEGER PRIMARY KEY)",
+			"INSERT INTO t VALUES (1), (2), (3)",
+		} {
+			if _, err := e.Query(ctx, query); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for i, query := range tt.order {
+			result, err := e.Query(ctx, query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.RowsAffected != tt.want[i] {
+				t.Errorf("%q deleted %d row(s), want %d", query, result.RowsAffected, tt.want[i])
+			}
+		}
+		store.Close()
+	}
+}
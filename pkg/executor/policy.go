@@ -0,0 +1,169 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// executeCreatePolicy executes CREATE POLICY statement
+func (e *Executor) executeCreatePolicy(ctx context.Context, stmt *parser.CreatePolicyStmt) (*Result, error) {
+	table, err := e.storage.GetTable(stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPolicyCondition(table.Schema, stmt.Using); err != nil {
+		return nil, fmt.Errorf("policy %s: %w", stmt.Name, err)
+	}
+
+	policy := storage.Policy{Name: stmt.Name, Using: parser.FormatExpression(stmt.Using)}
+	if err := e.storage.CreatePolicy(stmt.TableName, policy); err != nil {
+		return nil, err
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: fmt.Sprintf("Policy %s created on table %s", stmt.Name, stmt.TableName)}, nil
+}
+
+// executeDropPolicy executes DROP POLICY statement
+func (e *Executor) executeDropPolicy(ctx context.Context, stmt *parser.DropPolicyStmt) (*Result, error) {
+	if err := e.storage.DropPolicy(stmt.TableName, stmt.Name); err != nil {
+		return nil, err
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: fmt.Sprintf("Policy %s dropped from table %s", stmt.Name, stmt.TableName)}, nil
+}
+
+// checkPolicyCondition rejects USING clauses that cannot be evaluated
+// against a row: a policy is one comparison of columns, literals and
+// @current_user
+func checkPolicyCondition(schema *storage.Schema, expr parser.Expression) error {
+	cond, ok := expr.(*parser.BinaryExpr)
+	if !ok || isValueOperator(cond.Operator) {
+		return fmt.Errorf("USING must be a comparison")
+	}
+	if err := checkPolicyOperand(schema, cond.Left); err != nil {
+		return err
+	}
+	return checkPolicyOperand(schema, cond.Right)
+}
+
+// checkPolicyOperand checks one side of a policy comparison
+func checkPolicyOperand(schema *storage.Schema, expr parser.Expression) error {
+	switch ex := expr.(type) {
+	case *parser.Literal, *parser.NullLiteral:
+		return nil
+	case *parser.Identifier:
+		if schema.GetColumnIndex(ex.Value) == -1 {
+			return fmt.Errorf("column %s does not exist", ex.Value)
+		}
+		return nil
+	case *parser.VariableRef:
+		if ex.Name != CurrentUserVar {
+			return fmt.Errorf("only @%s may be used, not @%s", CurrentUserVar, ex.Name)
+		}
+		return nil
+	case *parser.FunctionCall:
+		if fn, ok := functions[ex.Name]; ok && fn.volatile {
+			return fmt.Errorf("volatile function %s is not allowed", ex.Name)
+		}
+		for _, arg := range ex.Args {
+			if err := checkPolicyOperand(schema, arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parser.BinaryExpr:
+		if !isValueOperator(ex.Operator) {
+			return fmt.Errorf("operator %s is not allowed", ex.Operator)
+		}
+		if err := checkPolicyOperand(schema, ex.Left); err != nil {
+			return err
+		}
+		return checkPolicyOperand(schema, ex.Right)
+	default:
+		return fmt.Errorf("unsupported expression")
+	}
+}
+
+// policies returns the USING conditions restricting the current user's
+// access to a table. Statements that run for no user are not restricted.
+func (e *Executor) policies(ctx context.Context, schema *storage.Schema) ([]parser.Expression, error) {
+	if UserFrom(ctx) == "" || len(schema.Policies) == 0 {
+		return nil, nil
+	}
+	conditions := make([]parser.Expression, len(schema.Policies))
+	for i, policy := range schema.Policies {
+		cond, err := e.storedExpression(policy.Using)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy %s: %w", policy.Name, err)
+		}
+		conditions[i] = cond
+	}
+	return conditions, nil
+}
+
+// visible reports whether a row passes any of a table's policies. The row
+// must include its virtual columns.
+func (e *Executor) visible(ctx context.Context, policies []parser.Expression, row *storage.Row, schema *storage.Schema) (bool, error) {
+	if policies == nil {
+		return true, nil
+	}
+	for _, cond := range policies {
+		match, err := e.evaluateCondition(ctx, cond, row, schema)
+		if err != nil {
+			return false, err
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// visibleRows returns the rows of a table the current user may see
+func (e *Executor) visibleRows(ctx context.Context, schema *storage.Schema, rows []*storage.Row) ([]*storage.Row, error) {
+	policies, err := e.policies(ctx, schema)
+	if err != nil || policies == nil {
+		return rows, err
+	}
+	visibleRows := []*storage.Row{}
+	for i, row := range rows {
+		if err := checkCancelled(ctx, i); err != nil {
+			return nil, err
+		}
+		ok, err := e.visible(ctx, policies, row, schema)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			visibleRows = append(visibleRows, row)
+		}
+	}
+	return visibleRows, nil
+}
+
+// checkPolicies rejects a new or updated row the current user would not be
+// allowed to see
+func (e *Executor) checkPolicies(ctx context.Context, schema *storage.Schema, policies []parser.Expression, values []interface{}) error {
+	if policies == nil {
+		return nil
+	}
+	row, err := e.withVirtual(ctx, schema, storage.NewRow(values))
+	if err != nil {
+		return err
+	}
+	ok, err := e.visible(ctx, policies, row, schema)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("new row violates row-level security policy for table %s", schema.TableName)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrQueryCanceled is returned by a statement stopped with KillQuery
+var ErrQueryCanceled = errors.New("canceling statement due to user request")
+
+// RunningQuery describes a statement that is executing or waiting to be
+// admitted
+type RunningQuery struct {
+	ID        int64     `json:"id"`
+	Query     string    `json:"query"`
+	User      string    `json:"user,omitempty"`
+	Session   string    `json:"session,omitempty"`
+	State     string    `json:"state"` // "queued" while waiting for admission, then "running"
+	StartedAt time.Time `json:"startedAt"`
+	ElapsedMs float64   `json:"elapsedMs"`
+}
+
+// runningQuery is a registered statement and the function that stops it
+type runningQuery struct {
+	info   RunningQuery
+	cancel context.CancelCauseFunc
+}
+
+// queryRegistry tracks the statements the executor is running
+type queryRegistry struct {
+	mu      sync.Mutex
+	nextID  int64
+	running map[int64]*runningQuery
+}
+
+// trackQuery registers a statement until the returned function is called,
+// returning a context that KillQuery cancels
+func (e *Executor) trackQuery(ctx context.Context, query string) (context.Context, *runningQuery, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	q := &runningQuery{
+		info: RunningQuery{
+			Query:     query,
+			User:      UserFrom(ctx),
+			State:     "queued",
+			StartedAt: time.Now(),
+		},
+		cancel: cancel,
+	}
+	if session := SessionFrom(ctx); session != nil {
+		q.info.Session = session.ID
+	}
+
+	r := &e.queries
+	r.mu.Lock()
+	if r.running == nil {
+		r.running = make(map[int64]*runningQuery)
+	}
+	r.nextID++
+	q.info.ID = r.nextID
+	r.running[q.info.ID] = q
+	r.mu.Unlock()
+
+	return ctx, q, func() {
+		r.mu.Lock()
+		delete(r.running, q.info.ID)
+		r.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// setRunning marks a tracked statement as admitted
+func (e *Executor) setRunning(q *runningQuery) {
+	e.queries.mu.Lock()
+	q.info.State = "running"
+	e.queries.mu.Unlock()
+}
+
+// RunningQueries lists the statements executing or waiting for admission,
+// oldest first
+func (e *Executor) RunningQueries() []RunningQuery {
+	r := &e.queries
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	queries := make([]RunningQuery, 0, len(r.running))
+	for _, q := range r.running {
+		info := q.info
+		info.ElapsedMs = float64(now.Sub(info.StartedAt).Microseconds()) / 1000
+		queries = append(queries, info)
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].ID < queries[j].ID })
+	return queries
+}
+
+// KillQuery cancels a running statement, which fails with
+// ErrQueryCanceled the next time it checks for cancellation. A statement
+// that has finished scanning and is applying its changes completes them.
+func (e *Executor) KillQuery(id int64) error {
+	r := &e.queries
+	r.mu.Lock()
+	q, ok := r.running[id]
+	r.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no running query with id %d", id)
+	}
+	q.cancel(ErrQueryCanceled)
+	return nil
+}
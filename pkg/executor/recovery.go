@@ -0,0 +1,252 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/wal"
+)
+
+// backupDirName is the directory (inside the data directory) base backups
+// are written to, one subdirectory per backup named after its LSN
+const backupDirName = "backups"
+
+// restoreUndoDirName is the directory (inside the data directory) RESTORE
+// copies the tables to while it runs
+const restoreUndoDirName = "restore-undo"
+
+// timestampLayouts are the accepted formats for RESTORE TO TIMESTAMP
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// baseBackup describes a snapshot of all tables taken at an LSN
+type baseBackup struct {
+	LSN uint64
+	Dir string
+}
+
+// executeBackup executes BACKUP statement
+func (e *Executor) executeBackup(ctx context.Context, stmt *parser.BackupStmt) (*Result, error) {
+	if e.wal == nil {
+		return nil, fmt.Errorf("BACKUP requires a WAL")
+	}
+
+	lsn := e.wal.LastLSN()
+	dir := filepath.Join(e.storage.DataDir(), backupDirName, fmt.Sprintf("%020d", lsn))
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to replace existing backup: %w", err)
+	}
+	if err := e.storage.SnapshotTo(dir); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Message: fmt.Sprintf("Base backup created at LSN %d", lsn),
+	}, nil
+}
+
+// executeRestore executes RESTORE TO statement. The newest base backup at or
+// before the target is loaded and the WAL is replayed on top of it. WAL
+// records after the target are archived and removed from the log.
+func (e *Executor) executeRestore(ctx context.Context, stmt *parser.RestoreStmt) (*Result, error) {
+	if e.wal == nil {
+		return nil, fmt.Errorf("point-in-time recovery requires a WAL")
+	}
+
+	target := stmt.LSN
+	if stmt.Timestamp != "" {
+		t, err := parseTimestamp(stmt.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		target = e.wal.LSNAt(t)
+	}
+
+	if last := e.wal.LastLSN(); target > last {
+		return nil, fmt.Errorf("LSN %d is beyond the end of the WAL (last LSN is %d)", target, last)
+	}
+	for _, restore := range e.wal.Restores() {
+		if restore.To < target && target <= restore.LSN {
+			return nil, fmt.Errorf("LSN %d was discarded by the restore to LSN %d", target, restore.To)
+		}
+	}
+
+	base, err := e.findBaseBackup(target)
+	if err != nil {
+		return nil, err
+	}
+	if first := e.wal.FirstLSN(); first > base.LSN+1 {
+		return nil, fmt.Errorf("cannot restore to LSN %d: WAL starts at LSN %d and no base backup covers the gap", target, first)
+	}
+
+	// Keep the tables as they are, to put back if the restore fails part
+	// way rather than leave them half restored
+	undoDir := filepath.Join(e.storage.DataDir(), restoreUndoDirName)
+	if err := os.RemoveAll(undoDir); err != nil {
+		return nil, fmt.Errorf("failed to replace existing undo copy: %w", err)
+	}
+	defer os.RemoveAll(undoDir)
+	if err := e.storage.SnapshotTo(undoDir); err != nil {
+		return nil, err
+	}
+	settings := e.settingValues()
+
+	replayed, err := e.replayTo(ctx, base, target)
+	var restore wal.Restore
+	var discarded int
+	if err == nil {
+		restore, discarded, err = e.wal.TruncateAfter(target)
+	}
+	if err != nil {
+		if undoErr := e.storage.RestoreFrom(undoDir); undoErr != nil {
+			return nil, fmt.Errorf("%w (putting the tables back also failed: %v)", err, undoErr)
+		}
+		if undoErr := e.applySettingValues(settings); undoErr != nil {
+			return nil, fmt.Errorf("%w (putting the settings back also failed: %v)", err, undoErr)
+		}
+		return nil, err
+	}
+
+	// Changes published before the restore describe rows it took back
+	if discarded > 0 && e.changes != nil {
+		e.changes.Reset(restore.LSN)
+	}
+
+	// The restored tables must be on disk before the WAL grows again, or
+	// recovery would replay the new records onto the old tables
+	if e.flushPolicy != FlushEveryStatement {
+		if err := e.checkpoint(ctx, true); err != nil {
+			return nil, err
+		}
+	}
+
+	// Backups taken after the target describe the abandoned history
+	if err := e.removeBackupsAfter(target); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Message: fmt.Sprintf("Restored to LSN %d: replayed %d statement(s) from base backup at LSN %d, discarded %d later statement(s)",
+			target, replayed, base.LSN, discarded),
+	}, nil
+}
+
+// replayTo loads a base backup and replays the WAL on top of it up to
+// target, and returns the number of statements replayed
+func (e *Executor) replayTo(ctx context.Context, base baseBackup, target uint64) (int, error) {
+	if err := e.storage.RestoreFrom(base.Dir); err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, rec := range e.wal.ReadFrom(base.LSN, 0) {
+		if rec.LSN > target {
+			break
+		}
+		replayStmt, err := parser.NewParser(rec.Query).Parse()
+		if err != nil {
+			return replayed, fmt.Errorf("LSN %d: %w", rec.LSN, err)
+		}
+		if _, err := e.execute(WithUser(ctx, rec.User), replayStmt); err != nil {
+			return replayed, fmt.Errorf("LSN %d: %w", rec.LSN, err)
+		}
+		replayed++
+	}
+
+	if err := e.persist(ctx); err != nil {
+		return replayed, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return replayed, nil
+}
+
+// RestoreTo restores the database to lsn as RESTORE TO LSN does, also when
+// it is read-only. A follower uses it to take back the records its leader's
+// RESTORE discarded.
+func (e *Executor) RestoreTo(ctx context.Context, lsn uint64) (*Result, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.execute(ctx, &parser.RestoreStmt{LSN: lsn})
+}
+
+// listBackups returns all base backups ordered by LSN
+func (e *Executor) listBackups() ([]baseBackup, error) {
+	root := filepath.Join(e.storage.DataDir(), backupDirName)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	backups := []baseBackup{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		lsn, err := strconv.ParseUint(entry.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, baseBackup{LSN: lsn, Dir: filepath.Join(root, entry.Name())})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].LSN < backups[j].LSN })
+	return backups, nil
+}
+
+// removeBackupsAfter deletes base backups taken after lsn
+func (e *Executor) removeBackupsAfter(lsn uint64) error {
+	backups, err := e.listBackups()
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		if backup.LSN > lsn {
+			if err := os.RemoveAll(backup.Dir); err != nil {
+				return fmt.Errorf("failed to remove backup at LSN %d: %w", backup.LSN, err)
+			}
+		}
+	}
+	return nil
+}
+
+// findBaseBackup returns the newest base backup taken at or before lsn. When
+// there is none, an empty database at LSN 0 is used.
+func (e *Executor) findBaseBackup(lsn uint64) (baseBackup, error) {
+	backups, err := e.listBackups()
+	if err != nil {
+		return baseBackup{}, err
+	}
+
+	base := baseBackup{}
+	for _, backup := range backups {
+		if backup.LSN <= lsn {
+			base = backup
+		}
+	}
+	return base, nil
+}
+
+// parseTimestamp parses a RESTORE TO TIMESTAMP value. Times without a zone
+// are interpreted in the server's local time zone.
+func parseTimestamp(value string) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp: %s", value)
+}
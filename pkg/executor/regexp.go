@@ -0,0 +1,136 @@
+package executor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// maxCachedPatterns bounds the compiled patterns kept by a patternCache.
+// Queries usually repeat a handful of patterns, so when a cache fills it is
+// simply emptied rather than tracking which pattern was used least.
+const maxCachedPatterns = 256
+
+// patternCache holds compiled regular expressions by their source, so a
+// REGEXP condition compiles its pattern once rather than for every row
+type patternCache struct {
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+// compile returns the compiled form of pattern
+func (c *patternCache) compile(pattern string) (*regexp.Regexp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if re, ok := c.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+	}
+	if c.patterns == nil || len(c.patterns) >= maxCachedPatterns {
+		c.patterns = make(map[string]*regexp.Regexp)
+	}
+	c.patterns[pattern] = re
+	return re, nil
+}
+
+// isMatchOperator reports whether operator matches a string against a
+// regular expression: ~ (REGEXP) or !~ (NOT REGEXP)
+func isMatchOperator(operator string) bool {
+	return operator == "~" || operator == "!~"
+}
+
+// matchPattern applies ~ or !~, reporting whether left matches (or, for
+// !~, does not match) the regular expression right. As with the other
+// comparisons, NULL matches nothing.
+func (e *Executor) matchPattern(left, right interface{}, operator string) (bool, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+	s, ok := left.(string)
+	if !ok {
+		return false, fmt.Errorf("cannot match %T against a regular expression", left)
+	}
+	pattern, ok := right.(string)
+	if !ok {
+		return false, fmt.Errorf("regular expression must be a string, got %T", right)
+	}
+	re, err := e.patterns.compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s) == (operator == "~"), nil
+}
+
+// isLikeOperator reports whether operator matches a string against a LIKE
+// pattern: [NOT] LIKE or [NOT] ILIKE
+func isLikeOperator(operator string) bool {
+	switch operator {
+	case "LIKE", "NOT LIKE", "ILIKE", "NOT ILIKE":
+		return true
+	}
+	return false
+}
+
+// matchLike applies [NOT] LIKE or [NOT] ILIKE, reporting whether left
+// matches (or, with NOT, does not match) the whole of the pattern right. In
+// the pattern % matches any run of characters, _ any one character, and a
+// backslash makes the character after it match itself. ILIKE ignores case,
+// and so does LIKE under the nocase collation, as on CITEXT columns.
+func (e *Executor) matchLike(left, right interface{}, operator, collation string) (bool, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+	s, ok := left.(string)
+	if !ok {
+		return false, fmt.Errorf("cannot match %T against a LIKE pattern", left)
+	}
+	pattern, ok := right.(string)
+	if !ok {
+		return false, fmt.Errorf("LIKE pattern must be a string, got %T", right)
+	}
+	fold := strings.HasSuffix(operator, "ILIKE") || collation == storage.CollationNoCase
+	re, err := e.patterns.compile(likeExpression(pattern, fold))
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s) != strings.HasPrefix(operator, "NOT "), nil
+}
+
+// likeExpression translates a LIKE pattern into an anchored regular
+// expression, so compiled patterns share the REGEXP cache
+func likeExpression(pattern string, fold bool) string {
+	var b strings.Builder
+	b.WriteString("(?s)")
+	if fold {
+		b.WriteString("(?i)")
+	}
+	b.WriteByte('^')
+	escaped := false
+	for _, ch := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == '%':
+			b.WriteString(".*")
+		case ch == '_':
+			b.WriteByte('.')
+		default:
+			b.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	// A backslash ending the pattern matches itself
+	if escaped {
+		b.WriteString(`\\`)
+	}
+	b.WriteByte('$')
+	return b.String()
+}
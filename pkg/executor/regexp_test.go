@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// TestLike checks LIKE and ILIKE patterns, and that LIKE ignores case on
+// CITEXT columns only
+func TestLike(t *testing.T) {
+	store, err := storage.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	e := NewExecutor(store)
+	ctx := context.Background()
+	for _, query := range []string{
+		"CREATE TABLE u (id INTEGER PRIMARY KEY, email CITEXT, name VARCHAR(20))",
+		"INSERT INTO u VALUES (1, 'Bob@Example.com', 'Bob'), (2, 'alice@test.org', '50%_off'), (3, NULL, 'bobby')",
+	} {
+		if _, err := e.Query(ctx, query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		where string
+		want  []interface{}
+	}{
+		{"email LIKE 'bob@%'", []interface{}{1}},
+		{"name LIKE 'b%'", []interface{}{3}},
+		{"name ILIKE 'b%'", []interface{}{1, 3}},
+		{"name LIKE 'Bo_'", []interface{}{1}},
+		{"name LIKE '50\\%\\_off'", []interface{}{2}},
+		{"name LIKE '50\\%x'", nil},
+		{"email NOT LIKE '%.COM'", []interface{}{2}},
+		{"name NOT ILIKE 'BOB%'", []interface{}{2}},
+	}
+	for _, tt := range tests {
+		result, err := e.Query(ctx, "SELECT id FROM u WHERE "+tt.where+" ORDER BY id")
+		if err != nil {
+			t.Errorf("%s: %v", tt.where, err)
+			continue
+		}
+		var got []interface{}
+		for _, row := range result.Rows {
+			got = append(got, row[0])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s matched %v, want %v", tt.where, got, tt.want)
+		}
+	}
+}
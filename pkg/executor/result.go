@@ -11,6 +11,7 @@ type Result struct {
 	Rows         [][]interface{} // Row data for SELECT queries
 	Message      string          // Message for non-SELECT queries
 	RowsAffected int             // Number of rows affected
+	Plan         *Plan           // Query plan for EXPLAIN
 }
 
 // FormatTable formats the result as a table string
@@ -0,0 +1,285 @@
+package executor
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+)
+
+// ResultCacheLimits bounds the SELECT result cache. A zero MaxEntries
+// disables the cache; a zero MaxBytes or TTL means no limit.
+type ResultCacheLimits struct {
+	MaxEntries int
+	MaxBytes   int64
+	TTL        time.Duration
+}
+
+// resultCache is an LRU cache of SELECT results keyed on normalized SQL text
+// with session variables inlined. Entries are dropped as soon as a table
+// they read is written. Cached results are shared between callers and must
+// not be modified.
+type resultCache struct {
+	mu       sync.Mutex
+	limits   ResultCacheLimits
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+	byTable  map[string]map[*list.Element]bool
+	versions map[string]uint64 // writes seen per table
+	bytes    int64
+	hits     int64
+	misses   int64
+}
+
+// resultCacheEntry is a cached result and the table versions it reflects
+type resultCacheEntry struct {
+	key      string
+	result   *Result
+	versions map[string]uint64
+	size     int64
+	expires  time.Time // zero when entries do not expire
+}
+
+// newResultCache creates a disabled cache
+func newResultCache() *resultCache {
+	return &resultCache{
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		byTable:  make(map[string]map[*list.Element]bool),
+		versions: make(map[string]uint64),
+	}
+}
+
+// SetResultCacheLimits enables, resizes or (with zero MaxEntries) disables
+// the SELECT result cache
+func (e *Executor) SetResultCacheLimits(limits ResultCacheLimits) {
+	e.results.setLimits(limits)
+}
+
+// cachedSelect runs a SELECT through the result cache
+func (e *Executor) cachedSelect(ctx context.Context, query string, stmt *parser.SelectStmt, run func() (*Result, error)) (*Result, error) {
+	if !e.results.enabled() || !cacheable(stmt) || e.readsForeignTable(stmt) {
+		return run()
+	}
+	inlined, err := loggedQuery(ctx, query)
+	if err != nil {
+		return run()
+	}
+	key := normalizeQuery(inlined)
+	if user := UserFrom(ctx); user != "" {
+		// Policies may show each user different rows
+		key = user + "\x00" + key
+	}
+
+	if result, ok := e.results.get(key); ok {
+		return result, nil
+	}
+
+	// Take the versions before running, so a write that lands while the
+	// query runs keeps its result out of the cache
+	versions := e.results.snapshot(selectTables(stmt))
+	result, err := run()
+	if err == nil {
+		e.results.put(key, result, versions)
+	}
+	return result, err
+}
+
+// cacheable reports whether a SELECT returns the same rows every time the
+// tables it reads are unchanged
+func cacheable(stmt *parser.SelectStmt) bool {
+	if stmt.Sample != nil && !stmt.Sample.Repeatable {
+		return false
+	}
+
+	exprs := selectConditions(stmt)
+	volatile := false
+	for _, expr := range exprs {
+		parser.WalkExpression(expr, func(expr parser.Expression) {
+			if call, ok := expr.(*parser.FunctionCall); ok && functions[call.Name].volatile {
+				volatile = true
+			}
+		})
+	}
+	for _, sub := range expressionSubqueries(exprs...) {
+		if !cacheable(sub) {
+			return false
+		}
+	}
+	return !volatile
+}
+
+// selectTables returns the tables a SELECT reads, including those its
+// subqueries read
+func selectTables(stmt *parser.SelectStmt) []string {
+	tables := []string{stmt.TableName}
+	for _, join := range stmt.Joins {
+		tables = append(tables, join.TableName)
+	}
+	for _, sub := range expressionSubqueries(selectConditions(stmt)...) {
+		tables = append(tables, selectTables(sub)...)
+	}
+	return tables
+}
+
+// enabled reports whether results are being cached
+func (c *resultCache) enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limits.MaxEntries > 0
+}
+
+// get looks up a result and marks it as recently used
+func (c *resultCache) get(key string) (*Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok {
+		entry := elem.Value.(*resultCacheEntry)
+		if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+			c.remove(elem)
+			ok = false
+		}
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	result := *elem.Value.(*resultCacheEntry).result
+	return &result, true
+}
+
+// snapshot returns the current versions of tables
+func (c *resultCache) snapshot(tables []string) map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	versions := make(map[string]uint64, len(tables))
+	for _, table := range tables {
+		versions[table] = c.versions[table]
+	}
+	return versions
+}
+
+// put caches a result computed at the given table versions, unless a
+// table has been written since or the result is too large
+func (c *resultCache) put(key string, result *Result, versions map[string]uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.limits.MaxEntries <= 0 {
+		return
+	}
+	for table, version := range versions {
+		if c.versions[table] != version {
+			return
+		}
+	}
+	size := resultSize(result)
+	if c.limits.MaxBytes > 0 && size > c.limits.MaxBytes {
+		return
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	entry := &resultCacheEntry{key: key, result: result, versions: versions, size: size}
+	if c.limits.TTL > 0 {
+		entry.expires = time.Now().Add(c.limits.TTL)
+	}
+	elem := c.order.PushFront(entry)
+	c.entries[key] = elem
+	for table := range versions {
+		if c.byTable[table] == nil {
+			c.byTable[table] = make(map[*list.Element]bool)
+		}
+		c.byTable[table][elem] = true
+	}
+	c.bytes += size
+	c.evict()
+}
+
+// invalidate drops the results that read a table after it is written
+func (c *resultCache) invalidate(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.versions[table]++
+	for elem := range c.byTable[table] {
+		c.remove(elem)
+	}
+}
+
+// invalidateAll drops every result, e.g. after RESTORE
+func (c *resultCache) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for table := range c.versions {
+		c.versions[table]++
+	}
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	c.byTable = make(map[string]map[*list.Element]bool)
+	c.bytes = 0
+}
+
+// setLimits changes the limits, evicting entries as needed
+func (c *resultCache) setLimits(limits ResultCacheLimits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.limits = limits
+	c.evict()
+}
+
+// evict drops least recently used entries beyond the limits; callers must
+// hold the lock
+func (c *resultCache) evict() {
+	for c.order.Len() > 0 && (c.order.Len() > c.limits.MaxEntries ||
+		(c.limits.MaxBytes > 0 && c.bytes > c.limits.MaxBytes)) {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove drops an entry; callers must hold the lock
+func (c *resultCache) remove(elem *list.Element) {
+	entry := elem.Value.(*resultCacheEntry)
+	c.order.Remove(elem)
+	delete(c.entries, entry.key)
+	for table := range entry.versions {
+		delete(c.byTable[table], elem)
+		if len(c.byTable[table]) == 0 {
+			delete(c.byTable, table)
+		}
+	}
+	c.bytes -= entry.size
+}
+
+// counters returns the hit and miss counts, the number of entries and
+// their estimated size
+func (c *resultCache) counters() (hits, misses int64, size int, bytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hits, c.misses, c.order.Len(), c.bytes
+}
+
+// resultSize estimates the memory held by a result
+func resultSize(result *Result) int64 {
+	size := int64(len(result.Message))
+	for _, column := range result.Columns {
+		size += int64(len(column))
+	}
+	for _, row := range result.Rows {
+		for _, value := range row {
+			size += valueSize(value)
+		}
+	}
+	return size
+}
@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// returning collects the rows a write affected for its RETURNING clause,
+// as the user may read them
+type returning struct {
+	schema  *storage.Schema
+	access  *columnAccess
+	indices []int
+	columns []string
+	rows    [][]interface{}
+}
+
+// newReturning checks the RETURNING columns of a write to a table before it
+// runs, returning nil when it has none. RETURNING * lists the columns the
+// user may read; naming one they may not is an error.
+func (e *Executor) newReturning(ctx context.Context, schema *storage.Schema, columns []string) (*returning, error) {
+	if columns == nil {
+		return nil, nil
+	}
+	access, err := e.accessTo(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	r := &returning{schema: schema, access: access}
+	if len(columns) == 1 && columns[0] == "*" {
+		for i, col := range schema.Columns {
+			if access.readable(i) {
+				r.indices = append(r.indices, i)
+				r.columns = append(r.columns, col.Name)
+			}
+		}
+		return r, nil
+	}
+	for _, name := range columns {
+		idx := schema.GetColumnIndex(name)
+		if idx == -1 {
+			return nil, fmt.Errorf("column %s does not exist", name)
+		}
+		if err := access.check(idx); err != nil {
+			return nil, err
+		}
+		r.indices = append(r.indices, idx)
+		r.columns = append(r.columns, name)
+	}
+	return r, nil
+}
+
+// add records an affected row's values, after the write for INSERT and
+// UPDATE and before it for DELETE
+func (r *returning) add(ctx context.Context, e *Executor, values []interface{}) error {
+	row, err := e.withVirtual(ctx, r.schema, storage.NewRow(values))
+	if err != nil {
+		return err
+	}
+	projected := make([]interface{}, len(r.indices))
+	for i, idx := range r.indices {
+		projected[i] = r.access.mask(idx, row.Values[idx])
+	}
+	r.rows = append(r.rows, projected)
+	return nil
+}
+
+// result returns the result of the write: the returned rows, or just the
+// message when there is no RETURNING clause. The rows are not held to the
+// result limits, as the write has already been applied.
+func (r *returning) result(message string, affected int) *Result {
+	result := &Result{Message: message, RowsAffected: affected}
+	if r != nil {
+		result.Columns = r.columns
+		result.Rows = r.rows
+		if result.Rows == nil {
+			result.Rows = [][]interface{}{}
+		}
+	}
+	return result
+}
@@ -0,0 +1,337 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// sequenceFunctions are the functions reading sequences. An INSERT's calls
+// are replaced by their values before it runs; anywhere else they fail.
+var sequenceFunctions = map[string]bool{"NEXTVAL": true, "CURRVAL": true}
+
+// executeCreateSequence executes CREATE SEQUENCE statement
+func (e *Executor) executeCreateSequence(ctx context.Context, stmt *parser.CreateSequenceStmt) (*Result, error) {
+	table, err := e.systemTable(SequencesTable)
+	if err != nil {
+		return nil, err
+	}
+	if sequenceRow(table, stmt.Name) != nil {
+		return nil, fmt.Errorf("sequence %s already exists", stmt.Name)
+	}
+
+	row := storage.NewRow([]interface{}{stmt.Name, stmt.Start, stmt.Increment, stmt.Start})
+	if err := table.InsertRow(row); err != nil {
+		return nil, err
+	}
+	if cs := changeSetFrom(ctx); cs != nil {
+		cs.add(table.Schema, cdc.OpInsert, nil, row.Values)
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: fmt.Sprintf("Sequence %s created", stmt.Name)}, nil
+}
+
+// executeAlterSequence executes ALTER SEQUENCE ... RESTART, which is also
+// how advancing a sequence is logged
+func (e *Executor) executeAlterSequence(ctx context.Context, stmt *parser.AlterSequenceStmt) (*Result, error) {
+	table, err := e.storage.GetTable(SequencesTable)
+	if err != nil {
+		return nil, fmt.Errorf("sequence %s does not exist", stmt.Name)
+	}
+	cs := changeSetFrom(ctx)
+	count, err := table.UpdateRowsWith(func(row *storage.Row) bool {
+		return row.Values[0] == stmt.Name
+	}, func(values []interface{}) error {
+		old := append([]interface{}{}, values...)
+		values[1] = values[3]
+		if stmt.Restart != nil {
+			values[1] = *stmt.Restart
+		}
+		if cs != nil {
+			cs.add(table.Schema, cdc.OpUpdate, old, values)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("sequence %s does not exist", stmt.Name)
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: fmt.Sprintf("Sequence %s restarted", stmt.Name)}, nil
+}
+
+// executeDropSequence executes DROP SEQUENCE statement
+func (e *Executor) executeDropSequence(ctx context.Context, stmt *parser.DropSequenceStmt) (*Result, error) {
+	table, err := e.storage.GetTable(SequencesTable)
+	if err != nil {
+		return nil, fmt.Errorf("sequence %s does not exist", stmt.Name)
+	}
+	if column := e.sequenceColumn(stmt.Name); column != "" {
+		return nil, fmt.Errorf("cannot drop sequence %s: column %s defaults to it", stmt.Name, column)
+	}
+	cs := changeSetFrom(ctx)
+	count := table.DeleteRows(func(row *storage.Row) bool {
+		if row.Values[0] != stmt.Name {
+			return false
+		}
+		if cs != nil {
+			cs.add(table.Schema, cdc.OpDelete, row.Values, nil)
+		}
+		return true
+	})
+	if count == 0 {
+		return nil, fmt.Errorf("sequence %s does not exist", stmt.Name)
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: fmt.Sprintf("Sequence %s dropped", stmt.Name)}, nil
+}
+
+// changesSequence reports whether a statement creates, restarts or drops a
+// sequence, which must not race with INSERTs advancing it
+func changesSequence(stmt parser.Statement) bool {
+	switch stmt.(type) {
+	case *parser.CreateSequenceStmt, *parser.AlterSequenceStmt, *parser.DropSequenceStmt:
+		return true
+	default:
+		return false
+	}
+}
+
+// sequenceColumn returns a column, as table.column, whose DEFAULT
+// NEXTVAL() reads a sequence, or ""
+func (e *Executor) sequenceColumn(sequence string) string {
+	for _, name := range e.storage.ListTables() {
+		table, err := e.storage.GetTable(name)
+		if err != nil {
+			continue
+		}
+		for _, col := range table.Schema.Columns {
+			call := e.resolvedDefault(col)
+			if call != nil && call.Name == "NEXTVAL" && len(call.Args) == 1 && literalValue(call.Args[0]) == sequence {
+				return name + "." + col.Name
+			}
+		}
+	}
+	return ""
+}
+
+// sequenceRow returns the row of a sequence, or nil
+func sequenceRow(table *storage.Table, name string) *storage.Row {
+	for _, row := range table.SelectRows() {
+		if row.Values[0] == name {
+			return row
+		}
+	}
+	return nil
+}
+
+// usesSequences reports whether an INSERT calls NEXTVAL or CURRVAL
+func usesSequences(stmt *parser.InsertStmt) bool {
+	found := false
+	for _, row := range stmt.Values {
+		for _, expr := range row {
+			parser.WalkExpression(expr, func(expr parser.Expression) {
+				if call, ok := expr.(*parser.FunctionCall); ok && sequenceFunctions[call.Name] {
+					found = true
+				}
+			})
+		}
+	}
+	return found
+}
+
+// resolveSequences returns a copy of an INSERT with its NEXTVAL and CURRVAL
+// calls replaced by their values, so the statement that runs, and is
+// logged, no longer depends on the sequences. Each sequence NEXTVAL
+// advanced is logged first as ALTER SEQUENCE ... RESTART, in the order the
+// values were handed out, so replay leaves it where it is now. Like in
+// other databases, a value is not given back if the INSERT then fails.
+func (e *Executor) resolveSequences(ctx context.Context, stmt *parser.InsertStmt) (*parser.InsertStmt, error) {
+	if e.readOnly {
+		return nil, fmt.Errorf("cannot execute INSERT: database is read-only")
+	}
+	e.sequenceMu.Lock()
+	defer e.sequenceMu.Unlock()
+
+	table, err := e.storage.GetTable(SequencesTable)
+	if err != nil {
+		table = nil // no sequence was ever created
+	}
+	session := SessionFrom(ctx)
+	next := map[string]int{} // next value of each sequence advanced
+	var advanced []string
+
+	resolve := func(call *parser.FunctionCall) (parser.Expression, error) {
+		if len(call.Args) != 1 {
+			return nil, fmt.Errorf("%s() takes 1 argument(s), got %d", call.Name, len(call.Args))
+		}
+		name, ok := literalValue(call.Args[0]).(string)
+		if !ok {
+			return nil, fmt.Errorf("%s() takes the sequence name as a string", call.Name)
+		}
+		var row *storage.Row
+		if table != nil {
+			row = sequenceRow(table, name)
+		}
+		if row == nil {
+			return nil, fmt.Errorf("sequence %s does not exist", name)
+		}
+
+		if call.Name == "CURRVAL" {
+			if session != nil {
+				if value, ok := session.sequenceValue(name); ok {
+					return &parser.Literal{Value: value}, nil
+				}
+			}
+			return nil, fmt.Errorf("CURRVAL of sequence %s is not yet defined in this session", name)
+		}
+		value, ok := next[name]
+		if !ok {
+			value = row.Values[1].(int)
+			advanced = append(advanced, name)
+		}
+		// The value after this one is stored as the next, so the last
+		// value a sequence hands out is the one before it would overflow
+		increment := row.Values[2].(int)
+		if increment > 0 && value > math.MaxInt-increment {
+			return nil, fmt.Errorf("sequence %s reached its maximum value", name)
+		}
+		if increment < 0 && value < math.MinInt-increment {
+			return nil, fmt.Errorf("sequence %s reached its minimum value", name)
+		}
+		next[name] = value + increment
+		if session != nil {
+			session.setSequenceValue(name, value)
+		}
+		return &parser.Literal{Value: value}, nil
+	}
+
+	values := make([][]parser.Expression, len(stmt.Values))
+	for i, row := range stmt.Values {
+		values[i] = make([]parser.Expression, len(row))
+		for j, expr := range row {
+			if values[i][j], err = replaceCalls(expr, sequenceFunctions, resolve); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, name := range advanced {
+		restart := next[name]
+		query := fmt.Sprintf("ALTER SEQUENCE %s RESTART WITH %d", name, restart)
+		if err := e.applyLogged(ctx, &parser.AlterSequenceStmt{Name: name, Restart: &restart}, query); err != nil {
+			return nil, fmt.Errorf("failed to advance sequence %s: %w", name, err)
+		}
+	}
+	return &parser.InsertStmt{TableName: stmt.TableName, Columns: stmt.Columns, Values: values, OnConflict: stmt.OnConflict, Returning: stmt.Returning}, nil
+}
+
+// replaceCalls returns expr with each call to one of the named functions
+// replaced by resolve's result, copying the nodes above a call so the
+// parsed statement, which the plan cache shares, is left unchanged
+func replaceCalls(expr parser.Expression, names map[string]bool, resolve func(*parser.FunctionCall) (parser.Expression, error)) (parser.Expression, error) {
+	switch ex := expr.(type) {
+	case *parser.FunctionCall:
+		if names[ex.Name] {
+			return resolve(ex)
+		}
+		args := make([]parser.Expression, len(ex.Args))
+		for i, arg := range ex.Args {
+			var err error
+			if args[i], err = replaceCalls(arg, names, resolve); err != nil {
+				return nil, err
+			}
+		}
+		return &parser.FunctionCall{Name: ex.Name, Args: args}, nil
+	case *parser.BinaryExpr:
+		left, err := replaceCalls(ex.Left, names, resolve)
+		if err != nil {
+			return nil, err
+		}
+		right, err := replaceCalls(ex.Right, names, resolve)
+		if err != nil {
+			return nil, err
+		}
+		return &parser.BinaryExpr{Left: left, Operator: ex.Operator, Right: right}, nil
+	case *parser.UnaryExpr:
+		operand, err := replaceCalls(ex.Operand, names, resolve)
+		if err != nil {
+			return nil, err
+		}
+		return &parser.UnaryExpr{Operator: ex.Operator, Operand: operand}, nil
+	case *parser.InExpr:
+		left, err := replaceCalls(ex.Left, names, resolve)
+		if err != nil {
+			return nil, err
+		}
+		sub, err := replaceSelectCalls(ex.Subquery, names, resolve)
+		if err != nil {
+			return nil, err
+		}
+		return &parser.InExpr{Left: left, Subquery: sub, Not: ex.Not}, nil
+	case *parser.SubqueryExpr:
+		sub, err := replaceSelectCalls(ex.Select, names, resolve)
+		if err != nil {
+			return nil, err
+		}
+		return &parser.SubqueryExpr{Select: sub}, nil
+	default:
+		return expr, nil
+	}
+}
+
+// replaceSelectCalls is replaceCalls for the conditions of a subquery
+func replaceSelectCalls(stmt *parser.SelectStmt, names map[string]bool, resolve func(*parser.FunctionCall) (parser.Expression, error)) (*parser.SelectStmt, error) {
+	s := *stmt
+	var err error
+	if s.Where, err = replaceCalls(stmt.Where, names, resolve); err != nil {
+		return nil, err
+	}
+	if s.Having, err = replaceCalls(stmt.Having, names, resolve); err != nil {
+		return nil, err
+	}
+	s.Joins = make([]*parser.JoinClause, len(stmt.Joins))
+	for i, join := range stmt.Joins {
+		copied := *join
+		if copied.On, err = replaceCalls(join.On, names, resolve); err != nil {
+			return nil, err
+		}
+		s.Joins[i] = &copied
+	}
+	return &s, nil
+}
+
+// applyLogged executes a statement a query makes on the side and logs it
+// like Query logs statements. The caller holds the executor lock.
+func (e *Executor) applyLogged(ctx context.Context, stmt parser.Statement, query string) error {
+	var cs *changeSet
+	if e.changes != nil {
+		cs = &changeSet{}
+	}
+	if _, err := e.execute(withChangeSet(ctx, cs), stmt); err != nil {
+		return err
+	}
+
+	var lsn uint64
+	if e.wal != nil {
+		rec, err := e.wal.Append(query, "")
+		if err != nil {
+			return fmt.Errorf("statement applied but not logged: %w", err)
+		}
+		lsn = rec.LSN
+	}
+	e.publishChanges(lsn, cs)
+	return nil
+}
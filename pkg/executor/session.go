@@ -0,0 +1,326 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+)
+
+// StatementTimeoutVar is the session variable holding the statement timeout
+// in milliseconds; 0 disables it
+const StatementTimeoutVar = "statement_timeout"
+
+// CurrentUserVar is the read-only variable holding the user a statement runs
+// for, which row-level security policies compare rows against
+const CurrentUserVar = "current_user"
+
+// ErrStatementTimeout is returned when a statement runs longer than the
+// session's statement_timeout
+var ErrStatementTimeout = errors.New("canceling statement due to statement timeout")
+
+// Session holds the state of one client connection, such as the variables
+// assigned with SET
+type Session struct {
+	ID       string
+	mu       sync.Mutex
+	vars     map[string]interface{}
+	lastUsed time.Time
+
+	sequences map[string]int // value NEXTVAL last returned for each sequence
+}
+
+// NewSession creates an empty session
+func NewSession(id string) *Session {
+	return &Session{
+		ID:        id,
+		vars:      make(map[string]interface{}),
+		lastUsed:  time.Now(),
+		sequences: make(map[string]int),
+	}
+}
+
+// Get returns the value of a variable
+func (s *Session) Get(name string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.vars[name]
+	return value, ok
+}
+
+// Set assigns a variable
+func (s *Session) Set(name string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.vars[name] = value
+}
+
+// setSequenceValue records the value NEXTVAL returned for a sequence
+func (s *Session) setSequenceValue(name string, value int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sequences[name] = value
+}
+
+// sequenceValue returns the value NEXTVAL last returned for a sequence in
+// this session
+func (s *Session) sequenceValue(name string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.sequences[name]
+	return value, ok
+}
+
+// Variables returns a copy of all variables
+func (s *Session) Variables() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	vars := make(map[string]interface{}, len(s.vars))
+	for name, value := range s.vars {
+		vars[name] = value
+	}
+	return vars
+}
+
+// StatementTimeout returns the session's statement timeout, or 0
+func (s *Session) StatementTimeout() time.Duration {
+	value, ok := s.Get(StatementTimeoutVar)
+	if !ok {
+		return 0
+	}
+	ms, _ := value.(int)
+	return time.Duration(ms) * time.Millisecond
+}
+
+// touch records that the session was used
+func (s *Session) touch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastUsed = time.Now()
+}
+
+// idleSince returns when the session was last used
+func (s *Session) idleSince() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastUsed
+}
+
+type sessionKey struct{}
+
+type userKey struct{}
+
+// WithSession returns a context that runs queries in session s
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// SessionFrom returns the session attached to ctx, or nil
+func SessionFrom(ctx context.Context) *Session {
+	s, _ := ctx.Value(sessionKey{}).(*Session)
+	return s
+}
+
+// WithUser returns a context that runs queries for user. The user is set by
+// the application (e.g. from an authenticating proxy), not by SQL, so
+// row-level security policies can trust it. An empty user runs queries
+// unrestricted.
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFrom returns the user attached to ctx, or ""
+func UserFrom(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// Sessions tracks sessions by ID for stateless transports such as HTTP.
+// Sessions that are idle for longer than the idle timeout are discarded.
+type Sessions struct {
+	idleTimeout time.Duration
+	mu          sync.Mutex
+	sessions    map[string]*Session
+}
+
+// NewSessions creates a session registry
+func NewSessions(idleTimeout time.Duration) *Sessions {
+	return &Sessions{
+		idleTimeout: idleTimeout,
+		sessions:    make(map[string]*Session),
+	}
+}
+
+// Get returns the session with the given ID, creating it if needed
+func (r *Sessions) Get(id string) *Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expire()
+	s, ok := r.sessions[id]
+	if !ok {
+		s = NewSession(id)
+		r.sessions[id] = s
+	}
+	s.touch()
+	return s
+}
+
+// expire drops idle sessions; callers must hold the lock
+func (r *Sessions) expire() {
+	if r.idleTimeout <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-r.idleTimeout)
+	for id, s := range r.sessions {
+		if s.idleSince().Before(cutoff) {
+			delete(r.sessions, id)
+		}
+	}
+}
+
+// executeSet executes SET statement
+func (e *Executor) executeSet(ctx context.Context, stmt *parser.SetStmt) (*Result, error) {
+	session := SessionFrom(ctx)
+	if session == nil {
+		return nil, fmt.Errorf("SET requires a session")
+	}
+
+	ctx, err := e.withSubqueries(ctx, stmt.Value)
+	if err != nil {
+		return nil, err
+	}
+	value, err := e.evaluateExpression(ctx, stmt.Value, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.Name == CurrentUserVar {
+		return nil, fmt.Errorf("%s is read-only", CurrentUserVar)
+	}
+	if stmt.Name == StatementTimeoutVar {
+		if ms, ok := value.(int); !ok || ms < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative number of milliseconds", StatementTimeoutVar)
+		}
+	}
+
+	session.Set(stmt.Name, value)
+	return &Result{Message: "SET"}, nil
+}
+
+// executeShowVariable executes SHOW <name> and SHOW ALL statements
+func (e *Executor) executeShowVariable(ctx context.Context, stmt *parser.ShowVariableStmt) (*Result, error) {
+	session := SessionFrom(ctx)
+	vars := map[string]interface{}{}
+	if session != nil {
+		vars = session.Variables()
+	}
+	if user := UserFrom(ctx); user != "" {
+		vars[CurrentUserVar] = user
+	}
+
+	if stmt.Name != "" {
+		value, ok := vars[stmt.Name]
+		if !ok {
+			return nil, fmt.Errorf("variable %s is not set", stmt.Name)
+		}
+		return &Result{
+			Columns:      []string{stmt.Name},
+			Rows:         [][]interface{}{{value}},
+			RowsAffected: 1,
+		}, nil
+	}
+
+	rows := [][]interface{}{}
+	for _, name := range sortedKeys(vars) {
+		rows = append(rows, []interface{}{name, vars[name]})
+	}
+	return &Result{
+		Columns:      []string{"name", "value"},
+		Rows:         rows,
+		RowsAffected: len(rows),
+	}, nil
+}
+
+// variable resolves an @name reference in the current session
+func (e *Executor) variable(ctx context.Context, name string) (interface{}, error) {
+	if name == CurrentUserVar {
+		if user := UserFrom(ctx); user != "" {
+			return user, nil
+		}
+		return nil, fmt.Errorf("variable @%s is not set: the statement runs for no user", name)
+	}
+	if session := SessionFrom(ctx); session != nil {
+		if value, ok := session.Get(name); ok {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("variable @%s is not set", name)
+}
+
+// withStatementTimeout applies the session's statement timeout to ctx, or
+// the one set with SET GLOBAL when the session has none
+func (e *Executor) withStatementTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := e.defaultTimeout()
+	if session := SessionFrom(ctx); session != nil {
+		if _, ok := session.Get(StatementTimeoutVar); ok {
+			timeout = session.StatementTimeout()
+		}
+	}
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
+
+// checkCancelled reports whether a running statement should stop. It is
+// cheap enough to call once per scanned row.
+func checkCancelled(ctx context.Context, n int) error {
+	if n%1024 != 0 {
+		return nil
+	}
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return ErrStatementTimeout
+	default:
+		return context.Cause(ctx) // ErrQueryCanceled for a killed statement
+	}
+}
+
+// loggedQuery returns the text to write to the WAL for a query, with
+// session variables replaced by their values so replay does not need the
+// session
+func loggedQuery(ctx context.Context, query string) (string, error) {
+	session := SessionFrom(ctx)
+	user := UserFrom(ctx)
+	if session == nil && user == "" {
+		return query, nil
+	}
+	return parser.InlineVariables(query, func(name string) (interface{}, bool) {
+		if name == CurrentUserVar {
+			return user, user != ""
+		}
+		if session == nil {
+			return nil, false
+		}
+		return session.Get(name)
+	})
+}
+
+// ResolveVariables replaces the session variables in a query with their
+// values from ctx, so the query can run on another server
+func ResolveVariables(ctx context.Context, query string) (string, error) {
+	return loggedQuery(ctx, query)
+}
@@ -0,0 +1,323 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// engineSetting is an engine setting SET GLOBAL and PRAGMA change. Values
+// are kept as text, which is how the settings table stores them.
+type engineSetting struct {
+	description string
+	// get returns the current value
+	get func(e *Executor) string
+	// set validates a value and applies it; callers must hold e.mu
+	// exclusively
+	set func(e *Executor, value string) error
+}
+
+// engineSettings are the settings SET GLOBAL may change
+var engineSettings = map[string]engineSetting{
+	"flush_policy": {
+		description: "when writes reach the disk: statement, interval or group",
+		get:         func(e *Executor) string { return e.flushPolicy.String() },
+		set: func(e *Executor, value string) error {
+			policy, err := ParseFlushPolicy(value)
+			if err != nil {
+				return err
+			}
+			return e.switchFlushPolicy(policy, e.flushInterval)
+		},
+	},
+	"flush_interval": {
+		description: "milliseconds between checkpoints under the interval and group flush policies",
+		get:         func(e *Executor) string { return strconv.FormatInt(e.flushInterval.Milliseconds(), 10) },
+		set: func(e *Executor, value string) error {
+			ms, err := settingInt(value)
+			if err == nil && ms == 0 {
+				err = fmt.Errorf("flush interval must be positive")
+			}
+			if err != nil {
+				return err
+			}
+			return e.switchFlushPolicy(e.flushPolicy, time.Duration(ms)*time.Millisecond)
+		},
+	},
+	"max_result_rows": {
+		description: "rows a result may have; 0 for no limit",
+		get:         func(e *Executor) string { return strconv.Itoa(e.limits.MaxRows) },
+		set: func(e *Executor, value string) error {
+			n, err := settingInt(value)
+			if err == nil {
+				e.limits.MaxRows = int(n)
+			}
+			return err
+		},
+	},
+	"max_result_bytes": {
+		description: "estimated bytes a result may have; 0 for no limit",
+		get:         func(e *Executor) string { return strconv.FormatInt(e.limits.MaxBytes, 10) },
+		set: func(e *Executor, value string) error {
+			n, err := settingInt(value)
+			if err == nil {
+				e.limits.MaxBytes = n
+			}
+			return err
+		},
+	},
+	StatementTimeoutVar: {
+		description: "milliseconds a statement may run in sessions that do not SET their own; 0 for no limit",
+		get:         func(e *Executor) string { return strconv.FormatInt(e.defaultTimeout().Milliseconds(), 10) },
+		set: func(e *Executor, value string) error {
+			ms, err := settingInt(value)
+			if err == nil {
+				e.settingsMu.Lock()
+				e.statementTimeout = time.Duration(ms) * time.Millisecond
+				e.settingsMu.Unlock()
+			}
+			return err
+		},
+	},
+	"default_collation": {
+		description: "collation of VARCHAR columns created without COLLATE: binary, nocase or unicode",
+		get: func(e *Executor) string {
+			if e.defaultCollation == "" {
+				return storage.CollationBinary
+			}
+			return e.defaultCollation
+		},
+		set: func(e *Executor, value string) error {
+			collation, err := storage.ParseCollation(value)
+			if err == nil {
+				e.defaultCollation = collation
+			}
+			return err
+		},
+	},
+}
+
+// settingInt parses a non-negative integer setting
+func settingInt(value string) (int64, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("expected a non-negative integer, got %q", value)
+	}
+	return n, nil
+}
+
+// settingText renders a value given to SET GLOBAL as the text stored for it
+func settingText(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", fmt.Errorf("value must not be NULL")
+	case string:
+		return v, nil
+	case float64:
+		if v != float64(int64(v)) {
+			return "", fmt.Errorf("expected a whole number, got %v", v)
+		}
+		return strconv.FormatInt(int64(v), 10), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// executeSetGlobal executes SET GLOBAL and PRAGMA <name> = <value>
+// statements. The value is kept in the settings table, so it outlives a
+// restart and reaches replicas; DEFAULT goes back to the value the engine
+// was started with.
+func (e *Executor) executeSetGlobal(ctx context.Context, stmt *parser.SetGlobalStmt) (*Result, error) {
+	setting, ok := engineSettings[stmt.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown setting %s", stmt.Name)
+	}
+	table, err := e.systemTable(SettingsTable)
+	if err != nil {
+		return nil, err
+	}
+
+	e.rememberStartupSetting(stmt.Name)
+	value := e.settingDefaults[stmt.Name]
+	if stmt.Value != nil {
+		v, err := e.evaluateExpression(ctx, stmt.Value, nil)
+		if err == nil {
+			value, err = settingText(v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %w", stmt.Name, err)
+		}
+	}
+	if err := setting.set(e, value); err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %w", stmt.Name, err)
+	}
+
+	cs := changeSetFrom(ctx)
+	table.DeleteRows(func(row *storage.Row) bool {
+		if row.Values[0] != stmt.Name {
+			return false
+		}
+		if cs != nil {
+			cs.add(table.Schema, cdc.OpDelete, row.Values, nil)
+		}
+		return true
+	})
+	if stmt.Value != nil {
+		row := storage.NewRow([]interface{}{stmt.Name, value})
+		if err := table.InsertRow(row); err != nil {
+			return nil, err
+		}
+		if cs != nil {
+			cs.add(table.Schema, cdc.OpInsert, nil, row.Values)
+		}
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: fmt.Sprintf("%s = %s", stmt.Name, setting.get(e))}, nil
+}
+
+// executeShowSettings executes PRAGMA [<name>] statement, listing each
+// setting with its value and whether SET GLOBAL changed it
+func (e *Executor) executeShowSettings(ctx context.Context, stmt *parser.ShowSettingsStmt) (*Result, error) {
+	names := []string{stmt.Name}
+	if stmt.Name == "" {
+		names = names[:0]
+		for name := range engineSettings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	} else if _, ok := engineSettings[stmt.Name]; !ok {
+		return nil, fmt.Errorf("unknown setting %s", stmt.Name)
+	}
+
+	stored := map[string]bool{}
+	if table, err := e.storage.GetTable(SettingsTable); err == nil {
+		for _, row := range table.SelectRows() {
+			stored[row.Values[0].(string)] = true
+		}
+	}
+
+	result := &Result{Columns: []string{"name", "value", "source", "description"}}
+	for _, name := range names {
+		source := "startup"
+		if stored[name] {
+			source = "global"
+		}
+		setting := engineSettings[name]
+		result.Rows = append(result.Rows, []interface{}{name, setting.get(e), source, setting.description})
+	}
+	result.RowsAffected = len(result.Rows)
+	return result, nil
+}
+
+// loadSettings applies the settings stored by SET GLOBAL over those the
+// engine was started with; callers must hold e.mu exclusively
+func (e *Executor) loadSettings() error {
+	table, err := e.storage.GetTable(SettingsTable)
+	if err != nil {
+		return nil // nothing was ever SET GLOBAL
+	}
+	for _, row := range table.SelectRows() {
+		name, _ := row.Values[0].(string)
+		value, _ := row.Values[1].(string)
+		setting, ok := engineSettings[name]
+		if !ok {
+			continue // a setting this version no longer has
+		}
+		e.rememberStartupSetting(name)
+		if err := setting.set(e, value); err != nil {
+			return fmt.Errorf("invalid stored value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// settingValues returns the value of every setting, for
+// applySettingValues to go back to; callers must hold e.mu exclusively
+func (e *Executor) settingValues() map[string]string {
+	values := make(map[string]string, len(engineSettings))
+	for name, setting := range engineSettings {
+		values[name] = setting.get(e)
+	}
+	return values
+}
+
+// applySettingValues sets every setting to a value settingValues returned;
+// callers must hold e.mu exclusively
+func (e *Executor) applySettingValues(values map[string]string) error {
+	for name, value := range values {
+		if err := engineSettings[name].set(e, value); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// rememberStartupSetting records the value a setting had before it was
+// first changed, which DEFAULT restores
+func (e *Executor) rememberStartupSetting(name string) {
+	if _, ok := e.settingDefaults[name]; !ok {
+		e.settingDefaults[name] = engineSettings[name].get(e)
+	}
+}
+
+// defaultTimeout returns the statement timeout of sessions that set none
+func (e *Executor) defaultTimeout() time.Duration {
+	e.settingsMu.Lock()
+	defer e.settingsMu.Unlock()
+	return e.statementTimeout
+}
+
+// switchFlushPolicy changes the flush policy, starting background
+// checkpoints or writing out the tables they would have as needed; callers
+// must hold e.mu exclusively. During recovery only the policy is set, as
+// Recover starts checkpoints itself.
+func (e *Executor) switchFlushPolicy(policy FlushPolicy, interval time.Duration) error {
+	previous := e.flushPolicy
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	if err := e.SetFlushPolicy(policy, interval); err != nil {
+		return err
+	}
+	if e.recovering {
+		return nil
+	}
+
+	ctx := context.Background()
+	switch {
+	case policy == FlushEveryStatement && previous != FlushEveryStatement:
+		// As after recovery, tables are written after every statement from
+		// now on, so the checkpoint no longer describes them
+		if err := e.storage.SaveAllTables(); err != nil {
+			return err
+		}
+		path := filepath.Join(e.storage.DataDir(), checkpointFileName)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove checkpoint: %w", err)
+		}
+	case policy != FlushEveryStatement && previous == FlushEveryStatement:
+		if err := e.checkpoint(ctx, true); err != nil {
+			return err
+		}
+		if e.stopCheckpoints == nil {
+			e.startCheckpoints()
+		}
+	}
+	return nil
+}
+
+// changesSettings reports whether a statement changes an engine setting
+func changesSettings(stmt parser.Statement) bool {
+	_, ok := stmt.(*parser.SetGlobalStmt)
+	return ok
+}
@@ -0,0 +1,40 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+)
+
+// executePurge executes PURGE statement, removing soft-deleted rows for good
+func (e *Executor) executePurge(ctx context.Context, stmt *parser.PurgeStmt) (*Result, error) {
+	table, err := e.storage.GetTable(stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWritable(ctx, table); err != nil {
+		return nil, err
+	}
+
+	condition, err := e.deleteCondition(ctx, table.Schema, stmt.Where)
+	if err != nil {
+		return nil, err
+	}
+	count := table.PurgeRows(condition)
+
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{
+		Message:      fmt.Sprintf("%d row(s) purged", count),
+		RowsAffected: count,
+	}, nil
+}
+
+// changesSoftDelete reports whether a statement turns soft delete on or
+// off, which must not race with DELETEs checking it
+func changesSoftDelete(stmt parser.Statement) bool {
+	alter, ok := stmt.(*parser.AlterTableStmt)
+	return ok && (alter.Action == "ENABLE SOFT DELETE" || alter.Action == "DISABLE SOFT DELETE")
+}
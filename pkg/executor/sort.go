@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// sortRows returns a copy of rows in ORDER BY order, where key returns a
+// row's value of the i-th ORDER BY column and collations holds each
+// column's collation. Later keys break ties in earlier ones, and the sort
+// is stable, so rows with all keys equal keep the order they were scanned
+// in.
+func sortRows[T any](ctx context.Context, rows []T, key func(row T, i int) interface{}, order []parser.OrderBy, collations []string) ([]T, error) {
+	sorted := slices.Clone(rows)
+	var err error
+	compared := 0
+	slices.SortStableFunc(sorted, func(a, b T) int {
+		if err != nil {
+			return 0
+		}
+		if err = checkCancelled(ctx, compared); err != nil {
+			return 0
+		}
+		compared++
+
+		for i, o := range order {
+			c, cmpErr := compareForSort(key(a, i), key(b, i), o, collations[i])
+			if cmpErr != nil {
+				err = cmpErr
+				return 0
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sorted, nil
+}
+
+// compareForSort orders two values of an ORDER BY column. NULLs equal each
+// other and go before or after every other value as the key says, whatever
+// its direction.
+func compareForSort(a, b interface{}, order parser.OrderBy, collation string) (int, error) {
+	nulls := 1
+	if order.NullsFirst {
+		nulls = -1
+	}
+	switch {
+	case a == nil && b == nil:
+		return 0, nil
+	case a == nil:
+		return nulls, nil
+	case b == nil:
+		return -nulls, nil
+	}
+	c, err := storage.CompareValues(a, b, collation)
+	if order.Desc {
+		c = -c
+	}
+	return c, err
+}
@@ -0,0 +1,342 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// maxFunctionDepth bounds how deeply calls to SQL functions may nest, which
+// stops a function that ends up calling itself
+const maxFunctionDepth = 16
+
+// sqlFunction is a function created with CREATE FUNCTION
+type sqlFunction struct {
+	params  []parser.FunctionParam
+	returns string
+	body    string
+}
+
+// executeCreateFunction executes CREATE FUNCTION statement
+func (e *Executor) executeCreateFunction(ctx context.Context, stmt *parser.CreateFunctionStmt) (*Result, error) {
+	if builtinFunction(stmt.Name) {
+		return nil, fmt.Errorf("function %s already exists as a built-in function", stmt.Name)
+	}
+	// An expression body can only read its parameters; a query body reads
+	// columns of its table
+	if _, ok := stmt.Body.(*parser.SubqueryExpr); !ok {
+		var unknown string
+		parser.WalkExpression(stmt.Body, func(expr parser.Expression) {
+			if ident, ok := expr.(*parser.Identifier); ok && unknown == "" && !isParam(stmt.Params, ident.Value) {
+				unknown = ident.Value
+			}
+		})
+		if unknown != "" {
+			return nil, fmt.Errorf("function body refers to %s, which is not a parameter", unknown)
+		}
+	}
+
+	table, err := e.systemTable(FunctionsTable)
+	if err != nil {
+		return nil, err
+	}
+	if functionRow(table, stmt.Name) != nil {
+		return nil, fmt.Errorf("function %s already exists", stmt.Name)
+	}
+
+	params := make([]string, len(stmt.Params))
+	for i, param := range stmt.Params {
+		params[i] = param.Name + " " + param.Type
+	}
+	row := storage.NewRow([]interface{}{stmt.Name, strings.Join(params, ", "), stmt.Returns, stmt.Query})
+	if err := table.InsertRow(row); err != nil {
+		return nil, err
+	}
+	if cs := changeSetFrom(ctx); cs != nil {
+		cs.add(table.Schema, cdc.OpInsert, nil, row.Values)
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: fmt.Sprintf("Function %s created", stmt.Name)}, nil
+}
+
+// executeDropFunction executes DROP FUNCTION statement
+func (e *Executor) executeDropFunction(ctx context.Context, stmt *parser.DropFunctionStmt) (*Result, error) {
+	table, err := e.storage.GetTable(FunctionsTable)
+	if err != nil {
+		return nil, fmt.Errorf("function %s does not exist", stmt.Name)
+	}
+	cs := changeSetFrom(ctx)
+	count := table.DeleteRows(func(row *storage.Row) bool {
+		if row.Values[0] != stmt.Name {
+			return false
+		}
+		if cs != nil {
+			cs.add(table.Schema, cdc.OpDelete, row.Values, nil)
+		}
+		return true
+	})
+	if count == 0 {
+		return nil, fmt.Errorf("function %s does not exist", stmt.Name)
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	return &Result{Message: fmt.Sprintf("Function %s dropped", stmt.Name)}, nil
+}
+
+// executeCall executes CALL statement. resolveFunctions has already put the
+// function's body in place of the call.
+func (e *Executor) executeCall(ctx context.Context, stmt *parser.CallStmt) (*Result, error) {
+	ctx, err := e.withSubqueries(ctx, stmt.Call)
+	if err != nil {
+		return nil, err
+	}
+	value, err := e.evaluateExpression(ctx, stmt.Call, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		Columns:      []string{strings.ToLower(stmt.Name)},
+		Rows:         [][]interface{}{{value}},
+		RowsAffected: 1,
+	}, nil
+}
+
+// changesFunction reports whether a statement creates or drops a function,
+// which changes what the statements calling it do
+func changesFunction(stmt parser.Statement) bool {
+	switch stmt.(type) {
+	case *parser.CreateFunctionStmt, *parser.DropFunctionStmt:
+		return true
+	default:
+		return false
+	}
+}
+
+// builtinFunction reports whether a name is taken by a built-in function
+func builtinFunction(name string) bool {
+	_, scalar := functions[name]
+	_, aggregate := aggregateFunctions[name]
+	return scalar || aggregate || sequenceFunctions[name] || resolvedFunctions[name]
+}
+
+// isParam reports whether a name is one of a function's parameters
+func isParam(params []parser.FunctionParam, name string) bool {
+	for _, param := range params {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// functionRow returns the row of a function, or nil
+func functionRow(table *storage.Table, name string) *storage.Row {
+	for _, row := range table.SelectRows() {
+		if row.Values[0] == name {
+			return row
+		}
+	}
+	return nil
+}
+
+// sqlFunctions returns the functions created with CREATE FUNCTION by name
+func (e *Executor) sqlFunctions() map[string]*sqlFunction {
+	table, err := e.storage.GetTable(FunctionsTable)
+	if err != nil {
+		return nil // no function was ever created
+	}
+	fns := make(map[string]*sqlFunction)
+	for _, row := range table.SelectRows() {
+		name, _ := row.Values[0].(string)
+		params, _ := row.Values[1].(string)
+		fn := &sqlFunction{}
+		fn.returns, _ = row.Values[2].(string)
+		fn.body, _ = row.Values[3].(string)
+		if params != "" {
+			for _, param := range strings.Split(params, ", ") {
+				name, typ, _ := strings.Cut(param, " ")
+				fn.params = append(fn.params, parser.FunctionParam{Name: name, Type: typ})
+			}
+		}
+		fns[name] = fn
+	}
+	return fns
+}
+
+// inlineFunctions returns a copy of a statement with each call to a SQL
+// function replaced by the function's body: its parameters become the
+// arguments, cast to their declared types, and its value is cast to the
+// function's return type. Statements without such calls are returned as
+// is with changed false.
+func (e *Executor) inlineFunctions(stmt parser.Statement) (inlined parser.Statement, changed bool, err error) {
+	fns := e.sqlFunctions()
+	if len(fns) == 0 {
+		return stmt, false, nil
+	}
+	names := make(map[string]bool, len(fns))
+	for name := range fns {
+		names[name] = true
+	}
+
+	// inline returns the resolve function for replaceCalls that inlines
+	// calls nested depth functions deep
+	var inline func(depth int) func(*parser.FunctionCall) (parser.Expression, error)
+	inline = func(depth int) func(*parser.FunctionCall) (parser.Expression, error) {
+		return func(call *parser.FunctionCall) (parser.Expression, error) {
+			changed = true
+			if depth == maxFunctionDepth {
+				return nil, fmt.Errorf("function %s: calls nest more than %d deep", call.Name, maxFunctionDepth)
+			}
+			fn := fns[call.Name]
+			if len(call.Args) != len(fn.params) {
+				return nil, fmt.Errorf("%s() takes %d argument(s), got %d", call.Name, len(fn.params), len(call.Args))
+			}
+			args := make(map[string]parser.Expression, len(call.Args))
+			for i, arg := range call.Args {
+				arg, err := replaceCalls(arg, names, inline(depth))
+				if err != nil {
+					return nil, err
+				}
+				args[fn.params[i].Name] = castTo(arg, fn.params[i].Type)
+			}
+			body, err := parser.ParseFunctionBody(fn.body)
+			if err != nil {
+				return nil, fmt.Errorf("function %s: %w", call.Name, err)
+			}
+			if body, err = replaceCalls(substituteParams(body, args), names, inline(depth+1)); err != nil {
+				return nil, err
+			}
+			return castTo(body, fn.returns), nil
+		}
+	}
+	replace := func(expr parser.Expression) (parser.Expression, error) {
+		return replaceCalls(expr, names, inline(0))
+	}
+
+	switch s := stmt.(type) {
+	case *parser.InsertStmt:
+		insert := *s
+		if s.Select != nil {
+			if insert.Select, err = replaceSelectCalls(s.Select, names, inline(0)); err != nil {
+				return nil, false, err
+			}
+		}
+		insert.Values = make([][]parser.Expression, len(s.Values))
+		for i, row := range s.Values {
+			insert.Values[i] = make([]parser.Expression, len(row))
+			for j, expr := range row {
+				if insert.Values[i][j], err = replace(expr); err != nil {
+					return nil, false, err
+				}
+			}
+		}
+		if s.OnConflict != nil && s.OnConflict.Set != nil {
+			conflict := *s.OnConflict
+			if conflict.Set, err = replaceAssignments(s.OnConflict.Set, replace); err != nil {
+				return nil, false, err
+			}
+			insert.OnConflict = &conflict
+		}
+		inlined = &insert
+	case *parser.UpdateStmt:
+		update := *s
+		if update.Set, err = replaceAssignments(s.Set, replace); err != nil {
+			return nil, false, err
+		}
+		if update.Where, err = replace(s.Where); err != nil {
+			return nil, false, err
+		}
+		inlined = &update
+	case *parser.DeleteStmt:
+		remove := *s
+		if remove.Where, err = replace(s.Where); err != nil {
+			return nil, false, err
+		}
+		inlined = &remove
+	case *parser.MergeStmt:
+		if inlined, err = mapMerge(s, replace); err != nil {
+			return nil, false, err
+		}
+	case *parser.SelectStmt:
+		if inlined, err = replaceSelectCalls(s, names, inline(0)); err != nil {
+			return nil, false, err
+		}
+	case *parser.ExplainStmt:
+		explain := *s
+		if explain.Select, err = replaceSelectCalls(s.Select, names, inline(0)); err != nil {
+			return nil, false, err
+		}
+		inlined = &explain
+	case *parser.CallStmt:
+		call := *s
+		if call.Call, err = replace(s.Call); err != nil {
+			return nil, false, err
+		}
+		inlined = &call
+	default:
+		return stmt, false, nil
+	}
+
+	if !changed {
+		return stmt, false, nil
+	}
+	return inlined, true, nil
+}
+
+// replaceAssignments returns a copy of SET assignments with replace applied
+// to their values
+func replaceAssignments(set map[string]parser.Expression, replace func(parser.Expression) (parser.Expression, error)) (map[string]parser.Expression, error) {
+	replaced := make(map[string]parser.Expression, len(set))
+	for column, expr := range set {
+		var err error
+		if replaced[column], err = replace(expr); err != nil {
+			return nil, err
+		}
+	}
+	return replaced, nil
+}
+
+// substituteParams returns a function body with its parameters replaced by
+// the arguments, including in the conditions of its subqueries
+func substituteParams(body parser.Expression, args map[string]parser.Expression) parser.Expression {
+	return parser.MapExpression(body, func(expr parser.Expression) parser.Expression {
+		switch ex := expr.(type) {
+		case *parser.Identifier:
+			if arg, ok := args[ex.Value]; ok {
+				return arg
+			}
+		case *parser.InExpr:
+			return &parser.InExpr{Left: ex.Left, Subquery: substituteSelectParams(ex.Subquery, args), Not: ex.Not}
+		case *parser.SubqueryExpr:
+			return &parser.SubqueryExpr{Select: substituteSelectParams(ex.Select, args)}
+		}
+		return expr
+	})
+}
+
+// substituteSelectParams is substituteParams for the conditions of a
+// subquery
+func substituteSelectParams(stmt *parser.SelectStmt, args map[string]parser.Expression) *parser.SelectStmt {
+	s := *stmt
+	s.Where = substituteParams(stmt.Where, args)
+	s.Having = substituteParams(stmt.Having, args)
+	s.Joins = make([]*parser.JoinClause, len(stmt.Joins))
+	for i, join := range stmt.Joins {
+		copied := *join
+		copied.On = substituteParams(join.On, args)
+		s.Joins[i] = &copied
+	}
+	return &s
+}
+
+// castTo returns expr cast to a type as CAST names it
+func castTo(expr parser.Expression, typeName string) parser.Expression {
+	return &parser.FunctionCall{Name: "CAST", Args: []parser.Expression{expr, &parser.Literal{Value: typeName}}}
+}
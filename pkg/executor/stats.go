@@ -0,0 +1,226 @@
+package executor
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// StatementStats holds counters for one statement type
+type StatementStats struct {
+	Executed      int64         `json:"executed"`
+	Errors        int64         `json:"errors"`
+	TotalDuration time.Duration `json:"totalDuration"`
+}
+
+// TableStats holds counters for one table
+type TableStats struct {
+	Statements   int64 `json:"statements"`
+	RowsScanned  int64 `json:"rowsScanned"`
+	RowsReturned int64 `json:"rowsReturned"`
+	RowsInserted int64 `json:"rowsInserted"`
+	RowsUpdated  int64 `json:"rowsUpdated"`
+	RowsDeleted  int64 `json:"rowsDeleted"`
+}
+
+// PlanCacheStats holds parsed statement cache counters
+type PlanCacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Size   int   `json:"size"`
+}
+
+// ResultCacheStats holds SELECT result cache counters
+type ResultCacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Size   int   `json:"size"`
+	Bytes  int64 `json:"bytes"`
+}
+
+// AdmissionStats holds admission control counters
+type AdmissionStats struct {
+	Executing int   `json:"executing"`
+	Queued    int   `json:"queued"`
+	Admitted  int64 `json:"admitted"`
+	Waited    int64 `json:"waited"`
+	Rejected  int64 `json:"rejected"`
+}
+
+// Stats is a point-in-time copy of the executor's runtime counters
+type Stats struct {
+	StartedAt   time.Time                  `json:"startedAt"`
+	Uptime      time.Duration              `json:"uptime"`
+	ParseErrors int64                      `json:"parseErrors"`
+	Flushes     int64                      `json:"flushes"`
+	PlanCache   PlanCacheStats             `json:"planCache"`
+	ResultCache ResultCacheStats           `json:"resultCache"`
+	Admission   AdmissionStats             `json:"admission"`
+	Statements  map[string]*StatementStats `json:"statements"`
+	Tables      map[string]*TableStats     `json:"tables"`
+}
+
+// statsCollector accumulates runtime counters
+type statsCollector struct {
+	mu          sync.Mutex
+	startedAt   time.Time
+	parseErrors int64
+	flushes     int64
+	statements  map[string]*StatementStats
+	tables      map[string]*TableStats
+}
+
+// newStatsCollector creates an empty collector
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		startedAt:  time.Now(),
+		statements: make(map[string]*StatementStats),
+		tables:     make(map[string]*TableStats),
+	}
+}
+
+// Stats returns a snapshot of the executor's runtime counters
+func (e *Executor) Stats() Stats {
+	stats := e.stats.snapshot()
+	stats.PlanCache.Hits, stats.PlanCache.Misses, stats.PlanCache.Size = e.plans.counters()
+	stats.ResultCache.Hits, stats.ResultCache.Misses, stats.ResultCache.Size, stats.ResultCache.Bytes = e.results.counters()
+	stats.Admission = e.admission.counters()
+	return stats
+}
+
+// snapshot copies the current counters
+func (s *statsCollector) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := Stats{
+		StartedAt:   s.startedAt,
+		Uptime:      time.Since(s.startedAt),
+		ParseErrors: s.parseErrors,
+		Flushes:     s.flushes,
+		Statements:  make(map[string]*StatementStats, len(s.statements)),
+		Tables:      make(map[string]*TableStats, len(s.tables)),
+	}
+	for name, st := range s.statements {
+		copied := *st
+		stats.Statements[name] = &copied
+	}
+	for name, t := range s.tables {
+		copied := *t
+		stats.Tables[name] = &copied
+	}
+	return stats
+}
+
+// recordStatement counts an executed statement
+func (s *statsCollector) recordStatement(statementType string, duration time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.statements[statementType]
+	if !ok {
+		st = &StatementStats{}
+		s.statements[statementType] = st
+	}
+	st.Executed++
+	st.TotalDuration += duration
+	if err != nil {
+		st.Errors++
+	}
+}
+
+// recordParseError counts a statement that failed to parse
+func (s *statsCollector) recordParseError() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parseErrors++
+}
+
+// recordFlush counts a persistence flush
+func (s *statsCollector) recordFlush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushes++
+}
+
+// recordTable applies update to the counters of a table
+func (s *statsCollector) recordTable(tableName string, update func(*TableStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tables[tableName]
+	if !ok {
+		t = &TableStats{}
+		s.tables[tableName] = t
+	}
+	update(t)
+}
+
+// forgetTable drops the counters of a dropped table
+func (s *statsCollector) forgetTable(tableName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tables, tableName)
+}
+
+// statsResult renders a snapshot as rows of (scope, name, metric, value)
+func statsResult(stats Stats) *Result {
+	rows := [][]interface{}{
+		{"server", "", "uptime_seconds", stats.Uptime.Seconds()},
+		{"server", "", "parse_errors", stats.ParseErrors},
+		{"server", "", "flushes", stats.Flushes},
+		{"server", "", "plan_cache_hits", stats.PlanCache.Hits},
+		{"server", "", "plan_cache_misses", stats.PlanCache.Misses},
+		{"server", "", "plan_cache_size", stats.PlanCache.Size},
+		{"server", "", "result_cache_hits", stats.ResultCache.Hits},
+		{"server", "", "result_cache_misses", stats.ResultCache.Misses},
+		{"server", "", "result_cache_size", stats.ResultCache.Size},
+		{"server", "", "result_cache_bytes", stats.ResultCache.Bytes},
+		{"server", "", "statements_executing", stats.Admission.Executing},
+		{"server", "", "statements_queued", stats.Admission.Queued},
+		{"server", "", "statements_admitted", stats.Admission.Admitted},
+		{"server", "", "statements_waited", stats.Admission.Waited},
+		{"server", "", "statements_rejected", stats.Admission.Rejected},
+	}
+
+	for _, name := range sortedKeys(stats.Statements) {
+		st := stats.Statements[name]
+		avg := 0.0
+		if st.Executed > 0 {
+			avg = float64(st.TotalDuration.Microseconds()) / float64(st.Executed) / 1000
+		}
+		rows = append(rows,
+			[]interface{}{"statement", name, "executed", st.Executed},
+			[]interface{}{"statement", name, "errors", st.Errors},
+			[]interface{}{"statement", name, "avg_ms", avg},
+		)
+	}
+
+	for _, name := range sortedKeys(stats.Tables) {
+		t := stats.Tables[name]
+		rows = append(rows,
+			[]interface{}{"table", name, "statements", t.Statements},
+			[]interface{}{"table", name, "rows_scanned", t.RowsScanned},
+			[]interface{}{"table", name, "rows_returned", t.RowsReturned},
+			[]interface{}{"table", name, "rows_inserted", t.RowsInserted},
+			[]interface{}{"table", name, "rows_updated", t.RowsUpdated},
+			[]interface{}{"table", name, "rows_deleted", t.RowsDeleted},
+		)
+	}
+
+	return &Result{
+		Columns:      []string{"scope", "name", "metric", "value"},
+		Rows:         rows,
+		RowsAffected: len(rows),
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
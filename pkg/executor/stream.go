@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"context"
+)
+
+// RowWriter receives the rows of a streamed SELECT as they are produced.
+// An error from either method stops the query with that error.
+type RowWriter interface {
+	// WriteColumns is called once, before any row
+	WriteColumns(columns []string) error
+	WriteRow(row []interface{}) error
+}
+
+// rowWriterKey is the context key of the RowWriter a SELECT streams to
+type rowWriterKey struct{}
+
+// QueryStream is like Query, but a SELECT hands its rows to w one at a time
+// instead of collecting them in the Result, so the result limits do not
+// apply. The Result of a streamed SELECT has its columns and row count but
+// no rows; other statements return their result as Query does.
+func (e *Executor) QueryStream(ctx context.Context, query string, w RowWriter) (*Result, error) {
+	return e.Query(context.WithValue(ctx, rowWriterKey{}, w), query)
+}
+
+// rowWriterFrom returns the RowWriter a query streams to, if any
+func rowWriterFrom(ctx context.Context) RowWriter {
+	w, _ := ctx.Value(rowWriterKey{}).(RowWriter)
+	return w
+}
+
+// withoutRowWriter returns ctx for a statement whose rows must be
+// collected even when the query streams
+func withoutRowWriter(ctx context.Context) context.Context {
+	if rowWriterFrom(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, rowWriterKey{}, nil)
+}
+
+// resultBuilder builds the rows of a SELECT result, collecting them or
+// writing them to the query's RowWriter
+type resultBuilder struct {
+	guard  *resultGuard
+	writer RowWriter
+	result *Result
+}
+
+// newResultBuilder starts a result with the given columns, writing them
+// out first when the query streams
+func (e *Executor) newResultBuilder(ctx context.Context, columns []string) (*resultBuilder, error) {
+	b := &resultBuilder{
+		guard:  e.newResultGuard(),
+		writer: rowWriterFrom(ctx),
+		result: &Result{Columns: columns, Rows: [][]interface{}{}},
+	}
+	if b.writer != nil {
+		// A streamed result is never held in memory, so it is not limited
+		b.guard = &resultGuard{}
+		if err := b.writer.WriteColumns(columns); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// add appends a row to the result
+func (b *resultBuilder) add(row []interface{}) error {
+	if err := b.guard.add(row); err != nil {
+		return err
+	}
+	b.result.RowsAffected++
+	if b.writer != nil {
+		return b.writer.WriteRow(row)
+	}
+	b.result.Rows = append(b.result.Rows, row)
+	return nil
+}
+
+// finish returns the result built
+func (b *resultBuilder) finish() *Result {
+	return b.result
+}
@@ -0,0 +1,151 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+)
+
+// subqueriesKey is the context key of the results of the subqueries in the
+// running statement
+type subqueriesKey struct{}
+
+// subqueryResult is the materialized result of a subquery: the values of an
+// IN subquery, or the value of a scalar subquery, which is NULL when it
+// returns no row
+type subqueryResult struct {
+	values  []interface{}        // non-NULL values, in row order
+	keys    map[interface{}]bool // memberKey of each value
+	hasNull bool
+}
+
+// withSubqueries runs the subqueries in a statement's expressions. They
+// cannot refer to the outer row, so each runs once, before the rows they
+// apply to are scanned, and expressions look their results up in the
+// returned context.
+func (e *Executor) withSubqueries(ctx context.Context, exprs ...parser.Expression) (context.Context, error) {
+	subqueries := expressionSubqueries(exprs...)
+	if len(subqueries) == 0 {
+		return ctx, nil
+	}
+	scalar := make(map[*parser.SelectStmt]bool)
+	for _, expr := range exprs {
+		parser.WalkExpression(expr, func(expr parser.Expression) {
+			if sub, ok := expr.(*parser.SubqueryExpr); ok {
+				scalar[sub.Select] = true
+			}
+		})
+	}
+
+	// The subquery's rows are not the statement's result, nor its plan's
+	inner := withoutRowWriter(context.WithValue(ctx, planActualsKey{}, nil))
+	results := make(map[*parser.SelectStmt]*subqueryResult, len(subqueries))
+	for _, sub := range subqueries {
+		result, err := e.executeSelect(inner, sub)
+		if err != nil {
+			return nil, fmt.Errorf("subquery: %w", err)
+		}
+		if len(result.Columns) != 1 {
+			return nil, fmt.Errorf("subquery must return one column, got %d", len(result.Columns))
+		}
+		if scalar[sub] && len(result.Rows) > 1 {
+			return nil, fmt.Errorf("subquery used as a value must return at most one row, got %d", len(result.Rows))
+		}
+		set := &subqueryResult{keys: make(map[interface{}]bool, len(result.Rows))}
+		for _, row := range result.Rows {
+			if row[0] == nil {
+				set.hasNull = true
+				continue
+			}
+			set.values = append(set.values, row[0])
+			set.keys[memberKey(row[0])] = true
+		}
+		results[sub] = set
+	}
+	return context.WithValue(ctx, subqueriesKey{}, results), nil
+}
+
+// subqueryFrom returns the result withSubqueries stored for a subquery
+func subqueryFrom(ctx context.Context, sub *parser.SelectStmt) (*subqueryResult, error) {
+	results, _ := ctx.Value(subqueriesKey{}).(map[*parser.SelectStmt]*subqueryResult)
+	result, ok := results[sub]
+	if !ok {
+		return nil, fmt.Errorf("subqueries are not supported here")
+	}
+	return result, nil
+}
+
+// subqueryValue returns the value of a scalar subquery
+func subqueryValue(ctx context.Context, sub *parser.SelectStmt) (interface{}, error) {
+	result, err := subqueryFrom(ctx, sub)
+	if err != nil || len(result.values) == 0 {
+		return nil, err
+	}
+	return result.values[0], nil
+}
+
+// expressionSubqueries returns the subqueries in expressions, not counting
+// those nested in the subqueries themselves
+func expressionSubqueries(exprs ...parser.Expression) []*parser.SelectStmt {
+	var subqueries []*parser.SelectStmt
+	for _, expr := range exprs {
+		parser.WalkExpression(expr, func(expr parser.Expression) {
+			switch ex := expr.(type) {
+			case *parser.InExpr:
+				subqueries = append(subqueries, ex.Subquery)
+			case *parser.SubqueryExpr:
+				subqueries = append(subqueries, ex.Select)
+			}
+		})
+	}
+	return subqueries
+}
+
+// selectConditions returns the conditions of a SELECT: its WHERE, the ON
+// of each join and its HAVING
+func selectConditions(stmt *parser.SelectStmt) []parser.Expression {
+	exprs := []parser.Expression{stmt.Where}
+	for _, join := range stmt.Joins {
+		exprs = append(exprs, join.On)
+	}
+	return append(exprs, stmt.Having)
+}
+
+// contains tests whether value is in the result, comparing under
+// collation. As in SQL, the test is unknown rather than false for a NULL
+// value, or a value not found when the result has a NULL.
+func (r *subqueryResult) contains(e *Executor, value interface{}, collation string) (truth, error) {
+	if value == nil {
+		return unknown, nil
+	}
+	if collation == "" {
+		if r.keys[memberKey(value)] {
+			return isTrue, nil
+		}
+	} else {
+		for _, v := range r.values {
+			match, err := e.compareCollated(value, v, "=", collation)
+			if err != nil {
+				return unknown, err
+			}
+			if match {
+				return isTrue, nil
+			}
+		}
+	}
+	if r.hasNull {
+		return unknown, nil
+	}
+	return isFalse, nil
+}
+
+// memberKey returns a value that is equal for values = treats as equal
+// without a collation: integers are keyed as floats, as they compare with
+// floats as floats
+func memberKey(value interface{}) interface{} {
+	if i, ok := value.(int); ok {
+		return float64(i)
+	}
+	return value
+}
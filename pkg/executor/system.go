@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// System tables holding the jobs created with CREATE JOB, the history of
+// their runs, the sequences created with CREATE SEQUENCE, the functions
+// created with CREATE FUNCTION, the engine settings changed with SET
+// GLOBAL and the users created with CREATE USER. They are
+// ordinary tables, so they are logged, replicated, backed up and dumped like
+// any other.
+const (
+	JobsTable      = "system_jobs"
+	JobRunsTable   = "system_job_runs"
+	SequencesTable = "system_sequences"
+	FunctionsTable = "system_functions"
+	SettingsTable  = "system_settings"
+	UsersTable     = "system_users"
+)
+
+// systemTables are the columns of each system table
+var systemTables = map[string][]storage.Column{
+	JobsTable: {
+		{Name: "name", DataType: storage.TypeVarchar, Size: 100, PrimaryKey: true, NotNull: true},
+		{Name: "schedule", DataType: storage.TypeVarchar, Size: 100, NotNull: true},
+		{Name: "statement", DataType: storage.TypeVarchar, NotNull: true},
+	},
+	JobRunsTable: {
+		{Name: "job", DataType: storage.TypeVarchar, Size: 100, NotNull: true},
+		{Name: "started_at", DataType: storage.TypeVarchar, Size: 40, NotNull: true},
+		{Name: "duration_ms", DataType: storage.TypeInteger, NotNull: true},
+		{Name: "status", DataType: storage.TypeVarchar, Size: 10, NotNull: true},
+		{Name: "message", DataType: storage.TypeVarchar},
+	},
+	SequencesTable: {
+		{Name: "name", DataType: storage.TypeVarchar, Size: 100, PrimaryKey: true, NotNull: true},
+		{Name: "next_value", DataType: storage.TypeInteger, NotNull: true},
+		{Name: "increment", DataType: storage.TypeInteger, NotNull: true},
+		{Name: "start", DataType: storage.TypeInteger, NotNull: true},
+	},
+	FunctionsTable: {
+		{Name: "name", DataType: storage.TypeVarchar, Size: 100, PrimaryKey: true, NotNull: true},
+		{Name: "params", DataType: storage.TypeVarchar, NotNull: true},
+		{Name: "returns", DataType: storage.TypeVarchar, Size: 20, NotNull: true},
+		{Name: "body", DataType: storage.TypeVarchar, NotNull: true},
+	},
+	SettingsTable: {
+		{Name: "name", DataType: storage.TypeVarchar, Size: 100, PrimaryKey: true, NotNull: true},
+		{Name: "value", DataType: storage.TypeVarchar, NotNull: true},
+	},
+	UsersTable: {
+		{Name: "name", DataType: storage.TypeVarchar, Size: 100, PrimaryKey: true, NotNull: true},
+	},
+}
+
+// systemTable returns a system table, creating it on first use
+func (e *Executor) systemTable(name string) (*storage.Table, error) {
+	if table, err := e.storage.GetTable(name); err == nil {
+		return table, nil
+	}
+	schema := storage.NewSchema(name)
+	for _, col := range systemTables[name] {
+		schema.AddColumn(col)
+	}
+	// A concurrent statement may have created it first
+	if err := e.storage.CreateTable(schema); err != nil && !e.storage.TableExists(name) {
+		return nil, err
+	}
+	return e.storage.GetTable(name)
+}
+
+// isSystemTable reports whether a table is one of the system tables
+func isSystemTable(name string) bool {
+	_, ok := systemTables[name]
+	return ok
+}
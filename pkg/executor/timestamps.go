@@ -0,0 +1,380 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// nowFunctions are the functions that return the time of the statement
+var nowFunctions = map[string]bool{"NOW": true}
+
+// uuidFunctions are the functions that return a new random UUID
+var uuidFunctions = map[string]bool{"UUID": true, "GEN_RANDOM_UUID": true}
+
+// resolvedFunctions are the functions resolveFunctions replaces by values
+var resolvedFunctions = map[string]bool{"NOW": true, "UUID": true, "GEN_RANDOM_UUID": true}
+
+// columnDefaults checks a column's DEFAULT and ON UPDATE clauses and sets
+// them on col. A DEFAULT is NOW() on a TIMESTAMP column, UUID() on a UUID
+// or text column, NEXTVAL('<sequence>') on an INTEGER column, or a value
+// that does not depend on the row or the session, such as 'new' or 0; ON
+// UPDATE only supports NOW() on TIMESTAMP columns so far.
+func (e *Executor) columnDefaults(ctx context.Context, colDef *parser.ColumnDef, col *storage.Column) error {
+	clauses := []struct {
+		name   string
+		expr   parser.Expression
+		target *string
+	}{
+		{"DEFAULT", colDef.Default, &col.Default},
+		{"ON UPDATE", colDef.OnUpdate, &col.OnUpdate},
+	}
+	for _, clause := range clauses {
+		if clause.expr == nil {
+			continue
+		}
+		if colDef.Generated != nil {
+			return fmt.Errorf("generated column %s cannot have %s", colDef.Name, clause.name)
+		}
+		*clause.target = parser.FormatExpression(clause.expr)
+		if call, ok := clause.expr.(*parser.FunctionCall); ok && nowFunctions[call.Name] && len(call.Args) == 0 {
+			if col.DataType != storage.TypeTimestamp {
+				return fmt.Errorf("column %s: %s NOW() is only supported on TIMESTAMP columns", colDef.Name, clause.name)
+			}
+			continue
+		}
+		if call, ok := clause.expr.(*parser.FunctionCall); ok && uuidFunctions[call.Name] && len(call.Args) == 0 && clause.name == "DEFAULT" {
+			switch col.DataType {
+			case storage.TypeUUID, storage.TypeVarchar, storage.TypeText:
+				if col.Size > 0 && col.Size < 36 {
+					return fmt.Errorf("column %s: VARCHAR(%d) is too short for DEFAULT %s()", colDef.Name, col.Size, call.Name)
+				}
+			default:
+				return fmt.Errorf("column %s: DEFAULT %s() is only supported on UUID, VARCHAR and TEXT columns", colDef.Name, call.Name)
+			}
+			continue
+		}
+		if call, ok := clause.expr.(*parser.FunctionCall); ok && call.Name == "NEXTVAL" && clause.name == "DEFAULT" {
+			if err := e.checkSequenceDefault(colDef.Name, *col, call); err != nil {
+				return err
+			}
+			continue
+		}
+		if clause.name != "DEFAULT" {
+			return fmt.Errorf("column %s: only %s NOW() on a TIMESTAMP column is supported", colDef.Name, clause.name)
+		}
+
+		fixed := true
+		parser.WalkExpression(clause.expr, func(expr parser.Expression) {
+			switch ex := expr.(type) {
+			case *parser.Identifier, *parser.VariableRef, *parser.SubqueryExpr, *parser.InExpr, *parser.DefaultValue:
+				fixed = false
+			case *parser.FunctionCall:
+				if functions[ex.Name].volatile {
+					fixed = false
+				}
+			}
+		})
+		if !fixed {
+			return fmt.Errorf("column %s: DEFAULT must not depend on columns, variables, subqueries or the time", colDef.Name)
+		}
+		value, err := e.columnDefault(ctx, *col)
+		if err == nil && value != nil {
+			err = storage.ValidateValue(value, *col)
+		}
+		if err != nil {
+			return fmt.Errorf("DEFAULT %s: %w", *clause.target, err)
+		}
+	}
+	return nil
+}
+
+// columnDefault returns the value an INSERT gives a column it leaves out or
+// sets to DEFAULT: its DEFAULT, or NULL without one. A DEFAULT NOW() is
+// the current time and a DEFAULT UUID() a new UUID, which statements that
+// are logged resolve beforehand.
+func (e *Executor) columnDefault(ctx context.Context, col storage.Column) (interface{}, error) {
+	if col.Default == "" {
+		return nil, nil
+	}
+	expr, err := e.generatedExpression(col.Default)
+	if err != nil {
+		return nil, err
+	}
+	value, err := e.evaluateExpression(ctx, expr, nil)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NormalizeValue(value, col)
+}
+
+// checkSequenceDefault checks a DEFAULT NEXTVAL('<sequence>') clause
+func (e *Executor) checkSequenceDefault(name string, col storage.Column, call *parser.FunctionCall) error {
+	if col.DataType != storage.TypeInteger {
+		return fmt.Errorf("column %s: DEFAULT NEXTVAL() is only supported on INTEGER columns", name)
+	}
+	if len(call.Args) != 1 {
+		return fmt.Errorf("column %s: NEXTVAL() takes 1 argument(s), got %d", name, len(call.Args))
+	}
+	sequence, ok := literalValue(call.Args[0]).(string)
+	if !ok {
+		return fmt.Errorf("column %s: NEXTVAL() takes the sequence name as a string", name)
+	}
+	table, err := e.storage.GetTable(SequencesTable)
+	if err != nil || sequenceRow(table, sequence) == nil {
+		return fmt.Errorf("column %s: sequence %s does not exist", name, sequence)
+	}
+	return nil
+}
+
+// resolvedDefault returns the call of a column's DEFAULT NOW(), DEFAULT
+// UUID() or DEFAULT NEXTVAL(), or nil if its DEFAULT is none of them
+func (e *Executor) resolvedDefault(col storage.Column) *parser.FunctionCall {
+	if col.Default == "" {
+		return nil
+	}
+	expr, err := e.generatedExpression(col.Default)
+	if err != nil {
+		return nil
+	}
+	if call, ok := expr.(*parser.FunctionCall); ok && (resolvedFunctions[call.Name] || call.Name == "NEXTVAL") {
+		return call
+	}
+	return nil
+}
+
+// resolveFunctions returns a copy of an INSERT, UPDATE, DELETE or MERGE with its
+// NOW() and UUID() calls, and the DEFAULT NOW(), DEFAULT UUID() or ON
+// UPDATE NOW() columns it leaves out, replaced by values: now for the
+// time, and a new UUID for each call and row. DEFAULT NEXTVAL() columns
+// are given the call, which resolveSequences resolves; MERGE, which has no
+// rows of its own to resolve them for, rejects UUID() and NEXTVAL() (see
+// resolveMergeDefaults). The statement that
+// runs, and is logged, then gives the same rows when replayed. Calls to SQL
+// functions, in these and other statements, are inlined first. Statements
+// with nothing to resolve are returned as is with changed false.
+func (e *Executor) resolveFunctions(stmt parser.Statement, now time.Time) (resolved parser.Statement, changed bool, err error) {
+	stmt, inlined, err := e.inlineFunctions(stmt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	literal := &parser.Literal{Value: storage.FormatTimestamp(now)}
+	resolve := func(call *parser.FunctionCall) (parser.Expression, error) {
+		if len(call.Args) != 0 {
+			return nil, fmt.Errorf("%s() takes 0 argument(s), got %d", call.Name, len(call.Args))
+		}
+		changed = true
+		if uuidFunctions[call.Name] {
+			return &parser.Literal{Value: storage.NewUUID()}, nil
+		}
+		return literal, nil
+	}
+	replace := func(expr parser.Expression) (parser.Expression, error) {
+		return replaceCalls(expr, resolvedFunctions, resolve)
+	}
+
+	switch s := stmt.(type) {
+	case *parser.InsertStmt:
+		insert := &parser.InsertStmt{TableName: s.TableName, Columns: s.Columns, Values: make([][]parser.Expression, len(s.Values)), Returning: s.Returning}
+		if s.Select != nil {
+			if insert.Select, err = replaceSelectCalls(s.Select, resolvedFunctions, resolve); err != nil {
+				return nil, false, err
+			}
+		}
+		// DEFAULT for a DEFAULT NOW(), DEFAULT UUID() or DEFAULT NEXTVAL()
+		// column is resolved too
+		defaultColumns := e.resolvedColumns(s.TableName, false)
+		defaults := map[string]*parser.FunctionCall{}
+		for _, col := range defaultColumns {
+			defaults[col.Name] = e.resolvedDefault(col)
+		}
+		columns := s.Columns
+		if len(columns) == 0 && len(s.Values) > 0 {
+			columns = e.insertColumns(s.TableName)
+		}
+		for i, row := range s.Values {
+			insert.Values[i] = make([]parser.Expression, len(row))
+			for j, expr := range row {
+				if _, ok := expr.(*parser.DefaultValue); ok && j < len(columns) && defaults[columns[j]] != nil {
+					expr = defaults[columns[j]]
+					changed = true
+				}
+				if insert.Values[i][j], err = replace(expr); err != nil {
+					return nil, false, err
+				}
+			}
+		}
+		// Without a column list every column is given a value
+		if len(s.Columns) > 0 {
+			for _, col := range defaultColumns {
+				name := col.Name
+				if slices.Contains(s.Columns, name) {
+					continue
+				}
+				// The select list holds columns only, so it cannot be given
+				// the value
+				if s.Select != nil {
+					return nil, false, fmt.Errorf("INSERT ... SELECT must give a value for column %s, which has DEFAULT %s", name, parser.FormatExpression(defaults[name]))
+				}
+				insert.Columns = append(slices.Clip(insert.Columns), name)
+				changed = true
+				for i := range insert.Values {
+					value, err := replace(defaults[name])
+					if err != nil {
+						return nil, false, err
+					}
+					insert.Values[i] = append(insert.Values[i], value)
+				}
+			}
+		}
+		if s.OnConflict != nil {
+			var added bool
+			if insert.OnConflict, added, err = e.resolveConflictTimestamps(s, replace, literal); err != nil {
+				return nil, false, err
+			}
+			changed = changed || added
+		}
+		resolved = insert
+	case *parser.UpdateStmt:
+		update := &parser.UpdateStmt{TableName: s.TableName, Set: make(map[string]parser.Expression, len(s.Set)), Returning: s.Returning}
+		for column, expr := range s.Set {
+			if update.Set[column], err = replace(expr); err != nil {
+				return nil, false, err
+			}
+		}
+		if update.Where, err = replace(s.Where); err != nil {
+			return nil, false, err
+		}
+		for _, col := range e.resolvedColumns(s.TableName, true) {
+			if _, ok := update.Set[col.Name]; !ok {
+				update.Set[col.Name] = literal
+				changed = true
+			}
+		}
+		resolved = update
+	case *parser.DeleteStmt:
+		remove := &parser.DeleteStmt{TableName: s.TableName, Returning: s.Returning}
+		if remove.Where, err = replace(s.Where); err != nil {
+			return nil, false, err
+		}
+		resolved = remove
+	case *parser.MergeStmt:
+		// A MERGE may insert many rows, which one UUID would not tell apart
+		resolveOnce := func(call *parser.FunctionCall) (parser.Expression, error) {
+			if uuidFunctions[call.Name] {
+				return nil, fmt.Errorf("%s() cannot be used in MERGE", call.Name)
+			}
+			return resolve(call)
+		}
+		merge, err := mapMerge(s, func(expr parser.Expression) (parser.Expression, error) {
+			return replaceCalls(expr, resolvedFunctions, resolveOnce)
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		var added bool
+		if merge, added, err = e.resolveMergeDefaults(merge, literal); err != nil {
+			return nil, false, err
+		}
+		changed = changed || added
+		resolved = merge
+	default:
+		return stmt, inlined, nil
+	}
+
+	if !changed {
+		return stmt, inlined, nil
+	}
+	return resolved, true, nil
+}
+
+// resolveConflictTimestamps returns the ON CONFLICT clause of an INSERT
+// with replace applied to its DO UPDATE assignments, and the ON UPDATE
+// NOW() columns they leave out set to literal, reporting whether any were
+func (e *Executor) resolveConflictTimestamps(stmt *parser.InsertStmt, replace func(parser.Expression) (parser.Expression, error), literal parser.Expression) (*parser.OnConflict, bool, error) {
+	conflict := stmt.OnConflict
+	if conflict.Set == nil {
+		return conflict, false, nil
+	}
+	resolved := &parser.OnConflict{Columns: conflict.Columns, Set: make(map[string]parser.Expression, len(conflict.Set))}
+	for column, expr := range conflict.Set {
+		var err error
+		if resolved.Set[column], err = replace(expr); err != nil {
+			return nil, false, err
+		}
+	}
+	added := false
+	for _, col := range e.resolvedColumns(stmt.TableName, true) {
+		if _, ok := resolved.Set[col.Name]; !ok {
+			resolved.Set[col.Name] = literal
+			added = true
+		}
+	}
+	return resolved, added, nil
+}
+
+// resolvedColumns lists the columns of a table with DEFAULT NOW(), DEFAULT
+// UUID() or DEFAULT NEXTVAL(), or with onUpdate an ON UPDATE clause. A table that does
+// not exist has none; the statement using it fails later.
+func (e *Executor) resolvedColumns(tableName string, onUpdate bool) []storage.Column {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	table, err := e.storage.GetTable(tableName)
+	if err != nil {
+		return nil
+	}
+	var columns []storage.Column
+	for _, col := range table.Schema.Columns {
+		if !onUpdate && e.resolvedDefault(col) != nil || onUpdate && col.OnUpdate != "" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// insertColumns lists the columns an INSERT into a table without a column
+// list gives values for, or none if the table does not exist
+func (e *Executor) insertColumns(tableName string) []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	table, err := e.storage.GetTable(tableName)
+	if err != nil {
+		return nil
+	}
+	return valueColumns(table.Schema)
+}
+
+// valueColumns lists the columns of a table that can be given values: all
+// but the generated ones, in schema order
+func valueColumns(schema *storage.Schema) []string {
+	var columns []string
+	for _, col := range schema.Columns {
+		if col.Generated == "" {
+			columns = append(columns, col.Name)
+		}
+	}
+	return columns
+}
+
+// formatStatement renders an INSERT, UPDATE, DELETE or MERGE as SQL text
+func formatStatement(stmt parser.Statement) string {
+	switch s := stmt.(type) {
+	case *parser.InsertStmt:
+		return parser.FormatInsert(s)
+	case *parser.UpdateStmt:
+		return parser.FormatUpdate(s)
+	case *parser.DeleteStmt:
+		return parser.FormatDelete(s)
+	case *parser.MergeStmt:
+		return parser.FormatMerge(s)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,300 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// conflictKeys returns the column indexes of the keys an ON CONFLICT clause
+// checks: its target, which must be the primary key, a UNIQUE column or a
+// UNIQUE constraint, or without a target all of them. As in InsertRows,
+// each primary key column is a key of its own.
+func conflictKeys(schema *storage.Schema, conflict *parser.OnConflict) ([][]int, error) {
+	var keys [][]int
+	for _, name := range append(slices.Clone(schema.PrimaryKeys), schema.UniqueKeys...) {
+		if idx := schema.GetColumnIndex(name); idx != -1 {
+			keys = append(keys, []int{idx})
+		}
+	}
+	for _, constraint := range schema.UniqueConstraints {
+		key := make([]int, len(constraint.Columns))
+		for i, name := range constraint.Columns {
+			key[i] = schema.GetColumnIndex(name)
+		}
+		keys = append(keys, key)
+	}
+	if len(conflict.Columns) == 0 {
+		if conflict.Set != nil {
+			return nil, fmt.Errorf("ON CONFLICT DO UPDATE requires the key columns, e.g. ON CONFLICT (id)")
+		}
+		return keys, nil
+	}
+
+	target := make([]int, len(conflict.Columns))
+	for i, name := range conflict.Columns {
+		if target[i] = schema.GetColumnIndex(name); target[i] == -1 {
+			return nil, fmt.Errorf("column %s does not exist in table %s", name, schema.TableName)
+		}
+	}
+	slices.Sort(target)
+	target = slices.Compact(target)
+	for _, key := range keys {
+		sorted := slices.Clone(key)
+		slices.Sort(sorted)
+		if slices.Equal(sorted, target) {
+			return [][]int{key}, nil
+		}
+	}
+	return nil, fmt.Errorf("ON CONFLICT (%s) does not match a PRIMARY KEY or UNIQUE constraint of %s",
+		strings.Join(conflict.Columns, ", "), schema.TableName)
+}
+
+// conflictKey returns a key equal for exactly the rows whose values in the
+// key's columns are equal under the columns' collations, or false if any of
+// them is NULL, which never conflicts
+func conflictKey(schema *storage.Schema, values []interface{}, key []int) (string, bool) {
+	var b strings.Builder
+	for _, idx := range key {
+		if values[idx] == nil {
+			return "", false
+		}
+		s := fmt.Sprint(storage.CollationKey(values[idx], schema.Columns[idx]))
+		fmt.Fprintf(&b, "%T:%d:%s", values[idx], len(s), s)
+	}
+	return b.String(), true
+}
+
+// upsertRows writes the rows of an INSERT ... ON CONFLICT. Rows that
+// conflict with no existing row are inserted; for the others, DO NOTHING
+// skips them and DO UPDATE applies its assignments to the row they conflict
+// with, where excluded.<column> is the value the INSERT proposed. Rows the
+// user's policies hide are never updated. If the updates fail, the
+// inserted rows are taken back out. It returns the inserted and the updated
+// rows.
+func (e *Executor) upsertRows(ctx context.Context, table *storage.Table, stmt *parser.InsertStmt, rows []*storage.Row, policies []parser.Expression) (inserted, updated []*storage.Row, err error) {
+	schema := table.Schema
+	conflict := stmt.OnConflict
+	keys, err := conflictKeys(schema, conflict)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Index the existing rows by each key
+	taken := make([]map[string]*storage.Row, len(keys))
+	for i := range taken {
+		taken[i] = make(map[string]*storage.Row)
+	}
+	owner := make(map[*storage.Row]*storage.Table)
+	for _, target := range e.partitionsFor(table, nil) {
+		for _, row := range target.SelectRows() {
+			owner[row] = target
+			for i, key := range keys {
+				if k, ok := conflictKey(schema, row.Values, key); ok {
+					taken[i][k] = row
+				}
+			}
+		}
+	}
+
+	var toInsert []*storage.Row
+	proposed := make(map[*storage.Row][]interface{})
+	for _, row := range rows {
+		var existing *storage.Row
+		for i, key := range keys {
+			if k, ok := conflictKey(schema, row.Values, key); ok && taken[i][k] != nil {
+				existing = taken[i][k]
+				break
+			}
+		}
+		if existing == nil {
+			// Later rows of the INSERT conflict with this one
+			for i, key := range keys {
+				if k, ok := conflictKey(schema, row.Values, key); ok {
+					taken[i][k] = row
+				}
+			}
+			toInsert = append(toInsert, row)
+			continue
+		}
+		if conflict.Set == nil {
+			continue
+		}
+		if owner[existing] == nil {
+			return nil, nil, fmt.Errorf("ON CONFLICT DO UPDATE cannot insert a row and update it in the same statement")
+		}
+		if _, ok := proposed[existing]; ok {
+			return nil, nil, fmt.Errorf("ON CONFLICT DO UPDATE cannot update the same row twice")
+		}
+		visibleRow, err := e.withVirtual(ctx, schema, existing)
+		if err != nil {
+			return nil, nil, err
+		}
+		if visible, err := e.visible(ctx, policies, visibleRow, schema); err != nil {
+			return nil, nil, err
+		} else if !visible {
+			return nil, nil, fmt.Errorf("ON CONFLICT DO UPDATE conflicts with a row hidden by policies")
+		}
+		proposed[existing] = row.Values
+	}
+
+	if err := e.insertRows(table, toInsert); err != nil {
+		return nil, nil, err
+	}
+	if len(proposed) > 0 {
+		if updated, err = e.applyConflictUpdates(ctx, table, conflict.Set, proposed, owner, policies); err != nil {
+			e.removeInserted(table, toInsert)
+			return nil, nil, err
+		}
+	}
+	if cs := changeSetFrom(ctx); cs != nil {
+		for _, row := range toInsert {
+			cs.add(schema, cdc.OpInsert, nil, row.Values)
+		}
+	}
+	return toInsert, updated, nil
+}
+
+// removeInserted takes rows a statement inserted back out of a table, when
+// the rest of the statement fails
+func (e *Executor) removeInserted(table *storage.Table, rows []*storage.Row) {
+	added := make(map[*storage.Row]bool, len(rows))
+	for _, row := range rows {
+		added[row] = true
+	}
+	for _, target := range e.partitionsFor(table, nil) {
+		target.DeleteRows(func(row *storage.Row) bool { return added[row] })
+	}
+}
+
+// applyConflictUpdates applies the DO UPDATE assignments of an ON CONFLICT
+// clause to the rows in proposed, each evaluated against the row and the
+// values the INSERT proposed for it, and returns the updated rows
+func (e *Executor) applyConflictUpdates(ctx context.Context, table *storage.Table, set map[string]parser.Expression, proposed map[*storage.Row][]interface{}, owner map[*storage.Row]*storage.Table, policies []parser.Expression) ([]*storage.Row, error) {
+	schema := table.Schema
+
+	// Unqualified columns are the existing row's, which comes first; the
+	// proposed values follow, under either case of EXCLUDED
+	width := len(schema.Columns)
+	rowSchema := &joinSchema{width: 2 * width, sources: []joinSource{
+		{name: schema.TableName, schema: schema},
+		{name: "excluded", schema: schema, offset: width},
+		{name: "EXCLUDED", schema: schema, offset: width},
+	}}
+	access, err := e.accessTo(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	exprs := make(map[int]parser.Expression, len(set))
+	for colName, expr := range set {
+		idx := schema.GetColumnIndex(colName)
+		if idx == -1 {
+			return nil, fmt.Errorf("column %s not found", colName)
+		}
+		if schema.Columns[idx].Generated != "" {
+			return nil, fmt.Errorf("cannot update generated column %s", colName)
+		}
+		// The proposed values are the user's own; only the existing row's
+		// columns need the right to read them
+		if err := access.checkReferences(expr, func(name string) int {
+			if idx := rowSchema.index(name); idx < width {
+				return idx
+			}
+			return -1
+		}); err != nil {
+			return nil, err
+		}
+		exprs[idx] = expr
+	}
+	setExprs := make([]parser.Expression, 0, len(set))
+	for _, expr := range set {
+		setExprs = append(setExprs, expr)
+	}
+	if ctx, err = e.withSubqueries(ctx, setExprs...); err != nil {
+		return nil, err
+	}
+
+	// Rows are updated partition by partition
+	var targets []*storage.Table
+	for row := range proposed {
+		if !slices.Contains(targets, owner[row]) {
+			targets = append(targets, owner[row])
+		}
+	}
+
+	refs, err := e.newReferenceCheck(table, func(idx int) bool {
+		_, ok := exprs[idx]
+		return ok || schema.Columns[idx].Generated != ""
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cs := changeSetFrom(ctx)
+	var matched []*storage.Row
+	var before [][]interface{}
+	for _, target := range targets {
+		bound, keyCol, bounded := e.storage.PartitionBound(target)
+		// UpdateRowsWith hands update the values of the row condition
+		// last matched
+		var current *storage.Row
+		condition := func(row *storage.Row) bool {
+			if _, ok := proposed[row]; !ok {
+				return false
+			}
+			current = row
+			matched = append(matched, row)
+			if cs != nil {
+				before = append(before, append([]interface{}{}, row.Values...))
+			}
+			return true
+		}
+		_, err := target.UpdateRowsWith(condition, func(values []interface{}) error {
+			old := slices.Clone(values)
+			row, err := e.withVirtual(ctx, schema, storage.NewRow(old))
+			if err != nil {
+				return err
+			}
+			combined := &CombinedRow{values: append(slices.Clone(row.Values), proposed[current]...), schema: rowSchema}
+			for idx, expr := range exprs {
+				value, err := e.getJoinColumnValue(ctx, expr, combined)
+				if err != nil {
+					return err
+				}
+				if values[idx], err = storage.NormalizeValue(value, schema.Columns[idx]); err != nil {
+					return err
+				}
+			}
+			if err := e.computeGenerated(ctx, schema, values, false); err != nil {
+				return err
+			}
+			if err := e.checkPolicies(ctx, schema, policies, values); err != nil {
+				return err
+			}
+			if err := refs.check(old, values); err != nil {
+				return err
+			}
+			if bounded {
+				return checkPartitionBound(target, bound, keyCol, values)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if schema.Partitioned() && len(matched) > 0 {
+		table.MarkDirty()
+	}
+	if cs != nil {
+		for i, row := range matched {
+			cs.add(schema, cdc.OpUpdate, before[i], row.Values)
+		}
+	}
+	return matched, nil
+}
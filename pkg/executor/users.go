@@ -0,0 +1,160 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cdc"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// executeCreateUser executes CREATE USER statement
+func (e *Executor) executeCreateUser(ctx context.Context, stmt *parser.CreateUserStmt) (*Result, error) {
+	table, err := e.systemTable(UsersTable)
+	if err != nil {
+		return nil, err
+	}
+	if slices.ContainsFunc(table.SelectRows(), func(row *storage.Row) bool { return row.Values[0] == stmt.Name }) {
+		return nil, fmt.Errorf("user %s already exists", stmt.Name)
+	}
+
+	row := storage.NewRow([]interface{}{stmt.Name})
+	if err := table.InsertRow(row); err != nil {
+		return nil, err
+	}
+	if cs := changeSetFrom(ctx); cs != nil {
+		cs.add(table.Schema, cdc.OpInsert, nil, row.Values)
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	// Users that were not created may have cached results they can no
+	// longer run
+	e.results.invalidateAll()
+	return &Result{Message: fmt.Sprintf("User %s created", stmt.Name)}, nil
+}
+
+// executeDropUser executes DROP USER statement
+func (e *Executor) executeDropUser(ctx context.Context, stmt *parser.DropUserStmt) (*Result, error) {
+	table, err := e.storage.GetTable(UsersTable)
+	if err != nil {
+		return nil, fmt.Errorf("user %s does not exist", stmt.Name)
+	}
+	if name := e.grantedTable(stmt.Name); name != "" {
+		return nil, fmt.Errorf("cannot drop user %s: it holds privileges on table %s", stmt.Name, name)
+	}
+	cs := changeSetFrom(ctx)
+	count := table.DeleteRows(func(row *storage.Row) bool {
+		if row.Values[0] != stmt.Name {
+			return false
+		}
+		if cs != nil {
+			cs.add(table.Schema, cdc.OpDelete, row.Values, nil)
+		}
+		return true
+	})
+	if count == 0 {
+		return nil, fmt.Errorf("user %s does not exist", stmt.Name)
+	}
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist data: %w", err)
+	}
+	e.results.invalidateAll()
+	return &Result{Message: fmt.Sprintf("User %s dropped", stmt.Name)}, nil
+}
+
+// grantedTable returns a table on which a user holds a privilege, or ""
+func (e *Executor) grantedTable(user string) string {
+	for _, name := range e.storage.ListTables() {
+		table, err := e.storage.GetTable(name)
+		if err != nil {
+			continue
+		}
+		if slices.ContainsFunc(table.Schema.Grants, func(g storage.Grant) bool { return g.User == user }) {
+			return name
+		}
+	}
+	return ""
+}
+
+// checkUser rejects statements running for a user that was not created
+// with CREATE USER. Until the first user is created any user may run
+// statements, so users can be set up before they are enforced.
+func (e *Executor) checkUser(ctx context.Context) error {
+	user := UserFrom(ctx)
+	if user == "" {
+		return nil
+	}
+	table, err := e.storage.GetTable(UsersTable)
+	if err != nil {
+		return nil
+	}
+	rows := table.SelectRows()
+	if len(rows) == 0 || slices.ContainsFunc(rows, func(row *storage.Row) bool { return row.Values[0] == user }) {
+		return nil
+	}
+	return fmt.Errorf("permission denied: user %s does not exist", user)
+}
+
+// writePrivileges returns the privileges a statement needs on the table it
+// writes: an upsert may also update and a MERGE needs those of its actions
+func writePrivileges(stmt parser.Statement) []string {
+	switch s := stmt.(type) {
+	case *parser.InsertStmt:
+		if s.OnConflict != nil && s.OnConflict.Set != nil {
+			return []string{storage.PrivilegeInsert, storage.PrivilegeUpdate}
+		}
+		return []string{storage.PrivilegeInsert}
+	case *parser.UpdateStmt:
+		return []string{storage.PrivilegeUpdate}
+	case *parser.DeleteStmt, *parser.PurgeStmt:
+		return []string{storage.PrivilegeDelete}
+	case *parser.MergeStmt:
+		var privileges []string
+		for _, clause := range s.Clauses {
+			if clause.Action != "NOTHING" && !slices.Contains(privileges, clause.Action) {
+				privileges = append(privileges, clause.Action)
+			}
+		}
+		return privileges
+	default:
+		return nil
+	}
+}
+
+// checkPrivileges rejects a write by a user without the privileges it
+// needs on its table
+func (e *Executor) checkPrivileges(ctx context.Context, stmt parser.Statement) error {
+	privileges := writePrivileges(stmt)
+	if UserFrom(ctx) == "" || privileges == nil {
+		return nil
+	}
+	// A table that does not exist fails later, with a better error
+	table, err := e.storage.GetTable(targetTable(stmt))
+	if err != nil {
+		return nil
+	}
+	for _, privilege := range privileges {
+		if err := checkPrivilege(ctx, table.Schema, privilege); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkPrivilege rejects a write by a user without a privilege on a table.
+// As with SELECT, a table only restricts a privilege once it has been
+// granted to someone.
+func checkPrivilege(ctx context.Context, schema *storage.Schema, privilege string) error {
+	user := UserFrom(ctx)
+	if user == "" {
+		return nil
+	}
+	grants := schema.GrantsOf(privilege)
+	if len(grants) == 0 || slices.ContainsFunc(grants, func(g storage.Grant) bool { return g.User == user }) {
+		return nil
+	}
+	return fmt.Errorf("permission denied: user %s has no %s privilege on table %s", user, privilege, schema.TableName)
+}
@@ -0,0 +1,246 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// executeCreateMaterializedView executes CREATE MATERIALIZED VIEW, storing
+// the result of its query as the rows of a new read-only table
+func (e *Executor) executeCreateMaterializedView(ctx context.Context, stmt *parser.CreateMaterializedViewStmt) (*Result, error) {
+	if e.storage.TableExists(stmt.Name) {
+		return nil, fmt.Errorf("table %s already exists", stmt.Name)
+	}
+
+	result, err := e.viewResult(ctx, stmt.Select)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := storage.NewSchema(stmt.Name)
+	for i := range result.Columns {
+		col := e.viewColumn(stmt.Select, result, i)
+		if schema.GetColumnIndex(col.Name) != -1 {
+			return nil, fmt.Errorf("materialized view %s has more than one column named %s; name them with AS", stmt.Name, col.Name)
+		}
+		schema.AddColumn(col)
+	}
+
+	tables := []string{}
+	for _, name := range selectTables(stmt.Select) {
+		if !slices.Contains(tables, name) {
+			tables = append(tables, name)
+		}
+	}
+	schema.View = &storage.View{Query: parser.FormatSelect(stmt.Select), Tables: tables}
+
+	if err := e.storage.CreateTable(schema); err != nil {
+		return nil, err
+	}
+	table, err := e.storage.GetTable(stmt.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := table.ReplaceRows(viewRows(result)); err != nil {
+		e.storage.DropTable(stmt.Name)
+		return nil, err
+	}
+
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist materialized view: %w", err)
+	}
+
+	return &Result{
+		Message:      fmt.Sprintf("Materialized view '%s' created with %d row(s)", stmt.Name, len(result.Rows)),
+		RowsAffected: len(result.Rows),
+	}, nil
+}
+
+// executeRefreshMaterializedView executes REFRESH MATERIALIZED VIEW,
+// replacing the view's rows with a new result of its query
+func (e *Executor) executeRefreshMaterializedView(ctx context.Context, stmt *parser.RefreshMaterializedViewStmt) (*Result, error) {
+	table, err := e.storage.GetTable(stmt.Name)
+	if err != nil {
+		return nil, err
+	}
+	if !table.Schema.Materialized() {
+		return nil, fmt.Errorf("%s is not a materialized view", stmt.Name)
+	}
+
+	parsed, err := parser.NewParser(table.Schema.View.Query).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("materialized view %s: %w", stmt.Name, err)
+	}
+	sel, ok := parsed.(*parser.SelectStmt)
+	if !ok {
+		return nil, fmt.Errorf("materialized view %s: query is not a SELECT", stmt.Name)
+	}
+
+	result, err := e.viewResult(ctx, sel)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Columns) != len(table.Schema.Columns) {
+		return nil, fmt.Errorf("materialized view %s: query returns %d column(s), expected %d", stmt.Name, len(result.Columns), len(table.Schema.Columns))
+	}
+	if err := table.ReplaceRows(viewRows(result)); err != nil {
+		return nil, fmt.Errorf("materialized view %s: %w", stmt.Name, err)
+	}
+
+	if err := e.persist(ctx); err != nil {
+		return nil, fmt.Errorf("failed to persist materialized view: %w", err)
+	}
+
+	return &Result{
+		Message:      fmt.Sprintf("Materialized view '%s' refreshed with %d row(s)", stmt.Name, len(result.Rows)),
+		RowsAffected: len(result.Rows),
+	}, nil
+}
+
+// viewResult runs the query of a materialized view. Like INSERT ... SELECT,
+// its rows are not streamed to the client and count against the result
+// limits.
+func (e *Executor) viewResult(ctx context.Context, sel *parser.SelectStmt) (*Result, error) {
+	inner := withoutRowWriter(context.WithValue(ctx, planActualsKey{}, nil))
+	return e.executeSelect(inner, sel)
+}
+
+// viewColumn returns the definition of the i-th column of a materialized
+// view. Columns read from a table keep its column's type; aggregates get
+// the type of their result, and anything else the type of its values.
+func (e *Executor) viewColumn(sel *parser.SelectStmt, result *Result, i int) storage.Column {
+	name := result.Columns[i]
+	if dot := strings.LastIndex(name, "."); dot != -1 {
+		name = name[dot+1:]
+	}
+	col := storage.Column{Name: name, DataType: valuesType(result.Rows, i)}
+
+	// Without *, result columns follow the SELECT list
+	if slices.Contains(sel.Columns, "*") || len(sel.Columns) != len(result.Columns) {
+		if source := e.viewSource(sel, result.Columns[i]); source != nil {
+			col.DataType, col.Size, col.Collation = source.DataType, source.Size, source.Collation
+		}
+		return col
+	}
+
+	var agg *parser.AggregateCall
+	if sel.Aggregates != nil {
+		agg = sel.Aggregates[i]
+	}
+	if agg == nil {
+		if source := e.viewSource(sel, sel.Columns[i]); source != nil {
+			col.DataType, col.Size, col.Collation = source.DataType, source.Size, source.Collation
+		}
+		return col
+	}
+
+	// An unaliased count(*) is named count
+	if sel.Aliases == nil || sel.Aliases[i] == "" {
+		col.Name = strings.ToLower(agg.Name)
+	}
+	switch agg.Name {
+	case "COUNT", "APPROX_COUNT_DISTINCT":
+		col.DataType = storage.TypeInteger
+	case "AVG":
+		col.DataType = storage.TypeFloat
+	default:
+		if source := e.viewSource(sel, agg.Column); source != nil {
+			if agg.Cast != "" {
+				cast := castColumn(*source, agg.Cast)
+				source = &cast
+			}
+			col.DataType = source.DataType
+			if agg.Name != "SUM" {
+				col.Size, col.Collation = source.Size, source.Collation
+			}
+		}
+	}
+	return col
+}
+
+// viewSource returns the table column a SELECT reads as name, which may be
+// qualified by a table name or alias, or nil if it is not one
+func (e *Executor) viewSource(sel *parser.SelectStmt, name string) *storage.Column {
+	qualifier, column, qualified := strings.Cut(name, ".")
+	if !qualified {
+		qualifier, column = "", name
+	}
+
+	type source struct{ table, alias string }
+	sources := []source{{sel.TableName, sel.TableAlias}}
+	for _, join := range sel.Joins {
+		sources = append(sources, source{join.TableName, join.Alias})
+	}
+	for _, src := range sources {
+		if qualifier != "" && qualifier != src.table && qualifier != src.alias {
+			continue
+		}
+		table, err := e.storage.GetTable(src.table)
+		if err != nil {
+			continue
+		}
+		if idx := table.Schema.GetColumnIndex(column); idx != -1 {
+			return &table.Schema.Columns[idx]
+		}
+	}
+	return nil
+}
+
+// valuesType returns the type of the values in the i-th column of rows,
+// or VARCHAR if they are all NULL
+func valuesType(rows [][]interface{}, i int) storage.DataType {
+	for _, row := range rows {
+		switch row[i].(type) {
+		case int:
+			return storage.TypeInteger
+		case float32, float64:
+			return storage.TypeFloat
+		case bool:
+			return storage.TypeBoolean
+		}
+		if row[i] != nil {
+			return storage.TypeVarchar
+		}
+	}
+	return storage.TypeVarchar
+}
+
+// viewRows converts the result of a materialized view's query into rows
+// of the view's table
+func viewRows(result *Result) []*storage.Row {
+	rows := make([]*storage.Row, len(result.Rows))
+	for i, values := range result.Rows {
+		rows[i] = storage.NewRow(slices.Clone(values))
+	}
+	return rows
+}
+
+// dependentView returns a materialized view whose query reads table, or ""
+func (e *Executor) dependentView(table string) string {
+	for _, name := range e.storage.ListTables() {
+		view, err := e.storage.GetTable(name)
+		if err != nil || !view.Schema.Materialized() || name == table {
+			continue
+		}
+		if slices.Contains(view.Schema.View.Tables, table) {
+			return name
+		}
+	}
+	return ""
+}
+
+// changesView reports whether a statement computes a materialized view's
+// rows, which must reflect a single state of the tables its query reads
+func changesView(stmt parser.Statement) bool {
+	switch stmt.(type) {
+	case *parser.CreateMaterializedViewStmt, *parser.RefreshMaterializedViewStmt:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/wal"
+)
+
+// SetWAL attaches a write-ahead log. Every write statement that succeeds
+// through Query is appended to it.
+func (e *Executor) SetWAL(log *wal.Log) {
+	e.wal = log
+}
+
+// WAL returns the attached write-ahead log, or nil
+func (e *Executor) WAL() *wal.Log {
+	return e.wal
+}
+
+// SetReadOnly makes the executor reject write statements from clients.
+// Replicated statements applied through Replay are still accepted.
+func (e *Executor) SetReadOnly(readOnly bool) {
+	e.readOnly = readOnly
+}
+
+// ReadOnly reports whether client writes are rejected
+func (e *Executor) ReadOnly() bool {
+	return e.readOnly
+}
+
+// Replay applies a logged statement (e.g. one pulled from a replication
+// leader) and records it in the local WAL under its original LSN
+func (e *Executor) Replay(ctx context.Context, rec wal.Record) error {
+	stmt, err := e.parse(rec.Query)
+	if err != nil {
+		return fmt.Errorf("LSN %d: %w", rec.LSN, err)
+	}
+
+	ctx = WithUser(ctx, rec.User)
+	var cs *changeSet
+	if e.changes != nil {
+		cs = &changeSet{}
+		ctx = withChangeSet(ctx, cs)
+	}
+
+	unlock := e.lock(stmt)
+	defer unlock()
+
+	if _, err := e.execute(ctx, stmt); err != nil {
+		return fmt.Errorf("LSN %d: %w", rec.LSN, err)
+	}
+
+	if e.wal != nil {
+		if err := e.wal.AppendRecord(rec); err != nil {
+			return fmt.Errorf("LSN %d: %w", rec.LSN, err)
+		}
+	}
+	e.publishChanges(rec.LSN, cs)
+	return nil
+}
+
+// isReadOnly reports whether a statement leaves the database unchanged
+func isReadOnly(stmt parser.Statement) bool {
+	switch stmt.(type) {
+	case *parser.SelectStmt, *parser.ExplainStmt, *parser.AdviseStmt, *parser.BackupStmt, *parser.ShowStatsStmt,
+		*parser.SetStmt, *parser.ShowVariableStmt, *parser.ShowIndexesStmt, *parser.ShowSettingsStmt,
+		*parser.ShowTablesStmt, *parser.DescribeStmt, *parser.CallStmt:
+		return true
+	default:
+		return false
+	}
+}
+
+// isMaintenance reports whether a statement operates on the database as a
+// whole rather than on its data
+func isMaintenance(stmt parser.Statement) bool {
+	switch stmt.(type) {
+	case *parser.BackupStmt, *parser.RestoreStmt, *parser.CheckpointStmt:
+		return true
+	default:
+		return false
+	}
+}
+
+// isLogged reports whether a statement is recorded in the WAL
+func isLogged(stmt parser.Statement) bool {
+	return !isReadOnly(stmt) && !isMaintenance(stmt)
+}
@@ -266,6 +266,58 @@ func (bt *BTree) traverse(node *BTreeNode, entries *[]IndexEntry) {
 	}
 }
 
+// Bound is one end of a range of keys
+type Bound struct {
+	Key       interface{}
+	Inclusive bool
+}
+
+// Range returns the entries whose keys lie between start and end, in key
+// order. A nil bound leaves that end of the range open. Subtrees wholly
+// outside the range are skipped, so only the entries in it and the path to
+// them are visited.
+func (bt *BTree) Range(start, end *Bound) []IndexEntry {
+	bt.mu.RLock()
+	defer bt.mu.RUnlock()
+
+	entries := []IndexEntry{}
+	bt.rangeNode(bt.root, start, end, &entries)
+	return entries
+}
+
+// rangeNode appends the entries of a node's subtree that lie between start
+// and end, in key order, and reports whether no key past end was reached
+func (bt *BTree) rangeNode(node *BTreeNode, start, end *Bound, entries *[]IndexEntry) bool {
+	if node == nil {
+		return true
+	}
+
+	for i, key := range node.keys {
+		// Keys in children[i] are below key, so none are in range unless
+		// key is above start
+		if !node.isLeaf && (start == nil || compare(key, start.Key) > 0) {
+			if !bt.rangeNode(node.children[i], start, end, entries) {
+				return false
+			}
+		}
+		if end != nil {
+			if c := compare(key, end.Key); c > 0 || c == 0 && !end.Inclusive {
+				return false
+			}
+		}
+		if start == nil {
+			*entries = append(*entries, IndexEntry{Key: key, RowIndex: node.values[i]})
+		} else if c := compare(key, start.Key); c > 0 || c == 0 && start.Inclusive {
+			*entries = append(*entries, IndexEntry{Key: key, RowIndex: node.values[i]})
+		}
+	}
+
+	if !node.isLeaf {
+		return bt.rangeNode(node.children[len(node.keys)], start, end, entries)
+	}
+	return true
+}
+
 // IndexEntry represents an entry in the index
 type IndexEntry struct {
 	Key      interface{}
@@ -52,6 +52,19 @@ func (m *Manager) DropIndex(tableName, columnName string) error {
 	return nil
 }
 
+// ResetIndex empties an index so it can be rebuilt, reporting whether it
+// exists
+func (m *Manager) ResetIndex(tableName, columnName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.indexes[tableName][columnName]; !exists {
+		return false
+	}
+	m.indexes[tableName][columnName] = NewBTree()
+	return true
+}
+
 // DropTableIndexes drops all indexes for a table
 func (m *Manager) DropTableIndexes(tableName string) {
 	m.mu.Lock()
@@ -136,3 +149,30 @@ func (m *Manager) GetIndexedColumns(tableName string) []string {
 	}
 	return columns
 }
+
+// Entries returns the entries of the index on tableName.columnName in key
+// order, and whether the index exists
+func (m *Manager) Entries(tableName, columnName string) ([]IndexEntry, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	btree, exists := m.indexes[tableName][columnName]
+	if !exists {
+		return nil, false
+	}
+	return btree.GetAll(), true
+}
+
+// Range returns the entries of the index on tableName.columnName whose keys
+// lie between start and end in key order, and whether the index exists.
+// A nil bound leaves that end of the range open.
+func (m *Manager) Range(tableName, columnName string, start, end *Bound) ([]IndexEntry, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	btree, exists := m.indexes[tableName][columnName]
+	if !exists {
+		return nil, false
+	}
+	return btree.Range(start, end), true
+}
@@ -1,5 +1,7 @@
 package parser
 
+import "strings"
+
 // Statement represents any SQL statement
 type Statement interface {
 	statementNode()
@@ -14,53 +16,344 @@ type Expression interface {
 type ColumnDef struct {
 	Name       string
 	DataType   string
-	Size       int  // for VARCHAR(size)
+	Size       int // for VARCHAR(size)
 	PrimaryKey bool
 	Unique     bool
 	NotNull    bool
+	Generated  Expression     // GENERATED ALWAYS AS (expr); nil for ordinary columns
+	Virtual    bool           // generated column computed at read time rather than stored
+	Collation  string         // COLLATE name; empty when not given
+	Default    Expression     // DEFAULT <expr>; nil when not given
+	OnUpdate   Expression     // ON UPDATE <expr>; nil when not given
+	Dictionary bool           // ENCODING DICTIONARY
+	References *ForeignKeyDef // REFERENCES <table> [(<column>)]; nil when not given
 }
 
 func (c *ColumnDef) statementNode() {}
 
 // CreateTableStmt represents CREATE TABLE statement
 type CreateTableStmt struct {
-	TableName string
-	Columns   []*ColumnDef
+	TableName       string
+	Columns         []*ColumnDef
+	Unique          [][]string // table-level UNIQUE (<column>, ...) constraints
+	ForeignKeys     []*ForeignKeyDef
+	PartitionBy     string // partition key column, if partitioned
+	PartitionMethod string // RANGE or HASH
+	Layout          string // USING COLUMNAR or ROW; empty when not given
+
+	// CREATE TABLE ... PARTITION OF parent FOR VALUES ... [LOCATION '<dir>']
+	PartitionOf string
+	Bound       *PartitionBound
+	Location    string
+
+	// CREATE FOREIGN TABLE ... SERVER <server> OPTIONS (<name> '<value>', ...)
+	Server  string
+	Options map[string]string
 }
 
 func (c *CreateTableStmt) statementNode() {}
 
-// DropTableStmt represents DROP TABLE statement
-type DropTableStmt struct {
+// ForeignKeyDef is a table-level FOREIGN KEY (<column>, ...) REFERENCES
+// <table> [(<column>, ...)], or a column's REFERENCES <table> [(<column>)].
+// Without referenced columns it references the table's primary key.
+type ForeignKeyDef struct {
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string // ON DELETE CASCADE or SET NULL; empty for RESTRICT, NO ACTION or none
+}
+
+// PartitionBound is FOR VALUES FROM (<from>) TO (<to>), where a nil
+// expression stands for MINVALUE or MAXVALUE, or FOR VALUES WITH (MODULUS
+// <n>, REMAINDER <r>)
+type PartitionBound struct {
+	From      Expression
+	To        Expression
+	Modulus   int // non-zero for a hash partition
+	Remainder int
+}
+
+// AlterTableStmt represents ALTER TABLE ... ATTACH PARTITION, DETACH
+// PARTITION, ENABLE / DISABLE SOFT DELETE or ALTER COLUMN ... SET MASK /
+// DROP MASK / SET ENCODING
+type AlterTableStmt struct {
+	TableName string
+	Action    string // ATTACH, DETACH, ENABLE SOFT DELETE, DISABLE SOFT DELETE, SET MASK, DROP MASK or SET ENCODING
+	Partition string
+	Bound     *PartitionBound // for ATTACH
+	Location  string          // for ATTACH
+	Column    string          // for SET MASK, DROP MASK and SET ENCODING
+	Mask      Expression      // for SET MASK
+	Encoding  string          // DICTIONARY or PLAIN, for SET ENCODING
+}
+
+func (a *AlterTableStmt) statementNode() {}
+
+// CreatePolicyStmt represents CREATE POLICY name ON table USING (expr)
+type CreatePolicyStmt struct {
+	Name      string
+	TableName string
+	Using     Expression
+}
+
+func (c *CreatePolicyStmt) statementNode() {}
+
+// DropPolicyStmt represents DROP POLICY name ON table
+type DropPolicyStmt struct {
+	Name      string
+	TableName string
+}
+
+func (d *DropPolicyStmt) statementNode() {}
+
+// GrantStmt represents GRANT privilege [(columns)] ON table TO user, or
+// REVOKE ... FROM user
+type GrantStmt struct {
+	Revoke    bool
+	Privilege string   // SELECT, INSERT, UPDATE, DELETE or UNMASK
+	Columns   []string // columns a SELECT privilege covers, nil for all
 	TableName string
+	User      string
+}
+
+func (g *GrantStmt) statementNode() {}
+
+// CreateUserStmt represents CREATE USER name
+type CreateUserStmt struct {
+	Name string
+}
+
+func (c *CreateUserStmt) statementNode() {}
+
+// DropUserStmt represents DROP USER name
+type DropUserStmt struct {
+	Name string
+}
+
+func (d *DropUserStmt) statementNode() {}
+
+// CreateJobStmt represents CREATE JOB name SCHEDULE '<cron>' AS <statement>
+type CreateJobStmt struct {
+	Name      string
+	Schedule  string
+	Statement Statement // statement the job runs
+	Query     string    // text of Statement
+}
+
+func (c *CreateJobStmt) statementNode() {}
+
+// DropJobStmt represents DROP JOB name
+type DropJobStmt struct {
+	Name string
+}
+
+func (d *DropJobStmt) statementNode() {}
+
+// CreateFunctionStmt represents CREATE FUNCTION name(<param> <type>, ...)
+// RETURNS <type> AS '<body>'
+type CreateFunctionStmt struct {
+	Name    string // upper-cased, as calls name it
+	Params  []FunctionParam
+	Returns string     // type the result is cast to, as CAST names it
+	Query   string     // text of the body
+	Body    Expression // the body's value: an expression or a SubqueryExpr
+}
+
+func (c *CreateFunctionStmt) statementNode() {}
+
+// FunctionParam is a parameter of a SQL function
+type FunctionParam struct {
+	Name string
+	Type string // type arguments are cast to, as CAST names it
+}
+
+// DropFunctionStmt represents DROP FUNCTION name
+type DropFunctionStmt struct {
+	Name string // upper-cased
+}
+
+func (d *DropFunctionStmt) statementNode() {}
+
+// CallStmt represents CALL name(<args>)
+type CallStmt struct {
+	Name string     // upper-cased function name
+	Call Expression // the call, replaced by its function's body before it runs
+}
+
+func (c *CallStmt) statementNode() {}
+
+// CreateSequenceStmt represents CREATE SEQUENCE name [START WITH n]
+// [INCREMENT BY n]
+type CreateSequenceStmt struct {
+	Name      string
+	Start     int
+	Increment int
+}
+
+func (c *CreateSequenceStmt) statementNode() {}
+
+// AlterSequenceStmt represents ALTER SEQUENCE name RESTART [WITH n]
+type AlterSequenceStmt struct {
+	Name    string
+	Restart *int // next value NEXTVAL returns, nil for the start value
+}
+
+func (a *AlterSequenceStmt) statementNode() {}
+
+// DropSequenceStmt represents DROP SEQUENCE name
+type DropSequenceStmt struct {
+	Name string
+}
+
+func (d *DropSequenceStmt) statementNode() {}
+
+// CreateMaterializedViewStmt represents CREATE MATERIALIZED VIEW name AS
+// SELECT ...
+type CreateMaterializedViewStmt struct {
+	Name   string
+	Select *SelectStmt
+}
+
+func (c *CreateMaterializedViewStmt) statementNode() {}
+
+// RefreshMaterializedViewStmt represents REFRESH MATERIALIZED VIEW name
+type RefreshMaterializedViewStmt struct {
+	Name string
+}
+
+func (r *RefreshMaterializedViewStmt) statementNode() {}
+
+// DropTableStmt represents DROP TABLE or DROP MATERIALIZED VIEW statement
+type DropTableStmt struct {
+	TableName        string
+	MaterializedView bool // DROP MATERIALIZED VIEW
 }
 
 func (d *DropTableStmt) statementNode() {}
 
 // InsertStmt represents INSERT INTO statement
 type InsertStmt struct {
-	TableName string
-	Columns   []string
-	Values    [][]Expression
+	TableName  string
+	Columns    []string
+	Values     [][]Expression
+	Select     *SelectStmt // INSERT ... SELECT: the query giving the rows, with Values empty
+	OnConflict *OnConflict // ON CONFLICT clause, or nil
+	Returning  []string    // RETURNING columns or "*", or nil
 }
 
 func (i *InsertStmt) statementNode() {}
 
+// OnConflict is the ON CONFLICT clause of an INSERT, which says what to do
+// with a row whose key is already taken
+type OnConflict struct {
+	Columns []string              // conflict target; empty for any key, with DO NOTHING
+	Set     map[string]Expression // DO UPDATE SET assignments; nil for DO NOTHING
+}
+
+// CopyStmt represents COPY ... FROM STDIN, which loads delimited rows that
+// are supplied alongside the statement, or COPY ... FROM '<path>', which
+// reads them from a file on the server
+type CopyStmt struct {
+	TableName string
+	Columns   []string // empty for every non-generated column
+	Path      string   // file to read; empty for STDIN
+	Format    string   // "text" or "csv"
+	Delimiter string   // empty for the format's default
+	Header    bool     // the first line holds column names and is skipped
+	Null      string   // marker for NULL; empty for the format's default
+}
+
+func (c *CopyStmt) statementNode() {}
+
+// ExplainStmt represents EXPLAIN statement, which shows how a SELECT would
+// be executed. With Analyze set the SELECT is run and actual row counts are
+// shown alongside the estimates.
+type ExplainStmt struct {
+	Select  *SelectStmt
+	Analyze bool
+	Format  string // "text" or "json"
+}
+
+func (e *ExplainStmt) statementNode() {}
+
+// AdviseStmt represents EXPLAIN ADVISE statement, which suggests indexes
+// for the columns statements have filtered on
+type AdviseStmt struct {
+	TableName string // empty for every table
+}
+
+func (a *AdviseStmt) statementNode() {}
+
 // SelectStmt represents SELECT statement
 type SelectStmt struct {
-	Columns   []string // column names or "*"
-	TableName string
-	Joins     []*JoinClause
-	Where     Expression
+	Columns    []string // column names or "*"; aggregates are named after their call
+	TableName  string
+	TableAlias string       // alias of the FROM table, or empty
+	Sample     *TableSample // TABLESAMPLE clause on the FROM table, or nil
+	Joins      []*JoinClause
+	Where      Expression
+
+	// Aggregates holds, for each entry of Columns, its aggregate call, with
+	// nil for plain columns. It is nil when the list has no aggregates.
+	Aggregates []*AggregateCall
+	// Aliases holds, for each entry of Columns, the name given with AS, or
+	// "" for none. It is nil when the list has no aliases.
+	Aliases []string
+
+	WithDeleted bool       // WITH DELETED: include soft-deleted rows
+	GroupBy     []string   // GROUP BY columns, or nil
+	Having      Expression // HAVING condition, or nil
+	OrderBy     []OrderBy  // ORDER BY keys, most significant first, or nil
 }
 
 func (s *SelectStmt) statementNode() {}
 
+// OrderBy is a key of the ORDER BY clause of a SELECT
+type OrderBy struct {
+	Column string
+	Desc   bool
+	// NullsFirst puts NULLs before other values. Without NULLS FIRST or
+	// NULLS LAST it is set for DESC keys, as in PostgreSQL.
+	NullsFirst bool
+}
+
+// AggregateCall is an aggregate function in a SELECT list, such as
+// APPROX_COUNT_DISTINCT(user_id) or SUM(CAST(amount AS INTEGER))
+type AggregateCall struct {
+	Name     string // upper-cased function name
+	Column   string // argument column, or "*"
+	Distinct bool   // DISTINCT before the column: each value counts once
+	Cast     string // type the column's values are CAST to first, or ""
+}
+
+// String returns the call as the result column is named, e.g.
+// approx_count_distinct(user_id)
+func (a *AggregateCall) String() string {
+	arg := a.Column
+	if a.Cast != "" {
+		arg = "cast(" + arg + " as " + strings.ToLower(a.Cast) + ")"
+	}
+	if a.Distinct {
+		return strings.ToLower(a.Name) + "(distinct " + arg + ")"
+	}
+	return strings.ToLower(a.Name) + "(" + arg + ")"
+}
+
+// TableSample represents a TABLESAMPLE clause
+type TableSample struct {
+	Method     string  // "BERNOULLI", "SYSTEM" or "ROWS"
+	Percent    float64 // percentage of rows kept, for BERNOULLI and SYSTEM
+	Rows       int     // number of rows kept, for ROWS
+	Repeatable bool    // REPEATABLE (seed) was given
+	Seed       int64
+}
+
 // UpdateStmt represents UPDATE statement
 type UpdateStmt struct {
 	TableName string
 	Set       map[string]Expression
 	Where     Expression
+	Returning []string // RETURNING columns or "*", or nil
 }
 
 func (u *UpdateStmt) statementNode() {}
@@ -69,14 +362,124 @@ func (u *UpdateStmt) statementNode() {}
 type DeleteStmt struct {
 	TableName string
 	Where     Expression
+	Returning []string // RETURNING columns or "*", or nil
 }
 
 func (d *DeleteStmt) statementNode() {}
 
+// MergeStmt represents MERGE INTO target USING source ON condition followed
+// by WHEN clauses, which update or delete the target rows a source row
+// matches and insert rows for the source rows that match none
+type MergeStmt struct {
+	TableName   string
+	Alias       string // target alias, or ""
+	Source      string
+	SourceAlias string // source alias, or ""
+	On          Expression
+	Clauses     []*MergeClause // in order; a row takes the first that applies
+}
+
+func (m *MergeStmt) statementNode() {}
+
+// MergeClause is a WHEN [NOT] MATCHED [AND condition] THEN clause of a
+// MERGE
+type MergeClause struct {
+	Matched   bool
+	Condition Expression            // AND condition, or nil
+	Action    string                // "UPDATE", "DELETE", "INSERT" or "NOTHING"
+	Set       map[string]Expression // UPDATE SET assignments
+	Columns   []string              // INSERT columns; empty for every non-generated column
+	Values    []Expression          // INSERT VALUES
+}
+
+// PurgeStmt represents PURGE table [WHERE condition], which removes
+// soft-deleted rows for good
+type PurgeStmt struct {
+	TableName string
+	Where     Expression
+}
+
+func (p *PurgeStmt) statementNode() {}
+
+// BackupStmt represents BACKUP statement
+type BackupStmt struct{}
+
+func (b *BackupStmt) statementNode() {}
+
+// CheckpointStmt represents CHECKPOINT statement
+type CheckpointStmt struct{}
+
+func (c *CheckpointStmt) statementNode() {}
+
+// RestoreStmt represents RESTORE TO statement. Exactly one of LSN or
+// Timestamp is set.
+type RestoreStmt struct {
+	LSN       uint64 // restore up to and including this LSN
+	Timestamp string // restore up to the last statement committed at or before this time
+}
+
+func (r *RestoreStmt) statementNode() {}
+
+// SetStmt represents SET statement, which assigns a session variable
+type SetStmt struct {
+	Name  string // lower-cased
+	Value Expression
+}
+
+func (s *SetStmt) statementNode() {}
+
+// SetGlobalStmt represents SET GLOBAL <name> = <value> and PRAGMA <name> =
+// <value>, which change an engine setting for every session
+type SetGlobalStmt struct {
+	Name  string     // lower-cased
+	Value Expression // nil for DEFAULT
+}
+
+func (s *SetGlobalStmt) statementNode() {}
+
+// ShowSettingsStmt represents PRAGMA [<name>] statement
+type ShowSettingsStmt struct {
+	Name string // lower-cased; empty for all settings
+}
+
+func (s *ShowSettingsStmt) statementNode() {}
+
+// ShowVariableStmt represents SHOW <name> and SHOW ALL statements
+type ShowVariableStmt struct {
+	Name string // lower-cased; empty for SHOW ALL
+}
+
+func (s *ShowVariableStmt) statementNode() {}
+
+// ShowStatsStmt represents SHOW STATS statement
+type ShowStatsStmt struct{}
+
+func (s *ShowStatsStmt) statementNode() {}
+
+// ShowIndexesStmt represents SHOW INDEXES FROM <table> statement
+type ShowIndexesStmt struct {
+	TableName string
+}
+
+func (s *ShowIndexesStmt) statementNode() {}
+
+// ShowTablesStmt represents SHOW TABLES statement
+type ShowTablesStmt struct{}
+
+func (s *ShowTablesStmt) statementNode() {}
+
+// DescribeStmt represents DESCRIBE <table> statement
+type DescribeStmt struct {
+	TableName string
+}
+
+func (s *DescribeStmt) statementNode() {}
+
 // JoinClause represents a JOIN clause
 type JoinClause struct {
 	JoinType  string // "INNER", "LEFT", "RIGHT"
 	TableName string
+	Alias     string // alias of the joined table, or empty
 	On        Expression
 }
 
@@ -89,6 +492,33 @@ type BinaryExpr struct {
 
 func (b *BinaryExpr) expressionNode() {}
 
+// UnaryExpr represents a prefix operator applied to one operand (e.g.,
+// NOT price > 100)
+type UnaryExpr struct {
+	Operator string
+	Operand  Expression
+}
+
+func (u *UnaryExpr) expressionNode() {}
+
+// InExpr represents a test of whether a value is among the rows of a
+// subquery (e.g., user_id IN (SELECT id FROM users))
+type InExpr struct {
+	Left     Expression
+	Subquery *SelectStmt
+	Not      bool // NOT IN
+}
+
+func (i *InExpr) expressionNode() {}
+
+// SubqueryExpr represents a subquery used as a value (e.g., price >
+// (SELECT AVG(price) FROM products))
+type SubqueryExpr struct {
+	Select *SelectStmt
+}
+
+func (s *SubqueryExpr) expressionNode() {}
+
 // Identifier represents a column or table name
 type Identifier struct {
 	Value string
@@ -103,7 +533,81 @@ type Literal struct {
 
 func (l *Literal) expressionNode() {}
 
+// VariableRef represents a session variable reference (@name)
+type VariableRef struct {
+	Name string // lower-cased
+}
+
+func (v *VariableRef) expressionNode() {}
+
+// FunctionCall represents a call to a built-in function, such as RANDOM()
+type FunctionCall struct {
+	Name     string // upper-cased
+	Args     []Expression
+	Star     bool // called with *, as in COUNT(*)
+	Distinct bool // DISTINCT before the arguments, as in COUNT(DISTINCT email)
+}
+
+func (f *FunctionCall) expressionNode() {}
+
 // NullLiteral represents a NULL value
 type NullLiteral struct{}
 
 func (n *NullLiteral) expressionNode() {}
+
+// DefaultValue is DEFAULT among the VALUES of an INSERT: the column's
+// default value
+type DefaultValue struct{}
+
+func (d *DefaultValue) expressionNode() {}
+
+// WalkExpression calls visit for expr and every expression nested in it.
+// Subqueries are not entered, as their columns belong to another table.
+func WalkExpression(expr Expression, visit func(Expression)) {
+	if expr == nil {
+		return
+	}
+	visit(expr)
+	switch ex := expr.(type) {
+	case *BinaryExpr:
+		WalkExpression(ex.Left, visit)
+		WalkExpression(ex.Right, visit)
+	case *UnaryExpr:
+		WalkExpression(ex.Operand, visit)
+	case *InExpr:
+		WalkExpression(ex.Left, visit)
+	case *FunctionCall:
+		for _, arg := range ex.Args {
+			WalkExpression(arg, visit)
+		}
+	}
+}
+
+// MapExpression returns a copy of an expression tree in which every node,
+// children first, is replaced by what replace returns for it. Leaves that
+// replace returns unchanged are shared with the original, as are
+// subqueries, which are not entered.
+func MapExpression(expr Expression, replace func(Expression) Expression) Expression {
+	switch ex := expr.(type) {
+	case nil:
+		return nil
+	case *BinaryExpr:
+		expr = &BinaryExpr{
+			Left:     MapExpression(ex.Left, replace),
+			Operator: ex.Operator,
+			Right:    MapExpression(ex.Right, replace),
+		}
+	case *UnaryExpr:
+		expr = &UnaryExpr{Operator: ex.Operator, Operand: MapExpression(ex.Operand, replace)}
+	case *InExpr:
+		expr = &InExpr{Left: MapExpression(ex.Left, replace), Subquery: ex.Subquery, Not: ex.Not}
+	case *FunctionCall:
+		call := *ex
+		call.Args = make([]Expression, len(ex.Args))
+		for i, arg := range ex.Args {
+			call.Args[i] = MapExpression(arg, replace)
+		}
+		expr = &call
+	}
+	return replace(expr)
+}
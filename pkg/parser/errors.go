@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxError describes one syntax error in a statement, positioned so an
+// editor can underline it
+type SyntaxError struct {
+	Message  string   `json:"message"`
+	Line     int      `json:"line"`   // 1-based
+	Column   int      `json:"column"` // 1-based, in bytes
+	Offset   int      `json:"offset"` // byte offset in the statement
+	Token    string   `json:"token"`  // the offending token, empty at the end of input
+	Expected []string `json:"expected,omitempty"`
+	Snippet  string   `json:"snippet"` // the line with the error and a caret under it
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// SyntaxErrors are the syntax errors found in a statement, in order
+type SyntaxErrors []*SyntaxError
+
+func (e SyntaxErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("parsing errors: %s", strings.Join(messages, "; "))
+}
+
+// newSyntaxError creates the error for the token at offset in input
+func newSyntaxError(input string, offset int, token, message string, expected []string) *SyntaxError {
+	if offset > len(input) {
+		offset = len(input)
+	}
+	lineStart := strings.LastIndexByte(input[:offset], '\n') + 1
+	lineEnd := strings.IndexByte(input[offset:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(input)
+	} else {
+		lineEnd += offset
+	}
+	line := strings.TrimRight(input[lineStart:lineEnd], "\r")
+
+	// Keep tabs in the caret line so the caret lines up under them
+	var caret strings.Builder
+	for _, ch := range input[lineStart:offset] {
+		if ch == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+	caret.WriteRune('^')
+
+	return &SyntaxError{
+		Message:  message,
+		Line:     strings.Count(input[:offset], "\n") + 1,
+		Column:   offset - lineStart + 1,
+		Offset:   offset,
+		Token:    token,
+		Expected: expected,
+		Snippet:  line + "\n" + caret.String(),
+	}
+}
@@ -0,0 +1,473 @@
+package parser
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// literalEscaper escapes the characters of a string that end or escape a
+// single-quoted literal
+var literalEscaper = strings.NewReplacer(`\`, `\\`, "'", "''")
+
+// FormatLiteral renders a value as a SQL literal the parser reads back as
+// the same value
+func FormatLiteral(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		literal := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(literal, ".") {
+			literal += ".0"
+		}
+		return literal, nil
+	case string:
+		return "'" + literalEscaper.Replace(v) + "'", nil
+	case interface{ Bytes() []byte }:
+		// BLOB values
+		return "X'" + hex.EncodeToString(v.Bytes()) + "'", nil
+	default:
+		return "", fmt.Errorf("%T value %v has no SQL literal form", value, value)
+	}
+}
+
+// InlineVariables replaces each @name reference outside string literals and
+// comments with the literal form of its value, so the query no longer
+// depends on session state. lookup is called with the lower-cased name.
+func InlineVariables(query string, lookup func(name string) (interface{}, bool)) (string, error) {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && i+1 < len(query) && isEscaped(query[i+1]) {
+				b.WriteByte(ch)
+				i++
+				ch = query[i]
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case commentEnd(query, i) > i:
+			end := commentEnd(query, i)
+			b.WriteString(query[i:end])
+			i = end - 1
+			continue
+		case ch == '@' && i+1 < len(query) && isLetter(query[i+1]):
+			end := i + 1
+			for end < len(query) && (isLetter(query[end]) || isDigit(query[end]) || query[end] == '_') {
+				end++
+			}
+			name := strings.ToLower(query[i+1 : end])
+			value, ok := lookup(name)
+			if !ok {
+				return "", fmt.Errorf("variable @%s is not set", name)
+			}
+			literal, err := FormatLiteral(value)
+			if err != nil {
+				return "", fmt.Errorf("variable @%s: %w", name, err)
+			}
+			writeLiteral(&b, literal)
+			i = end - 1
+			continue
+		}
+		b.WriteByte(ch)
+	}
+
+	return b.String(), nil
+}
+
+// writeLiteral writes a literal in place of a placeholder, spaced from a
+// minus sign before it so that a negative number does not start a comment
+func writeLiteral(b *strings.Builder, literal string) {
+	if strings.HasPrefix(literal, "-") && strings.HasSuffix(b.String(), "-") {
+		b.WriteByte(' ')
+	}
+	b.WriteString(literal)
+}
+
+// StripComments replaces each comment outside string literals with a
+// space, so the text no longer depends on where comments end
+func StripComments(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && i+1 < len(query) && isEscaped(query[i+1]) {
+				b.WriteByte(ch)
+				i++
+				ch = query[i]
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case commentEnd(query, i) > i:
+			b.WriteByte(' ')
+			i = commentEnd(query, i) - 1
+			continue
+		}
+		b.WriteByte(ch)
+	}
+
+	return b.String()
+}
+
+// BindParameters replaces each parameter placeholder outside string
+// literals and comments with the literal form of its value: ? takes the
+// next value in order, and $n the n-th. A query uses one style or the
+// other, and must use exactly as many values as are given.
+func BindParameters(query string, params []interface{}) (string, error) {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	var quote, style byte
+	used := 0
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		n, end := 0, i+1
+		switch {
+		case quote != 0:
+			if ch == '\\' && i+1 < len(query) && isEscaped(query[i+1]) {
+				b.WriteByte(ch)
+				i++
+				ch = query[i]
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case commentEnd(query, i) > i:
+			end := commentEnd(query, i)
+			b.WriteString(query[i:end])
+			i = end - 1
+			continue
+		case ch == '?':
+			used++
+			n = used
+		case ch == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			for end < len(query) && isDigit(query[end]) {
+				end++
+			}
+			if n, _ = strconv.Atoi(query[i+1 : end]); n == 0 {
+				return "", fmt.Errorf("parameter $0 does not exist: parameters are numbered from $1")
+			}
+			used = max(used, n)
+		}
+		if n == 0 {
+			b.WriteByte(ch)
+			continue
+		}
+
+		if style != 0 && style != ch {
+			return "", fmt.Errorf("cannot mix ? and $n parameters in one query")
+		}
+		style = ch
+		if n > len(params) {
+			return "", fmt.Errorf("query has more parameters than the %d value(s) given", len(params))
+		}
+		literal, err := FormatLiteral(params[n-1])
+		if err != nil {
+			return "", fmt.Errorf("parameter %d: %w", n, err)
+		}
+		writeLiteral(&b, literal)
+		i = end - 1
+	}
+
+	if used != len(params) {
+		return "", fmt.Errorf("query has %d parameter(s) but %d value(s) were given", used, len(params))
+	}
+	return b.String(), nil
+}
+
+// FormatExpression renders an expression as SQL text that parses back to
+// the same expression
+func FormatExpression(expr Expression) string {
+	switch ex := expr.(type) {
+	case *Identifier:
+		return ex.Value
+	case *Literal:
+		if literal, err := FormatLiteral(ex.Value); err == nil {
+			return literal
+		}
+		return fmt.Sprint(ex.Value)
+	case *NullLiteral:
+		return "NULL"
+	case *DefaultValue:
+		return "DEFAULT"
+	case *VariableRef:
+		return "@" + ex.Name
+	case *BinaryExpr:
+		return formatOperand(ex.Left) + " " + ex.Operator + " " + formatOperand(ex.Right)
+	case *UnaryExpr:
+		return ex.Operator + " " + formatOperand(ex.Operand)
+	case *InExpr:
+		operator := " IN "
+		if ex.Not {
+			operator = " NOT IN "
+		}
+		return formatOperand(ex.Left) + operator + "(" + FormatSelect(ex.Subquery) + ")"
+	case *SubqueryExpr:
+		return "(" + FormatSelect(ex.Select) + ")"
+	case *FunctionCall:
+		if (ex.Name == "INTERVAL" || ex.Name == "DATE") && len(ex.Args) == 1 {
+			if literal, ok := ex.Args[0].(*Literal); ok {
+				return ex.Name + " " + FormatExpression(literal)
+			}
+		}
+		if ex.Name == "CAST" && len(ex.Args) == 2 {
+			if literal, ok := ex.Args[1].(*Literal); ok {
+				return "CAST(" + FormatExpression(ex.Args[0]) + " AS " + fmt.Sprint(literal.Value) + ")"
+			}
+		}
+		if ex.Star {
+			return ex.Name + "(*)"
+		}
+		args := make([]string, len(ex.Args))
+		for i, arg := range ex.Args {
+			args[i] = FormatExpression(arg)
+		}
+		if ex.Distinct {
+			return ex.Name + "(DISTINCT " + strings.Join(args, ", ") + ")"
+		}
+		return ex.Name + "(" + strings.Join(args, ", ") + ")"
+	default:
+		return fmt.Sprintf("%v", expr)
+	}
+}
+
+// formatOperand formats an operand of an operator, parenthesizing nested
+// operator expressions so grouping survives a round trip
+func formatOperand(expr Expression) string {
+	switch expr.(type) {
+	case *BinaryExpr, *UnaryExpr, *InExpr:
+		return "(" + FormatExpression(expr) + ")"
+	}
+	return FormatExpression(expr)
+}
+
+// FormatInsert renders an INSERT statement as SQL text
+func FormatInsert(stmt *InsertStmt) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO " + stmt.TableName)
+	if len(stmt.Columns) > 0 {
+		b.WriteString(" (" + strings.Join(stmt.Columns, ", ") + ")")
+	}
+	if stmt.Select != nil {
+		b.WriteString(" " + FormatSelect(stmt.Select))
+	} else {
+		b.WriteString(" VALUES ")
+		for i, row := range stmt.Values {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			values := make([]string, len(row))
+			for j, expr := range row {
+				values[j] = FormatExpression(expr)
+			}
+			b.WriteString("(" + strings.Join(values, ", ") + ")")
+		}
+	}
+	if conflict := stmt.OnConflict; conflict != nil {
+		b.WriteString(" ON CONFLICT")
+		if len(conflict.Columns) > 0 {
+			b.WriteString(" (" + strings.Join(conflict.Columns, ", ") + ")")
+		}
+		if conflict.Set == nil {
+			b.WriteString(" DO NOTHING")
+		} else {
+			b.WriteString(" DO UPDATE SET " + formatAssignments(conflict.Set))
+		}
+	}
+	b.WriteString(formatReturning(stmt.Returning))
+	return b.String()
+}
+
+// formatReturning renders a RETURNING clause, with a leading space, or ""
+// for none
+func formatReturning(columns []string) string {
+	if columns == nil {
+		return ""
+	}
+	return " RETURNING " + strings.Join(columns, ", ")
+}
+
+// formatAssignments renders the assignments of a SET clause in column name
+// order
+func formatAssignments(set map[string]Expression) string {
+	columns := make([]string, 0, len(set))
+	for column := range set {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = column + " = " + FormatExpression(set[column])
+	}
+	return strings.Join(assignments, ", ")
+}
+
+// FormatUpdate renders an UPDATE statement as SQL text, with its SET
+// assignments in column name order
+func FormatUpdate(stmt *UpdateStmt) string {
+	query := "UPDATE " + stmt.TableName + " SET " + formatAssignments(stmt.Set)
+	if stmt.Where != nil {
+		query += " WHERE " + FormatExpression(stmt.Where)
+	}
+	return query + formatReturning(stmt.Returning)
+}
+
+// FormatMerge renders a MERGE statement as SQL text, with the assignments
+// of its UPDATE clauses in column name order
+func FormatMerge(stmt *MergeStmt) string {
+	var b strings.Builder
+	b.WriteString("MERGE INTO " + stmt.TableName)
+	if stmt.Alias != "" {
+		b.WriteString(" " + stmt.Alias)
+	}
+	b.WriteString(" USING " + stmt.Source)
+	if stmt.SourceAlias != "" {
+		b.WriteString(" " + stmt.SourceAlias)
+	}
+	b.WriteString(" ON " + FormatExpression(stmt.On))
+	for _, clause := range stmt.Clauses {
+		b.WriteString(" WHEN ")
+		if !clause.Matched {
+			b.WriteString("NOT ")
+		}
+		b.WriteString("MATCHED")
+		if clause.Condition != nil {
+			b.WriteString(" AND " + FormatExpression(clause.Condition))
+		}
+		b.WriteString(" THEN ")
+		switch clause.Action {
+		case "UPDATE":
+			b.WriteString("UPDATE SET " + formatAssignments(clause.Set))
+		case "DELETE":
+			b.WriteString("DELETE")
+		case "INSERT":
+			b.WriteString("INSERT")
+			if len(clause.Columns) > 0 {
+				b.WriteString(" (" + strings.Join(clause.Columns, ", ") + ")")
+			}
+			values := make([]string, len(clause.Values))
+			for i, expr := range clause.Values {
+				values[i] = FormatExpression(expr)
+			}
+			b.WriteString(" VALUES (" + strings.Join(values, ", ") + ")")
+		default:
+			b.WriteString("DO NOTHING")
+		}
+	}
+	return b.String()
+}
+
+// FormatSelect renders a SELECT statement as SQL text
+func FormatSelect(stmt *SelectStmt) string {
+	items := make([]string, len(stmt.Columns))
+	for i, column := range stmt.Columns {
+		items[i] = column
+		if stmt.Aggregates != nil && stmt.Aggregates[i] != nil {
+			call := stmt.Aggregates[i]
+			arg := call.Column
+			if call.Cast != "" {
+				arg = "CAST(" + arg + " AS " + call.Cast + ")"
+			}
+			items[i] = call.Name + "(" + arg + ")"
+			if call.Distinct {
+				items[i] = call.Name + "(DISTINCT " + arg + ")"
+			}
+		}
+		if stmt.Aliases != nil && stmt.Aliases[i] != "" {
+			items[i] += " AS " + stmt.Aliases[i]
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT " + strings.Join(items, ", ") + " FROM " + stmt.TableName)
+	if stmt.TableAlias != "" {
+		b.WriteString(" " + stmt.TableAlias)
+	}
+	if sample := stmt.Sample; sample != nil {
+		if sample.Method == "ROWS" {
+			b.WriteString(" TABLESAMPLE (" + strconv.Itoa(sample.Rows) + " ROWS)")
+		} else {
+			b.WriteString(" TABLESAMPLE " + sample.Method + " (" + strconv.FormatFloat(sample.Percent, 'f', -1, 64) + ")")
+		}
+		if sample.Repeatable {
+			b.WriteString(" REPEATABLE (" + strconv.FormatInt(sample.Seed, 10) + ")")
+		}
+	}
+	for _, join := range stmt.Joins {
+		b.WriteString(" " + join.JoinType + " JOIN " + join.TableName)
+		if join.Alias != "" {
+			b.WriteString(" " + join.Alias)
+		}
+		b.WriteString(" ON " + FormatExpression(join.On))
+	}
+	if stmt.Where != nil {
+		b.WriteString(" WHERE " + FormatExpression(stmt.Where))
+	}
+	if stmt.WithDeleted {
+		b.WriteString(" WITH DELETED")
+	}
+	if len(stmt.GroupBy) > 0 {
+		b.WriteString(" GROUP BY " + strings.Join(stmt.GroupBy, ", "))
+	}
+	if stmt.Having != nil {
+		b.WriteString(" HAVING " + FormatExpression(stmt.Having))
+	}
+	if stmt.OrderBy != nil {
+		keys := make([]string, len(stmt.OrderBy))
+		for i, key := range stmt.OrderBy {
+			keys[i] = FormatOrderBy(key)
+		}
+		b.WriteString(" ORDER BY " + strings.Join(keys, ", "))
+	}
+	return b.String()
+}
+
+// FormatOrderBy renders an ORDER BY key, naming its NULLs placement only
+// where it is not the default for its direction
+func FormatOrderBy(key OrderBy) string {
+	s := key.Column
+	if key.Desc {
+		s += " DESC"
+	}
+	if key.NullsFirst != key.Desc {
+		if key.NullsFirst {
+			s += " NULLS FIRST"
+		} else {
+			s += " NULLS LAST"
+		}
+	}
+	return s
+}
+
+// FormatDelete renders a DELETE statement as SQL text
+func FormatDelete(stmt *DeleteStmt) string {
+	query := "DELETE FROM " + stmt.TableName
+	if stmt.Where != nil {
+		query += " WHERE " + FormatExpression(stmt.Where)
+	}
+	return query + formatReturning(stmt.Returning)
+}
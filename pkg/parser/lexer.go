@@ -48,9 +48,18 @@ func (l *Lexer) peekChar() byte {
 
 // NextToken returns the next token from the input
 func (l *Lexer) NextToken() Token {
-	var tok Token
-
 	l.skipWhitespace()
+	pos, line, column := l.position, l.line, l.column
+	tok := l.readToken()
+	// Multi-character tokens are read past their first character, so
+	// position them where they start
+	tok.Pos, tok.Line, tok.Column = pos, line, column
+	return tok
+}
+
+// readToken reads the token starting at the current character
+func (l *Lexer) readToken() Token {
+	var tok Token
 
 	tok.Line = l.line
 	tok.Column = l.column
@@ -58,8 +67,25 @@ func (l *Lexer) NextToken() Token {
 	switch l.ch {
 	case '*':
 		tok = Token{Type: ASTERISK, Literal: string(l.ch), Line: l.line, Column: l.column}
+	case '+':
+		tok = Token{Type: PLUS, Literal: string(l.ch), Line: l.line, Column: l.column}
+	case '-':
+		if l.peekChar() == '>' {
+			l.readChar()
+			tok = Token{Type: ARROW, Literal: "->", Line: l.line, Column: l.column}
+			if l.peekChar() == '>' {
+				l.readChar()
+				tok = Token{Type: ARROW2, Literal: "->>", Line: l.line, Column: l.column}
+			}
+		} else {
+			tok = Token{Type: MINUS, Literal: string(l.ch), Line: l.line, Column: l.column}
+		}
+	case '/':
+		tok = Token{Type: SLASH, Literal: string(l.ch), Line: l.line, Column: l.column}
 	case ',':
 		tok = Token{Type: COMMA, Literal: string(l.ch), Line: l.line, Column: l.column}
+	case '.':
+		tok = Token{Type: DOT, Literal: string(l.ch), Line: l.line, Column: l.column}
 	case ';':
 		tok = Token{Type: SEMICOLON, Literal: string(l.ch), Line: l.line, Column: l.column}
 	case '(':
@@ -73,9 +99,15 @@ func (l *Lexer) NextToken() Token {
 			ch := l.ch
 			l.readChar()
 			tok = Token{Type: NEQ, Literal: string(ch) + string(l.ch), Line: l.line, Column: l.column}
+		} else if l.peekChar() == '~' {
+			ch := l.ch
+			l.readChar()
+			tok = Token{Type: NMATCH, Literal: string(ch) + string(l.ch), Line: l.line, Column: l.column}
 		} else {
 			tok = Token{Type: ILLEGAL, Literal: string(l.ch), Line: l.line, Column: l.column}
 		}
+	case '~':
+		tok = Token{Type: MATCH, Literal: string(l.ch), Line: l.line, Column: l.column}
 	case '<':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -92,6 +124,28 @@ func (l *Lexer) NextToken() Token {
 		} else {
 			tok = Token{Type: GT, Literal: string(l.ch), Line: l.line, Column: l.column}
 		}
+	case '@':
+		if isLetter(l.peekChar()) {
+			l.readChar()
+			tok.Type = VARIABLE
+			tok.Literal = l.readIdentifier()
+			return tok
+		}
+		tok = Token{Type: ILLEGAL, Literal: string(l.ch), Line: l.line, Column: l.column}
+	case '?':
+		tok = Token{Type: PARAMETER, Literal: string(l.ch), Line: l.line, Column: l.column}
+	case '$':
+		if isDigit(l.peekChar()) {
+			l.readChar()
+			position := l.position
+			for isDigit(l.ch) {
+				l.readChar()
+			}
+			tok.Type = PARAMETER
+			tok.Literal = "$" + l.input[position:l.position]
+			return tok
+		}
+		tok = Token{Type: ILLEGAL, Literal: string(l.ch), Line: l.line, Column: l.column}
 	case '"', '\'':
 		tok.Type = STRING
 		tok.Literal = l.readString(l.ch)
@@ -148,34 +202,94 @@ func (l *Lexer) readNumber() (string, bool) {
 		}
 	}
 
+	// An exponent, as in 1e308 or 2.5E-3, makes it a float
+	if l.ch == 'e' || l.ch == 'E' {
+		digits := l.readPosition
+		if digits < len(l.input) && (l.input[digits] == '+' || l.input[digits] == '-') {
+			digits++
+		}
+		if digits < len(l.input) && isDigit(l.input[digits]) {
+			isFloat = true
+			for l.readPosition <= digits {
+				l.readChar()
+			}
+			for isDigit(l.ch) {
+				l.readChar()
+			}
+		}
+	}
+
 	return l.input[position:l.position], isFloat
 }
 
-// readString reads a string literal enclosed in quotes
+// readString reads a string literal enclosed in quotes and returns its
+// value. Inside, a doubled quote stands for one, and a backslash escapes a
+// quote or another backslash; any other backslash is kept as written.
 func (l *Lexer) readString(quote byte) string {
-	position := l.position + 1
+	var b strings.Builder
 	for {
 		l.readChar()
-		if l.ch == quote || l.ch == 0 {
-			break
-		}
-		if l.ch == '\n' {
+		switch {
+		case l.ch == 0:
+			return b.String()
+		case l.ch == quote && l.peekChar() == quote:
+			l.readChar()
+		case l.ch == quote:
+			return b.String()
+		case l.ch == '\\' && isEscaped(l.peekChar()):
+			l.readChar()
+		case l.ch == '\n':
 			l.line++
 			l.column = 0
 		}
+		b.WriteByte(l.ch)
 	}
-	return l.input[position:l.position]
 }
 
-// skipWhitespace skips whitespace characters
+// isEscaped reports whether a backslash before ch in a string literal
+// escapes it
+func isEscaped(ch byte) bool {
+	return ch == '\'' || ch == '"' || ch == '\\'
+}
+
+// skipWhitespace skips whitespace characters and comments
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
-		if l.ch == '\n' {
-			l.line++
-			l.column = 0
+	for {
+		end := l.position
+		if l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+			end++
+		} else if end = commentEnd(l.input, l.position); end == l.position {
+			return
 		}
-		l.readChar()
+		for l.position < end {
+			if l.ch == '\n' {
+				l.line++
+				l.column = 0
+			}
+			l.readChar()
+		}
+	}
+}
+
+// commentEnd returns the index just past the comment starting at s[i], or
+// i if none starts there. A -- comment runs to the end of the line and a
+// /* comment to the next */, or to the end of s if it is never closed.
+func commentEnd(s string, i int) int {
+	switch {
+	case i >= len(s):
+		return i
+	case strings.HasPrefix(s[i:], "--"):
+		if end := strings.IndexByte(s[i:], '\n'); end != -1 {
+			return i + end
+		}
+		return len(s)
+	case strings.HasPrefix(s[i:], "/*"):
+		if end := strings.Index(s[i+2:], "*/"); end != -1 {
+			return i + 2 + end + 2
+		}
+		return len(s)
 	}
+	return i
 }
 
 // isLetter checks if a character is a letter
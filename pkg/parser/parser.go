@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 )
@@ -11,14 +13,18 @@ type Parser struct {
 	lexer     *Lexer
 	curToken  Token
 	peekToken Token
-	errors    []string
+	errors    []*SyntaxError
+
+	// recovering is set after an error until the parser synchronizes, so
+	// the errors that follow from the first one are not reported
+	recovering bool
 }
 
 // NewParser creates a new Parser instance
 func NewParser(input string) *Parser {
 	p := &Parser{
 		lexer:  NewLexer(input),
-		errors: []string{},
+		errors: []*SyntaxError{},
 	}
 	// Read two tokens to initialize curToken and peekToken
 	p.nextToken()
@@ -28,7 +34,11 @@ func NewParser(input string) *Parser {
 
 // Errors returns parsing errors
 func (p *Parser) Errors() []string {
-	return p.errors
+	messages := make([]string, len(p.errors))
+	for i, err := range p.errors {
+		messages[i] = err.Error()
+	}
+	return messages
 }
 
 // nextToken advances to the next token
@@ -47,6 +57,17 @@ func (p *Parser) peekTokenIs(t TokenType) bool {
 	return p.peekToken.Type == t
 }
 
+// curWordIs checks if current token is the identifier word, ignoring case.
+// It matches context-sensitive words that are not reserved keywords.
+func (p *Parser) curWordIs(word string) bool {
+	return p.curTokenIs(IDENT) && strings.EqualFold(p.curToken.Literal, word)
+}
+
+// peekWordIs checks if peek token is the identifier word, ignoring case
+func (p *Parser) peekWordIs(word string) bool {
+	return p.peekTokenIs(IDENT) && strings.EqualFold(p.peekToken.Literal, word)
+}
+
 // expectPeek checks peek token and advances if match
 func (p *Parser) expectPeek(t TokenType) bool {
 	if p.peekTokenIs(t) {
@@ -59,15 +80,48 @@ func (p *Parser) expectPeek(t TokenType) bool {
 
 // peekError adds an error for unexpected peek token
 func (p *Parser) peekError(t TokenType) {
-	msg := fmt.Sprintf("line %d:%d: expected next token to be %s, got %s instead",
-		p.peekToken.Line, p.peekToken.Column, t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.errorAt(p.peekToken, fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type), t.String())
 }
 
 // addError adds a custom error message
 func (p *Parser) addError(msg string) {
-	p.errors = append(p.errors, fmt.Sprintf("line %d:%d: %s",
-		p.curToken.Line, p.curToken.Column, msg))
+	p.errorAt(p.curToken, msg)
+}
+
+// errorAt adds an error at the position of tok, unless the parser is
+// recovering from an earlier one
+func (p *Parser) errorAt(tok Token, msg string, expected ...string) {
+	if p.recovering {
+		return
+	}
+	p.errors = append(p.errors, newSyntaxError(p.lexer.input, tok.Pos, tok.Literal, msg, expected))
+	p.recovering = true
+}
+
+// synchronize skips tokens until the current one is one of stops, outside
+// any parentheses opened on the way, or EOF, and resumes reporting errors.
+// It lets the parser carry on after an error in one column, row or
+// assignment and report the errors in the rest of the statement too.
+func (p *Parser) synchronize(stops ...TokenType) {
+	depth := 0
+	for !p.curTokenIs(EOF) {
+		if depth == 0 {
+			for _, t := range stops {
+				if p.curTokenIs(t) {
+					p.recovering = false
+					return
+				}
+			}
+		}
+		switch {
+		case p.curTokenIs(LPAREN):
+			depth++
+		case p.curTokenIs(RPAREN) && depth > 0:
+			depth--
+		}
+		p.nextToken()
+	}
+	p.recovering = false
 }
 
 // Parse parses the SQL statement
@@ -76,9 +130,39 @@ func (p *Parser) Parse() (Statement, error) {
 
 	switch p.curToken.Type {
 	case CREATE:
-		stmt = p.parseCreateTable()
+		switch {
+		case p.peekWordIs("POLICY"):
+			stmt = p.parseCreatePolicy()
+		case p.peekWordIs("USER"):
+			stmt = p.parseCreateUser()
+		case p.peekWordIs("SEQUENCE"):
+			stmt = p.parseCreateSequence()
+		case p.peekWordIs("FUNCTION"):
+			stmt = p.parseCreateFunction()
+		case p.peekWordIs("JOB"):
+			stmt = p.parseCreateJob()
+		case p.peekWordIs("MATERIALIZED"):
+			stmt = p.parseCreateMaterializedView()
+		default:
+			stmt = p.parseCreateTable()
+		}
 	case DROP:
-		stmt = p.parseDropTable()
+		switch {
+		case p.peekWordIs("POLICY"):
+			stmt = p.parseDropPolicy()
+		case p.peekWordIs("USER"):
+			stmt = p.parseDropUser()
+		case p.peekWordIs("JOB"):
+			stmt = p.parseDropJob()
+		case p.peekWordIs("SEQUENCE"):
+			stmt = p.parseDropSequence()
+		case p.peekWordIs("FUNCTION"):
+			stmt = p.parseDropFunction()
+		case p.peekWordIs("MATERIALIZED"):
+			stmt = p.parseDropMaterializedView()
+		default:
+			stmt = p.parseDropTable()
+		}
 	case INSERT:
 		stmt = p.parseInsert()
 	case SELECT:
@@ -87,23 +171,159 @@ func (p *Parser) Parse() (Statement, error) {
 		stmt = p.parseUpdate()
 	case DELETE:
 		stmt = p.parseDelete()
+	case BACKUP:
+		stmt = &BackupStmt{}
+	case RESTORE:
+		stmt = p.parseRestore()
+	case SHOW:
+		stmt = p.parseShow()
+	case SET:
+		if p.peekWordIs("GLOBAL") {
+			stmt = p.parseSetGlobal()
+		} else {
+			stmt = p.parseSet()
+		}
+	case IDENT:
+		switch {
+		case p.curWordIs("COPY"):
+			stmt = p.parseCopy()
+		case p.curWordIs("MERGE"):
+			stmt = p.parseMerge()
+		case p.curWordIs("ALTER") && p.peekWordIs("SEQUENCE"):
+			stmt = p.parseAlterSequence()
+		case p.curWordIs("ALTER"):
+			stmt = p.parseAlterTable()
+		case p.curWordIs("GRANT"), p.curWordIs("REVOKE"):
+			stmt = p.parseGrant()
+		case p.curWordIs("PURGE"):
+			stmt = p.parsePurge()
+		case p.curWordIs("REFRESH"):
+			stmt = p.parseRefreshMaterializedView()
+		case p.curWordIs("CHECKPOINT"):
+			stmt = &CheckpointStmt{}
+		case p.curWordIs("DESCRIBE"):
+			stmt = p.parseDescribe()
+		case p.curWordIs("CALL"):
+			stmt = p.parseCall()
+		case p.curWordIs("PRAGMA"):
+			stmt = p.parsePragma()
+		case p.curWordIs("EXPLAIN") && p.peekWordIs("ADVISE"):
+			stmt = p.parseAdvise()
+		case p.curWordIs("EXPLAIN"):
+			stmt = p.parseExplain()
+		default:
+			p.addError(fmt.Sprintf("unexpected token: %s", p.curToken.Literal))
+		}
 	case EOF:
 		return nil, fmt.Errorf("empty statement")
 	default:
-		return nil, fmt.Errorf("unexpected token: %s", p.curToken.Type)
+		p.addError(fmt.Sprintf("unexpected token: %s", p.curToken.Type))
 	}
 
+	// The parse functions return a nil statement on errors they report, but
+	// a nil statement must never be returned as a success
+	if len(p.errors) == 0 && isNilStatement(stmt) {
+		p.addError("incomplete statement")
+	}
+	// A complete statement ends the query, or is followed by a semicolon,
+	// so a misspelt clause is not ignored along with what follows it
+	if len(p.errors) == 0 && p.peekTokenIs(SEMICOLON) {
+		p.nextToken()
+	}
+	if len(p.errors) == 0 && !p.peekTokenIs(EOF) {
+		p.errorAt(p.peekToken, fmt.Sprintf("unexpected token: %s", p.peekToken.Literal), EOF.String())
+	}
 	if len(p.errors) > 0 {
-		return nil, fmt.Errorf("parsing errors: %s", strings.Join(p.errors, "; "))
+		return nil, SyntaxErrors(p.errors)
 	}
 
 	return stmt, nil
 }
 
+// ParsedStatement is one statement of a script
+type ParsedStatement struct {
+	Statement Statement
+	Text      string // the statement's SQL, without the semicolon ending it
+	Offset    int    // byte offset of Text in the script
+}
+
+// Split splits a script into its statements at semicolon tokens, so a
+// semicolon in a string literal or comment does not end one. Statements
+// without tokens, such as empty ones, are skipped. The statements are not
+// parsed.
+func Split(script string) []ParsedStatement {
+	statements := []ParsedStatement{}
+
+	lexer := NewLexer(script)
+	start, empty := 0, true
+	for {
+		tok := lexer.NextToken()
+		if tok.Type != SEMICOLON && tok.Type != EOF {
+			empty = false
+			continue
+		}
+		if !empty {
+			end := min(tok.Pos, len(script))
+			text := strings.TrimSpace(script[start:end])
+			offset := start + strings.Index(script[start:end], text)
+			statements = append(statements, ParsedStatement{Text: text, Offset: offset})
+		}
+		if tok.Type == EOF {
+			break
+		}
+		start, empty = tok.Pos+1, true
+	}
+	return statements
+}
+
+// ParseAll splits a script into its statements, as Split does, and parses
+// them. Syntax errors in any statement are reported together, positioned
+// in the whole script.
+func ParseAll(script string) ([]ParsedStatement, error) {
+	statements := Split(script)
+	var errs SyntaxErrors
+	for i := range statements {
+		stmt := &statements[i]
+		parsed, err := NewParser(stmt.Text).Parse()
+		var syntaxErrs SyntaxErrors
+		switch {
+		case err == nil:
+			stmt.Statement = parsed
+		case errors.As(err, &syntaxErrs):
+			for _, e := range syntaxErrs {
+				errs = append(errs, newSyntaxError(script, stmt.Offset+e.Offset, e.Token, e.Message, e.Expected))
+			}
+		default:
+			errs = append(errs, newSyntaxError(script, stmt.Offset, "", err.Error(), nil))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return statements, nil
+}
+
+// isNilStatement reports whether stmt is nil, including a nil pointer
+// returned as a Statement by a parse function
+func isNilStatement(stmt Statement) bool {
+	if stmt == nil {
+		return true
+	}
+	value := reflect.ValueOf(stmt)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
+
 // parseCreateTable parses CREATE TABLE statement
 func (p *Parser) parseCreateTable() *CreateTableStmt {
 	stmt := &CreateTableStmt{}
 
+	foreign := false
+	if p.peekWordIs("FOREIGN") {
+		p.nextToken()
+		foreign = true
+	}
+
 	if !p.expectPeek(TABLE) {
 		return nil
 	}
@@ -113,11 +333,31 @@ func (p *Parser) parseCreateTable() *CreateTableStmt {
 	}
 	stmt.TableName = p.curToken.Literal
 
+	// CREATE TABLE name PARTITION OF parent FOR VALUES ...
+	if p.peekWordIs("PARTITION") {
+		p.nextToken()
+		p.nextToken()
+		if !p.curWordIs("OF") {
+			p.addError("expected OF after PARTITION")
+			return nil
+		}
+		if !p.expectPeek(IDENT) {
+			return nil
+		}
+		stmt.PartitionOf = p.curToken.Literal
+		p.nextToken()
+		if stmt.Bound = p.parsePartitionBound(); stmt.Bound == nil {
+			return nil
+		}
+		stmt.Location = p.parseLocation()
+		return stmt
+	}
+
 	if !p.expectPeek(LPAREN) {
 		return nil
 	}
 
-	stmt.Columns = p.parseColumnDefinitions()
+	stmt.Columns, stmt.Unique, stmt.ForeignKeys = p.parseColumnDefinitions()
 
 	// parseColumnDefinitions leaves curToken at ) or at the last token before )
 	// We need to ensure we're at the closing paren
@@ -126,276 +366,1962 @@ func (p *Parser) parseCreateTable() *CreateTableStmt {
 		return nil
 	}
 
-	return stmt
-}
-
-// parseColumnDefinitions parses column definitions
-func (p *Parser) parseColumnDefinitions() []*ColumnDef {
-	columns := []*ColumnDef{}
-
-	p.nextToken()
-
-	for !p.curTokenIs(RPAREN) && !p.curTokenIs(EOF) {
-		col := &ColumnDef{}
-
-		if !p.curTokenIs(IDENT) {
-			p.addError("expected column name")
+	if foreign {
+		if !p.parseForeignServer(stmt) {
 			return nil
 		}
-		col.Name = p.curToken.Literal
+		return stmt
+	}
 
+	// Parse PARTITION BY RANGE (<column>)
+	if p.peekWordIs("PARTITION") {
 		p.nextToken()
-
-		// Parse data type
-		switch p.curToken.Type {
-		case INTEGER:
-			col.DataType = "INTEGER"
-		case VARCHAR:
-			col.DataType = "VARCHAR"
-			if p.peekTokenIs(LPAREN) {
-				p.nextToken() // consume (
-				p.nextToken() // move to size
-				if p.curTokenIs(INT) {
-					size, _ := strconv.Atoi(p.curToken.Literal)
-					col.Size = size
-				}
-				if !p.expectPeek(RPAREN) {
-					return nil
-				}
-			}
-		case BOOLEAN:
-			col.DataType = "BOOLEAN"
-		case FLOAT_TYPE:
-			col.DataType = "FLOAT"
-		default:
-			p.addError(fmt.Sprintf("unknown data type: %s", p.curToken.Literal))
+		p.nextToken()
+		if !p.curWordIs("BY") {
+			p.addError("expected BY after PARTITION")
 			return nil
 		}
-
-		// Parse constraints
 		p.nextToken()
-		for p.curTokenIs(PRIMARY) || p.curTokenIs(UNIQUE) || p.curTokenIs(NOT) {
-			if p.curTokenIs(PRIMARY) {
-				if !p.expectPeek(KEY) {
-					return nil
-				}
-				col.PrimaryKey = true
-			} else if p.curTokenIs(UNIQUE) {
-				col.Unique = true
-			} else if p.curTokenIs(NOT) {
-				if !p.expectPeek(NULL) {
-					return nil
-				}
-				col.NotNull = true
-			}
-			p.nextToken()
+		if !p.curWordIs("RANGE") && !p.curWordIs("HASH") {
+			p.addError("expected RANGE or HASH after PARTITION BY")
+			return nil
 		}
-
-		columns = append(columns, col)
-
-		if p.curTokenIs(COMMA) {
-			p.nextToken()
-		} else {
-			break
+		stmt.PartitionMethod = strings.ToUpper(p.curToken.Literal)
+		if !p.expectPeek(LPAREN) || !p.expectPeek(IDENT) {
+			return nil
+		}
+		stmt.PartitionBy = p.curToken.Literal
+		if !p.expectPeek(RPAREN) {
+			return nil
 		}
 	}
 
-	return columns
-}
-
-// parseDropTable parses DROP TABLE statement
-func (p *Parser) parseDropTable() *DropTableStmt {
-	stmt := &DropTableStmt{}
-
-	if !p.expectPeek(TABLE) {
-		return nil
-	}
-
-	if !p.expectPeek(IDENT) {
-		return nil
+	// Parse USING COLUMNAR | ROW
+	if p.peekWordIs("USING") {
+		p.nextToken()
+		p.nextToken()
+		if !p.curWordIs("COLUMNAR") && !p.curWordIs("ROW") {
+			p.addError("expected COLUMNAR or ROW after USING")
+			return nil
+		}
+		stmt.Layout = strings.ToUpper(p.curToken.Literal)
 	}
-	stmt.TableName = p.curToken.Literal
 
 	return stmt
 }
 
-// parseInsert parses INSERT INTO statement
-func (p *Parser) parseInsert() *InsertStmt {
-	stmt := &InsertStmt{}
-
-	if !p.expectPeek(INTO) {
-		return nil
+// parseForeignServer parses SERVER <server> [OPTIONS (<name> '<value>', ...)]
+// after the columns of CREATE FOREIGN TABLE
+func (p *Parser) parseForeignServer(stmt *CreateTableStmt) bool {
+	p.nextToken()
+	if !p.curWordIs("SERVER") {
+		p.addError("expected SERVER after foreign table columns")
+		return false
 	}
-
 	if !p.expectPeek(IDENT) {
-		return nil
+		return false
 	}
-	stmt.TableName = p.curToken.Literal
+	stmt.Server = strings.ToLower(p.curToken.Literal)
+	stmt.Options = map[string]string{}
 
-	// Parse column names (optional)
-	if p.peekTokenIs(LPAREN) {
-		p.nextToken()
-		stmt.Columns = p.parseIdentifierList()
-		if !p.expectPeek(RPAREN) {
-			return nil
-		}
+	if !p.peekTokenIs(IDENT) || !strings.EqualFold(p.peekToken.Literal, "OPTIONS") {
+		return true
 	}
-
-	if !p.expectPeek(VALUES) {
-		return nil
+	p.nextToken()
+	if !p.expectPeek(LPAREN) {
+		return false
 	}
-
-	// Parse values
-	stmt.Values = [][]Expression{}
-	for p.peekTokenIs(LPAREN) {
-		p.nextToken()
+	for {
+		// Option names are words, some of which (NULL) are keywords
 		p.nextToken()
-		values := p.parseExpressionList()
-		stmt.Values = append(stmt.Values, values)
-
-		if !p.expectPeek(RPAREN) {
-			return nil
+		if _, keyword := keywords[strings.ToUpper(p.curToken.Literal)]; !p.curTokenIs(IDENT) && (!keyword || p.curTokenIs(STRING)) {
+			p.addError(fmt.Sprintf("expected option name, got %s", p.curToken.Type))
+			return false
+		}
+		name := strings.ToLower(p.curToken.Literal)
+		if !p.expectPeek(STRING) {
+			return false
 		}
+		stmt.Options[name] = p.curToken.Literal
 
-		if p.peekTokenIs(COMMA) {
-			p.nextToken()
-		} else {
+		if !p.peekTokenIs(COMMA) {
 			break
 		}
-	}
-
-	return stmt
-}
-
-// parseSelect parses SELECT statement
-func (p *Parser) parseSelect() *SelectStmt {
-	stmt := &SelectStmt{}
-
-	p.nextToken()
-
-	// Parse column list
-	if p.curTokenIs(ASTERISK) {
-		stmt.Columns = []string{"*"}
-		p.nextToken()
-	} else {
-		stmt.Columns = p.parseIdentifierList()
-		// parseIdentifierList leaves us at the last identifier, advance to next token
 		p.nextToken()
 	}
+	return p.expectPeek(RPAREN)
+}
 
-	if !p.curTokenIs(FROM) {
-		p.addError("expected FROM after column list")
+// parsePartitionBound parses FOR VALUES FROM (<value>) TO (<value>), where
+// either value may be MINVALUE or MAXVALUE, or FOR VALUES WITH (MODULUS <n>,
+// REMAINDER <r>), starting at FOR
+func (p *Parser) parsePartitionBound() *PartitionBound {
+	if !p.curWordIs("FOR") {
+		p.addError("expected FOR VALUES")
 		return nil
 	}
-
-	if !p.expectPeek(IDENT) {
+	if !p.expectPeek(VALUES) {
 		return nil
 	}
-	stmt.TableName = p.curToken.Literal
+	bound := &PartitionBound{}
 
-	// Parse JOINs
-	for p.peekTokenIs(INNER) || p.peekTokenIs(JOIN) {
+	if p.peekWordIs("WITH") {
 		p.nextToken()
-		join := &JoinClause{JoinType: "INNER"}
-
-		if p.curTokenIs(INNER) {
-			if !p.expectPeek(JOIN) {
+		if !p.expectPeek(LPAREN) {
+			return nil
+		}
+		for i, word := range []string{"MODULUS", "REMAINDER"} {
+			if i > 0 && !p.expectPeek(COMMA) {
+				return nil
+			}
+			p.nextToken()
+			if !p.curWordIs(word) {
+				p.addError(fmt.Sprintf("expected %s", word))
+				return nil
+			}
+			if !p.expectPeek(INT) {
 				return nil
 			}
+			n, _ := strconv.Atoi(p.curToken.Literal)
+			if word == "MODULUS" {
+				bound.Modulus = n
+			} else {
+				bound.Remainder = n
+			}
 		}
-
-		if !p.expectPeek(IDENT) {
+		if !p.expectPeek(RPAREN) {
 			return nil
 		}
-		join.TableName = p.curToken.Literal
-
-		if !p.expectPeek(ON) {
+		if bound.Modulus == 0 {
+			p.addError("MODULUS must be positive")
 			return nil
 		}
+		return bound
+	}
 
-		p.nextToken()
-		join.On = p.parseExpression()
-
-		stmt.Joins = append(stmt.Joins, join)
+	if !p.expectPeek(FROM) {
+		return nil
+	}
+	var ok bool
+	if bound.From, ok = p.parseBoundValue("MINVALUE"); !ok {
+		return nil
+	}
+	if !p.expectPeek(TO) {
+		return nil
+	}
+	if bound.To, ok = p.parseBoundValue("MAXVALUE"); !ok {
+		return nil
 	}
+	return bound
+}
 
-	// Parse WHERE clause
-	if p.peekTokenIs(WHERE) {
-		p.nextToken()
-		p.nextToken()
-		stmt.Where = p.parseExpression()
+// parseLocation parses an optional LOCATION '<dir>' after a partition bound
+func (p *Parser) parseLocation() string {
+	if !p.peekTokenIs(IDENT) || !strings.EqualFold(p.peekToken.Literal, "LOCATION") {
+		return ""
+	}
+	p.nextToken()
+	if !p.expectPeek(STRING) {
+		return ""
 	}
+	return p.curToken.Literal
+}
 
-	return stmt
+// parseBoundValue parses a parenthesized partition bound value; the
+// unbounded word yields a nil expression
+func (p *Parser) parseBoundValue(unbounded string) (Expression, bool) {
+	if !p.expectPeek(LPAREN) {
+		return nil, false
+	}
+	p.nextToken()
+	var value Expression
+	if !p.curWordIs(unbounded) {
+		if value = p.parseExpression(); value == nil {
+			return nil, false
+		}
+	}
+	return value, p.expectPeek(RPAREN)
 }
 
-// parseUpdate parses UPDATE statement
-func (p *Parser) parseUpdate() *UpdateStmt {
-	stmt := &UpdateStmt{Set: make(map[string]Expression)}
+// parseAlterTable parses ALTER TABLE <parent> ATTACH PARTITION <table> FOR
+// VALUES ... and ALTER TABLE <parent> DETACH PARTITION <table>
+func (p *Parser) parseAlterTable() *AlterTableStmt {
+	stmt := &AlterTableStmt{}
 
-	if !p.expectPeek(IDENT) {
+	if !p.expectPeek(TABLE) || !p.expectPeek(IDENT) {
 		return nil
 	}
 	stmt.TableName = p.curToken.Literal
 
-	if !p.expectPeek(SET) {
-		return nil
-	}
-
-	// Parse SET clause
 	p.nextToken()
-	for !p.curTokenIs(WHERE) && !p.curTokenIs(EOF) && !p.curTokenIs(SEMICOLON) {
-		if !p.curTokenIs(IDENT) {
-			p.addError("expected column name in SET clause")
+	switch {
+	case p.curWordIs("ATTACH"):
+		stmt.Action = "ATTACH"
+	case p.curWordIs("DETACH"):
+		stmt.Action = "DETACH"
+	case p.curWordIs("ALTER"):
+		return p.parseAlterColumn(stmt)
+	case p.curWordIs("ENABLE"), p.curWordIs("DISABLE"):
+		word := strings.ToUpper(p.curToken.Literal)
+		stmt.Action = word + " SOFT DELETE"
+		p.nextToken()
+		if !p.curWordIs("SOFT") {
+			p.addError(fmt.Sprintf("expected SOFT DELETE after %s", word))
 			return nil
 		}
-		colName := p.curToken.Literal
-
-		if !p.expectPeek(EQ) {
+		if !p.expectPeek(DELETE) {
 			return nil
 		}
+		return stmt
+	default:
+		p.addError("expected ATTACH PARTITION, DETACH PARTITION, ENABLE SOFT DELETE, DISABLE SOFT DELETE or ALTER COLUMN")
+		return nil
+	}
+	p.nextToken()
+	if !p.curWordIs("PARTITION") {
+		p.addError(fmt.Sprintf("expected PARTITION after %s", stmt.Action))
+		return nil
+	}
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.Partition = p.curToken.Literal
 
+	if stmt.Action == "ATTACH" {
 		p.nextToken()
-		stmt.Set[colName] = p.parseExpression()
-
-		if p.peekTokenIs(COMMA) {
-			p.nextToken()
-			p.nextToken()
-		} else {
-			break
+		if stmt.Bound = p.parsePartitionBound(); stmt.Bound == nil {
+			return nil
 		}
+		stmt.Location = p.parseLocation()
+	}
+	return stmt
+}
+
+// parseCreatePolicy parses CREATE POLICY name ON table USING (<expr>)
+func (p *Parser) parseCreatePolicy() *CreatePolicyStmt {
+	stmt := &CreatePolicyStmt{}
+
+	p.nextToken()
+	if !p.parsePolicyTarget(&stmt.Name, &stmt.TableName) {
+		return nil
+	}
+	p.nextToken()
+	if !p.curWordIs("USING") {
+		p.addError("expected USING after the table name")
+		return nil
+	}
+	if !p.expectPeek(LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Using = p.parseExpression()
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+	return stmt
+}
+
+// parseDropPolicy parses DROP POLICY name ON table
+func (p *Parser) parseDropPolicy() *DropPolicyStmt {
+	stmt := &DropPolicyStmt{}
+
+	p.nextToken()
+	if !p.parsePolicyTarget(&stmt.Name, &stmt.TableName) {
+		return nil
+	}
+	return stmt
+}
+
+// parsePolicyTarget parses the "name ON table" following POLICY
+func (p *Parser) parsePolicyTarget(name, table *string) bool {
+	if !p.expectPeek(IDENT) {
+		return false
+	}
+	*name = p.curToken.Literal
+	if !p.expectPeek(ON) || !p.expectPeek(IDENT) {
+		return false
+	}
+	*table = p.curToken.Literal
+	return true
+}
+
+// parseAlterColumn parses ALTER COLUMN name SET MASK <function> or DROP MASK
+// with the parser on ALTER
+func (p *Parser) parseAlterColumn(stmt *AlterTableStmt) *AlterTableStmt {
+	p.nextToken()
+	if !p.curWordIs("COLUMN") {
+		p.addError("expected COLUMN after ALTER")
+		return nil
+	}
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.Column = p.curToken.Literal
+
+	p.nextToken()
+	switch p.curToken.Type {
+	case SET:
+		stmt.Action = "SET MASK"
+	case DROP:
+		stmt.Action = "DROP MASK"
+	default:
+		p.addError("expected SET MASK, DROP MASK or SET ENCODING")
+		return nil
+	}
+	p.nextToken()
+	if stmt.Action == "SET MASK" && p.curWordIs("ENCODING") {
+		stmt.Action = "SET ENCODING"
+		p.nextToken()
+		if !p.curWordIs("DICTIONARY") && !p.curWordIs("PLAIN") {
+			p.addError("expected DICTIONARY or PLAIN after SET ENCODING")
+			return nil
+		}
+		stmt.Encoding = strings.ToUpper(p.curToken.Literal)
+		return stmt
+	}
+	if !p.curWordIs("MASK") {
+		p.addError(fmt.Sprintf("expected MASK after %s", p.curToken.Literal))
+		return nil
+	}
+	if stmt.Action == "SET MASK" {
+		p.nextToken()
+		stmt.Mask = p.parseExpression()
+	}
+	return stmt
+}
+
+// parseGrant parses GRANT <privilege> [(<columns>)] ON table TO user and
+// REVOKE ... FROM user
+func (p *Parser) parseGrant() *GrantStmt {
+	stmt := &GrantStmt{Revoke: p.curWordIs("REVOKE")}
+
+	p.nextToken()
+	if p.curTokenIs(EOF) {
+		p.addError("expected a privilege")
+		return nil
+	}
+	stmt.Privilege = strings.ToUpper(p.curToken.Literal)
+
+	if p.peekTokenIs(LPAREN) {
+		p.nextToken()
+		for {
+			if !p.expectPeek(IDENT) {
+				return nil
+			}
+			stmt.Columns = append(stmt.Columns, p.curToken.Literal)
+			if !p.peekTokenIs(COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(ON) || !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+
+	if stmt.Revoke {
+		if !p.expectPeek(FROM) {
+			return nil
+		}
+	} else if !p.expectPeek(TO) {
+		return nil
+	}
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.User = p.curToken.Literal
+	return stmt
+}
+
+// parseCreateUser parses CREATE USER name
+func (p *Parser) parseCreateUser() *CreateUserStmt {
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	return &CreateUserStmt{Name: p.curToken.Literal}
+}
+
+// parseDropUser parses DROP USER name
+func (p *Parser) parseDropUser() *DropUserStmt {
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	return &DropUserStmt{Name: p.curToken.Literal}
+}
+
+// parseCreateJob parses CREATE JOB name SCHEDULE '<cron>' AS <statement>.
+// The statement is the rest of the input.
+func (p *Parser) parseCreateJob() *CreateJobStmt {
+	stmt := &CreateJobStmt{}
+
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.Name = p.curToken.Literal
+	p.nextToken()
+	if !p.curWordIs("SCHEDULE") {
+		p.addError("expected SCHEDULE after the job name")
+		return nil
+	}
+	if !p.expectPeek(STRING) {
+		return nil
+	}
+	stmt.Schedule = p.curToken.Literal
+	if !p.expectPeek(AS) {
+		return nil
+	}
+
+	query := strings.TrimSpace(p.lexer.input[p.peekToken.Pos:])
+	stmt.Query = strings.TrimSpace(strings.TrimSuffix(query, ";"))
+	job, err := NewParser(stmt.Query).Parse()
+	if err != nil {
+		// Report the statement's errors where they are in the whole query
+		var errs SyntaxErrors
+		if !errors.As(err, &errs) {
+			p.addError(fmt.Sprintf("job statement: %v", err))
+			return nil
+		}
+		for _, e := range errs {
+			p.errors = append(p.errors, newSyntaxError(p.lexer.input, p.peekToken.Pos+e.Offset, e.Token,
+				"job statement: "+e.Message, e.Expected))
+		}
+		p.recovering = true
+		return nil
+	}
+	stmt.Statement = job
+
+	// Jobs run without a session, so they cannot read its variables
+	for !p.peekTokenIs(EOF) {
+		p.nextToken()
+		if p.curTokenIs(VARIABLE) {
+			p.addError(fmt.Sprintf("job statement cannot use session variable @%s", p.curToken.Literal))
+			return nil
+		}
+	}
+	return stmt
+}
+
+// parseDropJob parses DROP JOB name
+func (p *Parser) parseDropJob() *DropJobStmt {
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	return &DropJobStmt{Name: p.curToken.Literal}
+}
+
+// parseCreateSequence parses CREATE SEQUENCE name [START [WITH] n]
+// [INCREMENT [BY] n]
+func (p *Parser) parseCreateSequence() *CreateSequenceStmt {
+	stmt := &CreateSequenceStmt{Start: 1, Increment: 1}
+
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.Name = p.curToken.Literal
+
+	for p.peekWordIs("START") || p.peekWordIs("INCREMENT") {
+		p.nextToken()
+		option, noise := &stmt.Start, "WITH"
+		if p.curWordIs("INCREMENT") {
+			option, noise = &stmt.Increment, "BY"
+		}
+		value, ok := p.parseSequenceValue(noise)
+		if !ok {
+			return nil
+		}
+		*option = value
+	}
+	if stmt.Increment == 0 {
+		p.addError("INCREMENT must not be zero")
+		return nil
+	}
+	return stmt
+}
+
+// parseAlterSequence parses ALTER SEQUENCE name RESTART [[WITH] n]
+func (p *Parser) parseAlterSequence() *AlterSequenceStmt {
+	stmt := &AlterSequenceStmt{}
+
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.Name = p.curToken.Literal
+	p.nextToken()
+	if !p.curWordIs("RESTART") {
+		p.addError("expected RESTART after the sequence name")
+		return nil
+	}
+	if p.peekWordIs("WITH") || p.peekTokenIs(INT) || p.peekTokenIs(MINUS) {
+		value, ok := p.parseSequenceValue("WITH")
+		if !ok {
+			return nil
+		}
+		stmt.Restart = &value
+	}
+	return stmt
+}
+
+// parseSequenceValue parses the number of a sequence option, which may be
+// negative, optionally preceded by its noise word, with the parser on the
+// option
+func (p *Parser) parseSequenceValue(noise string) (int, bool) {
+	if p.peekWordIs(noise) {
+		p.nextToken()
+	}
+	sign := ""
+	if p.peekTokenIs(MINUS) {
+		p.nextToken()
+		sign = "-"
+	}
+	if !p.expectPeek(INT) {
+		return 0, false
+	}
+	value, err := strconv.Atoi(sign + p.curToken.Literal)
+	if err != nil {
+		p.addError(fmt.Sprintf("invalid sequence value %s%s", sign, p.curToken.Literal))
+		return 0, false
+	}
+	return value, true
+}
+
+// parseDropSequence parses DROP SEQUENCE name
+func (p *Parser) parseDropSequence() *DropSequenceStmt {
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	return &DropSequenceStmt{Name: p.curToken.Literal}
+}
+
+// parseCreateFunction parses CREATE FUNCTION name(<param> <type>, ...)
+// RETURNS <type> AS '<body>'
+func (p *Parser) parseCreateFunction() *CreateFunctionStmt {
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt := &CreateFunctionStmt{Name: strings.ToUpper(p.curToken.Literal), Params: []FunctionParam{}}
+	if !p.expectPeek(LPAREN) {
+		return nil
+	}
+	for !p.peekTokenIs(RPAREN) {
+		if len(stmt.Params) > 0 && !p.expectPeek(COMMA) {
+			return nil
+		}
+		if !p.expectPeek(IDENT) {
+			return nil
+		}
+		param := FunctionParam{Name: p.curToken.Literal}
+		p.nextToken()
+		var ok bool
+		if param.Type, ok = p.parseCastType(); !ok {
+			return nil
+		}
+		for _, other := range stmt.Params {
+			if other.Name == param.Name {
+				p.addError(fmt.Sprintf("parameter %s is declared more than once", param.Name))
+				return nil
+			}
+		}
+		stmt.Params = append(stmt.Params, param)
+	}
+	p.nextToken()
+
+	p.nextToken()
+	if !p.curWordIs("RETURNS") {
+		p.addError("expected RETURNS after the parameter list")
+		return nil
+	}
+	p.nextToken()
+	var ok bool
+	if stmt.Returns, ok = p.parseCastType(); !ok {
+		return nil
+	}
+	if !p.expectPeek(AS) || !p.expectPeek(STRING) {
+		return nil
+	}
+	stmt.Query = p.curToken.Literal
+	body, err := ParseFunctionBody(stmt.Query)
+	if err != nil {
+		p.addError(fmt.Sprintf("function body: %v", err))
+		return nil
+	}
+	stmt.Body = body
+	return stmt
+}
+
+// ParseFunctionBody parses the body of a SQL function: SELECT followed by
+// an expression, or a SELECT of one column from a table, which is used
+// like a scalar subquery
+func ParseFunctionBody(body string) (Expression, error) {
+	if stmt, err := NewParser(body).Parse(); err == nil {
+		sel, ok := stmt.(*SelectStmt)
+		if !ok {
+			return nil, fmt.Errorf("must be a SELECT")
+		}
+		if len(sel.Columns) != 1 || sel.Columns[0] == "*" {
+			return nil, fmt.Errorf("must select one column")
+		}
+		return &SubqueryExpr{Select: sel}, nil
+	}
+
+	p := NewParser(body)
+	if !p.curTokenIs(SELECT) {
+		return nil, fmt.Errorf("must be a SELECT")
+	}
+	p.nextToken()
+	expr := p.parseExpression()
+	if len(p.errors) == 0 && !p.peekTokenIs(EOF) && !p.peekTokenIs(SEMICOLON) {
+		p.peekError(EOF)
+	}
+	if len(p.errors) > 0 {
+		return nil, SyntaxErrors(p.errors)
+	}
+	return expr, nil
+}
+
+// parseDropFunction parses DROP FUNCTION name
+func (p *Parser) parseDropFunction() *DropFunctionStmt {
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	return &DropFunctionStmt{Name: strings.ToUpper(p.curToken.Literal)}
+}
+
+// parseCall parses CALL name(<args>)
+func (p *Parser) parseCall() *CallStmt {
+	if !p.expectPeek(IDENT) || !p.peekTokenIs(LPAREN) {
+		if len(p.errors) == 0 {
+			p.addError("expected a function call after CALL")
+		}
+		return nil
+	}
+	name := strings.ToUpper(p.curToken.Literal)
+	call := p.parseFunctionCall()
+	if call == nil {
+		return nil
+	}
+	return &CallStmt{Name: name, Call: call}
+}
+
+// expectMaterializedView moves past MATERIALIZED VIEW <name>, from the
+// token before it, and returns the name, or "" on an error
+func (p *Parser) expectMaterializedView() string {
+	p.nextToken()
+	if !p.curWordIs("MATERIALIZED") {
+		p.addError("expected MATERIALIZED")
+		return ""
+	}
+	p.nextToken()
+	if !p.curWordIs("VIEW") {
+		p.addError("expected VIEW after MATERIALIZED")
+		return ""
+	}
+	if !p.expectPeek(IDENT) {
+		return ""
+	}
+	return p.curToken.Literal
+}
+
+// parseCreateMaterializedView parses CREATE MATERIALIZED VIEW name AS
+// SELECT ...
+func (p *Parser) parseCreateMaterializedView() *CreateMaterializedViewStmt {
+	stmt := &CreateMaterializedViewStmt{}
+	if stmt.Name = p.expectMaterializedView(); stmt.Name == "" {
+		return nil
+	}
+	if !p.expectPeek(AS) || !p.expectPeek(SELECT) {
+		return nil
+	}
+	if stmt.Select = p.parseSelect(); stmt.Select == nil {
+		return nil
+	}
+	return stmt
+}
+
+// parseRefreshMaterializedView parses REFRESH MATERIALIZED VIEW name
+func (p *Parser) parseRefreshMaterializedView() *RefreshMaterializedViewStmt {
+	name := p.expectMaterializedView()
+	if name == "" {
+		return nil
+	}
+	return &RefreshMaterializedViewStmt{Name: name}
+}
+
+// parseDropMaterializedView parses DROP MATERIALIZED VIEW name
+func (p *Parser) parseDropMaterializedView() *DropTableStmt {
+	name := p.expectMaterializedView()
+	if name == "" {
+		return nil
+	}
+	return &DropTableStmt{TableName: name, MaterializedView: true}
+}
+
+// parseColumnDefinitions parses column definitions and table-level UNIQUE
+// (<column>, ...) and FOREIGN KEY constraints. The REFERENCES clauses of
+// columns are returned with the FOREIGN KEY constraints. A column with an
+// error is skipped up to the next comma so the remaining ones are checked
+// too.
+func (p *Parser) parseColumnDefinitions() ([]*ColumnDef, [][]string, []*ForeignKeyDef) {
+	columns := []*ColumnDef{}
+	var unique [][]string
+	var foreignKeys []*ForeignKeyDef
+
+	p.nextToken()
+
+	for !p.curTokenIs(RPAREN) && !p.curTokenIs(EOF) {
+		if p.curTokenIs(UNIQUE) {
+			if constraint := p.parseUniqueConstraint(); constraint == nil {
+				p.synchronize(COMMA, RPAREN)
+			} else {
+				unique = append(unique, constraint)
+			}
+		} else if p.curWordIs("FOREIGN") {
+			if constraint := p.parseForeignKey(); constraint == nil {
+				p.synchronize(COMMA, RPAREN)
+			} else {
+				foreignKeys = append(foreignKeys, constraint)
+			}
+		} else if col := p.parseColumnDefinition(); col == nil {
+			p.synchronize(COMMA, RPAREN)
+		} else {
+			columns = append(columns, col)
+			if col.References != nil {
+				foreignKeys = append(foreignKeys, col.References)
+			}
+		}
+
+		if p.curTokenIs(COMMA) {
+			p.nextToken()
+		} else {
+			break
+		}
+	}
+
+	return columns, unique, foreignKeys
+}
+
+// parseUniqueConstraint parses a table-level UNIQUE (<column>, ...) and
+// leaves the parser on the token after it
+func (p *Parser) parseUniqueConstraint() []string {
+	if !p.expectPeek(LPAREN) || !p.expectPeek(IDENT) {
+		return nil
+	}
+	columns := p.parseIdentifierList()
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+	p.nextToken()
+	return columns
+}
+
+// parseForeignKey parses a table-level FOREIGN KEY (<column>, ...)
+// REFERENCES <table> [(<column>, ...)] and leaves the parser on the token
+// after it
+func (p *Parser) parseForeignKey() *ForeignKeyDef {
+	if !p.expectPeek(KEY) || !p.expectPeek(LPAREN) || !p.expectPeek(IDENT) {
+		return nil
+	}
+	columns := p.parseIdentifierList()
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+	p.nextToken()
+	if !p.curWordIs("REFERENCES") {
+		p.addError("expected REFERENCES after FOREIGN KEY columns")
+		return nil
+	}
+	return p.parseReferences(columns)
+}
+
+// parseReferences parses the REFERENCES <table> [(<column>, ...)] [ON
+// DELETE <action>] of a foreign key over columns, starting on REFERENCES,
+// and leaves the parser on the token after it
+func (p *Parser) parseReferences(columns []string) *ForeignKeyDef {
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	def := &ForeignKeyDef{Columns: columns, RefTable: p.curToken.Literal}
+	if p.peekTokenIs(LPAREN) {
+		p.nextToken()
+		if !p.expectPeek(IDENT) {
+			return nil
+		}
+		def.RefColumns = p.parseIdentifierList()
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	}
+	p.nextToken()
+
+	// ON UPDATE after a column's REFERENCES is the column's own clause
+	if p.curTokenIs(ON) && p.peekTokenIs(DELETE) {
+		p.nextToken()
+		p.nextToken()
+		switch {
+		case p.curWordIs("CASCADE"):
+			def.OnDelete = "CASCADE"
+		case p.curWordIs("RESTRICT"):
+		case p.curTokenIs(SET) && p.peekTokenIs(NULL):
+			p.nextToken()
+			def.OnDelete = "SET NULL"
+		case p.curWordIs("NO"):
+			p.nextToken()
+			if !p.curWordIs("ACTION") {
+				p.addError("expected ACTION after NO")
+				return nil
+			}
+		default:
+			p.addError("expected CASCADE, SET NULL, RESTRICT or NO ACTION after ON DELETE")
+			return nil
+		}
+		p.nextToken()
+	}
+	return def
+}
+
+// parseColumnDefinition parses one column definition and leaves the parser
+// on the token after it
+func (p *Parser) parseColumnDefinition() *ColumnDef {
+	col := &ColumnDef{}
+
+	if !p.curTokenIs(IDENT) {
+		p.addError("expected column name")
+		return nil
+	}
+	col.Name = p.curToken.Literal
+
+	p.nextToken()
+
+	// Parse data type
+	switch p.curToken.Type {
+	case INTEGER:
+		col.DataType = "INTEGER"
+	case VARCHAR:
+		col.DataType = "VARCHAR"
+		if p.peekTokenIs(LPAREN) {
+			p.nextToken() // consume (
+			p.nextToken() // move to size
+			if p.curTokenIs(INT) {
+				size, _ := strconv.Atoi(p.curToken.Literal)
+				col.Size = size
+			}
+			if !p.expectPeek(RPAREN) {
+				return nil
+			}
+		}
+	case BOOLEAN:
+		col.DataType = "BOOLEAN"
+	case FLOAT_TYPE:
+		col.DataType = "FLOAT"
+	case CITEXT:
+		col.DataType = "CITEXT"
+	case IDENT:
+		switch {
+		case p.curWordIs("JSON"):
+			col.DataType = "JSON"
+		case p.curWordIs("TIMESTAMP"):
+			col.DataType = "TIMESTAMP"
+		case p.curWordIs("DATE"):
+			col.DataType = "DATE"
+		case p.curWordIs("TEXT"):
+			col.DataType = "TEXT"
+		case p.curWordIs("BLOB"), p.curWordIs("BYTEA"):
+			col.DataType = "BLOB"
+		case p.curWordIs("UUID"):
+			col.DataType = "UUID"
+		default:
+			p.addError(fmt.Sprintf("unknown data type: %s", p.curToken.Literal))
+			return nil
+		}
+	default:
+		p.addError(fmt.Sprintf("unknown data type: %s", p.curToken.Literal))
+		return nil
+	}
+
+	// Parse generated column clause
+	p.nextToken()
+	if p.curWordIs("GENERATED") {
+		if !p.parseGeneratedClause(col) {
+			return nil
+		}
+	}
+
+	// Parse constraints
+	for p.curTokenIs(PRIMARY) || p.curTokenIs(UNIQUE) || p.curTokenIs(NOT) || p.curWordIs("COLLATE") ||
+		p.curWordIs("DEFAULT") || p.curTokenIs(ON) || p.curWordIs("ENCODING") || p.curWordIs("REFERENCES") {
+		if p.curWordIs("REFERENCES") {
+			if col.References = p.parseReferences([]string{col.Name}); col.References == nil {
+				return nil
+			}
+			continue
+		} else if p.curWordIs("ENCODING") {
+			p.nextToken()
+			if !p.curWordIs("DICTIONARY") && !p.curWordIs("PLAIN") {
+				p.addError("expected DICTIONARY or PLAIN after ENCODING")
+				return nil
+			}
+			col.Dictionary = p.curWordIs("DICTIONARY")
+		} else if p.curWordIs("DEFAULT") {
+			p.nextToken()
+			col.Default = p.parseExpression()
+		} else if p.curTokenIs(ON) {
+			if !p.expectPeek(UPDATE) {
+				return nil
+			}
+			p.nextToken()
+			col.OnUpdate = p.parseExpression()
+		} else if p.curWordIs("COLLATE") {
+			if !p.expectPeek(IDENT) {
+				return nil
+			}
+			col.Collation = strings.ToLower(p.curToken.Literal)
+		} else if p.curTokenIs(PRIMARY) {
+			if !p.expectPeek(KEY) {
+				return nil
+			}
+			col.PrimaryKey = true
+		} else if p.curTokenIs(UNIQUE) {
+			col.Unique = true
+		} else if p.curTokenIs(NOT) {
+			if !p.expectPeek(NULL) {
+				return nil
+			}
+			col.NotNull = true
+		}
+		p.nextToken()
+	}
+
+	return col
+}
+
+// parseGeneratedClause parses GENERATED ALWAYS AS (<expr>) [STORED | VIRTUAL]
+// and leaves the parser on the token after it
+func (p *Parser) parseGeneratedClause(col *ColumnDef) bool {
+	p.nextToken()
+	if !p.curWordIs("ALWAYS") {
+		p.addError("expected ALWAYS after GENERATED")
+		return false
+	}
+	if !p.expectPeek(AS) || !p.expectPeek(LPAREN) {
+		return false
+	}
+	p.nextToken()
+	col.Generated = p.parseExpression()
+	if !p.expectPeek(RPAREN) {
+		return false
+	}
+
+	p.nextToken()
+	if p.curWordIs("STORED") {
+		p.nextToken()
+	} else if p.curWordIs("VIRTUAL") {
+		col.Virtual = true
+		p.nextToken()
+	}
+	return true
+}
+
+// parseDropTable parses DROP TABLE statement
+func (p *Parser) parseDropTable() *DropTableStmt {
+	stmt := &DropTableStmt{}
+
+	if !p.expectPeek(TABLE) {
+		return nil
+	}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+
+	return stmt
+}
+
+// parseInsert parses INSERT INTO statement
+func (p *Parser) parseInsert() *InsertStmt {
+	stmt := &InsertStmt{}
+
+	if !p.expectPeek(INTO) {
+		return nil
+	}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+
+	// Parse column names (optional)
+	if p.peekTokenIs(LPAREN) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Columns = p.parseIdentifierList()
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	}
+
+	if p.peekTokenIs(SELECT) {
+		p.nextToken()
+		stmt.Select = p.parseSelect()
+		if stmt.Select == nil {
+			return nil
+		}
+		if stmt = p.parseInsertConflict(stmt); stmt == nil {
+			return nil
+		}
+		stmt.Returning = p.parseReturning()
+		return stmt
+	}
+
+	if !p.expectPeek(VALUES) {
+		return nil
+	}
+
+	// Parse values. A row with an error is skipped up to its closing
+	// parenthesis so the remaining rows are checked too.
+	stmt.Values = [][]Expression{}
+	for p.peekTokenIs(LPAREN) {
+		p.nextToken()
+		p.nextToken()
+		before := len(p.errors)
+		values := p.parseExpressionList()
+		for i, value := range values {
+			if ident, ok := value.(*Identifier); ok && strings.EqualFold(ident.Value, "DEFAULT") {
+				values[i] = &DefaultValue{}
+			}
+		}
+		stmt.Values = append(stmt.Values, values)
+
+		if len(p.errors) > before || !p.expectPeek(RPAREN) {
+			p.synchronize(RPAREN)
+			if p.curTokenIs(EOF) {
+				return nil
+			}
+		}
+
+		if p.peekTokenIs(COMMA) {
+			p.nextToken()
+		} else {
+			break
+		}
+	}
+
+	if stmt = p.parseInsertConflict(stmt); stmt == nil {
+		return nil
+	}
+	stmt.Returning = p.parseReturning()
+	return stmt
+}
+
+// parseReturning parses the optional RETURNING * or RETURNING <columns>
+// ending an INSERT, UPDATE or DELETE, returning nil when there is none
+func (p *Parser) parseReturning() []string {
+	if !p.peekWordIs("RETURNING") {
+		return nil
+	}
+	p.nextToken()
+	if p.peekTokenIs(ASTERISK) {
+		p.nextToken()
+		return []string{"*"}
+	}
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	return p.parseIdentifierList()
+}
+
+// parseInsertConflict parses the optional ON CONFLICT [(<columns>)] DO
+// NOTHING or DO UPDATE SET <assignments> ending an INSERT
+func (p *Parser) parseInsertConflict(stmt *InsertStmt) *InsertStmt {
+	if !p.peekTokenIs(ON) {
+		return stmt
+	}
+	p.nextToken()
+	if !p.peekWordIs("CONFLICT") {
+		p.addError(fmt.Sprintf("expected CONFLICT after ON, got %s", p.peekToken.Literal))
+		return nil
+	}
+	p.nextToken()
+
+	conflict := &OnConflict{}
+	if p.peekTokenIs(LPAREN) {
+		p.nextToken()
+		p.nextToken()
+		conflict.Columns = p.parseIdentifierList()
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	}
+	if !p.peekWordIs("DO") {
+		p.addError(fmt.Sprintf("expected DO after ON CONFLICT, got %s", p.peekToken.Literal))
+		return nil
+	}
+	p.nextToken()
+
+	switch {
+	case p.peekWordIs("NOTHING"):
+		p.nextToken()
+	case p.peekTokenIs(UPDATE):
+		p.nextToken()
+		if !p.expectPeek(SET) {
+			return nil
+		}
+		p.nextToken()
+		conflict.Set = make(map[string]Expression)
+		p.parseAssignments(conflict.Set)
+		if p.curTokenIs(WHERE) {
+			p.addError("WHERE is not supported in ON CONFLICT DO UPDATE")
+			return nil
+		}
+	default:
+		p.addError(fmt.Sprintf("expected NOTHING or UPDATE after DO, got %s", p.peekToken.Literal))
+		return nil
+	}
+	stmt.OnConflict = conflict
+	return stmt
+}
+
+// parseSelect parses SELECT statement
+func (p *Parser) parseSelect() *SelectStmt {
+	stmt := &SelectStmt{}
+	var ok bool
+
+	p.nextToken()
+
+	// Parse column list
+	if p.curTokenIs(ASTERISK) {
+		stmt.Columns = []string{"*"}
+		p.nextToken()
+	} else {
+		if !p.parseSelectList(stmt) {
+			return nil
+		}
+		// parseSelectList leaves us at the last item, advance to next token
+		p.nextToken()
+	}
+
+	if !p.curTokenIs(FROM) {
+		p.addError("expected FROM after column list")
+		return nil
+	}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+	if stmt.TableAlias, ok = p.parseTableAlias(); !ok {
+		return nil
+	}
+
+	// Parse TABLESAMPLE
+	if p.peekWordIs("TABLESAMPLE") {
+		p.nextToken()
+		if stmt.Sample = p.parseTableSample(); stmt.Sample == nil {
+			return nil
+		}
+	}
+
+	// Parse JOINs
+	for p.peekTokenIs(INNER) || p.peekTokenIs(JOIN) || p.peekWordIs("LEFT") {
+		p.nextToken()
+		join := &JoinClause{JoinType: "INNER"}
+
+		if p.curTokenIs(INNER) {
+			if !p.expectPeek(JOIN) {
+				return nil
+			}
+		} else if p.curWordIs("LEFT") {
+			join.JoinType = "LEFT"
+			if p.peekWordIs("OUTER") {
+				p.nextToken()
+			}
+			if !p.expectPeek(JOIN) {
+				return nil
+			}
+		}
+
+		if !p.expectPeek(IDENT) {
+			return nil
+		}
+		join.TableName = p.curToken.Literal
+		if join.Alias, ok = p.parseTableAlias(); !ok {
+			return nil
+		}
+
+		if !p.expectPeek(ON) {
+			return nil
+		}
+
+		p.nextToken()
+		join.On = p.parseExpression()
+
+		stmt.Joins = append(stmt.Joins, join)
+	}
+
+	// Parse WHERE clause
+	if p.peekTokenIs(WHERE) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Where = p.parseExpression()
+	}
+
+	// Parse WITH DELETED
+	if p.peekWordIs("WITH") {
+		p.nextToken()
+		p.nextToken()
+		if !p.curWordIs("DELETED") {
+			p.addError("expected DELETED after WITH")
+			return nil
+		}
+		stmt.WithDeleted = true
+	}
+
+	// Parse GROUP BY
+	if p.peekWordIs("GROUP") {
+		p.nextToken()
+		p.nextToken()
+		if !p.curWordIs("BY") {
+			p.addError("expected BY after GROUP")
+			return nil
+		}
+		for {
+			if !p.expectPeek(IDENT) {
+				return nil
+			}
+			column, ok := p.parseColumnRef()
+			if !ok {
+				return nil
+			}
+			stmt.GroupBy = append(stmt.GroupBy, column)
+			if !p.peekTokenIs(COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+	}
+
+	// Parse HAVING
+	if p.peekWordIs("HAVING") {
+		p.nextToken()
+		p.nextToken()
+		stmt.Having = p.parseExpression()
+	}
+
+	// Parse ORDER BY
+	if p.peekWordIs("ORDER") {
+		p.nextToken()
+		if stmt.OrderBy = p.parseOrderBy(); stmt.OrderBy == nil {
+			return nil
+		}
+		// ORDER BY may name a column by its alias
+		for k := range stmt.OrderBy {
+			for i, alias := range stmt.Aliases {
+				if alias == stmt.OrderBy[k].Column && (stmt.Aggregates == nil || stmt.Aggregates[i] == nil) {
+					stmt.OrderBy[k].Column = stmt.Columns[i]
+					break
+				}
+			}
+		}
+	}
+
+	return stmt
+}
+
+// parseOrderBy parses the rest of
+// ORDER BY <column> [ASC|DESC] [NULLS FIRST|LAST] [, ...]
+func (p *Parser) parseOrderBy() []OrderBy {
+	p.nextToken()
+	if !p.curWordIs("BY") {
+		p.addError("expected BY after ORDER")
+		return nil
+	}
+	var keys []OrderBy
+	for {
+		if !p.expectPeek(IDENT) {
+			return nil
+		}
+		column, ok := p.parseColumnRef()
+		if !ok {
+			return nil
+		}
+		key := OrderBy{Column: column}
+		if p.peekWordIs("ASC") {
+			p.nextToken()
+		} else if p.peekWordIs("DESC") {
+			p.nextToken()
+			key.Desc = true
+		}
+		key.NullsFirst = key.Desc
+		if p.peekWordIs("NULLS") {
+			p.nextToken()
+			p.nextToken()
+			switch {
+			case p.curWordIs("FIRST"):
+				key.NullsFirst = true
+			case p.curWordIs("LAST"):
+				key.NullsFirst = false
+			default:
+				p.addError("expected FIRST or LAST after NULLS")
+				return nil
+			}
+		}
+		keys = append(keys, key)
+		if !p.peekTokenIs(COMMA) {
+			return keys
+		}
+		p.nextToken()
+	}
+}
+
+// tableClauseWords are the words that may follow a table name in FROM or
+// JOIN, which are therefore not read as its alias
+var tableClauseWords = map[string]bool{
+	"TABLESAMPLE": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true,
+	"WITH": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true,
+	"RETURNING": true, "USING": true,
+}
+
+// parseTableAlias parses the optional alias after a table name, written
+// AS <alias> or just <alias>, returning "" when there is none
+func (p *Parser) parseTableAlias() (string, bool) {
+	if p.peekTokenIs(AS) {
+		p.nextToken()
+		if !p.expectPeek(IDENT) {
+			return "", false
+		}
+		return p.curToken.Literal, true
+	}
+	if p.peekTokenIs(IDENT) && !tableClauseWords[strings.ToUpper(p.peekToken.Literal)] {
+		p.nextToken()
+		return p.curToken.Literal, true
+	}
+	return "", true
+}
+
+// parseColumnRef parses a column name, with the parser on its first word,
+// returning a qualified name such as u.id as "u.id"
+func (p *Parser) parseColumnRef() (string, bool) {
+	name := p.curToken.Literal
+	if p.peekTokenIs(DOT) {
+		p.nextToken()
+		if !p.expectPeek(IDENT) {
+			return "", false
+		}
+		name += "." + p.curToken.Literal
+	}
+	return name, true
+}
+
+// parseTableSample parses the rest of TABLESAMPLE BERNOULLI (<percent>),
+// TABLESAMPLE SYSTEM (<percent>) or TABLESAMPLE (<n> ROWS), each optionally
+// followed by REPEATABLE (<seed>)
+func (p *Parser) parseTableSample() *TableSample {
+	sample := &TableSample{Method: "ROWS"}
+
+	p.nextToken()
+	if p.curWordIs("BERNOULLI") || p.curWordIs("SYSTEM") {
+		sample.Method = strings.ToUpper(p.curToken.Literal)
+		p.nextToken()
+	}
+	if !p.curTokenIs(LPAREN) {
+		p.addError("expected ( after TABLESAMPLE")
+		return nil
+	}
+
+	p.nextToken()
+	switch {
+	case sample.Method == "ROWS" && p.curTokenIs(INT):
+		sample.Rows, _ = strconv.Atoi(p.curToken.Literal)
+		p.nextToken()
+		if !p.curWordIs("ROWS") {
+			p.addError("expected ROWS after sample size")
+			return nil
+		}
+	case sample.Method != "ROWS" && (p.curTokenIs(INT) || p.curTokenIs(FLOAT)):
+		sample.Percent, _ = strconv.ParseFloat(p.curToken.Literal, 64)
+		if sample.Percent > 100 {
+			p.addError("sample percentage must be between 0 and 100")
+			return nil
+		}
+	default:
+		p.addError("expected sample size after TABLESAMPLE")
+		return nil
+	}
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+
+	if p.peekWordIs("REPEATABLE") {
+		p.nextToken()
+		if !p.expectPeek(LPAREN) || !p.expectPeek(INT) {
+			return nil
+		}
+		sample.Repeatable = true
+		sample.Seed, _ = strconv.ParseInt(p.curToken.Literal, 10, 64)
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	}
+
+	return sample
+}
+
+// parseCopy parses COPY <table> [(<columns>)] FROM {STDIN | '<path>'} [WITH]
+// [(<options>)]
+func (p *Parser) parseCopy() *CopyStmt {
+	stmt := &CopyStmt{Format: "text"}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+
+	if p.peekTokenIs(LPAREN) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Columns = p.parseIdentifierList()
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(FROM) {
+		return nil
+	}
+	p.nextToken()
+	switch {
+	case p.curWordIs("STDIN"):
+	case p.curTokenIs(STRING) && p.curToken.Literal != "":
+		stmt.Path = p.curToken.Literal
+	default:
+		p.addError("expected STDIN or a file path after COPY ... FROM")
+		return nil
+	}
+
+	if p.peekWordIs("WITH") {
+		p.nextToken()
+	}
+	if !p.peekTokenIs(LPAREN) {
+		return stmt
+	}
+	p.nextToken()
+
+	for {
+		p.nextToken()
+		switch {
+		case p.curWordIs("FORMAT"):
+			p.nextToken()
+			format := strings.ToLower(p.curToken.Literal)
+			if format != "text" && format != "csv" {
+				p.addError(fmt.Sprintf("unknown COPY format: %s", p.curToken.Literal))
+				return nil
+			}
+			stmt.Format = format
+		case p.curWordIs("DELIMITER"):
+			if !p.expectPeek(STRING) {
+				return nil
+			}
+			if len([]rune(p.curToken.Literal)) != 1 {
+				p.addError("COPY delimiter must be a single character")
+				return nil
+			}
+			stmt.Delimiter = p.curToken.Literal
+		case p.curTokenIs(NULL):
+			if !p.expectPeek(STRING) {
+				return nil
+			}
+			stmt.Null = p.curToken.Literal
+		case p.curWordIs("HEADER"):
+			stmt.Header = true
+			if p.peekTokenIs(IDENT) {
+				p.nextToken()
+				switch strings.ToLower(p.curToken.Literal) {
+				case "true", "on":
+				case "false", "off":
+					stmt.Header = false
+				default:
+					p.addError(fmt.Sprintf("invalid HEADER value: %s", p.curToken.Literal))
+					return nil
+				}
+			}
+		default:
+			p.addError(fmt.Sprintf("unknown COPY option: %s", p.curToken.Literal))
+			return nil
+		}
+
+		if !p.peekTokenIs(COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseAdvise parses EXPLAIN ADVISE [<table>]
+func (p *Parser) parseAdvise() *AdviseStmt {
+	stmt := &AdviseStmt{}
+	p.nextToken()
+	if p.peekTokenIs(IDENT) {
+		p.nextToken()
+		stmt.TableName = p.curToken.Literal
+	}
+	return stmt
+}
+
+// parseExplain parses EXPLAIN [ANALYZE] [(option, ...)] SELECT ...
+func (p *Parser) parseExplain() *ExplainStmt {
+	stmt := &ExplainStmt{Format: "text"}
+
+	if p.peekWordIs("ANALYZE") {
+		p.nextToken()
+		stmt.Analyze = true
+	}
+
+	if p.peekTokenIs(LPAREN) {
+		p.nextToken()
+		for {
+			p.nextToken()
+			switch {
+			case p.curWordIs("FORMAT"):
+				p.nextToken()
+				format := strings.ToLower(p.curToken.Literal)
+				if format != "text" && format != "json" {
+					p.errorAt(p.curToken, fmt.Sprintf("unknown EXPLAIN format: %s", p.curToken.Literal), "TEXT", "JSON")
+					return nil
+				}
+				stmt.Format = format
+			case p.curWordIs("ANALYZE"):
+				stmt.Analyze = true
+				if p.peekTokenIs(IDENT) {
+					p.nextToken()
+					switch strings.ToLower(p.curToken.Literal) {
+					case "true", "on":
+					case "false", "off":
+						stmt.Analyze = false
+					default:
+						p.addError(fmt.Sprintf("invalid ANALYZE value: %s", p.curToken.Literal))
+						return nil
+					}
+				}
+			default:
+				p.errorAt(p.curToken, fmt.Sprintf("unknown EXPLAIN option: %s", p.curToken.Literal), "ANALYZE", "FORMAT")
+				return nil
+			}
+
+			if !p.peekTokenIs(COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(SELECT) {
+		return nil
+	}
+	stmt.Select = p.parseSelect()
+	if stmt.Select == nil {
+		return nil
+	}
+	return stmt
+}
+
+// parseUpdate parses UPDATE statement
+func (p *Parser) parseUpdate() *UpdateStmt {
+	stmt := &UpdateStmt{Set: make(map[string]Expression)}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+
+	if !p.expectPeek(SET) {
+		return nil
+	}
+
+	p.nextToken()
+	p.parseAssignments(stmt.Set)
+
+	// Parse WHERE clause
+	if p.curTokenIs(WHERE) {
+		p.nextToken()
+		stmt.Where = p.parseExpression()
+	}
+	stmt.Returning = p.parseReturning()
+
+	return stmt
+}
+
+// parseAssignments parses the assignments of a SET clause into set, with
+// the parser on the first one. It stops on a WHERE that follows them, or
+// else on their last token. An assignment with an error is skipped up to
+// the next comma so the remaining ones are checked too.
+func (p *Parser) parseAssignments(set map[string]Expression) {
+	for !p.curTokenIs(WHERE) && !p.curTokenIs(EOF) && !p.curTokenIs(SEMICOLON) {
+		before := len(p.errors)
+		p.parseAssignment(set)
+		if len(p.errors) > before {
+			p.synchronize(COMMA, WHERE)
+		} else if p.peekTokenIs(COMMA) || p.peekTokenIs(WHERE) {
+			p.nextToken()
+		}
+
+		if p.curTokenIs(COMMA) {
+			p.nextToken()
+		} else {
+			break
+		}
+	}
+}
+
+// parseAssignment parses <column> = <expr> in a SET clause
+func (p *Parser) parseAssignment(set map[string]Expression) {
+	if !p.curTokenIs(IDENT) {
+		p.addError("expected column name in SET clause")
+		return
+	}
+	colName := p.curToken.Literal
+
+	if !p.expectPeek(EQ) {
+		return
+	}
+
+	p.nextToken()
+	set[colName] = p.parseExpression()
+}
+
+// parseDelete parses DELETE statement
+func (p *Parser) parseDelete() *DeleteStmt {
+	stmt := &DeleteStmt{}
+
+	if !p.expectPeek(FROM) {
+		return nil
+	}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+
+	// Parse WHERE clause
+	if p.peekTokenIs(WHERE) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Where = p.parseExpression()
+	}
+	stmt.Returning = p.parseReturning()
+
+	return stmt
+}
+
+// parsePurge parses PURGE <table> [WHERE <condition>]
+func (p *Parser) parsePurge() *PurgeStmt {
+	stmt := &PurgeStmt{}
+
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+
+	if p.peekTokenIs(WHERE) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Where = p.parseExpression()
+	}
+
+	return stmt
+}
+
+// parseMerge parses MERGE INTO <table> [[AS] <alias>] USING <table>
+// [[AS] <alias>] ON <condition> and its WHEN clauses
+func (p *Parser) parseMerge() *MergeStmt {
+	stmt := &MergeStmt{}
+	var ok bool
+
+	if !p.expectPeek(INTO) || !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.TableName = p.curToken.Literal
+	if stmt.Alias, ok = p.parseTableAlias(); !ok {
+		return nil
+	}
+
+	if !p.peekWordIs("USING") {
+		p.addError(fmt.Sprintf("expected USING after MERGE INTO %s, got %s", stmt.TableName, p.peekToken.Literal))
+		return nil
+	}
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	stmt.Source = p.curToken.Literal
+	if stmt.SourceAlias, ok = p.parseTableAlias(); !ok {
+		return nil
+	}
+
+	if !p.expectPeek(ON) {
+		return nil
+	}
+	p.nextToken()
+	if stmt.On = p.parseExpression(); stmt.On == nil {
+		return nil
+	}
+
+	for p.peekWordIs("WHEN") {
+		p.nextToken()
+		clause := p.parseMergeClause()
+		if clause == nil {
+			return nil
+		}
+		stmt.Clauses = append(stmt.Clauses, clause)
+	}
+	if len(stmt.Clauses) == 0 {
+		p.addError(fmt.Sprintf("expected WHEN after MERGE ... ON, got %s", p.peekToken.Literal))
+		return nil
+	}
+	return stmt
+}
+
+// parseMergeClause parses [NOT] MATCHED [AND <condition>] THEN <action>
+// with the parser on WHEN. A matched row may be updated or deleted, and an
+// unmatched one inserted; either may be left alone with DO NOTHING.
+func (p *Parser) parseMergeClause() *MergeClause {
+	clause := &MergeClause{Matched: true}
+	if p.peekTokenIs(NOT) {
+		p.nextToken()
+		clause.Matched = false
+	}
+	if !p.peekWordIs("MATCHED") {
+		p.addError(fmt.Sprintf("expected MATCHED or NOT MATCHED after WHEN, got %s", p.peekToken.Literal))
+		return nil
+	}
+	p.nextToken()
+	if p.peekTokenIs(AND) {
+		p.nextToken()
+		p.nextToken()
+		if clause.Condition = p.parseExpression(); clause.Condition == nil {
+			return nil
+		}
+	}
+	if !p.peekWordIs("THEN") {
+		p.addError(fmt.Sprintf("expected THEN, got %s", p.peekToken.Literal))
+		return nil
+	}
+	p.nextToken()
+
+	switch {
+	case p.peekWordIs("DO"):
+		p.nextToken()
+		if !p.peekWordIs("NOTHING") {
+			p.addError(fmt.Sprintf("expected NOTHING after DO, got %s", p.peekToken.Literal))
+			return nil
+		}
+		p.nextToken()
+		clause.Action = "NOTHING"
+	case clause.Matched && p.peekTokenIs(UPDATE):
+		p.nextToken()
+		if !p.expectPeek(SET) {
+			return nil
+		}
+		p.nextToken()
+		clause.Action = "UPDATE"
+		clause.Set = make(map[string]Expression)
+		p.parseAssignments(clause.Set)
+		if p.curTokenIs(WHERE) {
+			p.addError("WHERE is not supported in MERGE; put the condition in WHEN MATCHED AND")
+			return nil
+		}
+	case clause.Matched && p.peekTokenIs(DELETE):
+		p.nextToken()
+		clause.Action = "DELETE"
+	case !clause.Matched && p.peekTokenIs(INSERT):
+		p.nextToken()
+		clause.Action = "INSERT"
+		if p.peekTokenIs(LPAREN) {
+			p.nextToken()
+			p.nextToken()
+			clause.Columns = p.parseIdentifierList()
+			if !p.expectPeek(RPAREN) {
+				return nil
+			}
+		}
+		if !p.expectPeek(VALUES) || !p.expectPeek(LPAREN) {
+			return nil
+		}
+		p.nextToken()
+		clause.Values = p.parseExpressionList()
+		for i, value := range clause.Values {
+			if ident, ok := value.(*Identifier); ok && strings.EqualFold(ident.Value, "DEFAULT") {
+				clause.Values[i] = &DefaultValue{}
+			}
+		}
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+	case clause.Matched:
+		p.addError(fmt.Sprintf("expected UPDATE, DELETE or DO NOTHING after WHEN MATCHED THEN, got %s", p.peekToken.Literal))
+		return nil
+	default:
+		p.addError(fmt.Sprintf("expected INSERT or DO NOTHING after WHEN NOT MATCHED THEN, got %s", p.peekToken.Literal))
+		return nil
+	}
+	return clause
+}
+
+// parseRestore parses RESTORE TO LSN <n> | RESTORE TO TIMESTAMP '<time>'
+func (p *Parser) parseRestore() *RestoreStmt {
+	stmt := &RestoreStmt{}
+
+	if !p.expectPeek(TO) {
+		return nil
+	}
+
+	if !p.expectPeek(IDENT) {
+		return nil
 	}
 
-	// Parse WHERE clause
-	if p.peekTokenIs(WHERE) {
-		p.nextToken()
-		p.nextToken()
-		stmt.Where = p.parseExpression()
+	switch strings.ToUpper(p.curToken.Literal) {
+	case "LSN":
+		if !p.expectPeek(INT) {
+			return nil
+		}
+		lsn, err := strconv.ParseUint(p.curToken.Literal, 10, 64)
+		if err != nil {
+			p.addError(fmt.Sprintf("invalid LSN: %s", p.curToken.Literal))
+			return nil
+		}
+		stmt.LSN = lsn
+	case "TIMESTAMP":
+		if !p.expectPeek(STRING) {
+			return nil
+		}
+		stmt.Timestamp = p.curToken.Literal
+	default:
+		p.addError(fmt.Sprintf("expected LSN or TIMESTAMP after RESTORE TO, got %s", p.curToken.Literal))
+		return nil
 	}
 
 	return stmt
 }
 
-// parseDelete parses DELETE statement
-func (p *Parser) parseDelete() *DeleteStmt {
-	stmt := &DeleteStmt{}
+// parseShow parses SHOW STATS | SHOW TABLES | SHOW INDEXES FROM <table> |
+// SHOW ALL | SHOW <variable>
+func (p *Parser) parseShow() Statement {
+	p.nextToken()
+	if !p.curTokenIs(IDENT) && !p.curTokenIs(VARIABLE) {
+		p.addError(fmt.Sprintf("expected STATS, ALL or a variable name after SHOW, got %s", p.curToken.Type))
+		return nil
+	}
+	if p.curTokenIs(VARIABLE) {
+		return &ShowVariableStmt{Name: strings.ToLower(p.curToken.Literal)}
+	}
+	if p.curWordIs("INDEXES") && p.peekTokenIs(FROM) {
+		p.nextToken()
+		if !p.expectPeek(IDENT) {
+			return nil
+		}
+		return &ShowIndexesStmt{TableName: p.curToken.Literal}
+	}
 
-	if !p.expectPeek(FROM) {
+	switch strings.ToUpper(p.curToken.Literal) {
+	case "STATS":
+		return &ShowStatsStmt{}
+	case "TABLES":
+		return &ShowTablesStmt{}
+	case "ALL":
+		return &ShowVariableStmt{}
+	default:
+		return &ShowVariableStmt{Name: strings.ToLower(p.curToken.Literal)}
+	}
+}
+
+// parseDescribe parses DESCRIBE <table>
+func (p *Parser) parseDescribe() Statement {
+	if !p.expectPeek(IDENT) {
+		return nil
+	}
+	return &DescribeStmt{TableName: p.curToken.Literal}
+}
+
+// parseSet parses SET <name> = <expr> | SET @<name> = <expr> (TO may be
+// used instead of =)
+func (p *Parser) parseSet() *SetStmt {
+	p.nextToken()
+	if !p.curTokenIs(IDENT) && !p.curTokenIs(VARIABLE) {
+		p.addError(fmt.Sprintf("expected variable name after SET, got %s", p.curToken.Type))
+		return nil
+	}
+	stmt := &SetStmt{Name: strings.ToLower(p.curToken.Literal)}
+
+	p.nextToken()
+	if !p.curTokenIs(EQ) && !p.curTokenIs(TO) {
+		p.addError(fmt.Sprintf("expected = or TO after SET %s, got %s", stmt.Name, p.curToken.Type))
 		return nil
 	}
 
+	p.nextToken()
+	stmt.Value = p.parseExpression()
+	return stmt
+}
+
+// parseSetGlobal parses SET GLOBAL <name> = <expr> | SET GLOBAL <name> =
+// DEFAULT (TO may be used instead of =)
+func (p *Parser) parseSetGlobal() *SetGlobalStmt {
+	p.nextToken()
 	if !p.expectPeek(IDENT) {
 		return nil
 	}
-	stmt.TableName = p.curToken.Literal
+	return p.parseSettingValue("SET GLOBAL")
+}
 
-	// Parse WHERE clause
-	if p.peekTokenIs(WHERE) {
-		p.nextToken()
-		p.nextToken()
-		stmt.Where = p.parseExpression()
+// parsePragma parses PRAGMA [<name>], which shows engine settings, and
+// PRAGMA <name> = <expr>, which is SET GLOBAL
+func (p *Parser) parsePragma() Statement {
+	if !p.peekTokenIs(IDENT) {
+		return &ShowSettingsStmt{}
+	}
+	p.nextToken()
+	if !p.peekTokenIs(EQ) && !p.peekTokenIs(TO) {
+		return &ShowSettingsStmt{Name: strings.ToLower(p.curToken.Literal)}
+	}
+	return p.parseSettingValue("PRAGMA")
+}
+
+// parseSettingValue parses = <expr> or = DEFAULT after the setting name a
+// SET GLOBAL or PRAGMA statement (named by keyword) is on
+func (p *Parser) parseSettingValue(keyword string) *SetGlobalStmt {
+	stmt := &SetGlobalStmt{Name: strings.ToLower(p.curToken.Literal)}
+
+	p.nextToken()
+	if !p.curTokenIs(EQ) && !p.curTokenIs(TO) {
+		p.addError(fmt.Sprintf("expected = or TO after %s %s, got %s", keyword, stmt.Name, p.curToken.Type))
+		return nil
 	}
 
+	p.nextToken()
+	if p.curWordIs("DEFAULT") {
+		return stmt
+	}
+	stmt.Value = p.parseExpression()
 	return stmt
 }
 
@@ -419,6 +2345,97 @@ func (p *Parser) parseIdentifierList() []string {
 	return list
 }
 
+// parseSelectList parses the columns of a SELECT, each a column name or an
+// aggregate call such as COUNT(*), SUM(<column>), COUNT(DISTINCT <column>)
+// or SUM(CAST(<column> AS <type>)), optionally followed by AS <alias>
+func (p *Parser) parseSelectList(stmt *SelectStmt) bool {
+	for {
+		if !p.curTokenIs(IDENT) {
+			p.addError(fmt.Sprintf("expected column name, got %s", p.curToken.Literal))
+			return false
+		}
+		// Only an aggregate's column can be cast in the select list
+		if p.curWordIs("CAST") && p.peekTokenIs(LPAREN) {
+			p.addError("CAST cannot be selected: cast the column inside an aggregate, as in SUM(CAST(amount AS INTEGER)), or in WHERE, SET or VALUES")
+			return false
+		}
+		if p.peekTokenIs(LPAREN) {
+			call := &AggregateCall{Name: strings.ToUpper(p.curToken.Literal)}
+			if call.Name == "NEXTVAL" || call.Name == "CURRVAL" {
+				p.addError(fmt.Sprintf("%s() cannot be selected: it can only be used in INSERT ... VALUES", call.Name))
+				return false
+			}
+			// Other functions, such as COALESCE(price, qty), take
+			// expressions, which the select list does not
+			notSelectable := fmt.Sprintf("%s() cannot be selected: the select list takes only columns and aggregates of one column", call.Name)
+			nameToken := p.curToken
+			p.nextToken()
+			p.nextToken()
+			if p.curWordIs("DISTINCT") && p.peekTokenIs(IDENT) {
+				call.Distinct = true
+				p.nextToken()
+			}
+			switch {
+			case p.curTokenIs(ASTERISK):
+				call.Column = "*"
+			case p.curWordIs("CAST") && p.peekTokenIs(LPAREN):
+				if !p.parseAggregateCast(call) {
+					return false
+				}
+			case p.curTokenIs(IDENT):
+				column, ok := p.parseColumnRef()
+				if !ok {
+					return false
+				}
+				call.Column = column
+			default:
+				p.errorAt(nameToken, notSelectable)
+				return false
+			}
+			if p.peekTokenIs(COMMA) {
+				p.errorAt(nameToken, notSelectable)
+				return false
+			}
+			if !p.expectPeek(RPAREN) {
+				return false
+			}
+			if stmt.Aggregates == nil {
+				stmt.Aggregates = make([]*AggregateCall, len(stmt.Columns))
+			}
+			stmt.Columns = append(stmt.Columns, call.String())
+			stmt.Aggregates = append(stmt.Aggregates, call)
+		} else {
+			column, ok := p.parseColumnRef()
+			if !ok {
+				return false
+			}
+			stmt.Columns = append(stmt.Columns, column)
+			if stmt.Aggregates != nil {
+				stmt.Aggregates = append(stmt.Aggregates, nil)
+			}
+		}
+
+		if p.peekTokenIs(AS) {
+			p.nextToken()
+			if !p.expectPeek(IDENT) {
+				return false
+			}
+			if stmt.Aliases == nil {
+				stmt.Aliases = make([]string, len(stmt.Columns)-1)
+			}
+			stmt.Aliases = append(stmt.Aliases, p.curToken.Literal)
+		} else if stmt.Aliases != nil {
+			stmt.Aliases = append(stmt.Aliases, "")
+		}
+
+		if !p.peekTokenIs(COMMA) {
+			return true
+		}
+		p.nextToken()
+		p.nextToken()
+	}
+}
+
 // parseExpressionList parses a comma-separated list of expressions
 func (p *Parser) parseExpressionList() []Expression {
 	list := []Expression{}
@@ -434,33 +2451,360 @@ func (p *Parser) parseExpressionList() []Expression {
 	return list
 }
 
-// parseExpression parses an expression
+// ParseExpression parses a standalone expression, such as the stored
+// definition of a generated column
+func ParseExpression(input string) (Expression, error) {
+	p := NewParser(input)
+	expr := p.parseExpression()
+	if len(p.errors) == 0 && !p.peekTokenIs(EOF) {
+		p.peekError(EOF)
+	}
+	if len(p.errors) > 0 {
+		return nil, SyntaxErrors(p.errors)
+	}
+	return expr, nil
+}
+
+// parseExpression parses an expression. Each level below binds more
+// tightly than the one above it: OR, AND, NOT, comparisons, + and -, * and
+// /, JSON paths, and primary expressions, which include parenthesized ones.
+// Binary operators associate to the left.
 func (p *Parser) parseExpression() Expression {
-	left := p.parsePrimary()
+	return p.parseOr()
+}
+
+// parseOr parses a chain of OR over AND expressions
+func (p *Parser) parseOr() Expression {
+	left := p.parseAnd()
+	for left != nil && p.peekTokenIs(OR) {
+		p.nextToken()
+		operator := p.curToken.Literal
+		p.nextToken()
+		left = &BinaryExpr{Left: left, Operator: operator, Right: p.parseAnd()}
+	}
+	return left
+}
+
+// parseAnd parses a chain of AND over NOT expressions
+func (p *Parser) parseAnd() Expression {
+	left := p.parseNot()
+	for left != nil && p.peekTokenIs(AND) {
+		p.nextToken()
+		operator := p.curToken.Literal
+		p.nextToken()
+		left = &BinaryExpr{Left: left, Operator: operator, Right: p.parseNot()}
+	}
+	return left
+}
+
+// parseNot parses a comparison preceded by any number of NOTs, so NOT binds
+// more loosely than comparisons but more tightly than AND and OR
+func (p *Parser) parseNot() Expression {
+	if p.curTokenIs(NOT) {
+		p.nextToken()
+		operand := p.parseNot()
+		if operand == nil {
+			return nil
+		}
+		return &UnaryExpr{Operator: "NOT", Operand: operand}
+	}
+	return p.parseComparison()
+}
 
-	// Check for binary operators
+// parseComparison parses a comparison of two arithmetic expressions, a
+// pattern match with ~, !~, [NOT] REGEXP, [NOT] LIKE or [NOT] ILIKE, an
+// [NOT] IN (SELECT ...) test, a [NOT] BETWEEN range, or a lone arithmetic
+// expression. REGEXP is read as ~ and NOT REGEXP as !~.
+func (p *Parser) parseComparison() Expression {
+	left := p.parseAdditive()
+	if left == nil {
+		return nil
+	}
+	if p.peekTokenIs(NOT) || p.peekWordIs("IN") {
+		return p.parseIn(left)
+	}
+	if p.peekWordIs("REGEXP") {
+		p.nextToken()
+		p.nextToken()
+		return &BinaryExpr{Left: left, Operator: "~", Right: p.parseAdditive()}
+	}
+	if p.peekWordIs("LIKE") || p.peekWordIs("ILIKE") {
+		p.nextToken()
+		operator := strings.ToUpper(p.curToken.Literal)
+		p.nextToken()
+		return &BinaryExpr{Left: left, Operator: operator, Right: p.parseAdditive()}
+	}
+	if p.peekWordIs("BETWEEN") {
+		p.nextToken()
+		return p.parseBetween(left)
+	}
 	if p.peekTokenIs(EQ) || p.peekTokenIs(NEQ) || p.peekTokenIs(LT) ||
 		p.peekTokenIs(GT) || p.peekTokenIs(LTE) || p.peekTokenIs(GTE) ||
-		p.peekTokenIs(AND) || p.peekTokenIs(OR) {
+		p.peekTokenIs(MATCH) || p.peekTokenIs(NMATCH) {
 		p.nextToken()
 		operator := p.curToken.Literal
 		p.nextToken()
-		right := p.parseExpression()
-		return &BinaryExpr{
-			Left:     left,
-			Operator: operator,
-			Right:    right,
-		}
+		return &BinaryExpr{Left: left, Operator: operator, Right: p.parseAdditive()}
+	}
+	return left
+}
+
+// parseAdditive parses a chain of + and - over multiplicative terms
+func (p *Parser) parseAdditive() Expression {
+	left := p.parseMultiplicative()
+	for p.peekTokenIs(PLUS) || p.peekTokenIs(MINUS) {
+		p.nextToken()
+		operator := p.curToken.Literal
+		p.nextToken()
+		left = &BinaryExpr{Left: left, Operator: operator, Right: p.parseMultiplicative()}
+	}
+	return left
+}
+
+// parseMultiplicative parses a chain of * and / over JSON paths
+func (p *Parser) parseMultiplicative() Expression {
+	left := p.parseJSONPath()
+	for p.peekTokenIs(ASTERISK) || p.peekTokenIs(SLASH) {
+		p.nextToken()
+		operator := p.curToken.Literal
+		p.nextToken()
+		left = &BinaryExpr{Left: left, Operator: operator, Right: p.parseJSONPath()}
 	}
+	return left
+}
 
+// parseJSONPath parses a chain of -> and ->> over primary expressions
+func (p *Parser) parseJSONPath() Expression {
+	left := p.parsePrimary()
+	for p.peekTokenIs(ARROW) || p.peekTokenIs(ARROW2) {
+		p.nextToken()
+		operator := p.curToken.Literal
+		p.nextToken()
+		left = &BinaryExpr{Left: left, Operator: operator, Right: p.parsePrimary()}
+	}
 	return left
 }
 
-// parsePrimary parses a primary expression (literal or identifier)
+// parseIn parses [NOT] IN (SELECT ...), or NOT REGEXP, NOT LIKE or NOT
+// ILIKE, after its left operand
+func (p *Parser) parseIn(left Expression) Expression {
+	in := &InExpr{Left: left}
+	if p.peekTokenIs(NOT) {
+		p.nextToken()
+		in.Not = true
+		if p.peekWordIs("REGEXP") {
+			p.nextToken()
+			p.nextToken()
+			return &BinaryExpr{Left: left, Operator: "!~", Right: p.parseAdditive()}
+		}
+		if p.peekWordIs("LIKE") || p.peekWordIs("ILIKE") {
+			p.nextToken()
+			operator := "NOT " + strings.ToUpper(p.curToken.Literal)
+			p.nextToken()
+			return &BinaryExpr{Left: left, Operator: operator, Right: p.parseAdditive()}
+		}
+		if p.peekWordIs("BETWEEN") {
+			p.nextToken()
+			between := p.parseBetween(left)
+			if between == nil {
+				return nil
+			}
+			return &UnaryExpr{Operator: "NOT", Operand: between}
+		}
+	}
+	if !p.peekWordIs("IN") {
+		p.addError(fmt.Sprintf("expected IN, REGEXP, LIKE, ILIKE or BETWEEN after NOT, got %s", p.peekToken.Literal))
+		return nil
+	}
+	p.nextToken()
+	if !p.expectPeek(LPAREN) || !p.expectPeek(SELECT) {
+		return nil
+	}
+	if in.Subquery = p.parseSelect(); in.Subquery == nil {
+		return nil
+	}
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+	return in
+}
+
+// parseBetween parses BETWEEN <low> AND <high> with the parser on BETWEEN,
+// reading it as left >= low AND left <= high so the comparisons can prune
+// partitions and bound index scans like any other. parseIn reads NOT
+// BETWEEN by negating its result.
+func (p *Parser) parseBetween(left Expression) Expression {
+	p.nextToken()
+	low := p.parseAdditive()
+	if low == nil || !p.expectPeek(AND) {
+		return nil
+	}
+	p.nextToken()
+	high := p.parseAdditive()
+	if high == nil {
+		return nil
+	}
+	return &BinaryExpr{
+		Left:     &BinaryExpr{Left: left, Operator: ">=", Right: low},
+		Operator: "AND",
+		Right:    &BinaryExpr{Left: left, Operator: "<=", Right: high},
+	}
+}
+
+// parseCast parses CAST(<expr> AS <type>) with the parser on CAST, reading
+// it as a call of CAST() whose second argument is the type name
+func (p *Parser) parseCast() Expression {
+	p.nextToken()
+	p.nextToken()
+	expr := p.parseExpression()
+	if expr == nil || !p.expectPeek(AS) {
+		return nil
+	}
+	p.nextToken()
+
+	typeName, ok := p.parseCastType()
+	if !ok || !p.expectPeek(RPAREN) {
+		return nil
+	}
+	return &FunctionCall{Name: "CAST", Args: []Expression{expr, &Literal{Value: typeName}}}
+}
+
+// parseAggregateCast parses the CAST(<column> AS <type>) argument of an
+// aggregate call with the parser on CAST
+func (p *Parser) parseAggregateCast(call *AggregateCall) bool {
+	p.nextToken()
+	if !p.expectPeek(IDENT) {
+		return false
+	}
+	column, ok := p.parseColumnRef()
+	if !ok || !p.expectPeek(AS) {
+		return false
+	}
+	p.nextToken()
+	if call.Cast, ok = p.parseCastType(); !ok {
+		return false
+	}
+	call.Column = column
+	return p.expectPeek(RPAREN)
+}
+
+// parseCastType parses a type values can be CAST to, with the parser on
+// its first token, and returns its name as CAST takes it
+func (p *Parser) parseCastType() (string, bool) {
+	var typeName string
+	switch p.curToken.Type {
+	case INTEGER:
+		typeName = "INTEGER"
+	case FLOAT_TYPE:
+		typeName = "FLOAT"
+	case BOOLEAN:
+		typeName = "BOOLEAN"
+	case VARCHAR:
+		typeName = "VARCHAR"
+		if p.peekTokenIs(LPAREN) {
+			p.nextToken()
+			if !p.expectPeek(INT) {
+				return "", false
+			}
+			typeName = "VARCHAR(" + p.curToken.Literal + ")"
+			if !p.expectPeek(RPAREN) {
+				return "", false
+			}
+		}
+	case IDENT:
+		// TEXT is VARCHAR without a size limit
+		if !p.curWordIs("TEXT") {
+			p.addError(fmt.Sprintf("cannot CAST to %s: use INTEGER, FLOAT, BOOLEAN, VARCHAR or TEXT", p.curToken.Literal))
+			return "", false
+		}
+		typeName = "VARCHAR"
+	default:
+		p.addError(fmt.Sprintf("cannot CAST to %s: use INTEGER, FLOAT, BOOLEAN, VARCHAR or TEXT", p.curToken.Literal))
+		return "", false
+	}
+	return typeName, true
+}
+
+// parseFunctionCall parses name(<args>) with the parser on the name
+func (p *Parser) parseFunctionCall() Expression {
+	call := &FunctionCall{Name: strings.ToUpper(p.curToken.Literal), Args: []Expression{}}
+
+	p.nextToken()
+	if p.peekTokenIs(RPAREN) {
+		p.nextToken()
+		return call
+	}
+	if p.peekTokenIs(ASTERISK) {
+		p.nextToken()
+		call.Star = true
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+		return call
+	}
+
+	p.nextToken()
+	if p.curWordIs("DISTINCT") && p.peekTokenIs(IDENT) {
+		call.Distinct = true
+		p.nextToken()
+	}
+	call.Args = p.parseExpressionList()
+	if !p.expectPeek(RPAREN) {
+		return nil
+	}
+	return call
+}
+
+// parsePrimary parses a primary expression (literal, identifier, a
+// negated expression, a parenthesized expression or a subquery)
 func (p *Parser) parsePrimary() Expression {
 	switch p.curToken.Type {
+	case LPAREN:
+		if p.peekTokenIs(SELECT) {
+			p.nextToken()
+			sub := p.parseSelect()
+			if sub == nil || !p.expectPeek(RPAREN) {
+				return nil
+			}
+			return &SubqueryExpr{Select: sub}
+		}
+		p.nextToken()
+		expr := p.parseExpression()
+		if !p.expectPeek(RPAREN) {
+			return nil
+		}
+		return expr
 	case IDENT:
-		return &Identifier{Value: p.curToken.Literal}
+		if p.curWordIs("CAST") && p.peekTokenIs(LPAREN) {
+			return p.parseCast()
+		}
+		if p.peekTokenIs(LPAREN) {
+			return p.parseFunctionCall()
+		}
+		// CURRENT_TIMESTAMP is another name for NOW()
+		if p.curWordIs("CURRENT_TIMESTAMP") {
+			return &FunctionCall{Name: "NOW", Args: []Expression{}}
+		}
+		// INTERVAL '<n> <unit> ...' and DATE '<date>' are read as calls of
+		// INTERVAL() and DATE()
+		if (p.curWordIs("INTERVAL") || p.curWordIs("DATE")) && p.peekTokenIs(STRING) {
+			name := strings.ToUpper(p.curToken.Literal)
+			p.nextToken()
+			return &FunctionCall{Name: name, Args: []Expression{&Literal{Value: p.curToken.Literal}}}
+		}
+		// X'<hex digits>' is binary data, read as a call of UNHEX()
+		if p.curWordIs("X") && p.peekTokenIs(STRING) {
+			p.nextToken()
+			return &FunctionCall{Name: "UNHEX", Args: []Expression{&Literal{Value: p.curToken.Literal}}}
+		}
+		if p.curWordIs("TRUE") || p.curWordIs("FALSE") {
+			return &Literal{Value: p.curWordIs("TRUE")}
+		}
+		name, ok := p.parseColumnRef()
+		if !ok {
+			return nil
+		}
+		return &Identifier{Value: name}
 	case INT:
 		val, _ := strconv.Atoi(p.curToken.Literal)
 		return &Literal{Value: val}
@@ -471,6 +2815,29 @@ func (p *Parser) parsePrimary() Expression {
 		return &Literal{Value: p.curToken.Literal}
 	case NULL:
 		return &NullLiteral{}
+	case MINUS:
+		// A minus sign negates a number in place, and anything else by
+		// subtracting it from 0
+		p.nextToken()
+		operand := p.parseJSONPath()
+		if literal, ok := operand.(*Literal); ok {
+			switch v := literal.Value.(type) {
+			case int:
+				return &Literal{Value: -v}
+			case float64:
+				return &Literal{Value: -v}
+			}
+		}
+		if operand == nil {
+			return nil
+		}
+		return &BinaryExpr{Left: &Literal{Value: 0}, Operator: "-", Right: operand}
+	case VARIABLE:
+		return &VariableRef{Name: strings.ToLower(p.curToken.Literal)}
+	case PARAMETER:
+		// Parameters are replaced by their values before the query is parsed
+		p.addError(fmt.Sprintf("parameter %s has no value", p.curToken.Literal))
+		return nil
 	default:
 		p.addError(fmt.Sprintf("unexpected token in expression: %s", p.curToken.Type))
 		return nil
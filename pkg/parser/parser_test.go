@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestParseRejectsTrailingTokens checks that a complete statement must end
+// the query, or be followed by a semicolon, and that anything after it is
+// reported where it starts
+func TestParseRejectsTrailingTokens(t *testing.T) {
+	tests := []struct {
+		query  string
+		token  string // "" when the query parses
+		column int
+	}{
+		{"DELETE FROM t WHERE id = 1", "", 0},
+		{"DELETE FROM t WHERE id = 1;", "", 0},
+		{"DELETE FROM t WHRE id = 1", "WHRE", 15},
+		{"SELECT * FROM t ORDER BY id LIMIT 5", "LIMIT", 29},
+		{"UPDATE t SET a = 1 WHERE id = 1 garbage", "garbage", 33},
+		{"SELECT * FROM t; SELECT * FROM u", "SELECT", 18},
+	}
+	for _, tt := range tests {
+		_, err := NewParser(tt.query).Parse()
+		if tt.token == "" {
+			if err != nil {
+				t.Errorf("%q: %v", tt.query, err)
+			}
+			continue
+		}
+		var errs SyntaxErrors
+		if !errors.As(err, &errs) || len(errs) != 1 {
+			t.Errorf("%q: got %v, want one syntax error", tt.query, err)
+			continue
+		}
+		if errs[0].Token != tt.token || errs[0].Column != tt.column {
+			t.Errorf("%q: error at %q, column %d, want %q, column %d", tt.query, errs[0].Token, errs[0].Column, tt.token, tt.column)
+		}
+	}
+}
+
+// TestParseSelectListCast checks that CAST is refused as a select list item
+// with an error saying so, and accepted inside an aggregate
+func TestParseSelectListCast(t *testing.T) {
+	if _, err := NewParser("SELECT SUM(CAST(x AS INTEGER)) FROM t").Parse(); err != nil {
+		t.Errorf("CAST in an aggregate: %v", err)
+	}
+	_, err := NewParser("SELECT id, CAST(x AS INTEGER) FROM t").Parse()
+	var errs SyntaxErrors
+	if !errors.As(err, &errs) || errs[0].Token != "CAST" || !strings.Contains(errs[0].Message, "CAST cannot be selected") {
+		t.Errorf("CAST in the select list: got %v", err)
+	}
+}
+
+// TestParseSelectListFunction checks that functions taking expressions are
+// refused in the select list, pointing at the function
+func TestParseSelectListFunction(t *testing.T) {
+	for _, query := range []string{
+		"SELECT COALESCE(price, qty) FROM p",
+		"SELECT id, NULLIF(price, 0) FROM p",
+		"SELECT NEXTVAL('s')",
+		"SELECT NEXTVAL(s) FROM p",
+	} {
+		_, err := NewParser(query).Parse()
+		var errs SyntaxErrors
+		if !errors.As(err, &errs) || !strings.Contains(errs[0].Message, "cannot be selected") {
+			t.Errorf("%s: got %v", query, err)
+		}
+	}
+}
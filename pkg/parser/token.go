@@ -10,10 +10,12 @@ const (
 	WHITESPACE
 
 	// Literals
-	IDENT  // table names, column names
-	INT    // 123
-	STRING // "hello" or 'hello'
-	FLOAT  // 123.45
+	IDENT     // table names, column names
+	INT       // 123
+	STRING    // "hello" or 'hello'
+	FLOAT     // 123.45
+	VARIABLE  // @name
+	PARAMETER // ? or $1
 
 	// Keywords
 	SELECT
@@ -38,15 +40,24 @@ const (
 	OR
 	NOT
 	NULL
+	BACKUP
+	RESTORE
+	TO
+	SHOW
+	AS
 
 	// Data types
 	INTEGER
 	VARCHAR
 	BOOLEAN
 	FLOAT_TYPE
+	CITEXT
 
 	// Operators
 	ASTERISK  // *
+	PLUS      // +
+	MINUS     // -
+	SLASH     // /
 	COMMA     // ,
 	SEMICOLON // ;
 	LPAREN    // (
@@ -57,6 +68,11 @@ const (
 	GT        // >
 	LTE       // <=
 	GTE       // >=
+	ARROW     // ->
+	ARROW2    // ->>
+	MATCH     // ~
+	NMATCH    // !~
+	DOT       // .
 )
 
 // Token represents a lexical token
@@ -65,6 +81,7 @@ type Token struct {
 	Literal string
 	Line    int
 	Column  int
+	Pos     int // byte offset of the token in the input
 }
 
 // Keywords maps string literals to their token types
@@ -91,10 +108,16 @@ var keywords = map[string]TokenType{
 	"OR":      OR,
 	"NOT":     NOT,
 	"NULL":    NULL,
+	"BACKUP":  BACKUP,
+	"RESTORE": RESTORE,
+	"TO":      TO,
+	"SHOW":    SHOW,
+	"AS":      AS,
 	"INTEGER": INTEGER,
 	"VARCHAR": VARCHAR,
 	"BOOLEAN": BOOLEAN,
 	"FLOAT":   FLOAT_TYPE,
+	"CITEXT":  CITEXT,
 }
 
 // LookupIdent checks if an identifier is a keyword
@@ -120,6 +143,10 @@ func (t TokenType) String() string {
 		return "STRING"
 	case FLOAT:
 		return "FLOAT"
+	case VARIABLE:
+		return "VARIABLE"
+	case PARAMETER:
+		return "PARAMETER"
 	case SELECT:
 		return "SELECT"
 	case FROM:
@@ -164,6 +191,16 @@ func (t TokenType) String() string {
 		return "NOT"
 	case NULL:
 		return "NULL"
+	case BACKUP:
+		return "BACKUP"
+	case RESTORE:
+		return "RESTORE"
+	case TO:
+		return "TO"
+	case SHOW:
+		return "SHOW"
+	case AS:
+		return "AS"
 	case INTEGER:
 		return "INTEGER"
 	case VARCHAR:
@@ -172,8 +209,26 @@ func (t TokenType) String() string {
 		return "BOOLEAN"
 	case FLOAT_TYPE:
 		return "FLOAT_TYPE"
+	case CITEXT:
+		return "CITEXT"
+	case ARROW:
+		return "->"
+	case ARROW2:
+		return "->>"
+	case MATCH:
+		return "~"
+	case NMATCH:
+		return "!~"
+	case DOT:
+		return "."
 	case ASTERISK:
 		return "*"
+	case PLUS:
+		return "+"
+	case MINUS:
+		return "-"
+	case SLASH:
+		return "/"
 	case COMMA:
 		return ","
 	case SEMICOLON:
@@ -0,0 +1,244 @@
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/wal"
+)
+
+// WALPath is the leader endpoint followers pull records from
+const WALPath = "/api/replication/wal"
+
+// Batch is the payload served by the leader's WAL endpoint
+type Batch struct {
+	Records []wal.Record `json:"records"`
+	LastLSN uint64       `json:"lastLsn"`
+	// Restores lists the leader's RESTOREs, which discarded records a
+	// follower may have applied
+	Restores []wal.Restore `json:"restores,omitempty"`
+}
+
+// ReadBatch builds the response for a follower asking for records after since
+func ReadBatch(log *wal.Log, since uint64, limit int) Batch {
+	return Batch{
+		Records:  log.ReadFrom(since, limit),
+		LastLSN:  log.LastLSN(),
+		Restores: log.Restores(),
+	}
+}
+
+// nextLSN returns the LSN of the leader's record after lsn, skipping the
+// ones its restores discarded or took
+func nextLSN(restores []wal.Restore, lsn uint64) uint64 {
+	next := lsn + 1
+	for _, restore := range restores {
+		if restore.To < next && next <= restore.LSN {
+			next = restore.LSN + 1
+		}
+	}
+	return next
+}
+
+// Status describes a follower's replication progress
+type Status struct {
+	Leader     string    `json:"leader"`
+	AppliedLSN uint64    `json:"appliedLsn"`
+	LeaderLSN  uint64    `json:"leaderLsn"`
+	LastPoll   time.Time `json:"lastPoll,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Follower pulls WAL records from a leader and applies them locally
+type Follower struct {
+	leaderURL string
+	advertise string // URL the leader may forward reads to, if any
+	header    http.Header
+	exec      *executor.Executor
+	client    *http.Client
+	interval  time.Duration
+	batchSize int
+	mu        sync.RWMutex
+	status    Status
+}
+
+// NewFollower creates a follower for the given leader base URL. The executor
+// must have a WAL attached; its last LSN is the replication position, so a
+// restarted follower resumes where it left off.
+func NewFollower(leaderURL string, exec *executor.Executor) (*Follower, error) {
+	if exec.WAL() == nil {
+		return nil, fmt.Errorf("replication requires a WAL")
+	}
+
+	leaderURL = strings.TrimRight(leaderURL, "/")
+	return &Follower{
+		leaderURL: leaderURL,
+		exec:      exec,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		interval:  time.Second,
+		batchSize: 500,
+		status: Status{
+			Leader:     leaderURL,
+			AppliedLSN: exec.WAL().LastLSN(),
+		},
+	}, nil
+}
+
+// SetAdvertiseURL sets the URL this follower serves queries on. It is sent
+// with every poll so a leader that routes reads to this URL knows how far
+// it has replicated.
+func (f *Follower) SetAdvertiseURL(url string) {
+	f.advertise = strings.TrimRight(url, "/")
+}
+
+// SetUser names the user to pull the WAL as, in the header a leader
+// started with -user-header reads users from
+func (f *Follower) SetUser(header, user string) {
+	f.header = http.Header{}
+	f.header.Set(header, user)
+}
+
+// Run polls the leader until ctx is cancelled
+func (f *Follower) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := f.Sync(ctx); err != nil {
+			log.Printf("replication: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync fetches and applies records until the follower has caught up
+func (f *Follower) Sync(ctx context.Context) error {
+	for {
+		batch, err := f.fetch(ctx, f.exec.WAL().LastLSN())
+		if err != nil {
+			f.setError(err)
+			return err
+		}
+
+		// Records a RESTORE on the leader discarded are taken back here
+		// too, and the records after them fetched again
+		restored, err := f.restore(ctx, batch.Restores)
+		if err != nil {
+			f.setError(err)
+			return err
+		}
+		if restored {
+			continue
+		}
+
+		// A leader that truncated its WAL past our position cannot catch
+		// us up record by record
+		if since := f.exec.WAL().LastLSN(); len(batch.Records) > 0 && batch.Records[0].LSN != nextLSN(batch.Restores, since) {
+			err := fmt.Errorf("leader's WAL starts at LSN %d, after LSN %d applied here: start the follower from a copy of the leader's data", batch.Records[0].LSN, since)
+			f.setError(err)
+			return err
+		}
+
+		for _, rec := range batch.Records {
+			if err := f.exec.Replay(ctx, rec); err != nil {
+				err = fmt.Errorf("failed to apply record: %w", err)
+				f.setError(err)
+				return err
+			}
+		}
+
+		applied := f.exec.WAL().LastLSN()
+		f.mu.Lock()
+		f.status.AppliedLSN = applied
+		f.status.LeaderLSN = batch.LastLSN
+		f.status.LastPoll = time.Now()
+		f.status.Error = ""
+		f.mu.Unlock()
+
+		if len(batch.Records) == 0 || applied >= batch.LastLSN {
+			return nil
+		}
+	}
+}
+
+// restore restores the local database to the point of each of the
+// leader's restores that discarded records applied here, and reports
+// whether it did
+func (f *Follower) restore(ctx context.Context, restores []wal.Restore) (bool, error) {
+	restored := false
+	for _, restore := range restores {
+		applied := f.exec.WAL().LastLSN()
+		if applied <= restore.To || applied > restore.LSN {
+			continue
+		}
+		if _, err := f.exec.RestoreTo(ctx, restore.To); err != nil {
+			return restored, fmt.Errorf("leader was restored to LSN %d, discarding LSN %d applied here, and restoring here failed: %w: start the follower from a copy of the leader's data", restore.To, applied, err)
+		}
+		log.Printf("replication: leader was restored to LSN %d; restored from LSN %d to match", restore.To, applied)
+		restored = true
+	}
+	return restored, nil
+}
+
+// fetch requests the records after since from the leader
+func (f *Follower) fetch(ctx context.Context, since uint64) (*Batch, error) {
+	query := url.Values{}
+	query.Set("since", strconv.FormatUint(since, 10))
+	query.Set("limit", strconv.Itoa(f.batchSize))
+	if f.advertise != "" {
+		query.Set("replica", f.advertise)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.leaderURL+WALPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range f.header {
+		req.Header[name] = values
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach leader: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("leader returned status %d", resp.StatusCode)
+	}
+
+	var batch Batch
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("invalid response from leader: %w", err)
+	}
+	return &batch, nil
+}
+
+// setError records the most recent replication failure
+func (f *Follower) setError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.status.Error = err.Error()
+	f.status.LastPoll = time.Now()
+}
+
+// Status returns the follower's current progress
+func (f *Follower) Status() Status {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.status
+}
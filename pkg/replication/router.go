@@ -0,0 +1,179 @@
+package replication
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryPath is the endpoint reads are forwarded to on a replica
+const QueryPath = "/api/query"
+
+// failureBackoff is how long a replica that failed a forwarded read is
+// skipped
+const failureBackoff = 10 * time.Second
+
+// Replica describes a follower the leader may send reads to
+type Replica struct {
+	URL        string    `json:"url"`
+	AppliedLSN uint64    `json:"appliedLsn"`          // as of its last WAL poll
+	LastPoll   time.Time `json:"lastPoll,omitempty"`  // zero if it never polled
+	FailedAt   time.Time `json:"failedAt,omitempty"`  // last forwarded read that failed
+	LastError  string    `json:"lastError,omitempty"` // why it failed
+	Reads      int64     `json:"reads"`               // reads it has answered
+}
+
+// Router spreads read-only queries over a leader's replicas, round robin
+type Router struct {
+	client   *http.Client
+	mu       sync.Mutex
+	replicas []*Replica
+	next     int
+}
+
+// NewRouter creates a router with no replicas
+func NewRouter() *Router {
+	return &Router{client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Register adds a replica by its base URL, which stays registered until
+// Unregister
+func (r *Router) Register(replicaURL string) (string, error) {
+	replicaURL, err := normalizeURL(replicaURL)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.find(replicaURL) == nil {
+		r.replicas = append(r.replicas, &Replica{URL: replicaURL})
+	}
+	return replicaURL, nil
+}
+
+// Unregister removes a replica, reporting whether it was registered
+func (r *Router) Unregister(replicaURL string) bool {
+	replicaURL, err := normalizeURL(replicaURL)
+	if err != nil {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, replica := range r.replicas {
+		if replica.URL == replicaURL {
+			r.replicas = append(r.replicas[:i], r.replicas[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Polled records a WAL poll from a follower that advertises replicaURL.
+// since is the last LSN it has applied. Polls never register a replica, as
+// anyone can poll: those of replicas that are not registered are ignored.
+func (r *Router) Polled(replicaURL string, since uint64) error {
+	replicaURL, err := normalizeURL(replicaURL)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if replica := r.find(replicaURL); replica != nil {
+		replica.AppliedLSN = since
+		replica.LastPoll = time.Now()
+	}
+	return nil
+}
+
+// Replicas returns the registered replicas
+func (r *Router) Replicas() []Replica {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	replicas := make([]Replica, len(r.replicas))
+	for i, replica := range r.replicas {
+		replicas[i] = *replica
+	}
+	return replicas
+}
+
+// Pick returns the next replica to send a read to that has not failed
+// recently and is not known to be behind minLSN, or false if there is none
+func (r *Router) Pick(minLSN uint64) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for range r.replicas {
+		replica := r.replicas[r.next%len(r.replicas)]
+		r.next++
+		if now.Sub(replica.FailedAt) < failureBackoff {
+			continue
+		}
+		// Replicas that never polled are checked by the replica itself
+		if !replica.LastPoll.IsZero() && replica.AppliedLSN < minLSN {
+			continue
+		}
+		return replica.URL, true
+	}
+	return "", false
+}
+
+// Forward sends a query request to a replica and returns its response,
+// which the caller must close. A replica that cannot be reached or is
+// unable to answer (503) is skipped for a while.
+func (r *Router) Forward(ctx context.Context, replicaURL string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, replicaURL+QueryPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+
+	resp, err := r.client.Do(req)
+	if err == nil && resp.StatusCode == http.StatusServiceUnavailable {
+		resp.Body.Close()
+		err = fmt.Errorf("replica returned status %d", resp.StatusCode)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if replica := r.find(replicaURL); replica != nil {
+		if err != nil {
+			replica.FailedAt = time.Now()
+			replica.LastError = err.Error()
+		} else {
+			replica.Reads++
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// find returns a registered replica; callers must hold r.mu
+func (r *Router) find(replicaURL string) *Replica {
+	for _, replica := range r.replicas {
+		if replica.URL == replicaURL {
+			return replica
+		}
+	}
+	return nil
+}
+
+// normalizeURL checks a replica base URL and strips its trailing slash
+func normalizeURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid replica URL %q: expected http(s)://host:port", raw)
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
@@ -0,0 +1,101 @@
+// Package scheduler runs the jobs created with CREATE JOB on their cron
+// schedules
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/cron"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+)
+
+// Scheduler checks the jobs once a minute and runs those that are due
+type Scheduler struct {
+	exec *executor.Executor
+
+	mu      sync.Mutex
+	running map[string]bool // jobs whose previous run has not finished
+	wg      sync.WaitGroup
+	stop    context.CancelFunc
+}
+
+// New creates a scheduler for the jobs of an executor
+func New(exec *executor.Executor) *Scheduler {
+	return &Scheduler{exec: exec, running: make(map[string]bool)}
+}
+
+// Start starts checking the jobs in the background
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stop = cancel
+	s.wg.Add(1)
+	go s.run(ctx)
+}
+
+// Stop stops the scheduler and waits for running jobs to finish
+func (s *Scheduler) Stop() {
+	if s.stop != nil {
+		s.stop()
+	}
+	s.wg.Wait()
+}
+
+// run wakes at the start of every minute until ctx is cancelled
+func (s *Scheduler) run(ctx context.Context) {
+	defer s.wg.Done()
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case t := <-timer.C:
+			s.runDue(t)
+		}
+	}
+}
+
+// runDue starts the jobs scheduled for the minute containing t. A job whose
+// previous run is still going is skipped rather than run twice.
+func (s *Scheduler) runDue(t time.Time) {
+	jobs, err := s.exec.Jobs()
+	if err != nil {
+		log.Printf("scheduler: failed to read jobs: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		schedule, err := cron.Parse(job.Schedule)
+		if err != nil {
+			log.Printf("scheduler: job %s: %v", job.Name, err)
+			continue
+		}
+		if !schedule.Matches(t) {
+			continue
+		}
+
+		s.mu.Lock()
+		if s.running[job.Name] {
+			s.mu.Unlock()
+			log.Printf("scheduler: job %s is still running, skipping", job.Name)
+			continue
+		}
+		s.running[job.Name] = true
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func(job executor.Job) {
+			defer s.wg.Done()
+			if err := s.exec.RunJob(context.Background(), job); err != nil {
+				log.Printf("scheduler: job %s failed: %v", job.Name, err)
+			}
+			s.mu.Lock()
+			delete(s.running, job.Name)
+			s.mu.Unlock()
+		}(job)
+	}
+}
@@ -0,0 +1,105 @@
+package seed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// parseJSON parses a JSON fixture file, keeping tables and columns in the
+// order they are written
+func parseJSON(data []byte) ([]Fixture, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	lineAt := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+	fail := func(err error) error {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			return fmt.Errorf("line %d: %w", lineAt(syntaxErr.Offset), err)
+		}
+		return err
+	}
+
+	if err := expectDelim(dec, '{', "an object of tables"); err != nil {
+		return nil, fail(err)
+	}
+
+	fixtures := []Fixture{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, fail(err)
+		}
+		fixture := Fixture{Table: token.(string)}
+
+		if err := expectDelim(dec, '[', "a list of rows for table "+fixture.Table); err != nil {
+			return nil, fail(err)
+		}
+		for dec.More() {
+			// The offset is just past the comma, so skip to the row itself
+			offset := dec.InputOffset()
+			for offset < int64(len(data)) && strings.ContainsRune(" \t\r\n,", rune(data[offset])) {
+				offset++
+			}
+			row := Row{Line: lineAt(offset)}
+
+			if err := expectDelim(dec, '{', "an object for each row of table "+fixture.Table); err != nil {
+				return nil, fail(err)
+			}
+			for dec.More() {
+				token, err := dec.Token()
+				if err != nil {
+					return nil, fail(err)
+				}
+				var raw interface{}
+				if err := dec.Decode(&raw); err != nil {
+					return nil, fail(err)
+				}
+				value, err := jsonValue(raw)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: column %s: %w", row.Line, token, err)
+				}
+				row.Columns = append(row.Columns, token.(string))
+				row.Values = append(row.Values, value)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, fail(err)
+			}
+			fixture.Rows = append(fixture.Rows, row)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, fail(err)
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures, nil
+}
+
+// expectDelim reads the next token, which must be delim
+func expectDelim(dec *json.Decoder, delim json.Delim, what string) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s, got %v", what, token)
+	}
+	return nil
+}
+
+// jsonValue converts a decoded JSON scalar to a column value
+func jsonValue(raw interface{}) (interface{}, error) {
+	switch v := raw.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return int(n), nil
+		}
+		return v.Float64()
+	default:
+		return nil, fmt.Errorf("expected a string, number, boolean or null, got %T", raw)
+	}
+}
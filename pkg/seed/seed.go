@@ -0,0 +1,180 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/parser"
+)
+
+// Fixture holds the rows a fixture file lists for one table
+type Fixture struct {
+	Table string
+	Rows  []Row
+}
+
+// Row is one fixture row, with its columns in the order the file lists them
+type Row struct {
+	Line    int // line the row starts on
+	Columns []string
+	Values  []interface{}
+}
+
+// Summary reports what a load inserted
+type Summary struct {
+	Files int
+	Rows  map[string]int // rows inserted per table
+}
+
+// Loader loads fixture files into a database through its executor, so rows
+// are type-checked, constrained and logged like any INSERT
+type Loader struct {
+	exec *executor.Executor
+
+	// Reset deletes (and purges) the existing rows of every table the
+	// fixtures name before loading, so a load can be repeated
+	Reset bool
+}
+
+// NewLoader creates a loader that executes against exec
+func NewLoader(exec *executor.Executor) *Loader {
+	return &Loader{exec: exec}
+}
+
+// LoadDir loads every fixture file in dir, in the order FixtureFiles lists
+// them
+func (l *Loader) LoadDir(ctx context.Context, dir string) (*Summary, error) {
+	paths, err := FixtureFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return l.LoadFiles(ctx, paths...)
+}
+
+// FixtureFiles lists the .json, .yaml and .yml files in dir in file name
+// order, so prefixes like 01_ and 02_ control the order tables are filled in
+func FixtureFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture directory: %w", err)
+	}
+
+	paths := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .json, .yaml or .yml files in %s", dir)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// LoadFiles loads fixture files in the order given, stopping at the first
+// row that fails. Rows inserted before the failure are kept.
+func (l *Loader) LoadFiles(ctx context.Context, paths ...string) (*Summary, error) {
+	files := make([][]Fixture, len(paths))
+	for i, path := range paths {
+		fixtures, err := ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files[i] = fixtures
+	}
+
+	summary := &Summary{Rows: map[string]int{}}
+	if l.Reset {
+		if err := l.reset(ctx, files); err != nil {
+			return summary, err
+		}
+	}
+
+	for i, fixtures := range files {
+		for _, fixture := range fixtures {
+			for _, row := range fixture.Rows {
+				if err := l.insert(ctx, fixture.Table, row); err != nil {
+					return summary, fmt.Errorf("%s:%d: table %s: %w", paths[i], row.Line, fixture.Table, err)
+				}
+				summary.Rows[fixture.Table]++
+			}
+		}
+		summary.Files++
+	}
+	return summary, nil
+}
+
+// reset empties the fixture tables, last loaded first so rows referring to
+// earlier tables go before the rows they refer to
+func (l *Loader) reset(ctx context.Context, files [][]Fixture) error {
+	tables := []string{}
+	seen := map[string]bool{}
+	for _, fixtures := range files {
+		for _, fixture := range fixtures {
+			if !seen[fixture.Table] {
+				seen[fixture.Table] = true
+				tables = append(tables, fixture.Table)
+			}
+		}
+	}
+
+	for i := len(tables) - 1; i >= 0; i-- {
+		// PURGE drops the rows DELETE only hides on soft-delete tables
+		for _, query := range []string{"DELETE FROM " + tables[i], "PURGE " + tables[i]} {
+			if _, err := l.exec.Query(ctx, query); err != nil {
+				return fmt.Errorf("failed to reset table %s: %w", tables[i], err)
+			}
+		}
+	}
+	return nil
+}
+
+// insert executes the INSERT for one fixture row
+func (l *Loader) insert(ctx context.Context, table string, row Row) error {
+	if len(row.Columns) == 0 {
+		return fmt.Errorf("row has no columns")
+	}
+	literals := make([]string, len(row.Values))
+	for i, value := range row.Values {
+		literal, err := parser.FormatLiteral(value)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", row.Columns[i], err)
+		}
+		literals[i] = literal
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(row.Columns, ", "), strings.Join(literals, ", "))
+	_, err := l.exec.Query(ctx, query)
+	return err
+}
+
+// ReadFile reads a JSON or YAML fixture file: a mapping from table name to
+// a list of rows, each a mapping from column name to value
+func ReadFile(path string) ([]Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture file: %w", err)
+	}
+
+	var fixtures []Fixture
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		fixtures, err = parseJSON(data)
+	} else {
+		fixtures, err = parseYAML(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return fixtures, nil
+}
@@ -0,0 +1,54 @@
+package seed
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/executor"
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/storage"
+)
+
+// TestBooleansAndNegativeNumbers checks that YAML and JSON fixtures load
+// true, false and negative numbers
+func TestBooleansAndNegativeNumbers(t *testing.T) {
+	store, err := storage.NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	exec := executor.NewExecutor(store)
+	ctx := context.Background()
+	if _, err := exec.Query(ctx, "CREATE TABLE t (id INTEGER PRIMARY KEY, ok BOOLEAN, n INTEGER, f FLOAT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"01_t.yaml": "t:\n  - id: 1\n    ok: true\n    n: -3\n    f: -1.5\n  - id: 2\n    ok: false\n    n: 4\n    f: -2\n",
+		"02_t.json": `{"t": [{"id": 3, "ok": true, "n": -9, "f": -0.5}]}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := NewLoader(exec).LoadDir(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := exec.Query(ctx, "SELECT * FROM t ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]interface{}{
+		{1, true, -3, -1.5},
+		{2, false, 4, -2.0},
+		{3, true, -9, -0.5},
+	}
+	if !reflect.DeepEqual(result.Rows, want) {
+		t.Errorf("seeded rows %v, want %v", result.Rows, want)
+	}
+}
@@ -0,0 +1,161 @@
+package seed
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseYAML parses a YAML fixture file. Only the block layout fixtures
+// need is understood: table names at the top level, each holding a list of
+// rows written as "- column: value" lines, with scalar values.
+//
+//	users:
+//	  - id: 1
+//	    name: Alice   # comments are allowed
+//	orders: []
+func parseYAML(data string) ([]Fixture, error) {
+	fixtures := []Fixture{}
+	var fixture *Fixture
+	var row *Row
+	rowIndent := -1
+
+	for i, raw := range strings.Split(data, "\n") {
+		lineNo := i + 1
+		line := strings.TrimRight(stripComment(raw), " \t\r")
+		if strings.TrimSpace(line) == "" || line == "---" {
+			continue
+		}
+		if strings.ContainsRune(line[:len(line)-len(strings.TrimLeft(line, " \t"))], '\t') {
+			return nil, fmt.Errorf("line %d: indent with spaces, not tabs", lineNo)
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		text := line[indent:]
+
+		// A table name starts a new list of rows
+		if indent == 0 {
+			key, value, ok := splitKey(text)
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected a table name followed by ':'", lineNo)
+			}
+			if value != "" && value != "[]" {
+				return nil, fmt.Errorf("line %d: table %s must hold a list of rows", lineNo, key)
+			}
+			fixtures = append(fixtures, Fixture{Table: key})
+			fixture = &fixtures[len(fixtures)-1]
+			row, rowIndent = nil, -1
+			continue
+		}
+		if fixture == nil {
+			return nil, fmt.Errorf("line %d: expected a table name", lineNo)
+		}
+
+		// "- " starts a row, and may hold its first column
+		if text == "-" || strings.HasPrefix(text, "- ") {
+			fixture.Rows = append(fixture.Rows, Row{Line: lineNo})
+			row = &fixture.Rows[len(fixture.Rows)-1]
+			rest := strings.TrimLeft(strings.TrimPrefix(text, "-"), " ")
+			rowIndent = len(line) - len(rest)
+			if rest == "" {
+				rowIndent = -1 // set by the first column line
+				continue
+			}
+			text, indent = rest, rowIndent
+		}
+		if row == nil {
+			return nil, fmt.Errorf("line %d: expected a row starting with '- '", lineNo)
+		}
+		if rowIndent == -1 {
+			rowIndent = indent
+		}
+		if indent != rowIndent {
+			return nil, fmt.Errorf("line %d: column is not aligned with the rest of its row", lineNo)
+		}
+
+		key, value, ok := splitKey(text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'column: value'", lineNo)
+		}
+		parsed, err := yamlScalar(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: column %s: %w", lineNo, key, err)
+		}
+		row.Columns = append(row.Columns, key)
+		row.Values = append(row.Values, parsed)
+	}
+	return fixtures, nil
+}
+
+// splitKey splits "key: value" into its key and (possibly empty) value
+func splitKey(text string) (string, string, bool) {
+	key, value, found := strings.Cut(text, ":")
+	if !found || (value != "" && value[0] != ' ') {
+		return "", "", false
+	}
+	key = strings.TrimSpace(key)
+	if unquoted, err := strconv.Unquote(key); err == nil {
+		key = unquoted
+	}
+	if key == "" {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(value), true
+}
+
+// stripComment removes a trailing "# comment" outside quotes
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// yamlScalar converts a YAML scalar to a column value
+func yamlScalar(text string) (interface{}, error) {
+	switch {
+	case text == "" || text == "~" || text == "null" || text == "Null" || text == "NULL":
+		return nil, nil
+	case strings.HasPrefix(text, `"`):
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid double-quoted string %s", text)
+		}
+		return s, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("invalid single-quoted string %s", text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") ||
+		strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">"):
+		return nil, fmt.Errorf("only single-line scalar values are supported, got %s", text)
+	}
+
+	switch strings.ToLower(text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if n, err := strconv.Atoi(text); err == nil {
+		return n, nil
+	}
+	// ParseFloat also reads words like "Infinity", which are strings here
+	if f, err := strconv.ParseFloat(text, 64); err == nil && strings.ContainsAny(text, "0123456789") {
+		return f, nil
+	}
+	return text, nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Blob is a BLOB value. Its bytes are held in a string so that, like other
+// values, it can be compared with == and used as a map key.
+type Blob string
+
+func init() {
+	// Row values are stored as interfaces, which gob only decodes into
+	// registered types
+	gob.Register(Blob(""))
+}
+
+// String renders the bytes in hex the way PostgreSQL shows bytea values,
+// e.g. \x48690a
+func (b Blob) String() string {
+	return `\x` + hex.EncodeToString([]byte(b))
+}
+
+// Bytes returns the bytes of the value
+func (b Blob) Bytes() []byte {
+	return []byte(b)
+}
+
+// MarshalJSON encodes the bytes in base64, as encoding/json does []byte
+func (b Blob) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]byte(b))
+}
+
+// ParseBlob reads a string written to a BLOB column: \x followed by hex
+// digits is the bytes they spell, and anything else stands for its own
+// bytes
+func ParseBlob(value string) (Blob, error) {
+	digits, ok := strings.CutPrefix(value, `\x`)
+	if !ok {
+		return Blob(value), nil
+	}
+	data, err := hex.DecodeString(digits)
+	if err != nil {
+		return "", fmt.Errorf("invalid hex BLOB %q", value)
+	}
+	return Blob(data), nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Collations supported for VARCHAR columns
+const (
+	CollationBinary  = "binary"  // byte order, exact equality
+	CollationNoCase  = "nocase"  // case-insensitive ordering and equality
+	CollationUnicode = "unicode" // ignores case and accents when ordering
+)
+
+// ParseCollation validates a collation name, returning it in canonical form
+func ParseCollation(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", CollationBinary:
+		return CollationBinary, nil
+	case CollationNoCase:
+		return CollationNoCase, nil
+	case CollationUnicode:
+		return CollationUnicode, nil
+	default:
+		return "", fmt.Errorf("unknown collation: %s", name)
+	}
+}
+
+// CompareStrings orders two strings under a collation, returning -1, 0 or 1.
+// An empty collation is binary.
+//
+// The unicode collation sorts by letters first, ignoring case and accents,
+// and breaks ties in byte order so only identical strings compare equal.
+func CompareStrings(a, b, collation string) int {
+	switch collation {
+	case CollationNoCase:
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	case CollationUnicode:
+		if c := strings.Compare(foldAccents(a), foldAccents(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// CompareCollation returns the collation the column's values are compared
+// under: nocase for CITEXT, otherwise the declared collation
+func (c Column) CompareCollation() string {
+	if c.DataType == TypeCIText {
+		return CollationNoCase
+	}
+	return c.Collation
+}
+
+// collatedEqual reports whether two column values are equal under the
+// column's collation, for constraint checks
+func collatedEqual(a, b interface{}, col Column) bool {
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if aok && bok {
+		return CompareStrings(as, bs, col.CompareCollation()) == 0
+	}
+	return a == b
+}
+
+// CollationKey returns a value that is equal for exactly the values
+// collatedEqual treats as equal under the column's collation, so constraint
+// checks, DISTINCT aggregates and GROUP BY can hash them
+func CollationKey(value interface{}, col Column) interface{} {
+	if s, ok := value.(string); ok && col.CompareCollation() == CollationNoCase {
+		return strings.ToLower(s)
+	}
+	return value
+}
+
+// accentFolds maps lower-case accented Latin letters to their base letters
+var accentFolds = map[rune]string{}
+
+func init() {
+	for _, group := range []string{
+		"aàáâãäåāăą", "cçćĉċč", "dďđ", "eèéêëēĕėęě", "gĝğġģ", "hĥħ",
+		"iìíîïĩīĭįı", "jĵ", "kķ", "lĺļľŀł", "nñńņň", "oòóôõöøōŏő",
+		"rŕŗř", "sśŝşš", "tţťŧ", "uùúûüũūŭůűų", "wŵ", "yýÿŷ", "zźżž",
+	} {
+		runes := []rune(group)
+		for _, r := range runes[1:] {
+			accentFolds[r] = string(runes[0])
+		}
+	}
+	accentFolds['ß'] = "ss"
+	accentFolds['æ'] = "ae"
+	accentFolds['œ'] = "oe"
+}
+
+// foldAccents lower-cases s and strips accents from Latin letters,
+// including combining marks of decomposed text
+func foldAccents(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if folded, ok := accentFolds[r]; ok {
+			b.WriteString(folded)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Table layouts
+const (
+	LayoutRow      = "row"
+	LayoutColumnar = "columnar"
+)
+
+// ParseLayout checks a layout name, returning it in its canonical form
+func ParseLayout(name string) (string, error) {
+	switch layout := strings.ToLower(name); layout {
+	case LayoutRow, LayoutColumnar:
+		return layout, nil
+	default:
+		return "", fmt.Errorf("unknown table layout %s: expected row or columnar", name)
+	}
+}
+
+// Columnar reports whether a table uses the columnar layout
+func (s *Schema) Columnar() bool {
+	return s.Layout == LayoutColumnar
+}
+
+// columnStore holds the rows of a columnar table column by column, so a
+// scan reading a few columns of a wide table walks one slice per column
+// instead of every row's values. Vectors are built the first time a scan
+// asks for their column.
+type columnStore struct {
+	rows    []*Row          // the rows the vectors hold, soft-deleted ones left out
+	vectors [][]interface{} // vectors[c][i] is column c of rows[i]; nil until built
+}
+
+// ScanColumns returns the rows of a table, leaving out soft-deleted ones,
+// and a vector holding each of the given columns' values in the same
+// order. The slices are shared between scans and must not be modified.
+func (t *Table) ScanColumns(columns []int) ([]*Row, [][]interface{}) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	// Scans share t.mu, so building the store and its vectors is
+	// serialised separately
+	t.columnsMu.Lock()
+	defer t.columnsMu.Unlock()
+
+	store := t.columnStore()
+	vectors := make([][]interface{}, len(columns))
+	for i, c := range columns {
+		if store.vectors[c] == nil {
+			vector := make([]interface{}, len(store.rows))
+			for j, row := range store.rows {
+				vector[j] = row.Values[c]
+			}
+			store.vectors[c] = vector
+		}
+		vectors[i] = store.vectors[c]
+	}
+	return store.rows, vectors
+}
+
+// columnStore returns the table's column store, building it from the rows
+// if a write discarded it; callers must hold t.mu and t.columnsMu
+func (t *Table) columnStore() *columnStore {
+	if t.columns == nil {
+		rows := make([]*Row, 0, len(t.Rows))
+		for _, row := range t.Rows {
+			if !row.Deleted {
+				rows = append(rows, row)
+			}
+		}
+		t.columns = &columnStore{rows: rows, vectors: make([][]interface{}, len(t.Schema.Columns))}
+	}
+	return t.columns
+}
+
+// appendColumns adds newly inserted rows to the column store, if there is
+// one; callers must hold t.mu exclusively
+func (t *Table) appendColumns(rows []*Row) {
+	store := t.columns
+	if store == nil {
+		return
+	}
+	store.rows = append(store.rows, rows...)
+	for c, vector := range store.vectors {
+		if vector == nil {
+			continue
+		}
+		for _, row := range rows {
+			vector = append(vector, row.Values[c])
+		}
+		store.vectors[c] = vector
+	}
+}
+
+// discardColumns drops the column store after rows were changed or
+// removed, so the next scan builds it afresh; callers must hold t.mu
+// exclusively
+func (t *Table) discardColumns() {
+	t.columns = nil
+}
+
+// columnChunk is one column of a columnar table file. Values are kept in
+// a slice of their type rather than as interfaces, with simple compression:
+// integers are stored as the difference from the previous value, so
+// sequential and slowly changing columns take a byte or two per value, and
+// strings with few distinct values as codes into a dictionary.
+type columnChunk struct {
+	Nulls   []int // positions of NULLs, which the value slices leave out
+	Ints    []int // INTEGER values, each as the difference from the one before
+	Floats  []float64
+	Bools   []bool
+	Strings []string // string values of a column with many distinct values
+	Dict    []string // distinct string values of a column with few of them
+	Codes   []int    // index into Dict of each string value
+}
+
+// columnarFile is how a columnar table's rows are written to its file
+type columnarFile struct {
+	Count   int           // rows
+	Columns []columnChunk // one per schema column
+	Deleted []int         // positions of soft-deleted rows
+}
+
+// encodeColumnar turns rows into the columnar file form
+func encodeColumnar(schema *Schema, rows []*Row) (*columnarFile, error) {
+	file := &columnarFile{Count: len(rows), Columns: make([]columnChunk, len(schema.Columns))}
+	for c, col := range schema.Columns {
+		chunk := &file.Columns[c]
+		previous := 0
+		codes := map[string]int{}
+		for i, row := range rows {
+			value := row.Values[c]
+			if blob, ok := value.(Blob); ok {
+				// decodeColumnar restores BLOBs from the column type
+				value = string(blob)
+			}
+			switch v := value.(type) {
+			case nil:
+				chunk.Nulls = append(chunk.Nulls, i)
+			case int:
+				chunk.Ints = append(chunk.Ints, v-previous)
+				previous = v
+			case float64:
+				chunk.Floats = append(chunk.Floats, v)
+			case float32:
+				chunk.Floats = append(chunk.Floats, float64(v))
+			case bool:
+				chunk.Bools = append(chunk.Bools, v)
+			case string:
+				code, ok := codes[v]
+				if !ok {
+					code = len(chunk.Dict)
+					codes[v] = code
+					chunk.Dict = append(chunk.Dict, v)
+				}
+				chunk.Codes = append(chunk.Codes, code)
+			default:
+				return nil, fmt.Errorf("column %s: cannot store %T in a columnar table", col.Name, v)
+			}
+		}
+		// A dictionary only pays off when values repeat
+		if len(chunk.Dict) > len(chunk.Codes)/2 {
+			chunk.Strings = make([]string, len(chunk.Codes))
+			for i, code := range chunk.Codes {
+				chunk.Strings[i] = chunk.Dict[code]
+			}
+			chunk.Dict, chunk.Codes = nil, nil
+		}
+	}
+	for i, row := range rows {
+		if row.Deleted {
+			file.Deleted = append(file.Deleted, i)
+		}
+	}
+	return file, nil
+}
+
+// decodeColumnar rebuilds the rows of a columnar file
+func decodeColumnar(schema *Schema, file *columnarFile) ([]*Row, error) {
+	if len(file.Columns) != len(schema.Columns) {
+		return nil, fmt.Errorf("columnar file has %d columns but table has %d", len(file.Columns), len(schema.Columns))
+	}
+	rows := make([]*Row, file.Count)
+	for i := range rows {
+		rows[i] = &Row{Values: make([]interface{}, len(schema.Columns))}
+	}
+	for c, chunk := range file.Columns {
+		values := make([]interface{}, 0, file.Count)
+		previous := 0
+		for _, delta := range chunk.Ints {
+			previous += delta
+			values = append(values, previous)
+		}
+		for _, v := range chunk.Floats {
+			values = append(values, v)
+		}
+		for _, v := range chunk.Bools {
+			values = append(values, v)
+		}
+		for _, v := range chunk.Strings {
+			values = append(values, v)
+		}
+		// Rows share the boxed copy of each dictionary value
+		dict := make([]interface{}, len(chunk.Dict))
+		for i, v := range chunk.Dict {
+			dict[i] = v
+		}
+		for _, code := range chunk.Codes {
+			if code < 0 || code >= len(dict) {
+				return nil, fmt.Errorf("column %s: dictionary code %d out of range", schema.Columns[c].Name, code)
+			}
+			values = append(values, dict[code])
+		}
+
+		if schema.Columns[c].DataType == TypeBlob {
+			for i, v := range values {
+				if s, ok := v.(string); ok {
+					values[i] = Blob(s)
+				}
+			}
+		}
+
+		if len(values)+len(chunk.Nulls) != len(rows) {
+			return nil, fmt.Errorf("column %s has %d values, expected %d", schema.Columns[c].Name, len(values)+len(chunk.Nulls), len(rows))
+		}
+		nulls := chunk.Nulls
+		for i, row := range rows {
+			if len(nulls) > 0 && nulls[0] == i {
+				nulls = nulls[1:]
+				continue
+			}
+			if len(values) == 0 {
+				return nil, fmt.Errorf("column %s: invalid NULL positions", schema.Columns[c].Name)
+			}
+			row.Values[c], values = values[0], values[1:]
+		}
+	}
+	for _, i := range file.Deleted {
+		if i < 0 || i >= len(rows) {
+			return nil, fmt.Errorf("deleted row %d out of range", i)
+		}
+		rows[i].Deleted = true
+	}
+	return rows, nil
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+)
+
+// dictionary holds the distinct values of a dictionary-encoded column.
+// Rows store the entry for their value rather than a copy of it, so a
+// column of a few distinct values costs one interface slot per row; reads
+// see ordinary strings and need no decoding.
+type dictionary struct {
+	values map[string]interface{} // value -> the boxed copy rows share
+}
+
+// intern returns the shared copy of a value, adding it if it is new.
+// Values other than strings (NULL) are returned as they are.
+func (d *dictionary) intern(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	if shared, ok := d.values[s]; ok {
+		return shared
+	}
+	d.values[s] = value
+	return value
+}
+
+// DictionaryUsage describes the dictionary of one dictionary-encoded column
+type DictionaryUsage struct {
+	Column      string `json:"column"`
+	Values      int    `json:"values"`      // distinct values
+	MemoryBytes int64  `json:"memoryBytes"` // estimated size of the values and their map
+}
+
+// internValues replaces the values of dictionary-encoded columns with
+// their shared copies; callers must hold t.mu exclusively
+func (t *Table) internValues(values []interface{}) {
+	for i, col := range t.Schema.Columns {
+		if col.Dictionary && i < len(values) {
+			values[i] = t.dictionary(col.Name).intern(values[i])
+		}
+	}
+}
+
+// pruneDictionaries builds the dictionaries afresh from the rows, so
+// values no row holds any more are dropped. The rows already hold shared
+// copies and are left alone, as readers may be using them. Callers must
+// hold t.mu exclusively.
+func (t *Table) pruneDictionaries() {
+	if len(t.dictionaries) == 0 {
+		return
+	}
+	t.dictionaries = nil
+	for _, row := range t.Rows {
+		for i, col := range t.Schema.Columns {
+			if !col.Dictionary || i >= len(row.Values) {
+				continue
+			}
+			if s, ok := row.Values[i].(string); ok {
+				dict := t.dictionary(col.Name)
+				if _, ok := dict.values[s]; !ok {
+					dict.values[s] = row.Values[i]
+				}
+			}
+		}
+	}
+}
+
+// dictionary returns the dictionary of a column, creating it if needed;
+// callers must hold t.mu exclusively
+func (t *Table) dictionary(column string) *dictionary {
+	if t.dictionaries == nil {
+		t.dictionaries = make(map[string]*dictionary)
+	}
+	dict, ok := t.dictionaries[column]
+	if !ok {
+		dict = &dictionary{values: make(map[string]interface{})}
+		t.dictionaries[column] = dict
+	}
+	return dict
+}
+
+// dictionaryUsage returns the size of each dictionary, in column order;
+// callers must hold t.mu
+func (t *Table) dictionaryUsage() []DictionaryUsage {
+	usage := []DictionaryUsage{}
+	for name, dict := range t.dictionaries {
+		du := DictionaryUsage{Column: name, Values: len(dict.values)}
+		for value := range dict.values {
+			// The map key and the shared boxed value
+			du.MemoryBytes += 2*EstimateSize(value) + valueOverhead
+		}
+		usage = append(usage, du)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		return t.Schema.GetColumnIndex(usage[i].Column) < t.Schema.GetColumnIndex(usage[j].Column)
+	})
+	return usage
+}
+
+// SetColumnDictionary turns dictionary encoding of a column on or off,
+// re-encoding the rows the table already has
+func (s *Storage) SetColumnDictionary(tableName, column string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	table, ok := s.tables[tableName]
+	if !ok {
+		return fmt.Errorf("table %s does not exist", tableName)
+	}
+	idx := table.Schema.GetColumnIndex(column)
+	if idx == -1 {
+		return fmt.Errorf("column %s does not exist in table %s", column, tableName)
+	}
+	if dt := table.Schema.Columns[idx].DataType; enabled && dt != TypeVarchar && dt != TypeCIText && dt != TypeText {
+		return fmt.Errorf("dictionary encoding is only supported on VARCHAR, TEXT and CITEXT columns")
+	}
+
+	table.mu.Lock()
+	defer table.mu.Unlock()
+	columns := append([]Column{}, table.Schema.Columns...)
+	columns[idx].Dictionary = enabled
+	table.Schema.Columns = columns
+	if enabled {
+		// Replace the rows rather than change them, as readers may hold them
+		for i, row := range table.Rows {
+			values := append([]interface{}{}, row.Values...)
+			table.internValues(values)
+			table.Rows[i] = &Row{Values: values, Deleted: row.Deleted}
+		}
+		table.discardColumns()
+	} else {
+		delete(table.dictionaries, column)
+	}
+	table.dirty.Store(true)
+	return nil
+}
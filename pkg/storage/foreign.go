@@ -0,0 +1,13 @@
+package storage
+
+// ForeignTable describes where a foreign table's rows come from. A foreign
+// table stores no rows: they are read from the source on every scan.
+type ForeignTable struct {
+	Server  string            // kind of source, e.g. "csv"
+	Options map[string]string // server-specific options, e.g. the file path
+}
+
+// Foreign reports whether the table is a foreign table
+func (s *Schema) Foreign() bool {
+	return s.ForeignTable != nil
+}
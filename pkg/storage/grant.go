@@ -0,0 +1,193 @@
+package storage
+
+import "fmt"
+
+// Privileges that can be granted on a table
+const (
+	PrivilegeSelect = "SELECT" // read the table, or some of its columns
+	PrivilegeInsert = "INSERT" // insert rows
+	PrivilegeUpdate = "UPDATE" // update rows
+	PrivilegeDelete = "DELETE" // delete rows
+	PrivilegeUnmask = "UNMASK" // read masked columns unmasked
+)
+
+// Grant is a privilege a user holds on a table. A table has at most one
+// grant per user and privilege.
+type Grant struct {
+	User      string
+	Privilege string
+	Columns   []string // columns a SELECT grant covers, nil for all
+}
+
+// GrantsOf returns the grants of a privilege on a table
+func (s *Schema) GrantsOf(privilege string) []Grant {
+	grants := []Grant{}
+	for _, grant := range s.Grants {
+		if grant.Privilege == privilege {
+			grants = append(grants, grant)
+		}
+	}
+	return grants
+}
+
+// GrantPrivilege gives a user a privilege on a table, adding to the
+// columns it covers if the user already holds it
+func (s *Storage) GrantPrivilege(tableName string, grant Grant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, err := s.grantTable(tableName, grant)
+	if err != nil {
+		return err
+	}
+
+	grants := []Grant{}
+	for _, existing := range table.Schema.Grants {
+		if existing.User != grant.User || existing.Privilege != grant.Privilege {
+			grants = append(grants, existing)
+			continue
+		}
+		if existing.Columns == nil {
+			return nil // already covers every column
+		}
+		if grant.Columns != nil {
+			grant.Columns = unionColumns(existing.Columns, grant.Columns)
+		}
+	}
+
+	// Replace rather than modify the slice concurrent readers may hold
+	table.Schema.Grants = append(grants, grant)
+	table.dirty.Store(true)
+	return nil
+}
+
+// RevokePrivilege takes a privilege, or some of the columns it covers,
+// away from a user
+func (s *Storage) RevokePrivilege(tableName string, grant Grant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, err := s.grantTable(tableName, grant)
+	if err != nil {
+		return err
+	}
+
+	grants := []Grant{}
+	found := false
+	for _, existing := range table.Schema.Grants {
+		if existing.User != grant.User || existing.Privilege != grant.Privilege {
+			grants = append(grants, existing)
+			continue
+		}
+		found = true
+		if grant.Columns == nil {
+			continue
+		}
+		remaining := existing.Columns
+		if remaining == nil {
+			remaining = make([]string, len(table.Schema.Columns))
+			for i, col := range table.Schema.Columns {
+				remaining[i] = col.Name
+			}
+		}
+		remaining = subtractColumns(remaining, grant.Columns)
+		if len(remaining) > 0 {
+			grants = append(grants, Grant{User: existing.User, Privilege: existing.Privilege, Columns: remaining})
+		}
+	}
+	if !found {
+		return fmt.Errorf("user %s has no %s privilege on table %s", grant.User, grant.Privilege, tableName)
+	}
+
+	table.Schema.Grants = grants
+	table.dirty.Store(true)
+	return nil
+}
+
+// grantTable validates a grant and returns its table; callers must hold
+// the lock
+func (s *Storage) grantTable(tableName string, grant Grant) (*Table, error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+	table, ok := s.tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("table %s does not exist", tableName)
+	}
+	switch grant.Privilege {
+	case PrivilegeSelect:
+	case PrivilegeInsert, PrivilegeUpdate, PrivilegeDelete, PrivilegeUnmask:
+		if grant.Columns != nil {
+			return nil, fmt.Errorf("%s cannot be granted on columns", grant.Privilege)
+		}
+	default:
+		return nil, fmt.Errorf("unknown privilege %s (supported: %s, %s, %s, %s, %s)", grant.Privilege,
+			PrivilegeSelect, PrivilegeInsert, PrivilegeUpdate, PrivilegeDelete, PrivilegeUnmask)
+	}
+	for _, name := range grant.Columns {
+		if table.Schema.GetColumnIndex(name) == -1 {
+			return nil, fmt.Errorf("column %s does not exist in table %s", name, tableName)
+		}
+	}
+	return table, nil
+}
+
+// SetColumnMask sets (or, with an empty mask, removes) the masking function
+// of a column
+func (s *Storage) SetColumnMask(tableName, column, mask string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	table, ok := s.tables[tableName]
+	if !ok {
+		return fmt.Errorf("table %s does not exist", tableName)
+	}
+	idx := table.Schema.GetColumnIndex(column)
+	if idx == -1 {
+		return fmt.Errorf("column %s does not exist in table %s", column, tableName)
+	}
+	if mask == "" && table.Schema.Columns[idx].Mask == "" {
+		return fmt.Errorf("column %s of table %s is not masked", column, tableName)
+	}
+
+	columns := append([]Column{}, table.Schema.Columns...)
+	columns[idx].Mask = mask
+	table.Schema.Columns = columns
+	table.dirty.Store(true)
+	return nil
+}
+
+// unionColumns returns the columns in a or b, in that order
+func unionColumns(a, b []string) []string {
+	union := append([]string{}, a...)
+	for _, name := range b {
+		if !containsColumn(union, name) {
+			union = append(union, name)
+		}
+	}
+	return union
+}
+
+// subtractColumns returns the columns in a that are not in b
+func subtractColumns(a, b []string) []string {
+	difference := []string{}
+	for _, name := range a {
+		if !containsColumn(b, name) {
+			difference = append(difference, name)
+		}
+	}
+	return difference
+}
+
+// containsColumn reports whether names contains a column name
+func containsColumn(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
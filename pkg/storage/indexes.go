@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Techbite-sudo/pesapal-rdbms/pkg/index"
+)
+
+// IndexInfo describes an index on a table
+type IndexInfo struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary"`
+	Type    string   `json:"type"`
+}
+
+// Indexes returns the indexes of a table in column order, followed by those
+// of its table-level UNIQUE constraints. Indexes are created for PRIMARY KEY
+// and UNIQUE columns and constraints and named like PostgreSQL names them:
+// <table>_pkey and <table>_<column>_key.
+func (s *Storage) Indexes(tableName string) ([]IndexInfo, error) {
+	table, err := s.GetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := []IndexInfo{}
+	for _, col := range table.Schema.Columns {
+		if !s.indexMgr.HasIndex(tableName, col.Name) {
+			continue
+		}
+		info := IndexInfo{
+			Name:    fmt.Sprintf("%s_%s_key", tableName, col.Name),
+			Columns: []string{col.Name},
+			Unique:  true,
+			Primary: col.PrimaryKey,
+			Type:    "btree",
+		}
+		if col.PrimaryKey {
+			info.Name = tableName + "_pkey"
+		}
+		indexes = append(indexes, info)
+	}
+	for _, constraint := range table.Schema.UniqueConstraints {
+		indexes = append(indexes, IndexInfo{
+			Name:    constraint.Name,
+			Columns: append([]string{}, constraint.Columns...),
+			Unique:  true,
+			Type:    "btree",
+		})
+	}
+	return indexes, nil
+}
+
+// rowsChanged marks a table whose rows were written, so the next checkpoint
+// saves it and the next index lookup rebuilds its indexes; callers must
+// hold t.mu exclusively
+func (t *Table) rowsChanged() {
+	t.dirty.Store(true)
+	t.indexed = false
+}
+
+// LookupRows returns the rows of a table whose column equals value under
+// the column's collation, found through the column's index, and whether
+// the index could answer the lookup. It cannot when the column has no
+// index, value is NULL or not of the column's type, or the rows break the
+// index's uniqueness. Soft-deleted rows are left out.
+//
+// Indexes are rebuilt from the rows the first time they are searched after
+// a write, so a table written between every lookup gains nothing from them.
+func (s *Storage) LookupRows(table *Table, column string, value interface{}) ([]*Row, bool) {
+	name := table.Schema.TableName
+	idx := table.Schema.GetColumnIndex(column)
+	if idx == -1 || !s.indexMgr.HasIndex(name, column) {
+		return nil, false
+	}
+	key, ok := indexKey(value, table.Schema.Columns[idx])
+	if !ok {
+		return nil, false
+	}
+
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+	// Lookups share t.mu, so building the indexes is serialised separately
+	table.indexMu.Lock()
+	defer table.indexMu.Unlock()
+
+	if !s.indexesBuilt(table) {
+		return nil, false
+	}
+	pos, found := s.indexMgr.Search(name, column, key)
+	if !found {
+		return []*Row{}, true
+	}
+	return []*Row{table.Rows[pos]}, true
+}
+
+// IndexBound is one end of a range of values looked up through an index
+type IndexBound struct {
+	Value     interface{}
+	Inclusive bool
+}
+
+// LookupRange returns the rows of a table whose column lies between lower
+// and upper, found through the column's index, and whether the index could
+// answer the lookup. A nil bound leaves that end of the range open. Besides
+// the cases LookupRows cannot answer, it cannot when the column is compared
+// under the unicode collation, whose order is not the order of its keys.
+// The rows are returned in table order, as a scan would find them.
+func (s *Storage) LookupRange(table *Table, column string, lower, upper *IndexBound) ([]*Row, bool) {
+	name := table.Schema.TableName
+	idx := table.Schema.GetColumnIndex(column)
+	if idx == -1 || !s.indexMgr.HasIndex(name, column) {
+		return nil, false
+	}
+	col := table.Schema.Columns[idx]
+	if col.CompareCollation() == CollationUnicode {
+		return nil, false
+	}
+	start, ok := rangeBound(lower, col)
+	if !ok {
+		return nil, false
+	}
+	end, ok := rangeBound(upper, col)
+	if !ok {
+		return nil, false
+	}
+
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+	table.indexMu.Lock()
+	defer table.indexMu.Unlock()
+
+	if !s.indexesBuilt(table) {
+		return nil, false
+	}
+	entries, _ := s.indexMgr.Range(name, column, start, end)
+	positions := make([]int, len(entries))
+	for i, entry := range entries {
+		positions[i] = entry.RowIndex
+	}
+	sort.Ints(positions)
+
+	rows := make([]*Row, len(positions))
+	for i, pos := range positions {
+		rows[i] = table.Rows[pos]
+	}
+	return rows, true
+}
+
+// rangeBound returns the index bound a value bound is searched with in a
+// column, and whether its value can be indexed there
+func rangeBound(bound *IndexBound, col Column) (*index.Bound, bool) {
+	if bound == nil {
+		return nil, true
+	}
+	key, ok := indexKey(bound.Value, col)
+	if !ok {
+		return nil, false
+	}
+	return &index.Bound{Key: key, Inclusive: bound.Inclusive}, true
+}
+
+// indexesBuilt builds a table's indexes if it was written since they were
+// last built, and reports whether they hold its rows; callers must hold
+// t.mu and t.indexMu
+func (s *Storage) indexesBuilt(t *Table) bool {
+	if !t.indexed {
+		t.indexErr = s.buildIndexes(t)
+		t.indexed = true
+	}
+	return t.indexErr == nil
+}
+
+// buildIndexes fills a table's indexes with the positions of its rows;
+// callers must hold t.mu and t.indexMu
+func (s *Storage) buildIndexes(t *Table) error {
+	name := t.Schema.TableName
+	for _, column := range s.indexMgr.GetIndexedColumns(name) {
+		idx := t.Schema.GetColumnIndex(column)
+		if idx == -1 || !s.indexMgr.ResetIndex(name, column) {
+			continue
+		}
+		col := t.Schema.Columns[idx]
+		for pos, row := range t.Rows {
+			if row.Deleted || row.Values[idx] == nil {
+				continue
+			}
+			key, ok := indexKey(row.Values[idx], col)
+			if !ok {
+				return fmt.Errorf("column %s holds %T values, which cannot be indexed", column, row.Values[idx])
+			}
+			if err := s.indexMgr.Insert(name, column, key, pos); err != nil {
+				return fmt.Errorf("index on column %s: %w", column, err)
+			}
+		}
+	}
+	return nil
+}
+
+// indexKey returns the key a value is indexed under in a column, so keys
+// are equal exactly when the values are under the column's collation, and
+// whether the column's type and the value's can be indexed at all
+func indexKey(value interface{}, col Column) (interface{}, bool) {
+	switch col.DataType {
+	case TypeInteger:
+		n, ok := value.(int)
+		return n, ok
+	case TypeFloat:
+		switch v := value.(type) {
+		case float64:
+			return v, true
+		case int:
+			return float64(v), true
+		}
+	case TypeVarchar, TypeText, TypeCIText:
+		if str, ok := value.(string); ok {
+			return CollationKey(str, col), true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lockFileName is the file in the data directory that is locked by the
+// process owning the database. It holds the owner's PID.
+const lockFileName = "LOCK"
+
+// ErrReadOnly is returned when writing to storage opened read-only
+var ErrReadOnly = errors.New("storage is opened read-only")
+
+// errWouldBlock is returned by lockFile when another process holds the lock
+var errWouldBlock = errors.New("lock is held by another process")
+
+// LockedError is returned when another process owns the data directory
+type LockedError struct {
+	Dir string
+	PID int // 0 if unknown
+}
+
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("database %s is locked by another process", e.Dir)
+	}
+	return fmt.Sprintf("database is locked by PID %d", e.PID)
+}
+
+// dirLock is an exclusive lock on a data directory
+type dirLock struct {
+	file *os.File
+}
+
+// lockDataDir takes the exclusive lock on dir and records our PID in it
+func lockDataDir(dir string) (*dirLock, error) {
+	path := filepath.Join(dir, lockFileName)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(file); err != nil {
+		pid := readLockPID(file)
+		file.Close()
+		if errors.Is(err, errWouldBlock) {
+			return nil, &LockedError{Dir: dir, PID: pid}
+		}
+		return nil, fmt.Errorf("failed to lock data directory: %w", err)
+	}
+
+	if err := file.Truncate(0); err == nil {
+		_, err = file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+		if err == nil {
+			err = file.Sync()
+		}
+	}
+	if err != nil {
+		unlockFile(file)
+		file.Close()
+		return nil, fmt.Errorf("failed to write lock file: %w", err)
+	}
+
+	return &dirLock{file: file}, nil
+}
+
+// release gives up the lock. The lock file is left in place so that a
+// process waiting on it is not racing against a new file.
+func (l *dirLock) release() error {
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return fmt.Errorf("failed to unlock data directory: %w", err)
+	}
+	return l.file.Close()
+}
+
+// readLockPID returns the PID recorded in a lock file, or 0
+func readLockPID(file *os.File) int {
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 32))
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// lockFile is a no-op on platforms without flock; the lock file still
+// records the owner's PID
+func lockFile(file *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op on platforms without flock
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,468 @@
+package storage
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Partitioning methods
+const (
+	PartitionRange = "RANGE"
+	PartitionHash  = "HASH"
+)
+
+// PartitionBound is the set of partition key values a partition holds.
+// A range partition holds From (inclusive) to To (exclusive), where a nil
+// bound is unbounded; a hash partition holds the values whose hash modulo
+// Modulus is Remainder.
+type PartitionBound struct {
+	Table     string
+	From      interface{}
+	To        interface{}
+	Modulus   int // non-zero for a hash partition
+	Remainder int
+
+	// Dir is the directory holding the partition's table file, when it is
+	// not the data directory. A relative Dir is inside the data directory.
+	Dir string
+}
+
+// Partitioned reports whether the table is partitioned
+func (s *Schema) Partitioned() bool {
+	return s.PartitionKey != ""
+}
+
+// HashPartitioned reports whether the table is hash-partitioned
+func (s *Schema) HashPartitioned() bool {
+	return s.PartitionMethod == PartitionHash
+}
+
+// Contains reports whether a partition key value falls within the bound.
+// NULL falls within no bound.
+func (b PartitionBound) Contains(value interface{}, col Column) bool {
+	if value == nil {
+		return false
+	}
+	if b.Modulus > 0 {
+		hash, err := HashKey(value, col)
+		return err == nil && hash%uint64(b.Modulus) == uint64(b.Remainder)
+	}
+	if b.From != nil {
+		if c, err := CompareValues(value, b.From, col.CompareCollation()); err != nil || c < 0 {
+			return false
+		}
+	}
+	if b.To != nil {
+		if c, err := CompareValues(value, b.To, col.CompareCollation()); err != nil || c >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps reports whether two bounds share any value
+func (b PartitionBound) overlaps(other PartitionBound, col Column) bool {
+	// Hash buckets share values when their remainders agree modulo the
+	// greatest common divisor of their moduli
+	if b.Modulus > 0 {
+		g := gcd(b.Modulus, other.Modulus)
+		return b.Remainder%g == other.Remainder%g
+	}
+
+	// Each range must start before the other ends
+	startsBefore := func(from, to interface{}) bool {
+		if from == nil || to == nil {
+			return true
+		}
+		c, err := CompareValues(from, to, col.CompareCollation())
+		return err == nil && c < 0
+	}
+	return startsBefore(b.From, other.To) && startsBefore(other.From, b.To)
+}
+
+// String renders the bound as it appears after FOR VALUES
+func (b PartitionBound) String() string {
+	if b.Modulus > 0 {
+		return fmt.Sprintf("WITH (MODULUS %d, REMAINDER %d)", b.Modulus, b.Remainder)
+	}
+	format := func(value interface{}, unbounded string) string {
+		switch v := value.(type) {
+		case nil:
+			return unbounded
+		case string:
+			return fmt.Sprintf("'%s'", v)
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+	return fmt.Sprintf("FROM (%s) TO (%s)", format(b.From, "MINVALUE"), format(b.To, "MAXVALUE"))
+}
+
+// PartitionFor returns the partition of a partitioned table that holds rows
+// with the given key value
+func (s *Schema) PartitionFor(value interface{}) (string, error) {
+	col := s.Columns[s.GetColumnIndex(s.PartitionKey)]
+	for _, bound := range s.Partitions {
+		if bound.Contains(value, col) {
+			return bound.Table, nil
+		}
+	}
+	if value == nil {
+		return "", fmt.Errorf("no partition of %s for NULL %s", s.TableName, s.PartitionKey)
+	}
+	return "", fmt.Errorf("no partition of %s for %s = %v", s.TableName, s.PartitionKey, value)
+}
+
+// PartitionBound returns the bound of a partition, looked up in its parent
+func (s *Storage) PartitionBound(partition *Table) (PartitionBound, Column, bool) {
+	parentName := partition.Schema.PartitionOf
+	if parentName == "" {
+		return PartitionBound{}, Column{}, false
+	}
+	parent, err := s.GetTable(parentName)
+	if err != nil {
+		return PartitionBound{}, Column{}, false
+	}
+	col := parent.Schema.Columns[parent.Schema.GetColumnIndex(parent.Schema.PartitionKey)]
+	for _, bound := range parent.Schema.Partitions {
+		if bound.Table == partition.Schema.TableName {
+			return bound, col, true
+		}
+	}
+	return PartitionBound{}, Column{}, false
+}
+
+// Partitions returns the partitions of a partitioned table, in the order
+// they were attached
+func (s *Storage) Partitions(table *Table) []*Table {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	partitions := make([]*Table, 0, len(table.Schema.Partitions))
+	for _, bound := range table.Schema.Partitions {
+		if partition, ok := s.tables[bound.Table]; ok {
+			partitions = append(partitions, partition)
+		}
+	}
+	return partitions
+}
+
+// MarkDirty marks a table as changed so the next checkpoint writes it. A
+// partitioned table holds no rows itself, but is marked when a statement
+// writes its partitions through it, so its checkpoint LSN covers that
+// statement.
+func (t *Table) MarkDirty() {
+	t.dirty.Store(true)
+}
+
+// AttachPartition makes an existing table a partition of a partitioned
+// table. The table must have the same columns as the parent, and all of
+// its rows must fall within the bound. If the bound has a Dir, the table's
+// file is moved there.
+func (s *Storage) AttachPartition(parentName string, bound PartitionBound) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	parent, ok := s.tables[parentName]
+	if !ok {
+		return fmt.Errorf("table %s does not exist", parentName)
+	}
+	child, ok := s.tables[bound.Table]
+	if !ok {
+		return fmt.Errorf("table %s does not exist", bound.Table)
+	}
+	if !parent.Schema.Partitioned() {
+		return fmt.Errorf("table %s is not partitioned", parentName)
+	}
+	if child.Schema.Partitioned() {
+		return fmt.Errorf("cannot attach partitioned table %s as a partition", bound.Table)
+	}
+	if child.Schema.Foreign() {
+		return fmt.Errorf("cannot attach foreign table %s as a partition", bound.Table)
+	}
+	if child.Schema.PartitionOf != "" {
+		return fmt.Errorf("table %s is already a partition of %s", bound.Table, child.Schema.PartitionOf)
+	}
+	if err := sameColumns(parent.Schema, child.Schema); err != nil {
+		return err
+	}
+
+	keyIndex := parent.Schema.GetColumnIndex(parent.Schema.PartitionKey)
+	col := parent.Schema.Columns[keyIndex]
+	if err := checkBound(parent.Schema, bound, col); err != nil {
+		return err
+	}
+	for _, other := range parent.Schema.Partitions {
+		if bound.overlaps(other, col) {
+			return fmt.Errorf("partition %s would overlap partition %s", bound.Table, other.Table)
+		}
+	}
+
+	child.mu.RLock()
+	for _, row := range child.Rows {
+		if !bound.Contains(row.Values[keyIndex], col) {
+			child.mu.RUnlock()
+			return fmt.Errorf("table %s has a row with %s = %v outside %s", bound.Table, col.Name, row.Values[keyIndex], bound)
+		}
+	}
+	child.mu.RUnlock()
+
+	if err := s.moveTable(child, bound.Dir); err != nil {
+		return err
+	}
+
+	// Replace rather than append to the slice concurrent readers may hold
+	parent.Schema.Partitions = append(append([]PartitionBound{}, parent.Schema.Partitions...), bound)
+	child.Schema.PartitionOf = parentName
+	parent.dirty.Store(true)
+	child.dirty.Store(true)
+	return nil
+}
+
+// checkBound checks that a bound suits a partitioned table's method and key
+func checkBound(parent *Schema, bound PartitionBound, col Column) error {
+	if parent.HashPartitioned() {
+		if bound.Modulus <= 0 {
+			return fmt.Errorf("partitions of hash-partitioned table %s need FOR VALUES WITH (MODULUS .., REMAINDER ..)", parent.TableName)
+		}
+		if bound.Remainder < 0 || bound.Remainder >= bound.Modulus {
+			return fmt.Errorf("partition remainder %d must be between 0 and modulus %d", bound.Remainder, bound.Modulus-1)
+		}
+		return nil
+	}
+
+	if bound.Modulus > 0 {
+		return fmt.Errorf("partitions of range-partitioned table %s need FOR VALUES FROM (..) TO (..)", parent.TableName)
+	}
+	for _, value := range []interface{}{bound.From, bound.To} {
+		if value != nil {
+			if err := ValidateValue(value, Column{Name: col.Name, DataType: col.DataType}); err != nil {
+				return fmt.Errorf("partition bound: %w", err)
+			}
+		}
+	}
+	if bound.From != nil && bound.To != nil {
+		if c, _ := CompareValues(bound.From, bound.To, col.CompareCollation()); c >= 0 {
+			return fmt.Errorf("partition bound %s is empty", bound)
+		}
+	}
+	return nil
+}
+
+// DetachPartition turns a partition back into an ordinary table, moving
+// its file into the data directory if it was stored elsewhere
+func (s *Storage) DetachPartition(parentName, partitionName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	parent, ok := s.tables[parentName]
+	if !ok {
+		return fmt.Errorf("table %s does not exist", parentName)
+	}
+	child, ok := s.tables[partitionName]
+	if !ok || child.Schema.PartitionOf != parentName {
+		return fmt.Errorf("table %s is not a partition of %s", partitionName, parentName)
+	}
+
+	// Only the parent records where a partition is stored
+	if err := s.moveTable(child, ""); err != nil {
+		return err
+	}
+	s.detach(parent, child)
+	return nil
+}
+
+// detach removes a partition from its parent; callers must hold s.mu
+func (s *Storage) detach(parent, child *Table) {
+	partitions := []PartitionBound{}
+	for _, bound := range parent.Schema.Partitions {
+		if bound.Table != child.Schema.TableName {
+			partitions = append(partitions, bound)
+		}
+	}
+	parent.Schema.Partitions = partitions
+	child.Schema.PartitionOf = ""
+	parent.dirty.Store(true)
+	child.dirty.Store(true)
+}
+
+// moveTable rewrites a table's file in dir (the data directory if empty)
+// and removes the old one; callers must hold s.mu
+func (s *Storage) moveTable(table *Table, dir string) error {
+	name := table.Schema.TableName
+	oldPath := s.getTableFilePath(name)
+	oldDir, moved := s.locations[name]
+	if dir == "" {
+		delete(s.locations, name)
+	} else {
+		if err := os.MkdirAll(s.partitionDir(dir), 0755); err != nil {
+			return fmt.Errorf("failed to create partition directory: %w", err)
+		}
+		s.locations[name] = s.partitionDir(dir)
+	}
+	if s.getTableFilePath(name) == oldPath {
+		return nil
+	}
+
+	if err := s.saveTable(table); err != nil {
+		if moved {
+			s.locations[name] = oldDir
+		} else {
+			delete(s.locations, name)
+		}
+		return err
+	}
+	if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old table file: %w", err)
+	}
+	return nil
+}
+
+// partitionDir resolves a partition directory, which is relative to the
+// data directory unless absolute
+func (s *Storage) partitionDir(dir string) string {
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(s.dataDir, dir)
+}
+
+// loadPartitions loads the partitions stored outside the data directory
+// of the partitioned tables loaded so far; callers must hold s.mu or be
+// opening the storage
+func (s *Storage) loadPartitions() error {
+	var parents []*Table
+	for _, table := range s.tables {
+		parents = append(parents, table)
+	}
+	for _, parent := range parents {
+		for _, bound := range parent.Schema.Partitions {
+			if bound.Dir == "" {
+				continue
+			}
+			s.locations[bound.Table] = s.partitionDir(bound.Dir)
+			table, err := readTableFile(s.getTableFilePath(bound.Table))
+			if err != nil {
+				return fmt.Errorf("failed to load partition %s from %s: %w", bound.Table, s.partitionDir(bound.Dir), err)
+			}
+			s.tables[bound.Table] = table
+		}
+	}
+	return nil
+}
+
+// HashKey hashes a partition key value. Values that compare equal under
+// the column's type and collation hash alike.
+func HashKey(value interface{}, col Column) (uint64, error) {
+	h := fnv.New64a()
+	var buf [8]byte
+	switch col.DataType {
+	case TypeInteger:
+		v, ok := value.(int)
+		if !ok {
+			return 0, fmt.Errorf("column %s expects INTEGER, got %T", col.Name, value)
+		}
+		binary.BigEndian.PutUint64(buf[:], uint64(v))
+		h.Write(buf[:])
+	case TypeFloat:
+		var f float64
+		switch v := value.(type) {
+		case float64:
+			f = v
+		case int:
+			f = float64(v)
+		default:
+			return 0, fmt.Errorf("column %s expects FLOAT, got %T", col.Name, value)
+		}
+		if f == 0 {
+			f = 0 // -0 equals 0
+		}
+		binary.BigEndian.PutUint64(buf[:], math.Float64bits(f))
+		h.Write(buf[:])
+	case TypeVarchar, TypeCIText, TypeText:
+		if _, ok := value.(string); !ok {
+			return 0, fmt.Errorf("column %s expects %s, got %T", col.Name, col.DataType, value)
+		}
+		h.Write([]byte(CollationKey(value, col).(string)))
+	default:
+		return 0, fmt.Errorf("cannot hash %s column %s", col.DataType, col.Name)
+	}
+	return h.Sum64(), nil
+}
+
+// gcd returns the greatest common divisor of two positive integers
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// sameColumns checks that a table can hold a partitioned table's rows
+func sameColumns(parent, child *Schema) error {
+	if len(parent.Columns) != len(child.Columns) {
+		return fmt.Errorf("table %s has %d columns but %s has %d", child.TableName, len(child.Columns), parent.TableName, len(parent.Columns))
+	}
+	for i, col := range parent.Columns {
+		other := child.Columns[i]
+		if col.Name != other.Name || col.DataType != other.DataType || col.Size != other.Size ||
+			col.PrimaryKey != other.PrimaryKey || col.Unique != other.Unique || col.NotNull != other.NotNull ||
+			col.Generated != other.Generated || col.Virtual != other.Virtual || col.Collation != other.Collation {
+			return fmt.Errorf("column %s of %s does not match %s", other.Name, child.TableName, parent.TableName)
+		}
+	}
+	return nil
+}
+
+// CompareValues orders two non-NULL values of the same kind, returning -1,
+// 0 or 1. Integers and floats compare numerically, strings use collation
+// and false comes before true.
+func CompareValues(a, b interface{}, collation string) (int, error) {
+	switch av := a.(type) {
+	case int:
+		switch bv := b.(type) {
+		case int:
+			return cmp.Compare(av, bv), nil
+		case float64:
+			return cmp.Compare(float64(av), bv), nil
+		}
+	case float64:
+		switch bv := b.(type) {
+		case int:
+			return cmp.Compare(av, float64(bv)), nil
+		case float64:
+			return cmp.Compare(av, bv), nil
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return CompareStrings(av, bv, collation), nil
+		}
+	case Blob:
+		if bv, ok := b.(Blob); ok {
+			return cmp.Compare(av, bv), nil
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0, nil
+			case bv:
+				return -1, nil
+			default:
+				return 1, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %T and %T", a, b)
+}
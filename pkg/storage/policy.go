@@ -0,0 +1,61 @@
+package storage
+
+import "fmt"
+
+// Policy is a row-level security policy: a user sees (and may write) only
+// the rows of the table for which one of its policies holds
+type Policy struct {
+	Name  string
+	Using string // condition a row must meet, e.g. "tenant = @current_user"
+}
+
+// CreatePolicy adds a row-level security policy to a table
+func (s *Storage) CreatePolicy(tableName string, policy Policy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	table, ok := s.tables[tableName]
+	if !ok {
+		return fmt.Errorf("table %s does not exist", tableName)
+	}
+	for _, existing := range table.Schema.Policies {
+		if existing.Name == policy.Name {
+			return fmt.Errorf("policy %s already exists on table %s", policy.Name, tableName)
+		}
+	}
+
+	// Replace rather than append to the slice concurrent readers may hold
+	table.Schema.Policies = append(append([]Policy{}, table.Schema.Policies...), policy)
+	table.dirty.Store(true)
+	return nil
+}
+
+// DropPolicy removes a row-level security policy from a table
+func (s *Storage) DropPolicy(tableName, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	table, ok := s.tables[tableName]
+	if !ok {
+		return fmt.Errorf("table %s does not exist", tableName)
+	}
+	policies := []Policy{}
+	for _, policy := range table.Schema.Policies {
+		if policy.Name != name {
+			policies = append(policies, policy)
+		}
+	}
+	if len(policies) == len(table.Schema.Policies) {
+		return fmt.Errorf("policy %s does not exist on table %s", name, tableName)
+	}
+
+	table.Schema.Policies = policies
+	table.dirty.Store(true)
+	return nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import "fmt"
+
+// SetSoftDelete turns soft deletion on or off for a table. Rows already
+// soft-deleted stay hidden either way until they are purged.
+func (s *Storage) SetSoftDelete(tableName string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	table, ok := s.tables[tableName]
+	if !ok {
+		return fmt.Errorf("table %s does not exist", tableName)
+	}
+	schema := table.Schema
+	if schema.Partitioned() || schema.PartitionOf != "" || schema.Foreign() {
+		return fmt.Errorf("soft delete is not supported on partitioned, partition or foreign table %s", tableName)
+	}
+	if schema.SoftDelete == enabled {
+		if enabled {
+			return fmt.Errorf("soft delete is already enabled on table %s", tableName)
+		}
+		return fmt.Errorf("soft delete is not enabled on table %s", tableName)
+	}
+
+	schema.SoftDelete = enabled
+	table.dirty.Store(true)
+	return nil
+}
+
+// SoftDeleteRows marks the rows matching a condition as deleted. They keep
+// their PRIMARY KEY and UNIQUE values until purged.
+func (t *Table) SoftDeleteRows(condition func(*Row) bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := 0
+	for i, row := range t.Rows {
+		if row.Deleted || condition != nil && !condition(row) {
+			continue
+		}
+		// Replace the row rather than flag it, as readers may hold it
+		t.Rows[i] = &Row{Values: row.Values, Deleted: true}
+		count++
+	}
+	if count > 0 {
+		t.discardColumns()
+		t.rowsChanged()
+	}
+	return count
+}
+
+// SelectRowsWithDeleted returns all rows from a table, soft-deleted ones
+// included
+func (t *Table) SelectRowsWithDeleted() []*Row {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	rows := make([]*Row, len(t.Rows))
+	copy(rows, t.Rows)
+	return rows
+}
+
+// PurgeRows removes the soft-deleted rows matching a condition for good
+func (t *Table) PurgeRows(condition func(*Row) bool) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	newRows := make([]*Row, 0, len(t.Rows))
+	count := 0
+	for _, row := range t.Rows {
+		if row.Deleted && (condition == nil || condition(row)) {
+			count++
+		} else {
+			newRows = append(newRows, row)
+		}
+	}
+
+	t.Rows = newRows
+	if count > 0 {
+		t.pruneDictionaries()
+		t.rowsChanged()
+	}
+	return count
+}
@@ -3,49 +3,108 @@ package storage
 import (
 	"encoding/gob"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	"github.com/Techbite-sudo/pesapal-rdbms/pkg/index"
 )
 
 // Storage manages database storage
 type Storage struct {
-	dataDir     string
-	tables      map[string]*Table
-	indexMgr    *index.Manager
-	mu          sync.RWMutex
+	dataDir   string
+	tables    map[string]*Table
+	locations map[string]string // directories of tables stored outside dataDir
+	indexMgr  *index.Manager
+	readOnly  bool
+	lock      *dirLock
+	mu        sync.RWMutex
+}
+
+// Options configures how a data directory is opened
+type Options struct {
+	// ReadOnly opens the directory without taking the lock, so it can be
+	// inspected while another process owns it. Nothing is written to disk.
+	ReadOnly bool
 }
 
 // Table represents a database table
 type Table struct {
 	Schema *Schema
 	Rows   []*Row
+	lsn    uint64      // WAL LSN the table file was last checkpointed at
+	dirty  atomic.Bool // changed since it was last written to disk
 	mu     sync.RWMutex
+
+	dictionaries map[string]*dictionary // values of dictionary-encoded columns, by column
+
+	columns   *columnStore // column vectors of a columnar table, nil until scanned
+	columnsMu sync.Mutex
+
+	indexed  bool  // the storage's indexes of the table hold its current rows
+	indexErr error // why the indexes could not be built from them, if they could not
+	indexMu  sync.Mutex
 }
 
-// NewStorage creates a new storage instance
+// NewStorage creates a new storage instance that owns dataDir
 func NewStorage(dataDir string) (*Storage, error) {
-	// Create data directory if it doesn't exist
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create data directory: %w", err)
-	}
+	return Open(dataDir, Options{})
+}
 
+// Open opens a data directory. Unless opened read-only, the directory is
+// locked for the lifetime of the storage and opening it from a second
+// process fails with a *LockedError.
+func Open(dataDir string, opts Options) (*Storage, error) {
 	s := &Storage{
-		dataDir:  dataDir,
-		tables:   make(map[string]*Table),
-		indexMgr: index.NewManager(),
+		dataDir:   dataDir,
+		tables:    make(map[string]*Table),
+		locations: make(map[string]string),
+		indexMgr:  index.NewManager(),
+		readOnly:  opts.ReadOnly,
+	}
+
+	if !opts.ReadOnly {
+		// Create data directory if it doesn't exist
+		if err := os.MkdirAll(dataDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+
+		lock, err := lockDataDir(dataDir)
+		if err != nil {
+			return nil, err
+		}
+		s.lock = lock
 	}
 
 	// Load existing tables
 	if err := s.loadTables(); err != nil {
+		s.Close()
 		return nil, fmt.Errorf("failed to load tables: %w", err)
 	}
 
 	return s, nil
 }
 
+// ReadOnly reports whether the storage was opened read-only
+func (s *Storage) ReadOnly() bool {
+	return s.readOnly
+}
+
+// Close releases the data directory lock
+func (s *Storage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lock == nil {
+		return nil
+	}
+	err := s.lock.release()
+	s.lock = nil
+	return err
+}
+
 // CreateTable creates a new table
 func (s *Storage) CreateTable(schema *Schema) error {
 	s.mu.Lock()
@@ -86,10 +145,29 @@ func (s *Storage) DropTable(tableName string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.tables[tableName]; !exists {
+	table, exists := s.tables[tableName]
+	if !exists {
 		return fmt.Errorf("table %s does not exist", tableName)
 	}
+	if s.readOnly {
+		return ErrReadOnly
+	}
 
+	// A dropped partition leaves its parent; a dropped partitioned table
+	// takes its partitions with it
+	if parent, ok := s.tables[table.Schema.PartitionOf]; ok {
+		s.detach(parent, table)
+	}
+	for _, bound := range table.Schema.Partitions {
+		if err := s.removeTable(bound.Table); err != nil {
+			return err
+		}
+	}
+	return s.removeTable(tableName)
+}
+
+// removeTable deletes a table and its file; callers must hold s.mu
+func (s *Storage) removeTable(tableName string) error {
 	delete(s.tables, tableName)
 
 	// Drop all indexes for this table
@@ -97,6 +175,7 @@ func (s *Storage) DropTable(tableName string) error {
 
 	// Remove from disk
 	filePath := s.getTableFilePath(tableName)
+	delete(s.locations, tableName)
 	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove table file: %w", err)
 	}
@@ -155,96 +234,164 @@ func (t *Table) InsertRow(row *Row) error {
 		}
 	}
 
-	// Check primary key uniqueness
-	for _, pkCol := range t.Schema.PrimaryKeys {
-		pkIndex := t.Schema.GetColumnIndex(pkCol)
-		if pkIndex == -1 {
-			continue
-		}
-		pkValue := row.Values[pkIndex]
-		for _, existingRow := range t.Rows {
-			if existingRow.Values[pkIndex] == pkValue {
-				return fmt.Errorf("duplicate primary key value: %v", pkValue)
-			}
-		}
+	if err := t.checkKeys([][]interface{}{row.Values}, nil); err != nil {
+		return err
 	}
 
-	// Check unique constraints
-	for _, uniqueCol := range t.Schema.UniqueKeys {
-		uniqueIndex := t.Schema.GetColumnIndex(uniqueCol)
-		if uniqueIndex == -1 {
-			continue
-		}
-		uniqueValue := row.Values[uniqueIndex]
-		if uniqueValue == nil {
-			continue // NULL values are allowed in unique columns
+	t.internValues(row.Values)
+	t.Rows = append(t.Rows, row)
+	t.appendColumns([]*Row{row})
+	t.rowsChanged()
+	return nil
+}
+
+// InsertRows inserts a batch of rows. Primary key and unique constraints
+// are checked once for the whole batch, and nothing is inserted unless
+// every row is valid.
+func (t *Table) InsertRows(rows []*Row) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, row := range rows {
+		if len(row.Values) != len(t.Schema.Columns) {
+			return fmt.Errorf("row has %d values but table has %d columns", len(row.Values), len(t.Schema.Columns))
 		}
-		for _, existingRow := range t.Rows {
-			if existingRow.Values[uniqueIndex] == uniqueValue {
-				return fmt.Errorf("duplicate unique key value in column %s: %v", uniqueCol, uniqueValue)
+		for i, col := range t.Schema.Columns {
+			if err := ValidateValue(row.Values[i], col); err != nil {
+				return err
 			}
 		}
 	}
 
-	t.Rows = append(t.Rows, row)
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		values[i] = row.Values
+	}
+	if err := t.checkKeys(values, nil); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		t.internValues(row.Values)
+	}
+	t.Rows = append(t.Rows, rows...)
+	t.appendColumns(rows)
+	if len(rows) > 0 {
+		t.rowsChanged()
+	}
 	return nil
 }
 
-// SelectRows returns all rows from a table
+// SelectRows returns all rows from a table, leaving out soft-deleted ones
 func (t *Table) SelectRows() []*Row {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
 	// Return a copy to prevent external modification
-	rows := make([]*Row, len(t.Rows))
-	copy(rows, t.Rows)
+	rows := make([]*Row, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		if !row.Deleted {
+			rows = append(rows, row)
+		}
+	}
 	return rows
 }
 
-// UpdateRows updates rows matching a condition
+// RowCount returns the number of rows in a table, leaving out soft-deleted
+// ones
+func (t *Table) RowCount() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	count := 0
+	for _, row := range t.Rows {
+		if !row.Deleted {
+			count++
+		}
+	}
+	return count
+}
+
+// UpdateRows updates rows matching a condition. Like UpdateRowsWith, it
+// changes nothing unless every updated row is valid and keeps the keys
+// unique.
 func (t *Table) UpdateRows(condition func(*Row) bool, updates map[string]interface{}) (int, error) {
+	indexes := make(map[int]interface{}, len(updates))
+	for colName, value := range updates {
+		colIndex := t.Schema.GetColumnIndex(colName)
+		if colIndex == -1 {
+			return 0, fmt.Errorf("column %s not found", colName)
+		}
+		indexes[colIndex] = value
+	}
+	return t.UpdateRowsWith(condition, func(values []interface{}) error {
+		for colIndex, value := range indexes {
+			values[colIndex] = value
+		}
+		return nil
+	})
+}
+
+// UpdateRowsWith updates rows matching a condition by handing a copy of
+// each row's values to update. The modified values are validated before they
+// replace the rows, so update can derive values from the rest of the row.
+// Rows are only changed once every row's new values are valid and keep the
+// PRIMARY KEY and UNIQUE columns and constraints, so a failed update changes
+// nothing.
+func (t *Table) UpdateRowsWith(condition func(*Row) bool, update func(values []interface{}) error) (int, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	count := 0
+	var matched []*Row
+	var updated [][]interface{}
 	for _, row := range t.Rows {
-		if condition == nil || condition(row) {
-			for colName, value := range updates {
-				colIndex := t.Schema.GetColumnIndex(colName)
-				if colIndex == -1 {
-					return count, fmt.Errorf("column %s not found", colName)
-				}
-
-				col := t.Schema.Columns[colIndex]
-				if err := ValidateValue(value, col); err != nil {
-					return count, err
-				}
+		if row.Deleted || condition != nil && !condition(row) {
+			continue
+		}
 
-				row.Values[colIndex] = value
+		values := append([]interface{}{}, row.Values...)
+		if err := update(values); err != nil {
+			return 0, err
+		}
+		for i, col := range t.Schema.Columns {
+			if err := ValidateValue(values[i], col); err != nil {
+				return 0, err
 			}
-			count++
 		}
+		matched = append(matched, row)
+		updated = append(updated, values)
 	}
 
-	return count, nil
+	replaced := make(map[*Row]bool, len(matched))
+	for _, row := range matched {
+		replaced[row] = true
+	}
+	if err := t.checkKeys(updated, replaced); err != nil {
+		return 0, err
+	}
+
+	for i, row := range matched {
+		t.internValues(updated[i])
+		row.Values = updated[i]
+	}
+	if len(matched) > 0 {
+		t.pruneDictionaries()
+		t.discardColumns()
+		t.rowsChanged()
+	}
+	return len(matched), nil
 }
 
-// DeleteRows deletes rows matching a condition
+// DeleteRows deletes rows matching a condition. Soft-deleted rows are
+// left for PurgeRows.
 func (t *Table) DeleteRows(condition func(*Row) bool) int {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if condition == nil {
-		// Delete all rows
-		count := len(t.Rows)
-		t.Rows = []*Row{}
-		return count
-	}
-
 	newRows := []*Row{}
 	count := 0
 	for _, row := range t.Rows {
-		if !condition(row) {
+		if row.Deleted || condition != nil && !condition(row) {
 			newRows = append(newRows, row)
 		} else {
 			count++
@@ -252,33 +399,195 @@ func (t *Table) DeleteRows(condition func(*Row) bool) int {
 	}
 
 	t.Rows = newRows
+	if count > 0 {
+		t.pruneDictionaries()
+		t.discardColumns()
+		t.rowsChanged()
+	}
 	return count
 }
 
+// CheckpointLSN returns the WAL LSN the table's file was last written at
+// by Checkpoint, or 0 if it never was. Changes logged after it may be
+// missing from the file.
+func (t *Table) CheckpointLSN() uint64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.lsn
+}
+
+// DataDir returns the directory tables are stored in
+func (s *Storage) DataDir() string {
+	return s.dataDir
+}
+
+// SnapshotTo writes a copy of every table into dir
+func (s *Storage) SnapshotTo(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for name, table := range s.tables {
+		table.mu.RLock()
+		err := writeTableFile(filepath.Join(dir, name+".tbl"), table)
+		table.mu.RUnlock()
+		if err != nil {
+			return fmt.Errorf("failed to snapshot table %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// RestoreFrom replaces every table with the ones stored in dir. An empty
+// dir restores an empty database.
+func (s *Storage) RestoreFrom(dir string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	tables := make(map[string]*Table)
+	if dir != "" {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		for _, file := range files {
+			if filepath.Ext(file.Name()) != ".tbl" {
+				continue
+			}
+			table, err := readTableFile(filepath.Join(dir, file.Name()))
+			if err != nil {
+				return fmt.Errorf("failed to load snapshot table %s: %w", file.Name(), err)
+			}
+			tables[table.Schema.TableName] = table
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name := range s.tables {
+		s.indexMgr.DropTableIndexes(name)
+		if err := os.Remove(s.getTableFilePath(name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove table file: %w", err)
+		}
+	}
+
+	s.tables = tables
+	s.locations = make(map[string]string)
+	for _, table := range tables {
+		for _, bound := range table.Schema.Partitions {
+			if bound.Dir != "" {
+				s.locations[bound.Table] = s.partitionDir(bound.Dir)
+				if err := os.MkdirAll(s.partitionDir(bound.Dir), 0755); err != nil {
+					return fmt.Errorf("failed to create partition directory: %w", err)
+				}
+			}
+		}
+	}
+	for _, table := range tables {
+		for _, col := range table.Schema.Columns {
+			if col.PrimaryKey || col.Unique {
+				s.indexMgr.CreateIndex(table.Schema.TableName, col.Name)
+			}
+		}
+		if err := s.saveTable(table); err != nil {
+			return fmt.Errorf("failed to save table %s: %w", table.Schema.TableName, err)
+		}
+	}
+
+	return nil
+}
+
 // getTableFilePath returns the file path for a table
 func (s *Storage) getTableFilePath(tableName string) string {
+	if dir, ok := s.locations[tableName]; ok {
+		return filepath.Join(dir, tableName+".tbl")
+	}
 	return filepath.Join(s.dataDir, tableName+".tbl")
 }
 
 // saveTable saves a table to disk
 func (s *Storage) saveTable(table *Table) error {
-	filePath := s.getTableFilePath(table.Schema.TableName)
+	if s.readOnly {
+		return ErrReadOnly
+	}
 
-	file, err := os.Create(filePath)
+	table.mu.RLock()
+	defer table.mu.RUnlock()
+
+	table.dirty.Store(false)
+	if err := writeTableFile(s.getTableFilePath(table.Schema.TableName), table); err != nil {
+		table.dirty.Store(true)
+		return err
+	}
+	return nil
+}
+
+// writeTableFile encodes a table into the file at filePath. The file is
+// written under a temporary name and renamed into place, so a crash leaves
+// either the old or the new contents. Each write has a name of its own, as
+// concurrent saves of a table may write it at once.
+func writeTableFile(filePath string, table *Table) error {
+	file, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	tmpPath := file.Name()
 
+	err = file.Chmod(0644)
 	encoder := gob.NewEncoder(file)
-	if err := encoder.Encode(table.Schema); err != nil {
-		return err
+	if err == nil {
+		err = encoder.Encode(table.Schema)
 	}
-	if err := encoder.Encode(table.Rows); err != nil {
+	if err == nil && table.Schema.Columnar() {
+		var columnar *columnarFile
+		if columnar, err = encodeColumnar(table.Schema, table.Rows); err == nil {
+			err = encoder.Encode(columnar)
+		}
+	} else if err == nil {
+		err = encoder.Encode(table.Rows)
+	}
+	if err == nil {
+		err = encoder.Encode(table.lsn)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
 		return err
 	}
 
-	return nil
+	return os.Rename(tmpPath, filePath)
+}
+
+// Checkpoint writes every table changed since it was last saved, stamping
+// its file with lsn, the WAL position the in-memory tables reflect. It
+// returns the number of tables written.
+func (s *Storage) Checkpoint(lsn uint64) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	written := 0
+	for _, table := range s.tables {
+		if !table.dirty.Load() {
+			continue
+		}
+		table.mu.Lock()
+		table.lsn = lsn
+		table.mu.Unlock()
+		if err := s.saveTable(table); err != nil {
+			return written, fmt.Errorf("failed to save table %s: %w", table.Schema.TableName, err)
+		}
+		written++
+	}
+	return written, nil
 }
 
 // SaveAllTables saves all tables to disk
@@ -317,15 +626,27 @@ func (s *Storage) loadTables() error {
 		}
 
 		s.tables[tableName] = table
+
+		// Indexes are not stored; recreate them like CreateTable does
+		for _, col := range table.Schema.Columns {
+			if col.PrimaryKey || col.Unique {
+				if err := s.indexMgr.CreateIndex(tableName, col.Name); err != nil {
+					return fmt.Errorf("failed to create index: %w", err)
+				}
+			}
+		}
 	}
 
-	return nil
+	return s.loadPartitions()
 }
 
 // loadTable loads a single table from disk
 func (s *Storage) loadTable(tableName string) (*Table, error) {
-	filePath := s.getTableFilePath(tableName)
+	return readTableFile(s.getTableFilePath(tableName))
+}
 
+// readTableFile decodes a table from the file at filePath
+func readTableFile(filePath string) (*Table, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
@@ -340,12 +661,31 @@ func (s *Storage) loadTable(tableName string) (*Table, error) {
 	}
 
 	var rows []*Row
-	if err := decoder.Decode(&rows); err != nil {
+	if schema.Columnar() {
+		var columnar columnarFile
+		if err := decoder.Decode(&columnar); err != nil {
+			return nil, err
+		}
+		if rows, err = decodeColumnar(&schema, &columnar); err != nil {
+			return nil, err
+		}
+	} else if err := decoder.Decode(&rows); err != nil {
 		return nil, err
 	}
 
-	return &Table{
+	// Files written before checkpoints existed end after the rows
+	var lsn uint64
+	if err := decoder.Decode(&lsn); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	table := &Table{
 		Schema: &schema,
 		Rows:   rows,
-	}, nil
+		lsn:    lsn,
+	}
+	for _, row := range rows {
+		table.internValues(row.Values)
+	}
+	return table, nil
 }
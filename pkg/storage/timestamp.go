@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TimestampLayout is how TIMESTAMP values are stored: in UTC, to the
+// microsecond, so they order correctly as strings
+const TimestampLayout = "2006-01-02 15:04:05.000000"
+
+// DateLayout is how DATE values are stored, which also orders correctly
+// as strings
+const DateLayout = "2006-01-02"
+
+// timestampInputLayouts are the formats a TIMESTAMP value may be written in
+var timestampInputLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// FormatTimestamp renders a time as a stored TIMESTAMP value
+func FormatTimestamp(t time.Time) string {
+	return t.UTC().Format(TimestampLayout)
+}
+
+// NormalizeValue converts a value written to a column to the form the
+// column stores. TIMESTAMP values may be given as 'YYYY-MM-DD[ HH:MM[:SS[.
+// fraction]]]', which is taken as UTC, or in RFC 3339, which is converted
+// to UTC. DATE values may be given in the same forms, and keep only the
+// day. Strings written to BLOB columns are read by ParseBlob, and to UUID
+// columns by ParseUUID. An INTEGER written to a FLOAT column is widened,
+// and a FLOAT with no fractional part written to an INTEGER column is
+// narrowed. Other values are returned as is.
+func NormalizeValue(value interface{}, col Column) (interface{}, error) {
+	switch v := value.(type) {
+	case int:
+		if col.DataType == TypeFloat {
+			return float64(v), nil
+		}
+		return value, nil
+	case float64:
+		if col.DataType != TypeInteger {
+			return value, nil
+		}
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return nil, fmt.Errorf("column %s expects INTEGER, got %v", col.Name, v)
+		}
+		return int(v), nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		return value, nil
+	}
+	switch col.DataType {
+	case TypeTimestamp, TypeDate:
+	case TypeBlob:
+		blob, err := ParseBlob(str)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.Name, err)
+		}
+		return blob, nil
+	case TypeUUID:
+		id, err := ParseUUID(str)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.Name, err)
+		}
+		return id, nil
+	default:
+		return value, nil
+	}
+	t, err := ParseTimestamp(str)
+	if err != nil {
+		if col.DataType == TypeDate {
+			err = fmt.Errorf("invalid DATE %q", str)
+		}
+		return nil, fmt.Errorf("column %s: %w", col.Name, err)
+	}
+	if col.DataType == TypeDate {
+		return FormatDate(t), nil
+	}
+	return FormatTimestamp(t), nil
+}
+
+// FormatDate renders the day of a time as a stored DATE value
+func FormatDate(t time.Time) string {
+	return t.UTC().Format(DateLayout)
+}
+
+// DateAsTimestamp returns a and b to compare, with a DATE value compared
+// to a TIMESTAMP value turned into a TIMESTAMP at midnight of its day
+func DateAsTimestamp(a, b string) (string, string) {
+	if isStored(a, DateLayout) && isStored(b, TimestampLayout) {
+		return a + " 00:00:00.000000", b
+	}
+	if isStored(a, TimestampLayout) && isStored(b, DateLayout) {
+		return a, b + " 00:00:00.000000"
+	}
+	return a, b
+}
+
+// isStored reports whether value is in the stored form layout describes
+func isStored(value, layout string) bool {
+	if len(value) != len(layout) {
+		return false
+	}
+	_, err := time.Parse(layout, value)
+	return err == nil
+}
+
+// ParseTimestamp parses a TIMESTAMP value written in any of the formats
+// NormalizeValue accepts
+func ParseTimestamp(value string) (time.Time, error) {
+	for _, layout := range timestampInputLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid TIMESTAMP %q", value)
+}
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 )
 
 // DataType represents column data types
@@ -12,6 +15,13 @@ const (
 	TypeVarchar
 	TypeBoolean
 	TypeFloat
+	TypeCIText
+	TypeJSON
+	TypeTimestamp
+	TypeDate
+	TypeText
+	TypeBlob
+	TypeUUID
 )
 
 // String returns string representation of data type
@@ -25,6 +35,20 @@ func (d DataType) String() string {
 		return "BOOLEAN"
 	case TypeFloat:
 		return "FLOAT"
+	case TypeCIText:
+		return "CITEXT"
+	case TypeJSON:
+		return "JSON"
+	case TypeTimestamp:
+		return "TIMESTAMP"
+	case TypeDate:
+		return "DATE"
+	case TypeText:
+		return "TEXT"
+	case TypeBlob:
+		return "BLOB"
+	case TypeUUID:
+		return "UUID"
 	default:
 		return "UNKNOWN"
 	}
@@ -34,10 +58,17 @@ func (d DataType) String() string {
 type Column struct {
 	Name       string
 	DataType   DataType
-	Size       int  // for VARCHAR
+	Size       int  // for VARCHAR; TEXT has no limit
 	PrimaryKey bool
 	Unique     bool
 	NotNull    bool
+	Generated  string // expression of a generated column, empty otherwise
+	Virtual    bool   // generated column computed at read time instead of stored
+	Collation  string // VARCHAR collation, empty for binary
+	Mask       string // masking function applied when users without UNMASK read the column
+	Default    string // expression an INSERT that leaves the column out stores, empty for NULL
+	OnUpdate   string // expression every UPDATE that does not set the column stores, empty for none
+	Dictionary bool   // values are dictionary-encoded: rows share one copy of each distinct value
 }
 
 // Schema represents a table schema
@@ -46,6 +77,31 @@ type Schema struct {
 	Columns     []Column
 	PrimaryKeys []string
 	UniqueKeys  []string
+	// UniqueConstraints are table-level UNIQUE constraints over several
+	// columns, which UniqueKeys (one column each) cannot express
+	UniqueConstraints []UniqueConstraint
+	// ForeignKeys are the FOREIGN KEY constraints on the table's rows
+	ForeignKeys []ForeignKey
+
+	// A partitioned table stores no rows itself: they live in the
+	// partitions, each holding a range or hash bucket of PartitionKey values
+	PartitionKey    string           // column the table is partitioned on, empty otherwise
+	PartitionMethod string           // PartitionRange or PartitionHash
+	Partitions      []PartitionBound // partitions of a partitioned table
+	PartitionOf     string           // parent of a partition, empty otherwise
+
+	ForeignTable *ForeignTable // source of a foreign table's rows, nil otherwise
+	View         *View         // query of a materialized view, nil otherwise
+
+	Policies []Policy // row-level security policies
+	Grants   []Grant  // privileges granted to users
+
+	// SoftDelete makes DELETE mark rows deleted instead of removing them
+	SoftDelete bool
+
+	// Layout is how the table stores its rows: LayoutColumnar, or empty
+	// for the row layout
+	Layout string
 }
 
 // NewSchema creates a new schema
@@ -69,6 +125,71 @@ func (s *Schema) AddColumn(col Column) {
 	}
 }
 
+// UniqueConstraint is a table-level UNIQUE (<column>, ...) constraint: no
+// two rows may have the same values in all of its columns. As with UNIQUE
+// columns, rows with a NULL in any of them never conflict.
+type UniqueConstraint struct {
+	Name    string
+	Columns []string
+}
+
+// AddUniqueConstraint adds a table-level UNIQUE constraint over columns. A
+// single column is made a UNIQUE column instead.
+func (s *Schema) AddUniqueConstraint(columns []string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("UNIQUE constraint needs at least one column")
+	}
+	seen := map[string]bool{}
+	for _, name := range columns {
+		if s.GetColumnIndex(name) == -1 {
+			return fmt.Errorf("column %s named in UNIQUE constraint does not exist", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("column %s appears twice in UNIQUE constraint", name)
+		}
+		seen[name] = true
+	}
+
+	if len(columns) == 1 {
+		col := &s.Columns[s.GetColumnIndex(columns[0])]
+		if !col.Unique && !col.PrimaryKey {
+			col.Unique = true
+			s.UniqueKeys = append(s.UniqueKeys, col.Name)
+		}
+		return nil
+	}
+
+	name := s.TableName + "_" + strings.Join(columns, "_") + "_key"
+	for _, constraint := range s.UniqueConstraints {
+		if constraint.Name == name {
+			return fmt.Errorf("UNIQUE constraint on (%s) already exists", strings.Join(columns, ", "))
+		}
+	}
+	s.UniqueConstraints = append(s.UniqueConstraints, UniqueConstraint{
+		Name:    name,
+		Columns: append([]string{}, columns...),
+	})
+	return nil
+}
+
+// ForeignKey is a FOREIGN KEY (<column>, ...) REFERENCES <table>
+// (<column>, ...) constraint: a row's values in Columns must be those of
+// some row of RefTable in RefColumns, unless one of them is NULL. OnDelete
+// is what deleting a referenced row does to the rows referencing it.
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string // OnDeleteCascade, OnDeleteSetNull, or empty to reject the delete
+}
+
+// Referential actions of a ForeignKey
+const (
+	OnDeleteCascade = "CASCADE"
+	OnDeleteSetNull = "SET NULL"
+)
+
 // GetColumn returns a column by name
 func (s *Schema) GetColumn(name string) (*Column, error) {
 	for i := range s.Columns {
@@ -91,7 +212,8 @@ func (s *Schema) GetColumnIndex(name string) int {
 
 // Row represents a single row of data
 type Row struct {
-	Values []interface{}
+	Values  []interface{}
+	Deleted bool // soft-deleted: hidden from reads until purged
 }
 
 // NewRow creates a new row
@@ -128,13 +250,49 @@ func ValidateValue(value interface{}, col Column) error {
 		if _, ok := value.(int); !ok {
 			return fmt.Errorf("column %s expects INTEGER, got %T", col.Name, value)
 		}
-	case TypeVarchar:
+	case TypeVarchar, TypeCIText, TypeText:
 		if str, ok := value.(string); ok {
 			if col.Size > 0 && len(str) > col.Size {
 				return fmt.Errorf("column %s: string length %d exceeds maximum %d", col.Name, len(str), col.Size)
 			}
 		} else {
-			return fmt.Errorf("column %s expects VARCHAR, got %T", col.Name, value)
+			return fmt.Errorf("column %s expects %s, got %T", col.Name, col.DataType, value)
+		}
+	case TypeJSON:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("column %s expects JSON, got %T", col.Name, value)
+		}
+		if !json.Valid([]byte(str)) {
+			return fmt.Errorf("column %s: invalid JSON %q", col.Name, str)
+		}
+	case TypeTimestamp:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("column %s expects TIMESTAMP, got %T", col.Name, value)
+		}
+		if _, err := time.Parse(TimestampLayout, str); err != nil {
+			return fmt.Errorf("column %s: invalid TIMESTAMP %q", col.Name, str)
+		}
+	case TypeDate:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("column %s expects DATE, got %T", col.Name, value)
+		}
+		if _, err := time.Parse(DateLayout, str); err != nil {
+			return fmt.Errorf("column %s: invalid DATE %q", col.Name, str)
+		}
+	case TypeUUID:
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("column %s expects UUID, got %T", col.Name, value)
+		}
+		if !isUUID(str) {
+			return fmt.Errorf("column %s: invalid UUID %q", col.Name, str)
+		}
+	case TypeBlob:
+		if _, ok := value.(Blob); !ok {
+			return fmt.Errorf("column %s expects BLOB, got %T", col.Name, value)
 		}
 	case TypeBoolean:
 		if _, ok := value.(bool); !ok {
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkKeys checks the PRIMARY KEY, the UNIQUE columns and the table-level
+// UNIQUE constraints for rows about to be written: values holds the new
+// values, and replaced the rows they overwrite (nil for rows being
+// inserted), whose old values no longer count. Every write of new values,
+// inserted or updated, is checked here. The table must be locked by the
+// caller.
+func (t *Table) checkKeys(values [][]interface{}, replaced map[*Row]bool) error {
+	for _, name := range t.Schema.PrimaryKeys {
+		if err := t.checkColumnKey(name, true, values, replaced); err != nil {
+			return err
+		}
+	}
+	for _, name := range t.Schema.UniqueKeys {
+		if err := t.checkColumnKey(name, false, values, replaced); err != nil {
+			return err
+		}
+	}
+	return t.checkUniqueConstraints(values, replaced)
+}
+
+// checkColumnKey checks that the new values of a PRIMARY KEY or UNIQUE
+// column differ from each other and from the rows they do not replace,
+// against a hash of the existing keys rather than by scanning the table for
+// every row. The table must be locked by the caller.
+func (t *Table) checkColumnKey(name string, primary bool, values [][]interface{}, replaced map[*Row]bool) error {
+	colIndex := t.Schema.GetColumnIndex(name)
+	if colIndex == -1 {
+		return nil
+	}
+	col := t.Schema.Columns[colIndex]
+
+	seen := make(map[interface{}]bool, len(t.Rows)+len(values))
+	for _, row := range t.Rows {
+		if !replaced[row] {
+			seen[CollationKey(row.Values[colIndex], col)] = true
+		}
+	}
+	for _, rowValues := range values {
+		value := rowValues[colIndex]
+		if value == nil && !primary {
+			continue // NULL values are allowed in unique columns
+		}
+		key := CollationKey(value, col)
+		if seen[key] {
+			if primary {
+				return fmt.Errorf("duplicate primary key value: %v", value)
+			}
+			return fmt.Errorf("duplicate unique key value in column %s: %v", name, value)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// checkUniqueConstraints checks the table-level UNIQUE constraints for rows
+// about to be written: values holds the new values, and replaced the rows
+// they overwrite (nil for rows being inserted), whose old values no longer
+// count. The table must be locked by the caller.
+func (t *Table) checkUniqueConstraints(values [][]interface{}, replaced map[*Row]bool) error {
+	for _, constraint := range t.Schema.UniqueConstraints {
+		indexes := make([]int, len(constraint.Columns))
+		for i, name := range constraint.Columns {
+			if indexes[i] = t.Schema.GetColumnIndex(name); indexes[i] == -1 {
+				return fmt.Errorf("column %s of constraint %s not found", name, constraint.Name)
+			}
+		}
+
+		seen := make(map[string]bool, len(t.Rows)+len(values))
+		for _, row := range t.Rows {
+			if replaced[row] {
+				continue
+			}
+			if key, ok := t.uniqueKey(row.Values, indexes); ok {
+				seen[key] = true
+			}
+		}
+		for _, rowValues := range values {
+			key, ok := t.uniqueKey(rowValues, indexes)
+			if !ok {
+				continue // a NULL in any column never conflicts
+			}
+			if seen[key] {
+				conflicting := make([]string, len(indexes))
+				for i, idx := range indexes {
+					conflicting[i] = fmt.Sprint(rowValues[idx])
+				}
+				return fmt.Errorf("duplicate unique key value in columns (%s): (%s)",
+					strings.Join(constraint.Columns, ", "), strings.Join(conflicting, ", "))
+			}
+			seen[key] = true
+		}
+	}
+	return nil
+}
+
+// uniqueKey returns a key equal for exactly the rows whose values in the
+// given columns are equal under the columns' collations, or false if any of
+// them is NULL
+func (t *Table) uniqueKey(values []interface{}, indexes []int) (string, bool) {
+	var b strings.Builder
+	for _, idx := range indexes {
+		value := values[idx]
+		if value == nil {
+			return "", false
+		}
+		// The type keeps 1 and '1' apart; the length keeps ('a,', 'b') and
+		// ('a', ',b') apart
+		s := fmt.Sprint(CollationKey(value, t.Schema.Columns[idx]))
+		fmt.Fprintf(&b, "%T:%d:%s", value, len(s), s)
+	}
+	return b.String(), true
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Sizes used to estimate the in-memory footprint of rows
+const (
+	rowOverhead   = 8 + 24 // *Row pointer plus the Values slice header
+	valueOverhead = 16     // interface{} holding each value
+)
+
+// IndexUsage describes the size of one index
+type IndexUsage struct {
+	Column      string `json:"column"`
+	Keys        int    `json:"keys"`
+	MemoryBytes int64  `json:"memoryBytes"` // estimated
+}
+
+// TableUsage describes the memory and disk used by one table
+type TableUsage struct {
+	Name        string       `json:"name"`
+	Rows        int          `json:"rows"`
+	MemoryBytes int64        `json:"memoryBytes"` // estimated size of the rows in memory
+	DiskBytes   int64        `json:"diskBytes"`   // size of the table file
+	Indexes     []IndexUsage `json:"indexes"`
+	// Dictionaries are the dictionaries of dictionary-encoded columns,
+	// whose size MemoryBytes includes
+	Dictionaries []DictionaryUsage `json:"dictionaries,omitempty"`
+}
+
+// Usage describes the memory and disk used by a data directory. Tables are
+// held entirely in memory, so MemoryBytes is also the resident table data.
+type Usage struct {
+	Tables       []TableUsage `json:"tables"`
+	MemoryBytes  int64        `json:"memoryBytes"`  // tables and indexes
+	DiskBytes    int64        `json:"diskBytes"`    // table files
+	DataDirBytes int64        `json:"dataDirBytes"` // every file in the data directory, including the WAL and backups
+}
+
+// Usage reports per-table row counts and estimated sizes
+func (s *Storage) Usage() (*Usage, error) {
+	s.mu.RLock()
+	tables := make([]*Table, 0, len(s.tables))
+	for _, table := range s.tables {
+		tables = append(tables, table)
+	}
+	s.mu.RUnlock()
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Schema.TableName < tables[j].Schema.TableName
+	})
+
+	usage := &Usage{Tables: []TableUsage{}}
+	for _, table := range tables {
+		name := table.Schema.TableName
+		tu := TableUsage{Name: name, Indexes: []IndexUsage{}}
+		tu.Rows, tu.MemoryBytes, tu.Dictionaries = table.memoryUsage()
+
+		info, err := os.Stat(s.getTableFilePath(name))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			tu.DiskBytes = info.Size()
+		}
+
+		columns := s.indexMgr.GetIndexedColumns(name)
+		sort.Strings(columns)
+		for _, column := range columns {
+			entries, ok := s.indexMgr.Entries(name, column)
+			if !ok {
+				continue
+			}
+			iu := IndexUsage{Column: column, Keys: len(entries)}
+			for _, entry := range entries {
+				iu.MemoryBytes += valueOverhead + EstimateSize(entry.Key) + 8
+			}
+			tu.Indexes = append(tu.Indexes, iu)
+			usage.MemoryBytes += iu.MemoryBytes
+		}
+
+		usage.Tables = append(usage.Tables, tu)
+		usage.MemoryBytes += tu.MemoryBytes
+		usage.DiskBytes += tu.DiskBytes
+	}
+
+	err := filepath.WalkDir(s.dataDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		usage.DataDirBytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+// memoryUsage returns the row count and estimated in-memory size of a
+// table, and the size of its dictionaries. Rows only hold a reference to
+// the values of dictionary-encoded columns, which are counted once, in the
+// dictionary. The column vectors of a columnar table count as well.
+func (t *Table) memoryUsage() (int, int64, []DictionaryUsage) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var bytes int64
+	for _, row := range t.Rows {
+		bytes += rowOverhead
+		for i, value := range row.Values {
+			bytes += valueOverhead
+			if i >= len(t.Schema.Columns) || !t.Schema.Columns[i].Dictionary {
+				bytes += EstimateSize(value)
+			}
+		}
+	}
+	t.columnsMu.Lock()
+	if store := t.columns; store != nil {
+		bytes += int64(8 * len(store.rows))
+		for _, vector := range store.vectors {
+			bytes += int64(valueOverhead * len(vector))
+		}
+	}
+	t.columnsMu.Unlock()
+	dictionaries := t.dictionaryUsage()
+	for _, du := range dictionaries {
+		bytes += du.MemoryBytes
+	}
+	return len(t.Rows), bytes, dictionaries
+}
+
+// EstimateSize estimates the bytes a value occupies beyond the interface
+// holding it
+func EstimateSize(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil, bool:
+		return 0
+	case string:
+		return 16 + int64(len(v))
+	default:
+		return 8
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// NewUUID returns a random (version 4) UUID as a stored UUID value
+func NewUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// ParseUUID reads a UUID written as 32 hex digits in groups of 8, 4, 4, 4
+// and 12 separated by hyphens, in either case, and returns it in the
+// lower-case form UUID columns store
+func ParseUUID(value string) (string, error) {
+	if !isUUID(strings.ToLower(value)) {
+		return "", fmt.Errorf("invalid UUID %q", value)
+	}
+	return strings.ToLower(value), nil
+}
+
+// isUUID reports whether value is a UUID in its stored form
+func isUUID(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if ch != '-' {
+				return false
+			}
+		default:
+			if (ch < '0' || ch > '9') && (ch < 'a' || ch > 'f') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package storage
+
+import "fmt"
+
+// View describes how a materialized view's rows are computed. The rows
+// are stored like any table's and only change when the view is refreshed.
+type View struct {
+	Query  string   // the SELECT the rows are the result of, as SQL text
+	Tables []string // tables the query reads
+}
+
+// Materialized reports whether the table is a materialized view
+func (s *Schema) Materialized() bool {
+	return s.View != nil
+}
+
+// ReplaceRows replaces all of a table's rows with rows, as refreshing a
+// materialized view does. Nothing changes unless every row is valid.
+func (t *Table) ReplaceRows(rows []*Row) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, row := range rows {
+		if len(row.Values) != len(t.Schema.Columns) {
+			return fmt.Errorf("row has %d values but table has %d columns", len(row.Values), len(t.Schema.Columns))
+		}
+		for i, col := range t.Schema.Columns {
+			if err := ValidateValue(row.Values[i], col); err != nil {
+				return err
+			}
+		}
+	}
+
+	t.Rows = rows
+	t.pruneDictionaries()
+	for _, row := range rows {
+		t.internValues(row.Values)
+	}
+	t.discardColumns()
+	t.rowsChanged()
+	return nil
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// LogExporter writes finished spans to a logger, one line per span
+type LogExporter struct {
+	logger *log.Logger
+}
+
+// NewLogExporter creates an exporter that logs spans. A nil logger uses the
+// standard logger.
+func NewLogExporter(logger *log.Logger) *LogExporter {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogExporter{logger: logger}
+}
+
+// ExportSpan logs a finished span
+func (l *LogExporter) ExportSpan(span *SpanData) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[trace] %s trace=%s span=%s", span.Name, span.TraceID, span.SpanID))
+	if span.ParentID != "" {
+		sb.WriteString(" parent=" + span.ParentID)
+	}
+	sb.WriteString(fmt.Sprintf(" duration=%s", span.Duration()))
+
+	keys := make([]string, 0, len(span.Attributes))
+	for k := range span.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf(" %s=%v", k, span.Attributes[k]))
+	}
+
+	if span.Err != nil {
+		sb.WriteString(fmt.Sprintf(" error=%q", span.Err.Error()))
+	}
+
+	l.logger.Println(sb.String())
+}
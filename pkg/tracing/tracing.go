@@ -0,0 +1,223 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanContext identifies a span within a trace (W3C Trace Context compatible)
+type SpanContext struct {
+	TraceID string // 32 lowercase hex characters
+	SpanID  string // 16 lowercase hex characters
+	Sampled bool
+}
+
+// IsValid reports whether the span context carries usable identifiers
+func (sc SpanContext) IsValid() bool {
+	return len(sc.TraceID) == 32 && len(sc.SpanID) == 16 &&
+		strings.Trim(sc.TraceID, "0") != "" && strings.Trim(sc.SpanID, "0") != ""
+}
+
+// Traceparent formats the span context as a W3C traceparent header value
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", sc.TraceID, sc.SpanID, flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header value
+func ParseTraceparent(header string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	if parts[0] == "ff" || !isHex(parts[0]) || !isHex(parts[1]) || !isHex(parts[2]) || !isHex(parts[3]) {
+		return SpanContext{}, false
+	}
+
+	flags, _ := hex.DecodeString(parts[3])
+	sc := SpanContext{
+		TraceID: strings.ToLower(parts[1]),
+		SpanID:  strings.ToLower(parts[2]),
+		Sampled: flags[0]&0x01 == 0x01,
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+// SpanData is a finished span handed to the exporter
+type SpanData struct {
+	Name       string
+	TraceID    string
+	SpanID     string
+	ParentID   string
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes map[string]interface{}
+	Err        error
+}
+
+// Duration returns how long the span took
+func (d *SpanData) Duration() time.Duration {
+	return d.EndTime.Sub(d.StartTime)
+}
+
+// Exporter receives finished spans. Implementations can forward them to
+// OpenTelemetry, a log, or any other backend.
+type Exporter interface {
+	ExportSpan(span *SpanData)
+}
+
+var (
+	exporter   Exporter
+	exporterMu sync.RWMutex
+)
+
+// SetExporter installs the exporter that receives finished spans. Passing nil
+// disables tracing, which is the default.
+func SetExporter(e Exporter) {
+	exporterMu.Lock()
+	defer exporterMu.Unlock()
+	exporter = e
+}
+
+func currentExporter() Exporter {
+	exporterMu.RLock()
+	defer exporterMu.RUnlock()
+	return exporter
+}
+
+// Span represents a single timed operation. A nil *Span is valid and all of
+// its methods are no-ops, so callers never need to check whether tracing is on.
+type Span struct {
+	data     SpanData
+	sampled  bool
+	exporter Exporter
+	mu       sync.Mutex
+	ended    bool
+}
+
+type spanContextKey struct{}
+
+// Start starts a new span as a child of the span (or remote parent) in ctx
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	exp := currentExporter()
+	if exp == nil {
+		return ctx, nil
+	}
+
+	// A new trace is sampled; a child keeps its parent's decision
+	parent := FromContext(ctx)
+	traceID, sampled := parent.TraceID, parent.Sampled
+	if !parent.IsValid() {
+		traceID, sampled = randomHex(16), true
+	}
+
+	span := &Span{
+		data: SpanData{
+			Name:       name,
+			TraceID:    traceID,
+			SpanID:     randomHex(8),
+			ParentID:   parent.SpanID,
+			StartTime:  time.Now(),
+			Attributes: make(map[string]interface{}),
+		},
+		sampled:  sampled,
+		exporter: exp,
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, span.Context()), span
+}
+
+// ContextWithRemoteParent returns a context whose spans continue the given
+// remote trace, e.g. one extracted from an incoming traceparent header
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// FromContext returns the span context stored in ctx, if any
+func FromContext(ctx context.Context) SpanContext {
+	if sc, ok := ctx.Value(spanContextKey{}).(SpanContext); ok {
+		return sc
+	}
+	return SpanContext{}
+}
+
+// Context returns the span's identifiers
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return SpanContext{TraceID: s.data.TraceID, SpanID: s.data.SpanID, Sampled: s.sampled}
+}
+
+// SetAttribute attaches a key/value pair to the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes[key] = value
+}
+
+// RecordError marks the span as failed. A nil error is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Err = err
+}
+
+// End finishes the span and hands it to the exporter. Only the first call
+// has any effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.exporter.ExportSpan(&data)
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// Fall back to a time-derived value; uniqueness is best effort
+		ts := time.Now().UnixNano()
+		for i := range b {
+			b[i] = byte(ts >> (8 * (i % 8)))
+		}
+	}
+	return hex.EncodeToString(b)
+}
+
+// isHex checks if a string consists only of hex digits
+func isHex(s string) bool {
+	for _, ch := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", ch) {
+			return false
+		}
+	}
+	return true
+}
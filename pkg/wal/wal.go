@@ -0,0 +1,473 @@
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Record represents a single committed write statement
+type Record struct {
+	LSN   uint64    `json:"lsn"`
+	Time  time.Time `json:"time"`
+	Query string    `json:"query"`
+	User  string    `json:"user,omitempty"` // user the statement ran for, empty if none
+}
+
+// Restore records a point-in-time restore that truncated the log back to
+// To. The restore took LSN for itself, and the LSNs between To and LSN are
+// never assigned again, so records logged since cannot be mistaken for
+// discarded ones.
+type Restore struct {
+	To  uint64 `json:"to"`
+	LSN uint64 `json:"lsn"`
+}
+
+// SyncMode controls when appended records are fsynced
+type SyncMode int
+
+const (
+	// SyncEveryRecord fsyncs each record before Append returns
+	SyncEveryRecord SyncMode = iota
+	// SyncGroup also fsyncs before Append returns, but appends that arrive
+	// while an fsync is in progress share the next one (group commit)
+	SyncGroup
+	// SyncDeferred leaves fsyncing to Sync, trading a window of recent
+	// records for write throughput
+	SyncDeferred
+)
+
+// Log is an append-only log of committed write statements. Each record is
+// stored as one JSON object per line and, by default, fsynced before Append
+// returns.
+type Log struct {
+	path     string
+	file     *os.File
+	records  []Record
+	restores []Restore // kept in path.restores, oldest first
+	mode     SyncMode
+	mu       sync.RWMutex
+	syncMu   sync.Mutex    // serializes fsyncs
+	syncedTo atomic.Uint64 // LSN of the last record known to be on disk
+}
+
+// Open opens (or creates) the log at path and loads its records
+func Open(path string) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL: %w", err)
+	}
+
+	l := &Log{path: path, file: file}
+	if err := l.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := l.loadRestores(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// load reads all records from disk. A torn final line (from a crash in the
+// middle of an append) is discarded.
+func (l *Log) load() error {
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(l.file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read WAL: %w", err)
+		}
+
+		var rec Record
+		if jsonErr := json.Unmarshal(bytes.TrimSpace(line), &rec); jsonErr != nil {
+			return fmt.Errorf("corrupt WAL record at offset %d: %w", offset, jsonErr)
+		}
+		l.records = append(l.records, rec)
+		offset += int64(len(line))
+	}
+
+	// Drop anything after the last complete record
+	if err := l.file.Truncate(offset); err != nil {
+		return err
+	}
+	_, err := l.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// loadRestores reads the restores the log has been through
+func (l *Log) loadRestores() error {
+	data, err := os.ReadFile(l.path + ".restores")
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read WAL restores: %w", err)
+	}
+	if err := json.Unmarshal(data, &l.restores); err != nil {
+		return fmt.Errorf("corrupt WAL restores: %w", err)
+	}
+	return nil
+}
+
+// SetSyncMode changes when appended records are fsynced
+func (l *Log) SetSyncMode(mode SyncMode) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.mode = mode
+}
+
+// Append assigns the next LSN to a statement run for user (empty when it
+// ran for no user) and writes it to the log
+func (l *Log) Append(query, user string) (Record, error) {
+	rec, err := l.Add(query, user)
+	if err != nil {
+		return Record{}, err
+	}
+	if err := l.Commit(rec.LSN); err != nil {
+		return Record{}, err
+	}
+	return rec, nil
+}
+
+// Add writes a statement like Append but, in SyncGroup mode, leaves
+// waiting for its fsync to Commit. Callers that must log statements in the
+// order they ran add them one at a time and commit them after, so
+// concurrent commits still share an fsync.
+func (l *Log) Add(query, user string) (Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rec := Record{
+		LSN:   l.lastAssignedLSN() + 1,
+		Time:  time.Now().UTC(),
+		Query: query,
+		User:  user,
+	}
+	if _, err := l.write(rec); err != nil {
+		return Record{}, err
+	}
+	return rec, nil
+}
+
+// Commit waits, in SyncGroup mode, until the record Add wrote at lsn is on
+// disk. In the other modes Add has already synced it, or left it to Sync.
+func (l *Log) Commit(lsn uint64) error {
+	l.mu.RLock()
+	mode := l.mode
+	l.mu.RUnlock()
+
+	if mode == SyncGroup {
+		return l.syncThrough(lsn)
+	}
+	return nil
+}
+
+// AppendRecord writes a record that already has an LSN, e.g. one received
+// from a replication leader. The LSN must be greater than any assigned.
+func (l *Log) AppendRecord(rec Record) error {
+	l.mu.Lock()
+	if rec.LSN <= l.lastAssignedLSN() {
+		l.mu.Unlock()
+		return fmt.Errorf("out of order LSN %d (last is %d)", rec.LSN, l.lastAssignedLSN())
+	}
+	mode, err := l.write(rec)
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if mode == SyncGroup {
+		return l.syncThrough(rec.LSN)
+	}
+	return nil
+}
+
+// write encodes a record, syncing it in SyncEveryRecord mode, and returns
+// the sync mode in effect; callers must hold the lock
+func (l *Log) write(rec Record) (SyncMode, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return l.mode, err
+	}
+	data = append(data, '\n')
+
+	if _, err := l.file.Write(data); err != nil {
+		return l.mode, fmt.Errorf("failed to write WAL: %w", err)
+	}
+	if l.mode == SyncEveryRecord {
+		if err := l.file.Sync(); err != nil {
+			return l.mode, fmt.Errorf("failed to sync WAL: %w", err)
+		}
+	}
+
+	l.records = append(l.records, rec)
+	return l.mode, nil
+}
+
+// Sync fsyncs every record appended so far
+func (l *Log) Sync() error {
+	return l.syncThrough(l.LastLSN())
+}
+
+// syncThrough makes sure the record at lsn is on disk. Callers queue on
+// syncMu while an fsync is running; when their turn comes that fsync has
+// often covered their record already, so one fsync serves the whole group.
+func (l *Log) syncThrough(lsn uint64) error {
+	l.syncMu.Lock()
+	defer l.syncMu.Unlock()
+
+	if l.syncedTo.Load() >= lsn {
+		return nil
+	}
+
+	l.mu.RLock()
+	last, file := l.lastLSN(), l.file
+	l.mu.RUnlock()
+
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync WAL: %w", err)
+	}
+	l.syncedTo.Store(last)
+	return nil
+}
+
+// ReadFrom returns up to limit records with an LSN greater than lsn.
+// A limit of zero or less returns all of them.
+func (l *Log) ReadFrom(lsn uint64, limit int) []Record {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := []Record{}
+	for _, rec := range l.records {
+		if rec.LSN <= lsn {
+			continue
+		}
+		result = append(result, rec)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// LastLSN returns the LSN of the most recent record, or 0 if the log is empty
+func (l *Log) LastLSN() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.lastLSN()
+}
+
+func (l *Log) lastLSN() uint64 {
+	if len(l.records) == 0 {
+		return 0
+	}
+	return l.records[len(l.records)-1].LSN
+}
+
+// LastAssignedLSN returns the highest LSN assigned so far: that of the most
+// recent record, or of the last restore when it discarded later records
+func (l *Log) LastAssignedLSN() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.lastAssignedLSN()
+}
+
+func (l *Log) lastAssignedLSN() uint64 {
+	lsn := l.lastLSN()
+	if n := len(l.restores); n > 0 && l.restores[n-1].LSN > lsn {
+		lsn = l.restores[n-1].LSN
+	}
+	return lsn
+}
+
+// Restores returns the restores the log has been through, oldest first
+func (l *Log) Restores() []Restore {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return append([]Restore{}, l.restores...)
+}
+
+// FirstLSN returns the LSN of the oldest record, or 0 if the log is empty
+func (l *Log) FirstLSN() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if len(l.records) == 0 {
+		return 0
+	}
+	return l.records[0].LSN
+}
+
+// LSNAt returns the LSN of the last record committed at or before t, or 0
+// if every record is newer
+func (l *Log) LSNAt(t time.Time) uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var lsn uint64
+	for _, rec := range l.records {
+		if rec.Time.After(t) {
+			break
+		}
+		lsn = rec.LSN
+	}
+	return lsn
+}
+
+// TruncateAfter removes every record with an LSN greater than lsn for a
+// restore to lsn, and returns the restore and the number of removed
+// records. The removed records are archived next to the log rather than
+// discarded. When there are none the log is left as it is and the returned
+// restore is zero.
+func (l *Log) TruncateAfter(lsn uint64) (Restore, int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keep := 0
+	for keep < len(l.records) && l.records[keep].LSN <= lsn {
+		keep++
+	}
+	removed := l.records[keep:]
+	if len(removed) == 0 {
+		return Restore{}, 0, nil
+	}
+
+	// Archive the tail before rewriting the log
+	archivePath := fmt.Sprintf("%s.%d-%d.discarded", l.path, removed[0].LSN, removed[len(removed)-1].LSN)
+	if err := writeRecords(archivePath, removed); err != nil {
+		return Restore{}, 0, fmt.Errorf("failed to archive WAL tail: %w", err)
+	}
+
+	// Record the restore first, so the removed LSNs are not assigned again
+	// even after a crash part way through
+	restore := Restore{To: lsn, LSN: l.lastAssignedLSN() + 1}
+	if err := l.writeRestores(append(l.restores, restore)); err != nil {
+		return Restore{}, 0, err
+	}
+
+	kept := append([]Record{}, l.records[:keep]...)
+	if err := l.rewrite(kept); err != nil {
+		l.writeRestores(l.restores)
+		return Restore{}, 0, err
+	}
+	l.restores = append(l.restores, restore)
+	return restore, len(removed), nil
+}
+
+// writeRestores atomically replaces the restores kept next to the log;
+// callers must hold the lock
+func (l *Log) writeRestores(restores []Restore) error {
+	data, err := json.Marshal(restores)
+	if err != nil {
+		return err
+	}
+	path := l.path + ".restores"
+	file, err := os.Create(path + ".tmp")
+	if err == nil {
+		_, err = file.Write(data)
+		if err == nil {
+			err = file.Sync()
+		}
+		file.Close()
+	}
+	if err == nil {
+		err = os.Rename(path+".tmp", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record WAL restore: %w", err)
+	}
+	return nil
+}
+
+// TruncateBefore removes every record with an LSN less than lsn, once the
+// tables reflect them, and returns the number removed. The last record is
+// always kept so the log still knows the LSN to continue from.
+func (l *Log) TruncateBefore(lsn uint64) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	remove := 0
+	for remove < len(l.records)-1 && l.records[remove].LSN < lsn {
+		remove++
+	}
+	if remove == 0 {
+		return 0, nil
+	}
+
+	kept := append([]Record{}, l.records[remove:]...)
+	if err := l.rewrite(kept); err != nil {
+		return 0, err
+	}
+	return remove, nil
+}
+
+// rewrite atomically replaces the log contents; callers must hold the lock
+func (l *Log) rewrite(records []Record) error {
+	tmpPath := l.path + ".tmp"
+	if err := writeRecords(tmpPath, records); err != nil {
+		return fmt.Errorf("failed to rewrite WAL: %w", err)
+	}
+
+	l.file.Close()
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return fmt.Errorf("failed to replace WAL: %w", err)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen WAL: %w", err)
+	}
+	l.file = file
+	l.records = records
+	return nil
+}
+
+// writeRecords writes records to a new file and syncs it
+func writeRecords(path string, records []Record) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, rec := range records {
+		if err := encoder.Encode(rec); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// Close closes the underlying file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}